@@ -17,8 +17,7 @@ function App() {
     towers: [],
     enemies: [],
     projectiles: [],
-    muzzle_flashes: [],
-    explosions: [],
+    events: [],
     gold: 200,
     health: 100,
     wave: 1,
@@ -1,6 +1,6 @@
 import { useEffect, useRef, useState } from 'react'
 import './GameCanvas.css'
-import { Tower, TowerType, Enemy, GameState } from '../types/game'
+import { Tower, TowerType, Enemy, GameState, VisualEffect } from '../types/game'
 
 const GRID_WIDTH = 20
 const GRID_HEIGHT = 15
@@ -38,8 +38,35 @@ const GameCanvas = ({
   const towers = gameState?.towers || []
   const enemies = gameState?.enemies || []
   const projectiles = gameState?.projectiles || []
-  const muzzleFlashes = gameState?.muzzle_flashes || []
-  const explosions = gameState?.explosions || []
+  const events = gameState?.events || []
+
+  // Visual effects arrive as one-off events and are animated locally
+  const effectsRef = useRef<VisualEffect[]>([])
+  const seenEventsRef = useRef<Set<number>>(new Set())
+
+  useEffect(() => {
+    const now = performance.now()
+    events.forEach(event => {
+      if (seenEventsRef.current.has(event.id) || !event.position) return
+      if (event.type !== 'muzzle_flash' && event.type !== 'impact') return
+
+      seenEventsRef.current.add(event.id)
+      const duration = event.data?.duration ?? 0.1
+      effectsRef.current.push({
+        id: event.id,
+        type: event.type,
+        position: event.position,
+        duration,
+        radius: event.data?.radius ?? 0,
+        expiresAt: now + duration * 1000,
+      })
+    })
+
+    // Forget old event IDs so the set doesn't grow forever
+    if (seenEventsRef.current.size > 1000) {
+      seenEventsRef.current = new Set(effectsRef.current.map(effect => effect.id))
+    }
+  }, [events])
 
   // Animation loop - just render, don't update state
   useEffect(() => {
@@ -55,7 +82,7 @@ const GameCanvas = ({
         cancelAnimationFrame(animationFrameRef.current)
       }
     }
-  }, [towers, enemies, projectiles, muzzleFlashes, explosions, hoveredCell, selectedTower, gameState])
+  }, [towers, enemies, projectiles, hoveredCell, selectedTower, gameState])
 
   const render = () => {
     const canvas = canvasRef.current
@@ -97,14 +124,16 @@ const GameCanvas = ({
       drawEnemy(ctx, enemy)
     })
 
-    // Draw muzzle flashes
-    muzzleFlashes.forEach(flash => {
-      drawMuzzleFlash(ctx, flash)
-    })
-
-    // Draw explosions
-    explosions.forEach(explosion => {
-      drawExplosion(ctx, explosion)
+    // Draw muzzle flashes and explosions, dropping expired ones
+    const now = performance.now()
+    effectsRef.current = effectsRef.current.filter(effect => effect.expiresAt > now)
+    effectsRef.current.forEach(effect => {
+      const remaining = { ...effect, duration: (effect.expiresAt - now) / 1000 }
+      if (effect.type === 'muzzle_flash') {
+        drawMuzzleFlash(ctx, remaining)
+      } else {
+        drawExplosion(ctx, remaining)
+      }
     })
 
     if (hoveredCell && !isCellOccupied(hoveredCell)) {
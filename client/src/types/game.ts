@@ -34,22 +34,31 @@ export interface Projectile {
   id: number
   position: Position
   target_id: number
+  target_position?: Position
   speed: number
   damage: number
   tower_id: number
 }
 
-export interface MuzzleFlash {
+export type GameEventType = 'muzzle_flash' | 'impact' | 'tower_disabled'
+
+// One-off event sent with the tick it happened in
+export interface GameEvent {
   id: number
-  position: Position
-  duration: number
+  type: GameEventType
+  game_time: number
+  position?: Position
+  data?: Record<string, any>
 }
 
-export interface Explosion {
+// Visual effect animated locally from a muzzle_flash or impact event
+export interface VisualEffect {
   id: number
+  type: 'muzzle_flash' | 'impact'
   position: Position
   duration: number
   radius: number
+  expiresAt: number
 }
 
 export interface GameState {
@@ -58,8 +67,7 @@ export interface GameState {
   towers: Tower[]
   enemies: Enemy[]
   projectiles: Projectile[]
-  muzzle_flashes: MuzzleFlash[]
-  explosions: Explosion[]
+  events?: GameEvent[]
   gold: number
   health: number
   wave: number
@@ -1,15 +1,32 @@
 package main
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"rust-rush/server/internal/cluster"
     "rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/tracing"
     "rust-rush/server/internal/websocket"
 )
 
+// serverVersion is reported on the home page and alongside the protocol
+// test vectors
+const serverVersion = "0.1.0"
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -23,17 +40,216 @@ func main() {
 
 	// Initialize game manager
 	gameManager := game.NewManager()
+	if path := os.Getenv("BALANCE_FILE"); path != "" {
+		if err := gameManager.Balance().UseFile(path); err != nil {
+			log.Fatalf("Failed to load balance from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("EXPERIMENTS_FILE"); path != "" {
+		if err := gameManager.Experiments().UseFile(path, gameManager.Balance().Current()); err != nil {
+			log.Fatalf("Failed to load experiments from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("WAVES_FILE"); path != "" {
+		if err := gameManager.Waves().UseFile(path, gameManager.Balance().Current()); err != nil {
+			log.Fatalf("Failed to load wave scripts from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("TUTORIALS_FILE"); path != "" {
+		if err := gameManager.Tutorials().UseFile(path, gameManager.Balance().Current()); err != nil {
+			log.Fatalf("Failed to load tutorials from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("STATS_FILE"); path != "" {
+		if err := gameManager.BalanceStats().UseFile(path); err != nil {
+			log.Fatalf("Failed to load balance stats from %s: %v", path, err)
+		}
+	}
+	if spec := os.Getenv("TENANTS"); spec != "" {
+		if err := gameManager.Tenants().UseSpec(spec); err != nil {
+			log.Fatalf("Failed to load tenants: %v", err)
+		}
+	}
+	// Let players keep their identity across restarts and instances
+	if key := os.Getenv("IDENTITY_KEY"); key != "" {
+		gameManager.Identities().UseKey(key)
+	}
+	if path := os.Getenv("TEMPLATES_FILE"); path != "" {
+		if err := gameManager.Templates().UseFile(path); err != nil {
+			log.Fatalf("Failed to load room templates from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("BOT_KEYS_FILE"); path != "" {
+		if err := gameManager.BotKeys().UseFile(path); err != nil {
+			log.Fatalf("Failed to load bot keys from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("MODERATION_FILE"); path != "" {
+		if err := gameManager.Moderation().UseFile(path); err != nil {
+			log.Fatalf("Failed to load moderation history from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("PROFILES_FILE"); path != "" {
+		if err := gameManager.Profiles().UseFile(path); err != nil {
+			log.Fatalf("Failed to load player profiles from %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("NOTIFICATIONS_FILE"); path != "" {
+		if err := gameManager.Inbox().UseFile(path); err != nil {
+			log.Fatalf("Failed to load notifications from %s: %v", path, err)
+		}
+	}
+	if words := os.Getenv("NAME_BLOCKLIST"); words != "" {
+		gameManager.Names().UseFilter(game.BlocklistFilter(strings.Split(words, ",")))
+	}
+
+	// Tag rooms in the browser with where they're hosted, e.g. REGION=eu-west
+	region := os.Getenv("REGION")
+	if region != "" {
+		gameManager.SetRegion(region)
+	}
+
+	// Share rooms with other instances through a directory
+	if instanceID := os.Getenv("INSTANCE_ID"); instanceID != "" {
+		var directory cluster.Directory = cluster.NewMemoryDirectory()
+		if url := os.Getenv("DIRECTORY_URL"); url != "" {
+			redis, err := cluster.NewRedisDirectory(url)
+			if err != nil {
+				log.Fatalf("Failed to connect to room directory: %v", err)
+			}
+			directory = redis
+			// Drained rooms are parked next to the directory entries
+			gameManager.UseHandoffStore(redis)
+		}
+		gameManager.UseDirectory(directory, cluster.Instance{
+			ID:     instanceID,
+			WSURL:  os.Getenv("PUBLIC_WS_URL"),
+			Region: region,
+		})
+		go gameManager.StartDirectoryHeartbeat()
+	}
+
+	// Log room inputs for crash recovery and replays, e.g. WAL_DIR=/var/lib/rust-rush/wal
+	// and WAL_CHECKPOINT_TICKS=3600
+	if dir := os.Getenv("WAL_DIR"); dir != "" {
+		if err := gameManager.UseWAL(dir, uint64(max(envInt("WAL_CHECKPOINT_TICKS"), 0))); err != nil {
+			log.Fatalf("Failed to open WAL directory %s: %v", dir, err)
+		}
+		if recovered := gameManager.RecoverRooms(); len(recovered) > 0 {
+			log.Printf("🩹 Recovered %d rooms from %s", len(recovered), dir)
+		}
+	}
+
+	// Autosave rooms for other instances to pick up after a crash, e.g.
+	// AUTOSAVE_URL=s3://bucket/saves?endpoint=http://minio:9000, redis://host:6379
+	// or file:///var/lib/rust-rush/saves
+	if rawURL := os.Getenv("AUTOSAVE_URL"); rawURL != "" {
+		store, err := openSaveStore(rawURL)
+		if err != nil {
+			log.Fatalf("Failed to open autosave store: %v", err)
+		}
+		gameManager.UseAutosaves(store, game.AutosaveConfig{
+			Interval:  time.Duration(envInt("AUTOSAVE_INTERVAL_SECONDS")) * time.Second,
+			Retention: envInt("AUTOSAVE_RETENTION"),
+		})
+		go gameManager.RunAutosaves()
+	}
+
+	// Round positions sent to clients, e.g. POSITION_PRECISION=decimal:2,
+	// fixed:100 or float
+	precision, err := game.ParsePrecision(os.Getenv("POSITION_PRECISION"))
+	if err != nil {
+		log.Fatalf("Failed to read position precision: %v", err)
+	}
+	gameManager.SetPrecision(precision)
 
 	// Set up WebSocket hub
 	hub := websocket.NewHub(gameManager)
+	hub.ConfigureThrottle(websocket.ThrottleConfig{
+		MaxConnsPerIP:  envInt("WS_MAX_CONNS_PER_IP"),
+		ChallengeAbove: envInt("WS_CHALLENGE_ABOVE"),
+		Difficulty:     envInt("WS_CHALLENGE_DIFFICULTY"),
+		IdleMinutes:    envInt("WS_IDLE_MINUTES"),
+	})
+
+	// Trace a sample of client messages, e.g. TRACE_SAMPLE_RATE=0.01
+	if rate, err := strconv.ParseFloat(os.Getenv("TRACE_SAMPLE_RATE"), 64); err == nil && rate > 0 {
+		out := os.Stdout
+		if path := os.Getenv("TRACE_FILE"); path != "" {
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				log.Fatalf("Failed to open trace file %s: %v", path, err)
+			}
+			out = f
+		}
+		hub.UseTracer(tracing.New(rate, tracing.NewJSONExporter(out)))
+		log.Printf("🔍 Tracing %.1f%% of client messages", rate*100)
+	}
+
 	go hub.Run()
+	go closeOnSignal(hub)
+
+	// Pair players waiting in the matchmaking queue
+	go gameManager.StartMatchmaking(2 * time.Second)
+	go gameManager.StartStatsFlush(time.Minute)
+
+	// Keep a demo room running for visitors, e.g. DEMO_RESET_MINUTES=15
+	demoReset := game.DefaultDemoReset
+	if minutes := envInt("DEMO_RESET_MINUTES"); minutes > 0 {
+		demoReset = time.Duration(minutes) * time.Minute
+	}
+	go gameManager.RunDemoRoom(demoReset)
 
 	// HTTP routes
 	http.HandleFunc("/", handleHome)
-	http.HandleFunc("/health", handleHealth)
+	http.HandleFunc("/health", handleHealth(gameManager))
+	http.HandleFunc("/leaderboard", handleLeaderboard(gameManager))
+	http.HandleFunc("/matches", handleMatchHistory(gameManager))
+	http.HandleFunc("/quickchat", handleQuickChatCatalog)
+	http.HandleFunc("/closecodes", handleCloseCodes)
+	http.HandleFunc("/messages", handleMessageCatalog)
+	http.HandleFunc("/protocol/testvectors", handleTestVectors)
+	http.HandleFunc("/events", handleServerEvents(gameManager))
+	http.HandleFunc("/admin/announce", requireAdmin(handleAnnounce(hub)))
+	http.HandleFunc("/admin/events", requireAdmin(handleScheduleEvent(gameManager)))
+	http.HandleFunc("/templates", handleTemplates(gameManager))
+	http.HandleFunc("/balance", handleBalance(gameManager))
+	http.HandleFunc("/static/maps", handleStatic(gameManager, game.StaticMaps))
+	http.HandleFunc("/static/balance", handleStatic(gameManager, game.StaticBalance))
+	http.HandleFunc("/waves", handleWaveScripts(gameManager))
+	http.HandleFunc("/campaigns", handleCampaigns(gameManager))
+	http.HandleFunc("/tutorials", handleTutorials(gameManager))
+	http.HandleFunc("/ghosts", handleGhost(gameManager))
+	http.HandleFunc("/cosmetics", handleCosmetics(gameManager))
+	http.HandleFunc("/admin/balance/reload", requireAdmin(handleReloadBalance(gameManager)))
+	http.HandleFunc("/admin/templates", requireAdmin(handleSaveTemplate(gameManager)))
+	http.HandleFunc("/admin/rooms", requireAdmin(handleCreateRoom(gameManager)))
+	http.HandleFunc("/admin/audit", requireAdmin(handleAuditLog(gameManager)))
+	http.HandleFunc("/admin/timetravel", requireAdmin(handleTimeTravel(gameManager)))
+	http.HandleFunc("/admin/telemetry", requireAdmin(handleTelemetry(gameManager)))
+	http.HandleFunc("/admin/memory", requireAdmin(handleMemory(gameManager)))
+	http.HandleFunc("/admin/autosaves", requireAdmin(handleAutosaves(gameManager)))
+	http.HandleFunc("/admin/stats", requireAdmin(handleBalanceStats(gameManager)))
+	http.HandleFunc("/admin/experiments", requireAdmin(handleExperiments(gameManager)))
+	http.HandleFunc("/admin/quotas", requireAdmin(handleQuotas(gameManager)))
+	http.HandleFunc("/admin/quotas/room", requireAdmin(handleRoomQuota(gameManager)))
+	http.HandleFunc("/admin/bots", requireAdmin(handleBotKeys(gameManager)))
+	http.HandleFunc("/admin/history", requireAdmin(handleRoomHistory(gameManager)))
+	http.HandleFunc("/admin/reports", requireAdmin(handleReports(gameManager)))
+	http.HandleFunc("/admin/drain", requireAdmin(handleDrain(hub)))
+	http.HandleFunc("/admin/captures", requireAdmin(handleCaptures(hub)))
+	http.HandleFunc("/admin/cosmetics", requireAdmin(handleUnlockCosmetic(gameManager)))
+	http.HandleFunc("/admin/notifications", requireAdmin(handleNotifications(hub, gameManager)))
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		websocket.ServeWs(hub, w, r)
 	})
+	http.HandleFunc("/locate/", handleLocate(gameManager))
+	http.HandleFunc("/rooms/", func(w http.ResponseWriter, r *http.Request) {
+		websocket.ServeSnapshot(hub, w, r)
+	})
+	http.HandleFunc("/ws/challenge", func(w http.ResponseWriter, r *http.Request) {
+		websocket.ServeChallenge(hub, w, r)
+	})
 
 	log.Printf("Server starting on port %s", port)
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
@@ -43,10 +259,812 @@ func main() {
 
 func handleHome(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"message": "Rust Rush WebSocket Server", "version": "0.1.0"}`))
+	fmt.Fprintf(w, `{"message": "Rust Rush WebSocket Server", "version": %q}`, serverVersion)
+}
+
+// handleHealth reports unhealthy while draining so load balancers stop
+// sending players here
+func handleHealth(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if gameManager.IsDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status": "draining"}`))
+			return
+		}
+		w.Write([]byte(`{"status": "healthy"}`))
+	}
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
+func handleLeaderboard(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Leaderboard().Top(tenantOf(gameManager, r), queryLimit(r)))
+	}
+}
+
+func handleMatchHistory(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Leaderboard().History(tenantOf(gameManager, r), queryLimit(r)))
+	}
+}
+
+// tenantOf returns the realm a request belongs to
+func tenantOf(gameManager *game.Manager, r *http.Request) string {
+	return gameManager.Tenants().Resolve(r.Host, websocket.TenantToken(r))
+}
+
+func handleQuickChatCatalog(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, websocket.QuickChatCatalog())
+}
+
+func handleCloseCodes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, websocket.CloseCodes())
+}
+
+// handleTestVectors publishes example frames and the replies this version
+// of the server gives them, for other client implementations to test with
+func handleTestVectors(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"version": serverVersion,
+		"vectors": websocket.TestVectors(),
+	})
+}
+
+// closeOnSignal closes every connection with the shutdown close code when
+// the server is told to stop, giving the close frames a moment to go out
+func closeOnSignal(hub *websocket.Hub) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	log.Println("Shutting down, closing connections")
+	hub.Shutdown()
+	time.Sleep(time.Second)
+	os.Exit(0)
+}
+
+func handleMessageCatalog(w http.ResponseWriter, r *http.Request) {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+
+	catalog, ok := i18n.Catalog(locale)
+	if !ok {
+		locale = i18n.DefaultLocale
+		catalog, _ = i18n.Catalog(locale)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"locale":   locale,
+		"locales":  i18n.Locales(),
+		"messages": catalog,
+	})
+}
+
+// requireAdmin only lets through requests carrying the ADMIN_TOKEN as a
+// bearer token. Admin endpoints are disabled when no token is configured.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		got := []byte(r.Header.Get("Authorization"))
+		if token == "" || subtle.ConstantTimeCompare(got, []byte("Bearer "+token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAnnounce sends or schedules a global announcement. GET lists the
+// announcements still pending.
+func handleAnnounce(hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeJSON(w, hub.PendingAnnouncements())
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Message string    `json:"message"`
+			Level   string    `json:"level"`
+			SendAt  time.Time `json:"send_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+			http.Error(w, "invalid announcement", http.StatusBadRequest)
+			return
+		}
+		if req.Level == "" {
+			req.Level = websocket.AnnouncementInfo
+		}
+		if !websocket.ValidAnnouncementLevel(req.Level) {
+			http.Error(w, "unknown level", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, hub.Announce(req.Message, req.Level, req.SendAt))
+	}
+}
+
+func handleServerEvents(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Events().Upcoming(time.Now()))
+	}
+}
+
+// handleScheduleEvent adds a server event with POST or cancels one with
+// DELETE ?id=
+func handleScheduleEvent(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var event game.ServerEvent
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				http.Error(w, "invalid event", http.StatusBadRequest)
+				return
+			}
+
+			event, err := gameManager.Events().Add(event)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Scheduled server event %s (%s) from %s to %s", event.ID, event.Name, event.Start, event.End)
+			writeJSON(w, event)
+
+		case http.MethodDelete:
+			if !gameManager.Events().Remove(r.URL.Query().Get("id")) {
+				http.Error(w, "event not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func handleTemplates(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Templates().List(tenantOf(gameManager, r)))
+	}
+}
+
+// handleSaveTemplate saves a template with POST or deletes one with
+// DELETE ?name=
+func handleSaveTemplate(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var template game.RoomTemplate
+			if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+				http.Error(w, "invalid template", http.StatusBadRequest)
+				return
+			}
+			template.CreatedBy = game.TemplateAdmin
+
+			saved, err := gameManager.Templates().Save(template)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, saved)
+
+		case http.MethodDelete:
+			deleted, err := gameManager.Templates().Delete(r.URL.Query().Get("name"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !deleted {
+				http.Error(w, "template not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleCreateRoom opens a room from a template
+func handleCreateRoom(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			RoomID   string `json:"room_id"`
+			Template string `json:"template"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		room, err := gameManager.CreateRoomFromTemplate(req.RoomID, req.Template)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"room_id": room.RoomID,
+			"config":  room.Config,
+		})
+	}
+}
+
+// handleBalance serves the live stat tables so clients can refresh theirs
+// when a room's config_version changes
+func handleBalance(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Balance().Current())
+	}
+}
+
+// handleStatic serves static game data that clients cache. The ETag changes
+// with the data's version, so clients can revalidate cheaply.
+func handleStatic(gameManager *game.Manager, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		asset, ok := gameManager.Static(name)
+		if !ok {
+			http.Error(w, "unknown static data", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("ETag", asset.ETag)
+		w.Header().Set("X-Content-Version", asset.Version)
+		w.Header().Set("Cache-Control", "public, max-age=300, must-revalidate")
+		if r.Header.Get("If-None-Match") == asset.ETag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(asset.Body)
+	}
+}
+
+// handleReloadBalance re-reads BALANCE_FILE and stages it in running rooms
+func handleReloadBalance(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		balance, err := gameManager.ReloadBalance()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"config_version": balance.Version})
+	}
+}
+
+// handleDrain stops this instance taking new rooms and hands its active
+// rooms to the other instances, for deploys. POST only.
+func handleDrain(hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"handed_off": hub.Drain()})
+	}
+}
+
+// handleAuditLog returns a debug room's audit records: GET ?room_id=&limit=
+func handleAuditLog(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 500
+		}
+
+		records, err := gameManager.AuditLog(r.URL.Query().Get("room_id"), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, records)
+	}
+}
+
+// handleTimeTravel rebuilds a debug room as it was at a past tick:
+// GET ?room_id=&tick=
+func handleTimeTravel(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tick, err := strconv.ParseUint(r.URL.Query().Get("tick"), 10, 64)
+		if err != nil {
+			http.Error(w, "tick must be a tick number", http.StatusBadRequest)
+			return
+		}
+
+		snapshot, err := gameManager.TimeTravel(r.URL.Query().Get("room_id"), tick)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, snapshot)
+	}
+}
+
+// handleTelemetry downloads a match's telemetry as JSONL, or one part of it
+// (samples, events, ledger or towers) as CSV
+func handleTelemetry(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := r.URL.Query().Get("room_id")
+		bundle, ok := gameManager.Telemetry(roomID)
+		if !ok {
+			http.Error(w, "no telemetry for room "+roomID, http.StatusNotFound)
+			return
+		}
+
+		switch r.URL.Query().Get("format") {
+		case "csv":
+			part := r.URL.Query().Get("part")
+			if part == "" {
+				part = game.TelemetrySamples
+			}
+			var buf bytes.Buffer
+			if err := bundle.WriteCSV(&buf, part); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+roomID+"-"+part+`.csv"`)
+			w.Write(buf.Bytes())
+
+		case "", "jsonl":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+roomID+`.jsonl"`)
+			if err := bundle.WriteJSONL(w); err != nil {
+				log.Printf("Failed to write telemetry for room %s: %v", roomID, err)
+			}
+
+		default:
+			http.Error(w, "format must be jsonl or csv", http.StatusBadRequest)
+		}
+	}
+}
+
+func handleRoomHistory(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := r.URL.Query().Get("room_id")
+		if roomID == "" {
+			http.Error(w, "room_id is required", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, gameManager.Moderation().History(roomID))
+	}
+}
+
+// handleExperiments lists the running balance experiments and their variants
+func handleExperiments(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Experiments().List())
+	}
+}
+
+// handleBalanceStats reports the balance stats aggregated across coop
+// matches. ?flush=true flushes the pending matches first.
+func handleBalanceStats(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := gameManager.BalanceStats()
+		if r.URL.Query().Get("flush") == "true" {
+			if err := stats.Flush(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		writeJSON(w, stats.Report())
+	}
+}
+
+// handleMemory reports each room's approximate memory footprint, largest
+// first, with the instance total
+func handleMemory(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if roomID := r.URL.Query().Get("room_id"); roomID != "" {
+			room, ok := gameManager.GetShootingRoom(roomID)
+			if !ok {
+				http.Error(w, "no room "+roomID, http.StatusNotFound)
+				return
+			}
+			writeJSON(w, room.MemoryUsage())
+			return
+		}
+
+		rooms := gameManager.MemoryReport()
+		total := 0
+		for _, room := range rooms {
+			total += room.Bytes
+		}
+		writeJSON(w, map[string]interface{}{
+			"rooms":        rooms,
+			"approx_bytes": total,
+		})
+	}
+}
+
+func handleReports(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Moderation().Reports(r.URL.Query().Get("room_id"), queryLimit(r)))
+	}
+}
+
+// envInt reads an integer environment variable, returning 0 if it is unset
+// or invalid
+// openSaveStore opens the autosave backend a URL names. S3 credentials
+// come from the usual AWS variables.
+func openSaveStore(rawURL string) (cluster.SaveStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		return cluster.NewDiskSaveStore(u.Path)
+	case "s3":
+		return cluster.NewS3SaveStore(rawURL, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	case "redis":
+		return cluster.NewRedisDirectory(rawURL)
+	default:
+		return nil, fmt.Errorf("unsupported autosave scheme %q", u.Scheme)
+	}
+}
+
+// handleAutosaves reports autosave counts and upload latency
+func handleAutosaves(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.AutosaveStats())
+	}
+}
+
+func envInt(name string) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// handleWaveScripts lists the wave scripts for a map, the default map if
+// none is given
+func handleWaveScripts(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mapName := r.URL.Query().Get("map")
+		if mapName == "" {
+			mapName = game.DefaultMap
+		}
+		writeJSON(w, gameManager.Waves().List(mapName))
+	}
+}
+
+// handleTutorials lists the tutorial scripts
+func handleTutorials(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, gameManager.Tutorials().List())
+	}
+}
+
+// handleCampaigns lists the campaigns, or with ?campaign= and ?player_id=
+// a player's progress through one
+func handleCampaigns(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaignID := r.URL.Query().Get("campaign")
+		if campaignID == "" {
+			writeJSON(w, game.Campaigns())
+			return
+		}
+
+		progress, err := gameManager.CampaignProgress(r.URL.Query().Get("player_id"), campaignID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, progress)
+	}
+}
+
+// handleGhost returns the best recorded run of a campaign level
+func handleGhost(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := game.GhostKey(r.URL.Query().Get("campaign"), r.URL.Query().Get("level"))
+		run, ok := gameManager.Ghosts().Get(key)
+		if !ok {
+			http.Error(w, "no recorded run for "+key, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, run)
+	}
+}
+
+// handleCosmetics lists every skin, or the ones a player has unlocked
+func handleCosmetics(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		playerID := r.URL.Query().Get("player_id")
+		if playerID == "" {
+			writeJSON(w, game.Cosmetics())
+			return
+		}
+		writeJSON(w, gameManager.Profiles().Get(playerID).Cosmetics)
+	}
+}
+
+// handleUnlockCosmetic gives a player a skin
+// handleQuotas reports every realm's quota and usage. POST sets a realm's
+// quota, or the default quota with "default": true; DELETE with ?tenant=
+// puts a realm back on the default.
+func handleQuotas(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotas := gameManager.Quotas()
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var update struct {
+				game.Quota
+				Tenant  string `json:"tenant"`
+				Default bool   `json:"default"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, "invalid quota", http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			if update.Default {
+				err = quotas.SetDefault(update.Quota)
+			} else {
+				err = quotas.Set(update.Tenant, update.Quota)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Set quota for tenant %q (default %v): %+v", update.Tenant, update.Default, update.Quota)
+		case http.MethodDelete:
+			quotas.Reset(r.URL.Query().Get("tenant"))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, gameManager.QuotaUsage())
+	}
+}
+
+// handleRoomQuota changes how many players a live room may have
+func handleRoomQuota(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var update struct {
+			RoomID     string `json:"room_id"`
+			MaxPlayers int    `json:"max_players"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.RoomID == "" {
+			http.Error(w, "invalid room quota", http.StatusBadRequest)
+			return
+		}
+
+		room, exists := gameManager.GetShootingRoom(update.RoomID)
+		if !exists {
+			http.Error(w, "room not found", http.StatusNotFound)
+			return
+		}
+		if err := room.SetMaxPlayers(update.MaxPlayers); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Limited room %s to %d players", update.RoomID, update.MaxPlayers)
+		writeJSON(w, room.GetSnapshot().Config)
+	}
+}
+
+// handleBotKeys lists issued bot keys. POST issues a key for a bot,
+// replacing any it had, and is the only time the key is shown; DELETE with
+// ?name= revokes one.
+func handleBotKeys(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := gameManager.BotKeys()
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, keys.List())
+		case http.MethodPost:
+			var request struct {
+				Name   string   `json:"name"`
+				Scopes []string `json:"scopes"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "invalid bot key request", http.StatusBadRequest)
+				return
+			}
+
+			key, bot, err := keys.Issue(request.Name, request.Scopes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Issued bot key for %s with scopes %v", bot.Name, bot.Scopes)
+			writeJSON(w, map[string]interface{}{"key": key, "bot": bot})
+		case http.MethodDelete:
+			name := r.URL.Query().Get("name")
+			revoked, err := keys.Revoke(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				log.Printf("Revoked bot key for %s", name)
+			}
+			writeJSON(w, map[string]bool{"revoked": revoked})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleCaptures lists WebSocket captures, or returns one with its frames
+// given ?id=. POST with a client_id or room_id starts a capture; DELETE
+// with ?id= stops one, keeping what it recorded for export.
+func handleCaptures(hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Get("id") == "" {
+				writeJSON(w, hub.Captures())
+				return
+			}
+			capture, ok := hub.Capture(r.URL.Query().Get("id"))
+			if !ok {
+				http.Error(w, "capture not found", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, capture)
+		case http.MethodPost:
+			var request struct {
+				ClientID string `json:"client_id"`
+				RoomID   string `json:"room_id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "invalid capture", http.StatusBadRequest)
+				return
+			}
+			capture, err := hub.StartCapture(request.ClientID, request.RoomID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Started capture %s (client %q, room %q)", capture.ID, capture.ClientID, capture.RoomID)
+			writeJSON(w, capture)
+		case http.MethodDelete:
+			capture, ok := hub.StopCapture(r.URL.Query().Get("id"))
+			if !ok {
+				http.Error(w, "capture not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Stopped capture %s after %d frames", capture.ID, capture.Frames)
+			writeJSON(w, capture)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func handleUnlockCosmetic(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var unlock struct {
+			PlayerID string `json:"player_id"`
+			Cosmetic string `json:"cosmetic"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&unlock); err != nil || unlock.PlayerID == "" {
+			http.Error(w, "invalid unlock", http.StatusBadRequest)
+			return
+		}
+
+		if err := gameManager.Profiles().UnlockCosmetic(unlock.PlayerID, unlock.Cosmetic); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Unlocked cosmetic %s for player %s", unlock.Cosmetic, unlock.PlayerID)
+		writeJSON(w, gameManager.Profiles().Get(unlock.PlayerID).Cosmetics)
+	}
+}
+
+// handleNotifications sends a notification to one player, or to every
+// connected player when no player_id is given. GET with ?player_id= lists a
+// player's inbox.
+func handleNotifications(hub *websocket.Hub, gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeJSON(w, gameManager.Inbox().List(r.URL.Query().Get("player_id")))
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			PlayerID string                 `json:"player_id"`
+			Kind     string                 `json:"kind"`
+			Params   map[string]interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid notification", http.StatusBadRequest)
+			return
+		}
+		if !game.ValidInboxKind(req.Kind) {
+			http.Error(w, "unknown kind", http.StatusBadRequest)
+			return
+		}
+
+		if req.PlayerID == "" {
+			sent, err := hub.NotifyConnected(req.Kind, req.Params)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"sent": sent})
+			return
+		}
+
+		n, err := gameManager.Deliver(req.PlayerID, req.Kind, req.Params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, n)
+	}
+}
+
+// handleLocate reports which instance hosts /locate/{roomID}
+func handleLocate(gameManager *game.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID := strings.TrimPrefix(r.URL.Path, "/locate/")
+		instance, local, found := gameManager.Locate(roomID)
+		if roomID == "" || !found {
+			http.Error(w, "room not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"room_id":  roomID,
+			"instance": instance.ID,
+			"ws_url":   instance.WSURL,
+			"local":    local,
+		})
+	}
+}
+
+// queryLimit reads the optional ?limit= parameter, defaulting to 20
+func queryLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return 20
+	}
+	return limit
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"status": "healthy"}`))
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write JSON response: %v", err)
+	}
 }
@@ -0,0 +1,177 @@
+// Command replay plays a captured WebSocket session back against a server,
+// so a protocol bug report can be reproduced from its capture. Each client
+// in the capture gets its own connection and sends its inbound frames at
+// their recorded offsets; what the server sends back is compared with what
+// was recorded, by message type. Messages the server streams on a timer,
+// like game state, depend on timing and aren't compared.
+//
+//	go run ./cmd/replay -capture capture.json -url ws://localhost:8080/ws
+//
+// Captures come from GET /admin/captures?id=<id>. Player IDs are handed out by
+// the server, so frames naming another player refer to their recorded ID.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	ws "rust-rush/server/internal/websocket"
+)
+
+// streamed are the message types the server sends on a timer rather than in
+// answer to a client
+var streamed = map[string]bool{
+	ws.MessageTypeGameState:     true,
+	ws.MessageTypeLockstepFrame: true,
+	ws.MessageTypeMinimap:       true,
+	ws.MessageTypeDiagnostics:   true,
+	ws.MessageTypeAnalysis:      true,
+}
+
+func main() {
+	path := flag.String("capture", "", "capture file from /admin/captures?id=<id>")
+	url := flag.String("url", "ws://localhost:8080/ws", "WebSocket URL of the server to replay against")
+	speed := flag.Float64("speed", 1, "playback speed, 2 replays twice as fast")
+	linger := flag.Duration("linger", 2*time.Second, "how long to keep listening after the last frame")
+	verbose := flag.Bool("v", false, "print every frame received")
+	flag.Parse()
+
+	if *path == "" || *speed <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		log.Fatalf("Failed to read capture: %v", err)
+	}
+	var capture ws.Capture
+	if err := json.Unmarshal(data, &capture); err != nil {
+		log.Fatalf("Failed to parse capture: %v", err)
+	}
+
+	inbound := make(map[string][]ws.CapturedFrame)
+	recorded := make(map[string]map[string]int)
+	for _, frame := range capture.Frames {
+		if frame.Direction == ws.FrameIn {
+			inbound[frame.ClientID] = append(inbound[frame.ClientID], frame)
+			continue
+		}
+		if recorded[frame.ClientID] == nil {
+			recorded[frame.ClientID] = make(map[string]int)
+		}
+		recorded[frame.ClientID][messageType(frame.Data)]++
+	}
+	log.Printf("Replaying capture %s: %d frames from %d clients", capture.ID, len(capture.Frames), len(inbound))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	received := make(map[string]map[string]int)
+	for clientID, frames := range inbound {
+		wg.Add(1)
+		go func(clientID string, frames []ws.CapturedFrame) {
+			defer wg.Done()
+			counts := replayClient(*url, clientID, frames, *speed, *linger, *verbose)
+			mu.Lock()
+			received[clientID] = counts
+			mu.Unlock()
+		}(clientID, frames)
+	}
+	wg.Wait()
+
+	mismatches := 0
+	for clientID := range inbound {
+		for _, msgType := range messageTypes(recorded[clientID], received[clientID]) {
+			want, got := recorded[clientID][msgType], received[clientID][msgType]
+			if want != got && !streamed[msgType] {
+				mismatches++
+				log.Printf("%s: %s recorded %d, received %d", clientID, msgType, want, got)
+			}
+		}
+	}
+	if mismatches > 0 {
+		log.Printf("Replay differed from the capture in %d places", mismatches)
+		os.Exit(1)
+	}
+	log.Printf("Replay matched the capture")
+}
+
+// replayClient sends one client's inbound frames on a connection of its own
+// and counts what comes back by message type
+func replayClient(url, clientID string, frames []ws.CapturedFrame, speed float64, linger time.Duration, verbose bool) map[string]int {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		log.Printf("%s: failed to connect: %v", clientID, err)
+		return nil
+	}
+	defer conn.Close()
+
+	counts := make(map[string]int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			counts[messageType(string(data))]++
+			if verbose {
+				log.Printf("%s <- %s", clientID, data)
+			}
+		}
+	}()
+
+	start := time.Now()
+	for _, frame := range frames {
+		at := time.Duration(frame.AtMs / speed * float64(time.Millisecond))
+		time.Sleep(time.Until(start.Add(at)))
+		if verbose {
+			log.Printf("%s -> %s", clientID, frame.Data)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame.Data)); err != nil {
+			log.Printf("%s: failed to send frame: %v", clientID, err)
+			break
+		}
+	}
+
+	time.Sleep(linger)
+	conn.Close()
+	<-done
+	return counts
+}
+
+// messageType returns a frame's message type, or "?" if it isn't a message
+func messageType(data string) string {
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg.Type == "" {
+		return "?"
+	}
+	return msg.Type
+}
+
+// messageTypes lists the message types in either count, sorted
+func messageTypes(a, b map[string]int) []string {
+	seen := make(map[string]bool)
+	for t := range a {
+		seen[t] = true
+	}
+	for t := range b {
+		seen[t] = true
+	}
+	types := make([]string, 0, len(seen))
+	for t := range seen {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -11,4 +11,6 @@ require golang.org/x/net v0.17.0 // indirect
 
 replace rust-rush/server/internal/game => ./internal/game
 
+replace rust-rush/server/internal/i18n => ./internal/i18n
+
 replace rust-rush/server/internal/websocket => ./internal/websocket
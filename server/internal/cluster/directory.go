@@ -0,0 +1,81 @@
+// Package cluster lets several server instances share rooms by recording
+// which instance hosts each one.
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// RegistrationTTL is how long a room registration lives without being
+// refreshed, so rooms on a crashed instance drop out of the directory
+const RegistrationTTL = 60 * time.Second
+
+// Instance is a server process that hosts rooms
+type Instance struct {
+	ID     string `json:"id"`
+	WSURL  string `json:"ws_url"`
+	Region string `json:"region,omitempty"` // where it's hosted, e.g. eu-west
+}
+
+// Directory records which instance hosts each room
+type Directory interface {
+	Register(roomID string, instance Instance) error
+	// Reserve registers a room unless another instance already hosts it,
+	// reporting whether the room is now this instance's
+	Reserve(roomID string, instance Instance) (bool, error)
+	Unregister(roomID string) error
+	Lookup(roomID string) (Instance, bool, error)
+}
+
+// MemoryDirectory is a directory for a single process. It's the default
+// and is also handy when running several instances isn't needed.
+type MemoryDirectory struct {
+	mu    sync.RWMutex
+	rooms map[string]Instance
+}
+
+// NewMemoryDirectory creates an empty in-memory directory
+func NewMemoryDirectory() *MemoryDirectory {
+	return &MemoryDirectory{rooms: make(map[string]Instance)}
+}
+
+// Register records that an instance hosts a room
+func (d *MemoryDirectory) Register(roomID string, instance Instance) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.rooms[roomID] = instance
+	return nil
+}
+
+// Reserve records that an instance hosts a room, unless another one
+// already does
+func (d *MemoryDirectory) Reserve(roomID string, instance Instance) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if owner, ok := d.rooms[roomID]; ok && owner.ID != instance.ID {
+		return false, nil
+	}
+	d.rooms[roomID] = instance
+	return true, nil
+}
+
+// Unregister forgets a room
+func (d *MemoryDirectory) Unregister(roomID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.rooms, roomID)
+	return nil
+}
+
+// Lookup finds the instance hosting a room
+func (d *MemoryDirectory) Lookup(roomID string) (Instance, bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	instance, ok := d.rooms[roomID]
+	return instance, ok, nil
+}
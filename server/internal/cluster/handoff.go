@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HandoffTTL is how long a drained room waits for another instance to
+// adopt it before it's dropped
+const HandoffTTL = 10 * time.Minute
+
+const redisHandoffPrefix = "rustrush:handoff:"
+
+// HandoffStore parks serialized rooms while they move between instances.
+// Take removes the room, so only one instance adopts it.
+type HandoffStore interface {
+	Put(roomID string, data []byte) error
+	Take(roomID string) ([]byte, bool, error)
+}
+
+// MemoryHandoffStore is a handoff store for a single process, where a
+// drained room can only be adopted by the same process
+type MemoryHandoffStore struct {
+	mu    sync.Mutex
+	rooms map[string][]byte
+}
+
+// NewMemoryHandoffStore creates an empty in-memory handoff store
+func NewMemoryHandoffStore() *MemoryHandoffStore {
+	return &MemoryHandoffStore{rooms: make(map[string][]byte)}
+}
+
+// Put parks a room
+func (s *MemoryHandoffStore) Put(roomID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms[roomID] = data
+	return nil
+}
+
+// Take removes and returns a parked room
+func (s *MemoryHandoffStore) Take(roomID string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.rooms[roomID]
+	delete(s.rooms, roomID)
+	return data, ok, nil
+}
+
+// Put parks a room in Redis until HandoffTTL runs out
+func (d *RedisDirectory) Put(roomID string, data []byte) error {
+	_, err := d.do("SET", redisHandoffPrefix+roomID, string(data), "EX", strconv.Itoa(int(HandoffTTL.Seconds())))
+	return err
+}
+
+// Take removes and returns a parked room. GETDEL makes it atomic, so two
+// instances can't both adopt the room.
+func (d *RedisDirectory) Take(roomID string) ([]byte, bool, error) {
+	reply, err := d.do("GETDEL", redisHandoffPrefix+roomID)
+	if errors.Is(err, errRedisNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(reply), true, nil
+}
@@ -0,0 +1,231 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisKeyPrefix = "rustrush:room:"
+	redisTimeout   = 2 * time.Second
+)
+
+var errRedisNil = errors.New("redis: nil")
+
+// RedisDirectory keeps room registrations in Redis so every instance sees
+// them. It speaks just enough of the Redis protocol for SET, GET and DEL
+// over one connection, redialing when the connection breaks.
+type RedisDirectory struct {
+	mu       sync.Mutex
+	addr     string
+	password string
+	db       int
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+// NewRedisDirectory connects to a redis://[:password@]host:port[/db] URL
+func NewRedisDirectory(rawURL string) (*RedisDirectory, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported directory scheme %q", u.Scheme)
+	}
+
+	d := &RedisDirectory{addr: u.Host}
+	if password, ok := u.User.Password(); ok {
+		d.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if d.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.dial(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Register records that an instance hosts a room. Registrations expire
+// after RegistrationTTL unless refreshed.
+func (d *RedisDirectory) Register(roomID string, instance Instance) error {
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+	_, err = d.do("SET", redisKeyPrefix+roomID, string(data), "EX", strconv.Itoa(int(RegistrationTTL.Seconds())))
+	return err
+}
+
+// Reserve records that an instance hosts a room, unless another one
+// already does. SET NX makes the claim atomic across instances.
+func (d *RedisDirectory) Reserve(roomID string, instance Instance) (bool, error) {
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return false, err
+	}
+	_, err = d.do("SET", redisKeyPrefix+roomID, string(data), "NX", "EX", strconv.Itoa(int(RegistrationTTL.Seconds())))
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, errRedisNil) {
+		return false, err
+	}
+
+	// Already registered: fine if it's ours, e.g. a heartbeat got there first
+	owner, found, err := d.Lookup(roomID)
+	if err != nil {
+		return false, err
+	}
+	if found && owner.ID != instance.ID {
+		return false, nil
+	}
+	return true, d.Register(roomID, instance)
+}
+
+// Unregister forgets a room
+func (d *RedisDirectory) Unregister(roomID string) error {
+	_, err := d.do("DEL", redisKeyPrefix+roomID)
+	return err
+}
+
+// Lookup finds the instance hosting a room
+func (d *RedisDirectory) Lookup(roomID string) (Instance, bool, error) {
+	reply, err := d.do("GET", redisKeyPrefix+roomID)
+	if errors.Is(err, errRedisNil) {
+		return Instance{}, false, nil
+	}
+	if err != nil {
+		return Instance{}, false, err
+	}
+
+	var instance Instance
+	if err := json.Unmarshal([]byte(reply), &instance); err != nil {
+		return Instance{}, false, err
+	}
+	return instance, true, nil
+}
+
+// dial opens a connection and selects the database. Callers must hold the
+// lock.
+func (d *RedisDirectory) dial() error {
+	conn, err := net.DialTimeout("tcp", d.addr, redisTimeout)
+	if err != nil {
+		return err
+	}
+	d.conn = conn
+	d.reader = bufio.NewReader(conn)
+
+	if d.password != "" {
+		if _, err := d.roundTrip("AUTH", d.password); err != nil {
+			d.close()
+			return err
+		}
+	}
+	if d.db != 0 {
+		if _, err := d.roundTrip("SELECT", strconv.Itoa(d.db)); err != nil {
+			d.close()
+			return err
+		}
+	}
+	return nil
+}
+
+// close drops the connection. Callers must hold the lock.
+func (d *RedisDirectory) close() {
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+// do runs a command, redialing once if the connection has gone away
+func (d *RedisDirectory) do(args ...string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn == nil {
+		if err := d.dial(); err != nil {
+			return "", err
+		}
+	}
+
+	reply, err := d.roundTrip(args...)
+	if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, new(redisError)) {
+		// Connection-level failure: retry once on a fresh connection
+		d.close()
+		if err := d.dial(); err != nil {
+			return "", err
+		}
+		reply, err = d.roundTrip(args...)
+	}
+	return reply, err
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// roundTrip writes a command and reads its reply. Callers must hold the
+// lock.
+func (d *RedisDirectory) roundTrip(args ...string) (string, error) {
+	d.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := d.conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := d.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", err
+		}
+		if n < 0 {
+			return "", errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(d.reader, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	default:
+		return "", fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
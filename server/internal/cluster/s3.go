@@ -0,0 +1,237 @@
+package cluster
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Timeout     = 30 * time.Second
+	s3Service     = "s3"
+	s3DefaultHost = "https://s3.amazonaws.com"
+)
+
+// S3SaveStore keeps autosaves in an S3-compatible bucket, e.g. AWS S3 or
+// MinIO. It signs requests itself and addresses the bucket by path, which
+// every S3-compatible service accepts.
+type S3SaveStore struct {
+	endpoint  string // scheme and host
+	region    string
+	bucket    string
+	prefix    string // prepended to every key, e.g. saves/
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3SaveStore opens a bucket from an s3://bucket/prefix URL. The
+// endpoint and region query parameters point it at services other than
+// AWS, e.g. s3://saves?endpoint=http://minio:9000&region=us-east-1.
+func NewS3SaveStore(rawURL, accessKey, secretKey string) (*S3SaveStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 URL %q", rawURL)
+	}
+
+	s := &S3SaveStore{
+		endpoint:  strings.TrimSuffix(u.Query().Get("endpoint"), "/"),
+		region:    u.Query().Get("region"),
+		bucket:    u.Host,
+		prefix:    strings.TrimPrefix(u.Path, "/"),
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: s3Timeout},
+	}
+	if s.endpoint == "" {
+		s.endpoint = s3DefaultHost
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.prefix != "" && !strings.HasSuffix(s.prefix, "/") {
+		s.prefix += "/"
+	}
+	return s, nil
+}
+
+func (s *S3SaveStore) roomPrefix(roomID string) string {
+	return s.prefix + url.PathEscape(roomID) + "/"
+}
+
+// Save uploads a save
+func (s *S3SaveStore) Save(roomID string, tick uint64, data []byte) error {
+	_, err := s.request(http.MethodPut, s.roomPrefix(roomID)+saveName(tick), nil, data)
+	return err
+}
+
+// Load downloads a save
+func (s *S3SaveStore) Load(roomID string, tick uint64) ([]byte, error) {
+	return s.request(http.MethodGet, s.roomPrefix(roomID)+saveName(tick), nil, nil)
+}
+
+// List returns the ticks a room has saves for, following continuation
+// tokens past the first thousand
+func (s *S3SaveStore) List(roomID string) ([]uint64, error) {
+	prefix := s.roomPrefix(roomID)
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+
+	var ticks []uint64
+	for {
+		body, err := s.request(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, object := range result.Contents {
+			if tick, ok := parseSaveName(strings.TrimPrefix(object.Key, prefix)); ok {
+				ticks = append(ticks, tick)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i] < ticks[j] })
+	return ticks, nil
+}
+
+// Remove deletes a save
+func (s *S3SaveStore) Remove(roomID string, tick uint64) error {
+	_, err := s.request(http.MethodDelete, s.roomPrefix(roomID)+saveName(tick), nil, nil)
+	return err
+}
+
+// request sends a signed request for a key, or the bucket itself if key is
+// empty, and returns the response body
+func (s *S3SaveStore) request(method, key string, query url.Values, body []byte) ([]byte, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/" + s.bucket + "/" + key
+	u.RawPath = "/" + s3Escape(s.bucket) + "/" + s3Escape(key)
+	u.RawQuery = s3Query(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("s3: %s %s: %s", method, key, resp.Status)
+	}
+	return data, nil
+}
+
+// sign adds AWS Signature Version 4 headers to a request
+func (s *S3SaveStore) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/" + s3Service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// s3Escape percent-encodes a path the way signatures expect: everything
+// but unreserved characters and slashes
+func s3Escape(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// s3Query encodes query parameters sorted by name, as signatures expect
+func s3Query(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, strings.ReplaceAll(s3Escape(name), "/", "%2F")+"="+strings.ReplaceAll(s3Escape(value), "/", "%2F"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
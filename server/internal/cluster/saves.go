@@ -0,0 +1,180 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const redisSavePrefix = "rustrush:save:"
+
+// SaveStore keeps rooms' autosaves, each named by the tick it was taken
+// on. Only the instance hosting a room writes its saves.
+type SaveStore interface {
+	Save(roomID string, tick uint64, data []byte) error
+	Load(roomID string, tick uint64) ([]byte, error)
+	List(roomID string) ([]uint64, error) // oldest first
+	Remove(roomID string, tick uint64) error
+}
+
+// saveName is a save's file or object name. Ticks are zero padded so names
+// sort in the order they were taken.
+func saveName(tick uint64) string {
+	return fmt.Sprintf("%020d.json", tick)
+}
+
+// parseSaveName reads the tick back out of a save's name
+func parseSaveName(name string) (uint64, bool) {
+	digits, ok := strings.CutSuffix(name, ".json")
+	if !ok {
+		return 0, false
+	}
+	tick, err := strconv.ParseUint(digits, 10, 64)
+	return tick, err == nil
+}
+
+// DiskSaveStore keeps autosaves as files, one directory per room
+type DiskSaveStore struct {
+	dir string
+}
+
+// NewDiskSaveStore keeps autosaves under dir, creating it if needed
+func NewDiskSaveStore(dir string) (*DiskSaveStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskSaveStore{dir: dir}, nil
+}
+
+func (s *DiskSaveStore) roomDir(roomID string) string {
+	return filepath.Join(s.dir, url.PathEscape(roomID))
+}
+
+// Save writes a save. It's written aside and renamed into place, so a
+// crash mid-write never leaves a torn save behind.
+func (s *DiskSaveStore) Save(roomID string, tick uint64, data []byte) error {
+	dir := s.roomDir(roomID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, saveName(tick))
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Load reads a save
+func (s *DiskSaveStore) Load(roomID string, tick uint64) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.roomDir(roomID), saveName(tick)))
+}
+
+// List returns the ticks a room has saves for
+func (s *DiskSaveStore) List(roomID string) ([]uint64, error) {
+	entries, err := os.ReadDir(s.roomDir(roomID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ticks := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		if tick, ok := parseSaveName(entry.Name()); ok {
+			ticks = append(ticks, tick)
+		}
+	}
+	return ticks, nil
+}
+
+// Remove deletes a save, removing the room's directory with its last one
+func (s *DiskSaveStore) Remove(roomID string, tick uint64) error {
+	dir := s.roomDir(roomID)
+	if err := os.Remove(filepath.Join(dir, saveName(tick))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	os.Remove(dir) // fails while other saves remain
+	return nil
+}
+
+// Save stores a save in Redis. Each room keeps an index of its saves'
+// ticks next to them, since listing keys would scan the whole database.
+func (d *RedisDirectory) Save(roomID string, tick uint64, data []byte) error {
+	if _, err := d.do("SET", redisSaveKey(roomID, tick), string(data)); err != nil {
+		return err
+	}
+	ticks, err := d.List(roomID)
+	if err != nil {
+		return err
+	}
+	return d.setSaveIndex(roomID, append(ticks, tick))
+}
+
+// Load reads a save
+func (d *RedisDirectory) Load(roomID string, tick uint64) ([]byte, error) {
+	reply, err := d.do("GET", redisSaveKey(roomID, tick))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(reply), nil
+}
+
+// List returns the ticks a room has saves for
+func (d *RedisDirectory) List(roomID string) ([]uint64, error) {
+	reply, err := d.do("GET", redisSavePrefix+roomID)
+	if errors.Is(err, errRedisNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ticks []uint64
+	if err := json.Unmarshal([]byte(reply), &ticks); err != nil {
+		return nil, err
+	}
+	return ticks, nil
+}
+
+// Remove deletes a save and drops it from the room's index
+func (d *RedisDirectory) Remove(roomID string, tick uint64) error {
+	if _, err := d.do("DEL", redisSaveKey(roomID, tick)); err != nil {
+		return err
+	}
+	ticks, err := d.List(roomID)
+	if err != nil {
+		return err
+	}
+	kept := ticks[:0]
+	for _, t := range ticks {
+		if t != tick {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		_, err := d.do("DEL", redisSavePrefix+roomID)
+		return err
+	}
+	return d.setSaveIndex(roomID, kept)
+}
+
+func (d *RedisDirectory) setSaveIndex(roomID string, ticks []uint64) error {
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i] < ticks[j] })
+	data, err := json.Marshal(ticks)
+	if err != nil {
+		return err
+	}
+	_, err = d.do("SET", redisSavePrefix+roomID, string(data))
+	return err
+}
+
+func redisSaveKey(roomID string, tick uint64) string {
+	return redisSavePrefix + roomID + ":" + strconv.FormatUint(tick, 10)
+}
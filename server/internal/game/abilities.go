@@ -0,0 +1,49 @@
+package game
+
+// enemyAbility describes a special action an enemy type performs on a timer
+type enemyAbility struct {
+	Cooldown float64 `json:"cooldown"` // seconds between uses
+	Radius   float64 `json:"radius"`
+	Duration float64 `json:"duration"` // seconds the effect lasts
+}
+
+// updateEnemyAbility ticks an enemy's ability cooldown and fires it when ready
+func (gs *GameStateWithShooting) updateEnemyAbility(enemy *Enemy, deltaTime float64) {
+	ability := gs.mods.enemyStats(enemy.EnemyType).Ability
+	if ability == nil {
+		return
+	}
+
+	enemy.AbilityCooldown -= deltaTime
+	if enemy.AbilityCooldown > 0 {
+		return
+	}
+	enemy.AbilityCooldown = ability.Cooldown
+
+	gs.empBurst(enemy, ability)
+}
+
+// empBurst disables every active tower within the ability radius
+func (gs *GameStateWithShooting) empBurst(enemy *Enemy, ability *enemyAbility) {
+	disabled := make([]EntityID, 0)
+	for _, tower := range inRange(gs.Towers, enemy.Position, ability.Radius) {
+		if tower.State != TowerStateActive {
+			continue
+		}
+
+		gs.setTowerState(tower, TowerStateDisabled, ability.Duration)
+		disabled = append(disabled, tower.ID)
+	}
+
+	if len(disabled) == 0 {
+		return
+	}
+
+	pos := enemy.Position
+	gs.emitEvent(EventTowerDisabled, &pos, map[string]interface{}{
+		"enemy_id":  enemy.ID,
+		"tower_ids": disabled,
+		"radius":    ability.Radius,
+		"duration":  ability.Duration,
+	})
+}
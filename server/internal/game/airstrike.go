@@ -0,0 +1,146 @@
+package game
+
+import (
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// CommandAirstrike is a player calling in an airstrike
+const CommandAirstrike = "airstrike"
+
+// EventAirstrike is emitted where an airstrike lands
+const EventAirstrike = "airstrike"
+
+// Airstrike stats
+const (
+	airstrikeCost   = 100
+	airstrikeRadius = 1.5
+	airstrikeDamage = 80.0
+)
+
+// MaxRewind is the furthest back an action is resolved. Players aim at
+// enemies as they saw them, half a round trip late, and their action takes
+// the other half to arrive; beyond this a slow connection is on its own.
+const MaxRewind = 250 * time.Millisecond
+
+// trailSize is how many recent positions an enemy remembers, one per tick:
+// a little over MaxRewind at 60 ticks a second
+const trailSize = 16
+
+// trailPoint is where an enemy was at a game time
+type trailPoint struct {
+	at  float64
+	pos Position
+}
+
+// trail is a ring of an enemy's recent positions. It's a fixed array, so
+// copying an enemy copies its trail instead of sharing it.
+type trail struct {
+	points [trailSize]trailPoint
+	next   int
+	size   int
+}
+
+// record remembers a position at a game time
+func (t *trail) record(at float64, pos Position) {
+	t.points[t.next] = trailPoint{at: at, pos: pos}
+	t.next = (t.next + 1) % trailSize
+	if t.size < trailSize {
+		t.size++
+	}
+}
+
+// positionAt returns where the enemy was at a game time: the last position
+// recorded by then. It reports false if the enemy hadn't appeared yet.
+// Times older than the trail get its oldest position, and enemies with no
+// trail, such as ones just restored from a snapshot, their current one.
+func (e *Enemy) positionAt(at float64) (Position, bool) {
+	t := &e.trail
+	if t.size == 0 {
+		return e.Position, true
+	}
+
+	for i := 1; i <= t.size; i++ {
+		p := t.points[(t.next-i+trailSize)%trailSize]
+		if p.at <= at {
+			return p.pos, true
+		}
+	}
+	if t.size < trailSize {
+		return Position{}, false
+	}
+	return t.points[t.next].pos, true
+}
+
+// Airstrike damages every enemy around a spot of the map, as the player saw
+// them. Seen is the game time the player was looking at, stamped when the
+// action arrived, so enemies are hit where they were then rather than
+// where they've moved on to since.
+type Airstrike struct {
+	X        float64
+	Y        float64
+	Seen     float64
+	PlayerID string
+}
+
+func (Airstrike) Type() string { return CommandAirstrike }
+
+func (c Airstrike) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if !containsString(gs.Players, c.PlayerID) {
+		return nil, i18n.NewError(i18n.ErrNotInRoom, nil)
+	}
+	return gs.callAirstrike(c.PlayerID, Position{X: c.X, Y: c.Y}, c.Seen)
+}
+
+// ViewTime is the game time a client with the given round trip was looking
+// at when its action arrives, at most MaxRewind ago
+func (gs *GameStateWithShooting) ViewTime(rtt time.Duration) float64 {
+	if rtt > MaxRewind {
+		rtt = MaxRewind
+	}
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.GameTime - rtt.Seconds()
+}
+
+// callAirstrike damages every enemy that was within the radius of target
+// at game time seen. Callers must hold the state lock.
+func (gs *GameStateWithShooting) callAirstrike(playerID string, target Position, seen float64) ([]EntityID, error) {
+	if gs.IsLockstep() {
+		return nil, i18n.NewError(i18n.ErrLockstepAirstrike, nil)
+	}
+	if gs.Gold < airstrikeCost {
+		return nil, i18n.NewError(i18n.ErrInsufficientGold, map[string]interface{}{
+			"cost": airstrikeCost,
+			"gold": gs.Gold,
+		})
+	}
+	gs.adjustGold(GoldAirstrike, -airstrikeCost, playerID, 0)
+
+	// The stamp came from the client's connection, so bound it again here:
+	// a command that waited for a scheduled tick can't reach further back
+	if earliest := gs.GameTime - MaxRewind.Seconds(); seen < earliest {
+		seen = earliest
+	}
+
+	hit := make([]EntityID, 0)
+	for i := range gs.Enemies {
+		enemy := &gs.Enemies[i]
+		pos, ok := enemy.positionAt(seen)
+		if !ok || distance(pos, target) > airstrikeRadius {
+			continue
+		}
+		gs.damageEnemy(enemy, airstrikeDamage, 0)
+		hit = append(hit, enemy.ID)
+	}
+
+	gs.emitEvent(EventAirstrike, &target, map[string]interface{}{
+		"player_id": playerID,
+		"enemy_ids": hit,
+		"damage":    airstrikeDamage,
+		"radius":    airstrikeRadius,
+	})
+	return hit, nil
+}
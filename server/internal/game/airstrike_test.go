@@ -0,0 +1,78 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// fastEnemyRoom has one enemy heading right along y=5 at 20 units a second,
+// half a second in, so it's 10 units from where it started
+func fastEnemyRoom(t *testing.T) *GameStateWithShooting {
+	t.Helper()
+	room, err := NewManager().CreateShootingRoomWithConfig("airstrike-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+
+	room.mu.Lock()
+	room.Players = append(room.Players, "player-1")
+	room.Gold = 1000
+	room.addEnemy("basic", []Position{{X: 0, Y: 5}, {X: 40, Y: 5}}, "")
+	room.Enemies[0].Speed = 20
+	room.Enemies[0].Health = 1e6
+	room.mu.Unlock()
+
+	for i := 0; i < 30; i++ {
+		room.Update(1.0 / 60)
+	}
+	return room
+}
+
+func airstrike(t *testing.T, room *GameStateWithShooting, x, seen float64) []EntityID {
+	t.Helper()
+	result, err := room.ApplyCommand(Airstrike{X: x, Y: 5, Seen: seen, PlayerID: "player-1"})
+	if err != nil {
+		t.Fatalf("airstrike failed: %v", err)
+	}
+	return result.([]EntityID)
+}
+
+func TestAirstrikeHitsWhereThePlayerSawEnemies(t *testing.T) {
+	room := fastEnemyRoom(t)
+	now := room.ViewTime(0)
+
+	// 200ms ago the enemy was 4 units back
+	seen := now - 0.2
+	if hit := airstrike(t, room, room.Enemies[0].Position.X-4, seen); len(hit) != 1 {
+		t.Fatalf("airstrike where the player saw the enemy hit %v", hit)
+	}
+	if hit := airstrike(t, room, room.Enemies[0].Position.X-4, now); len(hit) != 0 {
+		t.Fatalf("airstrike behind the enemy's current position hit %v", hit)
+	}
+}
+
+func TestAirstrikeRewindIsBounded(t *testing.T) {
+	room := fastEnemyRoom(t)
+
+	// Claiming to have seen the enemy at its spawn reaches back only
+	// MaxRewind, by when it was 5 units along
+	if hit := airstrike(t, room, 0, 0); len(hit) != 0 {
+		t.Fatalf("airstrike half a second in the past hit %v", hit)
+	}
+	if seen := room.ViewTime(time.Second); room.GameTime-seen > MaxRewind.Seconds()+1e-9 {
+		t.Fatalf("a one second round trip rewinds %vs", room.GameTime-seen)
+	}
+}
+
+func TestAirstrikeCostsGold(t *testing.T) {
+	room := fastEnemyRoom(t)
+	room.Gold = airstrikeCost - 1
+
+	_, err := room.ApplyCommand(Airstrike{X: 0, Y: 5, PlayerID: "player-1"})
+	if !errors.Is(err, i18n.NewError(i18n.ErrInsufficientGold, nil)) {
+		t.Fatalf("airstrike without enough gold: %v", err)
+	}
+}
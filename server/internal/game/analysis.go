@@ -0,0 +1,107 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// AnalysisFrame is everything a room decided in one tick, for balance
+// dashboards and datasets. It's far larger than what players get, so it's
+// only built while an analyst is watching.
+type AnalysisFrame struct {
+	RoomID      string        `json:"room_id"`
+	Tick        uint64        `json:"tick"`
+	GameTime    float64       `json:"game_time"`
+	Gold        int           `json:"gold"`
+	Health      int           `json:"health"`
+	Decisions   []AuditRecord `json:"decisions"` // targeting, firing, damage and kills
+	Events      []GameEvent   `json:"events"`
+	Towers      []Tower       `json:"towers"`
+	Enemies     []Enemy       `json:"enemies"`
+	Projectiles []Projectile  `json:"projectiles"`
+	TowerStats  []TowerStats  `json:"tower_stats"` // overkill and wasted shots so far
+}
+
+// setAnalyzing turns recording of the analysis stream on or off
+func (gs *GameStateWithShooting) setAnalyzing(on bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	gs.analyzing = on
+	if !on {
+		gs.decisions = nil
+	}
+}
+
+// takeAnalysis returns the tick's analysis frame and starts collecting the
+// next one. It returns false if the room isn't being analyzed or didn't
+// tick, e.g. while paused.
+func (gs *GameStateWithShooting) takeAnalysis() (AnalysisFrame, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !gs.analyzing || gs.Tick == gs.analyzedTick {
+		return AnalysisFrame{}, false
+	}
+	gs.analyzedTick = gs.Tick
+
+	frame := AnalysisFrame{
+		RoomID:      gs.RoomID,
+		Tick:        gs.Tick,
+		GameTime:    gs.GameTime,
+		Gold:        gs.Gold,
+		Health:      gs.Health,
+		Decisions:   gs.decisions,
+		Events:      append([]GameEvent{}, gs.Events...),
+		Towers:      append([]Tower{}, gs.Towers...),
+		Enemies:     append([]Enemy{}, gs.Enemies...),
+		Projectiles: append([]Projectile{}, gs.Projectiles...),
+		TowerStats:  gs.towerStatsList(),
+	}
+	if frame.Decisions == nil {
+		frame.Decisions = []AuditRecord{}
+	}
+	gs.decisions = nil
+	return frame, true
+}
+
+// WatchAnalysis starts building a room's analysis stream. Calls are
+// counted, so each watcher needs a matching UnwatchAnalysis.
+func (m *Manager) WatchAnalysis(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.analysis[roomID]++
+}
+
+// UnwatchAnalysis drops one watcher of a room's analysis stream
+func (m *Manager) UnwatchAnalysis(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.analysis[roomID] <= 1 {
+		delete(m.analysis, roomID)
+		return
+	}
+	m.analysis[roomID]--
+}
+
+// analyzed reports whether anyone wants a room's analysis stream
+func (m *Manager) analyzed(roomID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.analysis[roomID] > 0
+}
+
+// publishAnalysis sends an analysis frame to the hub
+func (m *Manager) publishAnalysis(frame AnalysisFrame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("❌ Failed to marshal analysis frame: %v", err)
+		return
+	}
+	select {
+	case m.analysisFeed <- BroadcastMessage{RoomID: frame.RoomID, Kind: BroadcastAnalysis, Data: data}:
+	default:
+		// Analysts miss a frame rather than hold up the game loop
+	}
+}
@@ -0,0 +1,96 @@
+package game
+
+import "sort"
+
+// Tower attack kinds
+const (
+	attackProjectile = "projectile"
+	attackChain      = "chain"
+	attackSpread     = "spread"
+	attackIncome     = "income" // banks make gold instead of attacking
+)
+
+// fire makes a ready tower attack, starting with the given target
+func (gs *GameStateWithShooting) fire(tower *Tower, target *Enemy) {
+	gs.towerStatsFor(tower.ID).Shots++
+	stats := gs.mods.towerStats(tower.TowerType)
+
+	switch stats.Attack {
+	case attackChain:
+		gs.fireChain(tower, target, stats)
+	case attackSpread:
+		gs.fireSpread(tower, target, stats)
+	default:
+		gs.shootProjectile(tower, target)
+	}
+}
+
+// fireChain hits the target instantly with lightning that then jumps to up
+// to ChainTargets more enemies, each jump doing less damage
+func (gs *GameStateWithShooting) fireChain(tower *Tower, target *Enemy, stats towerStats) {
+	hit := map[EntityID]bool{target.ID: true}
+	hops := []Position{tower.Position, target.Position}
+	ids := []EntityID{target.ID}
+
+	damage := tower.Damage
+	gs.damageEnemy(target, damage, tower.ID)
+
+	current := target
+	for jump := 0; jump < stats.ChainTargets; jump++ {
+		var next *Enemy
+		nextDist := 0.0
+		for _, enemy := range gs.enemyIndex.query(gs.Enemies, current.Position, stats.ChainRange) {
+			if hit[enemy.ID] || !gs.lineOfSight(current.Position, enemy.Position) {
+				continue
+			}
+			if d := distance(current.Position, enemy.Position); next == nil || d < nextDist {
+				next, nextDist = enemy, d
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		damage *= stats.ChainDecay
+		gs.damageEnemy(next, damage, tower.ID)
+		hit[next.ID] = true
+		hops = append(hops, next.Position)
+		ids = append(ids, next.ID)
+		current = next
+	}
+
+	pos := tower.Position
+	gs.emitEvent(EventChainLightning, &pos, map[string]interface{}{
+		"tower_id":  tower.ID,
+		"enemy_ids": ids,
+		"hops":      hops,
+		"duration":  0.2,
+	})
+}
+
+// fireSpread fires Pellets projectiles at once, one per enemy in range
+// starting with the closest; if there are fewer enemies than pellets the
+// remaining pellets go to the primary target
+func (gs *GameStateWithShooting) fireSpread(tower *Tower, target *Enemy, stats towerStats) {
+	candidates := gs.enemyIndex.query(gs.Enemies, tower.Position, tower.Range)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distance(tower.Position, candidates[i].Position) < distance(tower.Position, candidates[j].Position)
+	})
+
+	targets := []*Enemy{target}
+	for _, enemy := range candidates {
+		if len(targets) >= stats.Pellets {
+			break
+		}
+		if enemy.ID != target.ID {
+			targets = append(targets, enemy)
+		}
+	}
+	for len(targets) < stats.Pellets {
+		targets = append(targets, target)
+	}
+
+	for _, t := range targets {
+		gs.shootProjectile(tower, t)
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+// auditCapacity is how many records a debug room keeps
+const auditCapacity = 4096
+
+// Audit record kinds
+const (
+	AuditTarget      = "target"       // tower picked a new target
+	AuditNoTarget    = "no_target"    // tower lost its target and found none in range
+	AuditFire        = "fire"         // tower attacked
+	AuditDamage      = "damage"       // enemy took damage
+	AuditTowerState  = "tower_state"  // tower changed state
+	AuditPathRecalc  = "path_recalc"  // enemy path was recomputed
+	AuditEnemyKilled = "enemy_killed" // enemy died
+)
+
+// AuditRecord is one intermediate simulation value recorded in debug rooms
+type AuditRecord struct {
+	Tick     uint64                 `json:"tick"`
+	GameTime float64                `json:"game_time"`
+	Kind     string                 `json:"kind"`
+	TowerID  EntityID               `json:"tower_id,omitempty"`
+	EnemyID  EntityID               `json:"enemy_id,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// auditLog is a fixed-size ring buffer of audit records
+type auditLog struct {
+	records []AuditRecord
+	next    int
+	full    bool
+}
+
+func newAuditLog(capacity int) *auditLog {
+	return &auditLog{records: make([]AuditRecord, capacity)}
+}
+
+// add appends a record, overwriting the oldest once full
+func (a *auditLog) add(record AuditRecord) {
+	a.records[a.next] = record
+	a.next = (a.next + 1) % len(a.records)
+	if a.next == 0 {
+		a.full = true
+	}
+}
+
+// recent returns up to limit of the newest records, oldest first
+func (a *auditLog) recent(limit int) []AuditRecord {
+	size := a.next
+	if a.full {
+		size = len(a.records)
+	}
+	if limit <= 0 || limit > size {
+		limit = size
+	}
+
+	result := make([]AuditRecord, 0, limit)
+	start := a.next - limit
+	for i := 0; i < limit; i++ {
+		idx := (start + i + len(a.records)) % len(a.records)
+		result = append(result, a.records[idx])
+	}
+	return result
+}
+
+// audit records a value if the room has debug auditing on or is being
+// analyzed. Callers must hold the state lock.
+func (gs *GameStateWithShooting) audit(kind string, towerID, enemyID EntityID, data map[string]interface{}) {
+	if gs.auditLog == nil && !gs.analyzing {
+		return
+	}
+
+	record := AuditRecord{
+		Tick:     gs.Tick,
+		GameTime: gs.GameTime,
+		Kind:     kind,
+		TowerID:  towerID,
+		EnemyID:  enemyID,
+		Data:     data,
+	}
+	if gs.auditLog != nil {
+		gs.auditLog.add(record)
+	}
+	if gs.analyzing {
+		gs.decisions = append(gs.decisions, record)
+	}
+}
+
+// AuditLog returns up to limit of the newest audit records. It returns false
+// if the room isn't a debug room.
+func (gs *GameStateWithShooting) AuditLog(limit int) ([]AuditRecord, bool) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	if gs.auditLog == nil {
+		return nil, false
+	}
+	return gs.auditLog.recent(limit), true
+}
@@ -0,0 +1,296 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/cluster"
+)
+
+// Autosave defaults
+const (
+	DefaultAutosaveInterval  = time.Minute
+	DefaultAutosaveRetention = 3
+	autosaveQueueSize        = 64 // saves waiting to upload before new ones are dropped
+)
+
+// AutosaveConfig is how often rooms are saved and how many saves each room
+// keeps. Zero values take the defaults.
+type AutosaveConfig struct {
+	Interval  time.Duration
+	Retention int
+}
+
+// AutosaveStats is how autosaving has gone since the server started
+type AutosaveStats struct {
+	Saves     int     `json:"saves"`
+	Failures  int     `json:"failures"`
+	Dropped   int     `json:"dropped"` // skipped because uploads fell behind
+	Pending   int     `json:"pending"` // waiting to upload
+	LastMs    float64 `json:"last_ms"` // upload latency
+	AvgMs     float64 `json:"avg_ms"`
+	MaxMs     float64 `json:"max_ms"`
+	LastBytes int     `json:"last_bytes"`
+}
+
+// autosaveJob is a save to upload, or with no data, a room whose saves are
+// no longer needed
+type autosaveJob struct {
+	roomID string
+	tick   uint64
+	data   []byte
+}
+
+// autosaver uploads room saves on its own goroutine, so a slow backend
+// never holds up a game loop
+type autosaver struct {
+	store  cluster.SaveStore
+	config AutosaveConfig
+	jobs   chan autosaveJob
+
+	mu        sync.Mutex
+	saved     map[string]uint64 // tick of each room's newest save
+	forgotten map[string]bool   // closed rooms whose saves are being removed
+	stats     AutosaveStats
+	uploading time.Duration // total upload time, for the average
+}
+
+func newAutosaver(store cluster.SaveStore, config AutosaveConfig) *autosaver {
+	if config.Interval <= 0 {
+		config.Interval = DefaultAutosaveInterval
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultAutosaveRetention
+	}
+	return &autosaver{
+		store:     store,
+		config:    config,
+		jobs:      make(chan autosaveJob, autosaveQueueSize),
+		saved:     make(map[string]uint64),
+		forgotten: make(map[string]bool),
+	}
+}
+
+// queue hands a job to the uploader, dropping it if the uploader is too
+// far behind
+func (a *autosaver) queue(job autosaveJob) bool {
+	select {
+	case a.jobs <- job:
+		return true
+	default:
+		a.mu.Lock()
+		a.stats.Dropped++
+		a.mu.Unlock()
+		return false
+	}
+}
+
+// run uploads queued saves until the queue is closed
+func (a *autosaver) run() {
+	for job := range a.jobs {
+		if job.data == nil {
+			a.removeAll(job.roomID)
+			continue
+		}
+		a.upload(job)
+	}
+}
+
+// upload stores one save and prunes the room's saves down to the
+// retention count
+func (a *autosaver) upload(job autosaveJob) {
+	start := time.Now()
+	err := a.store.Save(job.roomID, job.tick, job.data)
+	elapsed := time.Since(start)
+
+	a.mu.Lock()
+	if err != nil {
+		a.stats.Failures++
+		delete(a.saved, job.roomID) // try again next round
+	} else {
+		a.stats.Saves++
+		a.uploading += elapsed
+		a.stats.LastMs = float64(elapsed) / float64(time.Millisecond)
+		a.stats.AvgMs = float64(a.uploading) / float64(a.stats.Saves) / float64(time.Millisecond)
+		if a.stats.LastMs > a.stats.MaxMs {
+			a.stats.MaxMs = a.stats.LastMs
+		}
+		a.stats.LastBytes = len(job.data)
+	}
+	a.mu.Unlock()
+	if err != nil {
+		log.Printf("❌ Failed to autosave room %s: %v", job.roomID, err)
+		return
+	}
+
+	ticks, err := a.store.List(job.roomID)
+	if err != nil {
+		log.Printf("⚠️ Failed to list autosaves of room %s: %v", job.roomID, err)
+		return
+	}
+	for len(ticks) > a.config.Retention {
+		if err := a.store.Remove(job.roomID, ticks[0]); err != nil {
+			log.Printf("⚠️ Failed to prune autosave of room %s: %v", job.roomID, err)
+			return
+		}
+		ticks = ticks[1:]
+	}
+}
+
+// removeAll deletes every save of a closed room
+func (a *autosaver) removeAll(roomID string) {
+	ticks, err := a.store.List(roomID)
+	if err == nil {
+		for _, tick := range ticks {
+			if err = a.store.Remove(roomID, tick); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to remove autosaves of room %s: %v", roomID, err)
+	}
+
+	a.mu.Lock()
+	delete(a.forgotten, roomID)
+	a.mu.Unlock()
+}
+
+// latest loads a room's newest save, if it has one that isn't being
+// removed
+func (a *autosaver) latest(roomID string) ([]byte, bool, error) {
+	a.mu.Lock()
+	forgotten := a.forgotten[roomID]
+	a.mu.Unlock()
+	if forgotten {
+		return nil, false, nil
+	}
+
+	ticks, err := a.store.List(roomID)
+	if err != nil || len(ticks) == 0 {
+		return nil, false, err
+	}
+	data, err := a.store.Load(roomID, ticks[len(ticks)-1])
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// onRoomClosed drops the saves of rooms that closed for good. Rooms handed
+// off keep theirs for the instance that adopts them.
+func (a *autosaver) onRoomClosed(roomID string, _ uint64, reason string) {
+	if reason != RoomClosedDeleted {
+		return
+	}
+	a.mu.Lock()
+	delete(a.saved, roomID)
+	a.forgotten[roomID] = true
+	a.mu.Unlock()
+	if !a.queue(autosaveJob{roomID: roomID}) {
+		a.mu.Lock()
+		delete(a.forgotten, roomID)
+		a.mu.Unlock()
+	}
+}
+
+// encodeArchive serializes the whole room for an autosave
+func (gs *GameStateWithShooting) encodeArchive() ([]byte, uint64, error) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	snapshot := gs.snapshot()
+	data, err := json.Marshal(gs.archiveWith(&snapshot))
+	return data, gs.Tick, err
+}
+
+// UseAutosaves saves every room to a store on an interval, so another
+// instance can pick rooms up if this one dies without draining
+func (m *Manager) UseAutosaves(store cluster.SaveStore, config AutosaveConfig) {
+	saver := newAutosaver(store, config)
+
+	m.mu.Lock()
+	m.autosaves = saver
+	m.mu.Unlock()
+
+	m.Subscribe(RoomHooks{OnRoomClosed: saver.onRoomClosed})
+	go saver.run()
+}
+
+// RunAutosaves saves each room that has moved on since its last save,
+// every autosave interval. Rooms are serialized here and uploaded in the
+// background.
+func (m *Manager) RunAutosaves() {
+	m.mu.RLock()
+	saver := m.autosaves
+	m.mu.RUnlock()
+	if saver == nil {
+		return
+	}
+
+	ticker := time.NewTicker(saver.config.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, room := range m.shootingRooms() {
+			if room.IsDemo() {
+				continue
+			}
+			m.autosave(saver, room)
+		}
+	}
+}
+
+// autosave queues a save of one room, unless it hasn't changed
+func (m *Manager) autosave(saver *autosaver, room *GameStateWithShooting) {
+	room.mu.RLock()
+	tick := room.Tick
+	room.mu.RUnlock()
+
+	saver.mu.Lock()
+	last, saved := saver.saved[room.RoomID]
+	saver.mu.Unlock()
+	if saved && last == tick {
+		return
+	}
+
+	data, tick, err := room.encodeArchive()
+	if err != nil {
+		log.Printf("❌ Failed to encode autosave of room %s: %v", room.RoomID, err)
+		return
+	}
+	if saver.queue(autosaveJob{roomID: room.RoomID, tick: tick, data: data}) {
+		saver.mu.Lock()
+		saver.saved[room.RoomID] = tick
+		saver.mu.Unlock()
+	}
+}
+
+// latestAutosave loads a room's newest autosave, if autosaves are on
+func (m *Manager) latestAutosave(roomID string) ([]byte, bool, error) {
+	m.mu.RLock()
+	saver := m.autosaves
+	m.mu.RUnlock()
+	if saver == nil {
+		return nil, false, nil
+	}
+	return saver.latest(roomID)
+}
+
+// AutosaveStats reports autosave counts and upload latency
+func (m *Manager) AutosaveStats() AutosaveStats {
+	m.mu.RLock()
+	saver := m.autosaves
+	m.mu.RUnlock()
+	if saver == nil {
+		return AutosaveStats{}
+	}
+
+	saver.mu.Lock()
+	defer saver.mu.Unlock()
+	stats := saver.stats
+	stats.Pending = len(saver.jobs)
+	return stats
+}
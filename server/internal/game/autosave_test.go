@@ -0,0 +1,77 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"rust-rush/server/internal/cluster"
+)
+
+// waitFor polls until a condition holds or a second has passed
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAutosavesKeepTheNewestAndResumeTheRoom(t *testing.T) {
+	store, err := cluster.NewDiskSaveStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open save store: %v", err)
+	}
+	m := NewManager()
+	m.UseAutosaves(store, AutosaveConfig{Retention: 2})
+	room, err := m.CreateShootingRoomWithConfig("saved-1", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	// Paused, so the room only moves on when the test moves it
+	room.SetPaused(true)
+
+	for saves := 1; saves <= 3; saves++ {
+		room.mu.Lock()
+		room.Tick += 60
+		room.Gold = 700 + saves
+		room.mu.Unlock()
+		m.autosave(m.autosaves, room)
+		waitFor(t, "the save to upload", func() bool { return m.AutosaveStats().Saves == saves })
+	}
+
+	// Saving again before the room moves on is skipped
+	m.autosave(m.autosaves, room)
+	if stats := m.AutosaveStats(); stats.Pending != 0 || stats.Saves != 3 {
+		t.Fatalf("saved an unchanged room again: %+v", stats)
+	}
+
+	ticks, err := store.List("saved-1")
+	if err != nil {
+		t.Fatalf("failed to list saves: %v", err)
+	}
+	if len(ticks) != 2 {
+		t.Fatalf("kept saves at ticks %v, want the newest 2", ticks)
+	}
+
+	// Another instance picks the room up from its newest save
+	adopting := NewManager()
+	adopting.UseAutosaves(store, AutosaveConfig{})
+	adopted, ok := adopting.Adopt("saved-1")
+	if !ok {
+		t.Fatal("room wasn't resumed from its autosave")
+	}
+	defer adopting.DeleteRoom("saved-1")
+	if gold := adopted.GetSnapshot().Gold; gold != 703 {
+		t.Fatalf("resumed room has %d gold, want the newest save's 703", gold)
+	}
+
+	// Closing the room for good removes its saves
+	m.DeleteRoom("saved-1")
+	waitFor(t, "the saves to be removed", func() bool {
+		ticks, err := store.List("saved-1")
+		return err == nil && len(ticks) == 0
+	})
+}
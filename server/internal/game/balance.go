@@ -0,0 +1,349 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Balance is a versioned set of tower and enemy stats. A Balance is never
+// modified once published, so rooms can share it.
+type Balance struct {
+	Version int                   `json:"version"`
+	Towers  map[string]towerStats `json:"towers"`
+	Enemies map[string]enemyStats `json:"enemies"`
+}
+
+// defaultBalance returns the built-in stats
+func defaultBalance() *Balance {
+	return &Balance{
+		Version: 1,
+		Towers:  defaultTowerStats(),
+		Enemies: defaultEnemyStats(),
+	}
+}
+
+// tower returns a tower type's stats, falling back to the basic tower
+func (b *Balance) tower(towerType string) towerStats {
+	if s, ok := b.Towers[towerType]; ok {
+		return s
+	}
+	return b.Towers["basic"]
+}
+
+// enemy returns an enemy type's stats, falling back to the basic enemy
+func (b *Balance) enemy(enemyType string) enemyStats {
+	if s, ok := b.Enemies[enemyType]; ok {
+		return s
+	}
+	return b.Enemies["basic"]
+}
+
+// validate rejects stats that would break the simulation
+func (b *Balance) validate() error {
+	for towerType, s := range b.Towers {
+		if s.Cost < 0 || s.Range <= 0 || s.Damage < 0 || s.FireRate <= 0 || s.BuildTime < 0 || s.Income < 0 {
+			return i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": towerType})
+		}
+		if s.Dot != nil && (s.Dot.TickInterval <= 0 || s.Dot.MaxStacks < 1) {
+			return i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": towerType})
+		}
+		if !validateBranches(s.Upgrades) {
+			return i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": towerType})
+		}
+	}
+	for enemyType, s := range b.Enemies {
+		if s.Health <= 0 || s.Speed <= 0 || s.Armor < 0 || s.Armor >= 1 {
+			return i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": enemyType})
+		}
+	}
+	return nil
+}
+
+// BalanceStore holds the live balance, optionally loaded from a JSON file
+type BalanceStore struct {
+	mu      sync.RWMutex
+	current *Balance
+	path    string
+}
+
+// NewBalanceStore creates a store with the built-in stats
+func NewBalanceStore() *BalanceStore {
+	return &BalanceStore{
+		current: defaultBalance(),
+	}
+}
+
+// Current returns the live balance
+func (s *BalanceStore) Current() *Balance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.current
+}
+
+// UseFile loads stats from a JSON file and makes Reload read from it
+func (s *BalanceStore) UseFile(path string) error {
+	s.mu.Lock()
+	s.path = path
+	s.mu.Unlock()
+
+	_, err := s.Reload()
+	return err
+}
+
+// Reload re-reads the balance file and publishes it under a new version.
+// Entries in the file override the built-in stats; types it doesn't mention
+// keep their defaults.
+func (s *BalanceStore) Reload() (*Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return s.current, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Towers  map[string]towerStats `json:"towers"`
+		Enemies map[string]enemyStats `json:"enemies"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	balance := defaultBalance()
+	balance.Version = s.current.Version + 1
+	for towerType, stats := range file.Towers {
+		balance.Towers[towerType] = stats
+	}
+	for enemyType, stats := range file.Enemies {
+		balance.Enemies[enemyType] = stats
+	}
+	if err := balance.validate(); err != nil {
+		return nil, err
+	}
+
+	s.current = balance
+	return balance, nil
+}
+
+// QueueBalance stages new stats to take effect at the next wave boundary
+func (gs *GameStateWithShooting) QueueBalance(balance *Balance) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if balance.Version > gs.ConfigVersion {
+		gs.pendingBalance = balance
+	}
+}
+
+// applyPendingBalance swaps in staged stats. It runs between waves so no
+// enemy or tower changes stats mid-fight. Callers must hold the state lock.
+func (gs *GameStateWithShooting) applyPendingBalance() {
+	if gs.pendingBalance == nil {
+		return
+	}
+	balance := gs.pendingBalance
+	gs.pendingBalance = nil
+
+	gs.useBalance(balance)
+	gs.emitEvent(EventBalanceUpdated, nil, map[string]interface{}{
+		"config_version": balance.Version,
+	})
+}
+
+// useBalance rebuilds the room's modifiers on new stats. Existing towers are
+// scaled by the change in their base stats so upgrades are kept. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) useBalance(balance *Balance) {
+	old := gs.mods.balance
+	gs.mods = buildModifiers(gs.Config, balance)
+	gs.ConfigVersion = balance.Version
+
+	if old == nil {
+		return
+	}
+	for i := range gs.Towers {
+		tower := &gs.Towers[i]
+		before, after := old.tower(tower.TowerType), balance.tower(tower.TowerType)
+		tower.Range *= after.Range / before.Range
+		tower.Damage *= ratio(after.Damage, before.Damage)
+		tower.FireRate *= after.FireRate / before.FireRate
+	}
+}
+
+// ratio divides a by b, treating a zero base as unchanged
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 1
+	}
+	return a / b
+}
+
+type towerStats struct {
+	Cost         int      `json:"cost"`
+	BuildTime    float64  `json:"build_time"`
+	Attack       string   `json:"attack,omitempty"`
+	SplashRadius float64  `json:"splash_radius,omitempty"`
+	ChainTargets int      `json:"chain_targets,omitempty"` // extra enemies chain lightning jumps to
+	ChainRange   float64  `json:"chain_range,omitempty"`   // max distance of each jump
+	ChainDecay   float64  `json:"chain_decay,omitempty"`   // damage multiplier per jump
+	Pellets      int      `json:"pellets,omitempty"`       // projectiles per spread shot
+	Income       int      `json:"income,omitempty"`        // gold per payout, banks only
+	Dot          *dotSpec `json:"dot,omitempty"`
+	Range        float64  `json:"range"`
+	Damage       float64  `json:"damage"`
+	FireRate     float64  `json:"fire_rate"`
+
+	// Upgrade tree; types without one upgrade linearly
+	Upgrades []upgradeBranch `json:"upgrades,omitempty"`
+}
+
+// defaultTowerStats are the built-in tower stats
+func defaultTowerStats() map[string]towerStats {
+	return map[string]towerStats{
+		"basic": {
+			Cost:      50,
+			BuildTime: 2.0,
+			Range:     3.0,
+			Damage:    15.0,
+			FireRate:  1.0, // 1 shot per second
+		},
+		"sniper": {
+			Cost:      100,
+			BuildTime: 4.0,
+			Range:     6.0,
+			Damage:    50.0,
+			FireRate:  0.5, // 1 shot every 2 seconds
+			Upgrades: []upgradeBranch{
+				{
+					Name:        "armor_piercing",
+					Damage:      1.2,
+					ArmorPierce: 1,
+					Next:        []upgradeBranch{{Name: "deadeye", Cost: 150, Damage: 1.4, Range: 1.15}},
+				},
+				{
+					Name:     "rapid_fire",
+					Damage:   0.9,
+					FireRate: 1.6,
+					Next:     []upgradeBranch{{Name: "suppressor", Cost: 150, FireRate: 1.5, Range: 1.1}},
+				},
+			},
+		},
+		"splash": {
+			Cost:         75,
+			BuildTime:    3.0,
+			Range:        2.5,
+			SplashRadius: 1.0,
+			Dot: &dotSpec{
+				Kind:         DotBurn,
+				Damage:       2.0,
+				TickInterval: 0.5,
+				Duration:     3.0,
+				MaxStacks:    1,
+			},
+			Damage:   10.0,
+			FireRate: 1.5, // 1.5 shots per second
+		},
+		"slow": {
+			Cost:      60,
+			BuildTime: 2.5,
+			Range:     3.5,
+			Damage:    8.0,
+			FireRate:  0.8,
+		},
+		"tesla": {
+			Cost:         120,
+			BuildTime:    3.5,
+			Attack:       attackChain,
+			Range:        3.0,
+			Damage:       30.0,
+			FireRate:     0.7,
+			ChainTargets: 3,
+			ChainRange:   1.5,
+			ChainDecay:   0.7,
+		},
+		"venom": {
+			Cost:      80,
+			BuildTime: 2.5,
+			Range:     3.0,
+			Damage:    5.0,
+			FireRate:  1.0,
+			Dot: &dotSpec{
+				Kind:         DotPoison,
+				Damage:       3.0,
+				TickInterval: 1.0,
+				Duration:     5.0,
+				MaxStacks:    5,
+			},
+		},
+		"shotgun": {
+			Cost:      90,
+			BuildTime: 3.0,
+			Attack:    attackSpread,
+			Range:     2.5,
+			Damage:    12.0,
+			FireRate:  0.9,
+			Pellets:   3,
+		},
+		"bank": {
+			Cost:      100,
+			BuildTime: 3.0,
+			Attack:    attackIncome,
+			Range:     1.0, // unused, but kept positive like every tower's
+			Damage:    0,
+			FireRate:  0.1, // one payout every 10 seconds
+			Income:    15,
+		},
+	}
+}
+
+type enemyStats struct {
+	Health  float64       `json:"health"`
+	Speed   float64       `json:"speed"`
+	Armor   float64       `json:"armor,omitempty"` // share of each hit absorbed, below 1
+	Ability *enemyAbility `json:"ability,omitempty"`
+}
+
+// defaultEnemyStats are the built-in enemy stats
+func defaultEnemyStats() map[string]enemyStats {
+	return map[string]enemyStats{
+		"basic": {
+			Health: 100.0,
+			Speed:  2.0,
+		},
+		"fast": {
+			Health: 50.0,
+			Speed:  4.0,
+		},
+		"tank": {
+			Health: 300.0,
+			Speed:  1.0,
+			Armor:  0.3,
+		},
+		"flying": {
+			Health: 80.0,
+			Speed:  3.0,
+		},
+		"boss": {
+			Health: 1000.0,
+			Speed:  0.5,
+		},
+		"emp": {
+			Health: 120.0,
+			Speed:  1.8,
+			Ability: &enemyAbility{
+				Cooldown: 6.0,
+				Radius:   2.0,
+				Duration: 3.0,
+			},
+		},
+	}
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// balanceSample is what one finished coop match contributes to the balance
+// stats. It names no players or rooms.
+type balanceSample struct {
+	result     string
+	wave       int
+	difficulty string         // the room's mutator score multiplier
+	built      map[string]int // towers placed by type
+	variants   []string       // "experiment/variant" for each experiment the room was in
+}
+
+// balanceTotals are summed balance stats
+type balanceTotals struct {
+	Matches      int                         `json:"matches"`
+	TowerMatches map[string]int              `json:"tower_matches"` // matches each tower type was built in
+	TowersBuilt  map[string]int              `json:"towers_built"`
+	Difficulty   map[string]*difficultyTotal `json:"difficulty"` // keyed by score multiplier
+	Variants     map[string]*difficultyTotal `json:"variants"`   // keyed by experiment/variant
+	Defeats      int                         `json:"defeats"`
+	DefeatWaves  int                         `json:"defeat_waves"` // sum of the waves defeats happened on
+}
+
+type difficultyTotal struct {
+	Matches int `json:"matches"`
+	Wins    int `json:"wins"`
+}
+
+// winRate reports the total with how often its matches were won
+func (d *difficultyTotal) winRate() DifficultyWinRate {
+	return DifficultyWinRate{
+		Matches: d.Matches,
+		Wins:    d.Wins,
+		WinRate: float64(d.Wins) / float64(d.Matches),
+	}
+}
+
+func newBalanceTotals() balanceTotals {
+	return balanceTotals{
+		TowerMatches: make(map[string]int),
+		TowersBuilt:  make(map[string]int),
+		Difficulty:   make(map[string]*difficultyTotal),
+		Variants:     make(map[string]*difficultyTotal),
+	}
+}
+
+// add counts one match
+func (t *balanceTotals) add(s balanceSample) {
+	t.Matches++
+	for towerType, n := range s.built {
+		t.TowerMatches[towerType]++
+		t.TowersBuilt[towerType] += n
+	}
+
+	countResult(t.Difficulty, s.difficulty, s.result)
+	for _, variant := range s.variants {
+		countResult(t.Variants, variant, s.result)
+	}
+	if s.result == ResultDefeat {
+		t.Defeats++
+		t.DefeatWaves += s.wave
+	}
+}
+
+// countResult counts a match and whether it was won under a key
+func countResult(totals map[string]*difficultyTotal, key, result string) {
+	d := totals[key]
+	if d == nil {
+		d = &difficultyTotal{}
+		totals[key] = d
+	}
+	d.Matches++
+	if result == ResultVictory {
+		d.Wins++
+	}
+}
+
+// merge adds another set of totals into these
+func (t *balanceTotals) merge(o balanceTotals) {
+	t.Matches += o.Matches
+	for towerType, n := range o.TowerMatches {
+		t.TowerMatches[towerType] += n
+	}
+	for towerType, n := range o.TowersBuilt {
+		t.TowersBuilt[towerType] += n
+	}
+	mergeResults(t.Difficulty, o.Difficulty)
+	mergeResults(t.Variants, o.Variants)
+	t.Defeats += o.Defeats
+	t.DefeatWaves += o.DefeatWaves
+}
+
+// mergeResults adds one set of per-key results into another
+func mergeResults(totals, other map[string]*difficultyTotal) {
+	for key, od := range other {
+		d := totals[key]
+		if d == nil {
+			d = &difficultyTotal{}
+			totals[key] = d
+		}
+		d.Matches += od.Matches
+		d.Wins += od.Wins
+	}
+}
+
+// DifficultyWinRate is how often coop matches at one difficulty are won
+type DifficultyWinRate struct {
+	Matches int     `json:"matches"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+}
+
+// BalanceReport is the balance stats gathered so far, for tuning
+type BalanceReport struct {
+	Matches           int                          `json:"matches"`
+	TowerPickRates    map[string]float64           `json:"tower_pick_rates"` // share of matches each type was built in
+	TowersBuilt       map[string]int               `json:"towers_built"`
+	WinRates          map[string]DifficultyWinRate `json:"win_rates"` // keyed by score multiplier
+	VariantWinRates   map[string]DifficultyWinRate `json:"variant_win_rates,omitempty"`
+	AverageDefeatWave float64                      `json:"average_defeat_wave"`
+	Pending           int                          `json:"pending"` // matches not flushed yet
+}
+
+// BalanceStatsStore aggregates anonymous balance stats across coop matches.
+// Matches are counted in memory as they finish and flushed into the totals
+// periodically, which are written through to a file once one is given.
+type BalanceStatsStore struct {
+	mu      sync.Mutex
+	pending balanceTotals
+	totals  balanceTotals
+	path    string
+}
+
+// NewBalanceStatsStore creates an empty in-memory store
+func NewBalanceStatsStore() *BalanceStatsStore {
+	return &BalanceStatsStore{
+		pending: newBalanceTotals(),
+		totals:  newBalanceTotals(),
+	}
+}
+
+// UseFile loads the totals from a JSON file, if it exists, and writes them
+// back to it on every flush
+func (s *BalanceStatsStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	totals := newBalanceTotals()
+	if err := json.Unmarshal(data, &totals); err != nil {
+		return err
+	}
+	s.totals.merge(totals)
+	return nil
+}
+
+// record counts a finished match towards the next flush
+func (s *BalanceStatsStore) record(sample balanceSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending.add(sample)
+}
+
+// Flush moves the matches counted since the last flush into the totals and
+// saves them
+func (s *BalanceStatsStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending.Matches == 0 {
+		return nil
+	}
+	s.totals.merge(s.pending)
+	s.pending = newBalanceTotals()
+	return s.persist()
+}
+
+// Report summarizes the flushed totals
+func (s *BalanceStatsStore) Report() BalanceReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.totals
+	report := BalanceReport{
+		Matches:        t.Matches,
+		TowerPickRates: make(map[string]float64, len(t.TowerMatches)),
+		TowersBuilt:    make(map[string]int, len(t.TowersBuilt)),
+		WinRates:       make(map[string]DifficultyWinRate, len(t.Difficulty)),
+		Pending:        s.pending.Matches,
+	}
+	for towerType, n := range t.TowerMatches {
+		report.TowerPickRates[towerType] = float64(n) / float64(t.Matches)
+	}
+	for towerType, n := range t.TowersBuilt {
+		report.TowersBuilt[towerType] = n
+	}
+	for key, d := range t.Difficulty {
+		report.WinRates[key] = d.winRate()
+	}
+	if len(t.Variants) > 0 {
+		report.VariantWinRates = make(map[string]DifficultyWinRate, len(t.Variants))
+		for key, d := range t.Variants {
+			report.VariantWinRates[key] = d.winRate()
+		}
+	}
+	if t.Defeats > 0 {
+		report.AverageDefeatWave = float64(t.DefeatWaves) / float64(t.Defeats)
+	}
+	return report
+}
+
+// persist writes the totals to disk if a file is configured. Callers must
+// hold the lock.
+func (s *BalanceStatsStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.totals, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// balanceSample describes the finished match for the balance stats
+func (gs *GameStateWithShooting) balanceSample(result string) balanceSample {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	built := make(map[string]int, len(gs.built))
+	for towerType, n := range gs.built {
+		built[towerType] = n
+	}
+	variants := make([]string, 0, len(gs.Config.Experiments))
+	for name, variant := range gs.Config.Experiments {
+		variants = append(variants, name+"/"+variant)
+	}
+	return balanceSample{
+		result:     result,
+		wave:       gs.Wave,
+		difficulty: strconv.FormatFloat(gs.ScoreMultiplier, 'f', 2, 64),
+		built:      built,
+		variants:   variants,
+	}
+}
+
+// recordBalanceStats counts a finished coop match in the balance stats,
+// unless one of its players opted out
+func (m *Manager) recordBalanceStats(match MatchResult, sample balanceSample) {
+	for _, playerID := range match.Players {
+		if m.profiles.Get(playerID).NoTelemetry {
+			return
+		}
+	}
+	m.stats.record(sample)
+}
+
+// StartStatsFlush periodically flushes the balance stats to their store
+func (m *Manager) StartStatsFlush(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := m.stats.Flush(); err != nil {
+			log.Printf("❌ Failed to flush balance stats: %v", err)
+		}
+	}
+}
+
+// BalanceStats returns the balance stats store
+func (m *Manager) BalanceStats() *BalanceStatsStore {
+	return m.stats
+}
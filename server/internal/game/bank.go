@@ -0,0 +1,38 @@
+package game
+
+import "math"
+
+// Bank tuning. Every active bank after the first pays bankFalloff less than
+// the one before it, so income can't simply be stacked; upgrades raise a
+// bank's payout like they raise other towers' damage.
+const (
+	bankFalloff   = 0.8
+	minBankPayout = 1
+)
+
+// EventIncome is sent when a bank pays out
+const EventIncome = "income"
+
+// updateBank pays out an active bank whose timer has run down. Banks only
+// pay while a wave is being fought, so waiting between waves earns nothing.
+// Banks rank in placement order, oldest first; rank is how many active
+// banks came before this one. Callers must hold the state lock.
+func (gs *GameStateWithShooting) updateBank(tower *Tower, stats towerStats, rank int) {
+	if tower.Cooldown > 0 || !gs.inCombat() {
+		return
+	}
+	tower.Cooldown = 1.0 / tower.FireRate
+
+	payout := float64(stats.Income) *
+		math.Pow(bankFalloff, float64(rank)) *
+		math.Pow(upgradeDamageRatio, float64(tower.Level-1))
+	amount := int(math.Max(minBankPayout, math.Round(payout)))
+	gs.adjustGold(GoldIncome, amount, tower.OwnerID, tower.ID)
+
+	pos := tower.Position
+	gs.emitEvent(EventIncome, &pos, map[string]interface{}{
+		"tower_id": tower.ID,
+		"amount":   amount,
+		"rank":     rank,
+	})
+}
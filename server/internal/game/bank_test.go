@@ -0,0 +1,38 @@
+package game
+
+import "testing"
+
+// newBankRoom opens a room with one built bank, ready to pay out
+func newBankRoom(t *testing.T) *GameStateWithShooting {
+	t.Helper()
+	room, err := NewManager().CreateShootingRoomWithConfig("bank-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Towers = append(room.Towers, Tower{
+		ID:        room.ids.Next(),
+		TowerType: "bank",
+		Level:     1,
+		FireRate:  0.1,
+		State:     TowerStateActive,
+	})
+	return room
+}
+
+func TestBankPaysOnlyDuringWaves(t *testing.T) {
+	room := newBankRoom(t)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	gold := room.Gold
+	room.updateTowers(0.1)
+	if room.Gold != gold {
+		t.Fatalf("bank paid %d gold between waves", room.Gold-gold)
+	}
+
+	room.pendingSpawns = append(room.pendingSpawns, scheduledSpawn{enemyType: "basic"})
+	room.updateTowers(0.1)
+	if room.Gold <= gold {
+		t.Fatal("bank didn't pay during a wave")
+	}
+}
@@ -0,0 +1,134 @@
+package game
+
+// Late joiners get this much context on top of the keyframe
+const (
+	recentEventLimit = 50
+	scheduleLength   = 5
+)
+
+// visualEvents are animation cues that are stale by the time anyone joins,
+// so they're left out of the recent event log
+var visualEvents = map[string]bool{
+	EventMuzzleFlash:    true,
+	EventImpact:         true,
+	EventChainLightning: true,
+}
+
+// WaveInfo describes an upcoming wave
+type WaveInfo struct {
+	Wave        int          `json:"wave"`
+	Boss        bool         `json:"boss,omitempty"`
+	SuddenDeath bool         `json:"sudden_death,omitempty"`
+	Groups      []SpawnGroup `json:"groups,omitempty"` // scripted rooms only
+}
+
+// RosterEntry is a player in the room with their stats so far
+type RosterEntry struct {
+	PlayerID string        `json:"player_id"`
+	Host     bool          `json:"host,omitempty"`
+	Towers   int           `json:"towers"`
+	Kills    int           `json:"kills"`
+	Profile  Profile       `json:"profile"`
+	Versus   *VersusPlayer `json:"versus,omitempty"`
+}
+
+// Bootstrap is what a client joining mid-game needs to build its UI on top
+// of the state it's sent with the join
+type Bootstrap struct {
+	RecentEvents []GameEvent   `json:"recent_events"`
+	WaveSchedule []WaveInfo    `json:"wave_schedule"`
+	Roster       []RosterEntry `json:"roster"`
+}
+
+// rememberEvent keeps notable events for late joiners. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) rememberEvent(event GameEvent) {
+	if visualEvents[event.Type] {
+		return
+	}
+	gs.recentEvents = append(gs.recentEvents, event)
+	if len(gs.recentEvents) > recentEventLimit {
+		gs.recentEvents = gs.recentEvents[len(gs.recentEvents)-recentEventLimit:]
+	}
+}
+
+// InProgress reports whether the game has started
+func (gs *GameStateWithShooting) InProgress() bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.inProgress()
+}
+
+// inProgress reports whether waves have started and the game isn't over.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) inProgress() bool {
+	return gs.wavesStarted > 0 && !gs.GameOver
+}
+
+// waveSchedule lists the next few waves and what's special about them
+func (gs *GameStateWithShooting) waveSchedule() []WaveInfo {
+	first := gs.Wave
+	if gs.wavesStarted > 0 {
+		first++
+	}
+
+	schedule := make([]WaveInfo, 0, scheduleLength)
+	for wave := first; wave < first+scheduleLength; wave++ {
+		info := WaveInfo{
+			Wave:        wave,
+			Boss:        gs.Config.BossWaves && wave%bossWaveInterval == 0,
+			SuddenDeath: gs.isVersus() && gs.Config.SuddenDeathWave > 0 && wave == gs.Config.SuddenDeathWave,
+		}
+		if gs.waveScript != nil {
+			info.Groups = gs.waveScript.wave(wave).Groups
+		}
+		schedule = append(schedule, info)
+	}
+	return schedule
+}
+
+// roster lists the room's players with their tower stats. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) roster() []RosterEntry {
+	roster := make([]RosterEntry, 0, len(gs.Players))
+	for _, playerID := range gs.Players {
+		entry := RosterEntry{
+			PlayerID: playerID,
+			Host:     playerID == gs.Host,
+		}
+		for _, tower := range gs.Towers {
+			if tower.OwnerID == playerID {
+				entry.Towers++
+				entry.Kills += tower.Kills
+			}
+		}
+		if vp := gs.versusPlayer(playerID); vp != nil {
+			player := *vp
+			entry.Versus = &player
+		}
+		roster = append(roster, entry)
+	}
+	return roster
+}
+
+// Bootstrap builds the bundle sent to a player joining the room mid-game
+func (m *Manager) Bootstrap(roomID string) (*Bootstrap, bool) {
+	room, exists := m.GetShootingRoom(roomID)
+	if !exists {
+		return nil, false
+	}
+
+	room.mu.RLock()
+	bundle := &Bootstrap{
+		RecentEvents: make([]GameEvent, len(room.recentEvents)),
+		WaveSchedule: room.waveSchedule(),
+		Roster:       room.roster(),
+	}
+	copy(bundle.RecentEvents, room.recentEvents)
+	room.mu.RUnlock()
+
+	for i := range bundle.Roster {
+		bundle.Roster[i].Profile = m.profiles.Get(bundle.Roster[i].PlayerID)
+	}
+	return bundle, true
+}
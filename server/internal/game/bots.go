@@ -0,0 +1,228 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Bot key scopes. Bots never take part in ranked play, whatever their
+// scopes.
+const (
+	BotScopePlay     = "play"      // join and create sandbox rooms
+	BotScopeObserve  = "observe"   // observe rooms it could join
+	BotScopeLoadTest = "load_test" // skip per-address connection limits
+)
+
+// botPrefix starts the player ID of every bot connection, so bots are
+// recognisable wherever a player ID turns up
+const botPrefix = "bot-"
+
+// BotKey is a credential for a bot or automation client. Only a hash of
+// the key is kept; the key itself is shown once, when it's issued.
+type BotKey struct {
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allows reports whether the key grants a scope
+func (k BotKey) Allows(scope string) bool {
+	return containsString(k.Scopes, scope)
+}
+
+// NewBotPlayerID generates the player ID of a bot connection
+func NewBotPlayerID(name string) string {
+	return ids.New(botPrefix + name)
+}
+
+// IsBot reports whether a player ID belongs to a bot connection. Player IDs
+// are handed out by the server, so the prefix can't be claimed by players.
+func IsBot(playerID string) bool {
+	if i := strings.Index(playerID, tenantSeparator); i >= 0 {
+		playerID = playerID[i+len(tenantSeparator):]
+	}
+	return strings.HasPrefix(playerID, botPrefix)
+}
+
+// hashBotKey hashes a key for storage and lookup
+func hashBotKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// BotKeyStore keeps issued bot keys in memory and, once given a file,
+// writes them through to disk
+type BotKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]BotKey // by hash
+	path string
+}
+
+// NewBotKeyStore creates an empty in-memory bot key store
+func NewBotKeyStore() *BotKeyStore {
+	return &BotKeyStore{
+		keys: make(map[string]BotKey),
+	}
+}
+
+// UseFile loads bot keys from a JSON file, if it exists, and saves every
+// later change back to it
+func (s *BotKeyStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []BotKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		s.keys[k.Hash] = k
+	}
+	return nil
+}
+
+// Issue creates a key for a bot, replacing any it already had, and returns
+// the key along with what's stored for it
+func (s *BotKeyStore) Issue(name string, scopes []string) (string, BotKey, error) {
+	if name == "" || strings.ContainsAny(name, tenantSeparator+" ") {
+		return "", BotKey{}, i18n.NewError(i18n.ErrInvalidBotKey, nil)
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case BotScopePlay, BotScopeObserve, BotScopeLoadTest:
+		default:
+			return "", BotKey{}, i18n.NewError(i18n.ErrInvalidBotKey, nil)
+		}
+	}
+
+	key := ids.Token(24)
+
+	k := BotKey{
+		Name:      name,
+		Scopes:    append([]string{}, scopes...),
+		Hash:      hashBotKey(key),
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoke(name)
+	s.keys[k.Hash] = k
+	return key, k, s.persist()
+}
+
+// Revoke removes a bot's key
+func (s *BotKeyStore) Revoke(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.revoke(name) {
+		return false, nil
+	}
+	return true, s.persist()
+}
+
+// revoke drops a bot's key, reporting whether it had one. Callers must
+// hold the lock.
+func (s *BotKeyStore) revoke(name string) bool {
+	for hash, k := range s.keys {
+		if k.Name == name {
+			delete(s.keys, hash)
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup finds the bot a key was issued to
+func (s *BotKeyStore) Lookup(key string) (BotKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[hashBotKey(key)]
+	return k, ok
+}
+
+// List returns every issued key, sorted by bot name
+func (s *BotKeyStore) List() []BotKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]BotKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys
+}
+
+// persist writes the keys to disk if a file is configured. Callers must
+// hold the lock.
+func (s *BotKeyStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	keys := make([]BotKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// BotKeys returns the store of bot credentials
+func (m *Manager) BotKeys() *BotKeyStore {
+	return m.botKeys
+}
+
+// checkBots keeps bots out of ranked rooms
+func checkBots(room Room, playerIDs []string) error {
+	if shooting, ok := room.(*GameStateWithShooting); ok && shooting.Ranked() && anyBot(playerIDs) {
+		return i18n.NewError(i18n.ErrBotForbidden, nil)
+	}
+	return nil
+}
+
+// anyBot reports whether any of the players is a bot
+func anyBot(playerIDs []string) bool {
+	for _, playerID := range playerIDs {
+		if IsBot(playerID) {
+			return true
+		}
+	}
+	return false
+}
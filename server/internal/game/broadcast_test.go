@@ -0,0 +1,44 @@
+package game
+
+import "testing"
+
+func TestGameOverWaitsOutAFullChannel(t *testing.T) {
+	m := NewManager()
+	room, err := m.CreateShootingRoomWithConfig("over-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	for len(m.broadcast) < cap(m.broadcast) {
+		m.broadcast <- BroadcastMessage{RoomID: "busy-room", Kind: BroadcastState}
+	}
+
+	m.recordMatch(room, ResultDefeat)
+	for i := 0; i < cap(m.broadcast); i++ {
+		<-m.broadcast
+	}
+	m.resendGameOver(room)
+
+	select {
+	case msg := <-m.broadcast:
+		if msg.Kind != BroadcastGameOver || msg.RoomID != room.RoomID {
+			t.Fatalf("got %s for %s, want the held game over", msg.Kind, msg.RoomID)
+		}
+	default:
+		t.Fatal("game over was dropped while the channel was full")
+	}
+
+	m.resendGameOver(room)
+	if len(m.broadcast) != 0 {
+		t.Fatal("game over was sent twice")
+	}
+}
+
+func TestAnalysisFramesDontUseTheBroadcastChannel(t *testing.T) {
+	m := NewManager()
+	for i := 0; i < 2*cap(m.analysisFeed); i++ {
+		m.publishAnalysis(AnalysisFrame{RoomID: "watched-room"})
+	}
+	if len(m.broadcast) != 0 {
+		t.Fatalf("analysis frames took %d broadcast slots", len(m.broadcast))
+	}
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"sort"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Room browser sort orders. Every order falls back to room ID so pages
+// stay stable between requests.
+const (
+	SortRoomID     = "room_id"    // the default
+	SortLatency    = "latency"    // lowest expected round trip first
+	SortPlayers    = "players"    // most players first
+	SortWave       = "wave"       // furthest along first
+	SortDifficulty = "difficulty" // hardest first
+)
+
+// Room browser page sizes
+const (
+	defaultRoomPageSize = 20
+	maxRoomPageSize     = 100
+)
+
+// RoomQuery filters, sorts and pages the public room list. Zero values
+// don't filter.
+type RoomQuery struct {
+	Mode           string
+	Map            string
+	MinDifficulty  float64
+	MaxDifficulty  float64
+	HasSpace       bool // only rooms a player can still join
+	FriendsPresent bool // only rooms one of the player's friends is in
+	Region         string
+	Sort           string
+	Offset         int
+	Limit          int  // 0 for the default page size
+	Unpaged        bool // every matching room on one page, ignoring Offset and Limit
+
+	Latency ClientLatency // the searching client's, for the latency sort
+}
+
+// RoomPage is one page of the public room list
+type RoomPage struct {
+	Rooms  []RoomSummary `json:"rooms"`
+	Total  int           `json:"total"` // rooms matching the filters, across all pages
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+
+	CloserRegion string `json:"closer_region,omitempty"` // a region the client would see lower latency in
+}
+
+// Validate checks the sort order and page bounds
+func (q RoomQuery) Validate() error {
+	switch q.Sort {
+	case "", SortLatency, SortRoomID, SortPlayers, SortWave, SortDifficulty:
+	default:
+		return invalidRoomQuery("sort")
+	}
+	if q.Offset < 0 {
+		return invalidRoomQuery("offset")
+	}
+	if q.Limit < 0 || q.Limit > maxRoomPageSize {
+		return invalidRoomQuery("limit")
+	}
+	if q.MinDifficulty < 0 || q.MaxDifficulty < 0 {
+		return invalidRoomQuery("difficulty")
+	}
+	return nil
+}
+
+// matches reports whether a room passes the query's filters. friends is
+// the set of the searching player's friends.
+func (q RoomQuery) matches(room RoomSummary, players []string, friends map[string]bool) bool {
+	if q.Mode != "" && room.Mode != q.Mode {
+		return false
+	}
+	if q.Map != "" && room.Map != q.Map {
+		return false
+	}
+	if q.MinDifficulty > 0 && room.Difficulty < q.MinDifficulty {
+		return false
+	}
+	if q.MaxDifficulty > 0 && room.Difficulty > q.MaxDifficulty {
+		return false
+	}
+	if q.HasSpace && room.MaxPlayers > 0 && room.Players >= room.MaxPlayers {
+		return false
+	}
+	if q.Region != "" && room.Region != q.Region {
+		return false
+	}
+	if q.FriendsPresent {
+		for _, playerID := range players {
+			if friends[playerID] {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// less orders two rooms by the query's sort
+func (q RoomQuery) less(a, b RoomSummary) bool {
+	switch q.Sort {
+	case SortLatency:
+		// Rooms with a known round trip come first
+		if (a.RTTMs > 0) != (b.RTTMs > 0) {
+			return a.RTTMs > 0
+		}
+		if a.RTTMs != b.RTTMs {
+			return a.RTTMs < b.RTTMs
+		}
+	case SortPlayers:
+		if a.Players != b.Players {
+			return a.Players > b.Players
+		}
+	case SortWave:
+		if a.Wave != b.Wave {
+			return a.Wave > b.Wave
+		}
+	case SortDifficulty:
+		if a.Difficulty != b.Difficulty {
+			return a.Difficulty > b.Difficulty
+		}
+	}
+	return a.RoomID < b.RoomID
+}
+
+func invalidRoomQuery(field string) error {
+	return i18n.NewError(i18n.ErrInvalidRoomQuery, map[string]interface{}{"field": field})
+}
+
+// SetRegion tags the rooms this manager hosts with a region, e.g. eu-west
+func (m *Manager) SetRegion(region string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.region = region
+}
+
+// Region returns the tag rooms hosted here are listed under
+func (m *Manager) Region() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.region
+}
+
+// ListRooms returns a page of the public rooms matching a query. The
+// filters are applied before paging, so clients only download the rooms
+// they'll show.
+func (m *Manager) ListRooms(tenant, playerID string, q RoomQuery) (RoomPage, error) {
+	if err := q.Validate(); err != nil {
+		return RoomPage{}, err
+	}
+
+	var friends map[string]bool
+	if q.FriendsPresent {
+		friends = make(map[string]bool)
+		for _, friendID := range m.friends.Friends(playerID) {
+			friends[friendID] = true
+		}
+	}
+
+	region := m.Region()
+	rooms := make([]RoomSummary, 0)
+	for _, room := range m.shootingRooms() {
+		if TenantOf(room.RoomID) != tenant {
+			continue
+		}
+		room.mu.RLock()
+		if !room.IsPrivate() {
+			summary := room.summary()
+			summary.Region = region
+			if rtt, ok := q.Latency.expected(region, region); ok {
+				summary.RTTMs = int(rtt / time.Millisecond)
+			}
+			if q.matches(summary, room.Players, friends) {
+				rooms = append(rooms, summary)
+			}
+		}
+		room.mu.RUnlock()
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return q.less(rooms[i], rooms[j])
+	})
+
+	limit := q.Limit
+	switch {
+	case q.Unpaged:
+		q.Offset, limit = 0, len(rooms)
+	case limit == 0:
+		limit = defaultRoomPageSize
+	}
+	page := RoomPage{Rooms: make([]RoomSummary, 0), Total: len(rooms), Offset: q.Offset, Limit: limit}
+	page.CloserRegion, _, _ = q.Latency.closerRegion(region)
+	if q.Offset < len(rooms) {
+		end := q.Offset + limit
+		if end > len(rooms) {
+			end = len(rooms)
+		}
+		page.Rooms = rooms[q.Offset:end]
+	}
+	return page, nil
+}
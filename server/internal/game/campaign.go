@@ -0,0 +1,189 @@
+package game
+
+import (
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Star thresholds, as a share of starting health left when a level is won
+const (
+	threeStarHealth = 0.8
+	twoStarHealth   = 0.4
+)
+
+// CampaignLevel is one level of a campaign
+type CampaignLevel struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Map       string   `json:"map"`
+	Waves     string   `json:"waves"`      // wave script
+	WaveCount int      `json:"wave_count"` // waves to survive
+	Mutators  []string `json:"mutators,omitempty"`
+}
+
+// Campaign is an ordered set of levels. Each level unlocks once the one
+// before it is completed.
+type Campaign struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Levels []CampaignLevel `json:"levels"`
+}
+
+// LevelProgress is a player's standing on one campaign level
+type LevelProgress struct {
+	CampaignLevel
+	Unlocked bool `json:"unlocked"`
+	Stars    int  `json:"stars"`
+}
+
+// campaigns is the built-in campaign list
+var campaigns = []Campaign{
+	{
+		ID:   "rust_belt",
+		Name: "Rust Belt",
+		Levels: []CampaignLevel{
+			{ID: "outskirts", Name: "Outskirts", Map: DefaultMap, Waves: "classic", WaveCount: 3},
+			{ID: "scrapyard", Name: "Scrapyard", Map: DefaultMap, Waves: "classic", WaveCount: 6},
+			{ID: "rush_hour", Name: "Rush Hour", Map: DefaultMap, Waves: "rush", WaveCount: 5},
+			{ID: "iron_wall", Name: "Iron Wall", Map: DefaultMap, Waves: "siege", WaveCount: 5},
+			{ID: "foundry", Name: "Foundry", Map: DefaultMap, Waves: "classic", WaveCount: 10, Mutators: []string{MutatorTankyEnemies}},
+		},
+	},
+}
+
+// Campaigns returns every campaign
+func Campaigns() []Campaign {
+	return campaigns
+}
+
+// findLevel looks up a campaign and the index of one of its levels
+func findLevel(campaignID, levelID string) (Campaign, int, error) {
+	for _, c := range campaigns {
+		if c.ID != campaignID {
+			continue
+		}
+		for i, l := range c.Levels {
+			if l.ID == levelID {
+				return c, i, nil
+			}
+		}
+	}
+	return Campaign{}, 0, i18n.NewError(i18n.ErrUnknownLevel, map[string]interface{}{
+		"campaign": campaignID,
+		"level":    levelID,
+	})
+}
+
+// levelStars rates a won level by the health left
+func levelStars(health int) int {
+	switch {
+	case health >= int(threeStarHealth*startingHealth):
+		return 3
+	case health >= int(twoStarHealth*startingHealth):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// CampaignProgress lists a player's stars and unlocked levels in a campaign
+func (m *Manager) CampaignProgress(playerID, campaignID string) ([]LevelProgress, error) {
+	for _, c := range campaigns {
+		if c.ID != campaignID {
+			continue
+		}
+
+		profile := m.profiles.Get(playerID)
+		progress := make([]LevelProgress, len(c.Levels))
+		for i, l := range c.Levels {
+			progress[i] = LevelProgress{
+				CampaignLevel: l,
+				Unlocked:      i == 0 || profile.levelStars(c.ID, c.Levels[i-1].ID) > 0,
+				Stars:         profile.levelStars(c.ID, l.ID),
+			}
+		}
+		return progress, nil
+	}
+	return nil, i18n.NewError(i18n.ErrUnknownLevel, map[string]interface{}{"campaign": campaignID, "level": ""})
+}
+
+// SelectLevel opens a private room configured for a campaign level, if the
+// player has unlocked it
+func (m *Manager) SelectLevel(playerID, campaignID, levelID string, ghost bool) (string, error) {
+	c, index, err := findLevel(campaignID, levelID)
+	if err != nil {
+		return "", err
+	}
+	if index > 0 && m.profiles.Get(playerID).levelStars(c.ID, c.Levels[index-1].ID) == 0 {
+		return "", i18n.NewError(i18n.ErrLevelLocked, map[string]interface{}{"level": levelID})
+	}
+
+	config := DefaultRoomConfig()
+	config.Visibility = VisibilityPrivate
+	config.Campaign = campaignID
+	config.Level = levelID
+	config.Ghost = ghost
+
+	roomID := Qualify(TenantOf(playerID), m.NextRoomID("campaign"))
+	if _, err := m.OpenRoom(roomID, config); err != nil {
+		return "", err
+	}
+
+	log.Printf("🗺️ Opened %s/%s for %s in room %s", campaignID, levelID, playerID, roomID)
+	return roomID, nil
+}
+
+// applyCampaignLevel fills in the settings a campaign level dictates
+func applyCampaignLevel(config RoomConfig) (RoomConfig, *CampaignLevel, error) {
+	c, index, err := findLevel(config.Campaign, config.Level)
+	if err != nil {
+		return config, nil, err
+	}
+
+	level := c.Levels[index]
+	config.Waves = level.Waves
+	config.Mutators = append([]string(nil), level.Mutators...)
+	return config, &level, nil
+}
+
+// checkLevelComplete ends a campaign room in victory once its last wave is
+// fully spawned and cleared. Callers must hold the state lock.
+func (gs *GameStateWithShooting) checkLevelComplete() {
+	level := gs.campaignLevel
+	if level == nil || gs.GameOver || gs.Health <= 0 {
+		return
+	}
+	if gs.wavesStarted == 0 || gs.Wave < level.WaveCount {
+		return
+	}
+	if len(gs.pendingSpawns) > 0 || len(gs.Enemies) > 0 {
+		return
+	}
+
+	gs.Victory = true
+	gs.GameOver = true
+}
+
+// recordLevel saves the stars every player in a won campaign room earned
+func (m *Manager) recordLevel(room *GameStateWithShooting, match MatchResult) {
+	if match.Result != ResultVictory || room.campaignLevel == nil {
+		return
+	}
+
+	if m.ghosts.Record(room.ghostRun(match)) {
+		log.Printf("👻 New best run for %s/%s: %.1fs", room.Config.Campaign, room.Config.Level, match.Duration)
+	}
+
+	campaignID, levelID := room.Config.Campaign, room.Config.Level
+	for _, playerID := range match.Players {
+		best := m.profiles.RecordLevel(playerID, campaignID, levelID, match.Stars)
+		m.notify(playerID, NotifyLevelComplete, map[string]interface{}{
+			"room_id":  match.RoomID,
+			"campaign": campaignID,
+			"level":    levelID,
+			"stars":    match.Stars,
+			"best":     best,
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"sort"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Entity caps used when a room doesn't set its own, and the most any room
+// may ask for. They keep a runaway sandbox room from exhausting memory.
+const (
+	defaultMaxTowers      = 150
+	defaultMaxEnemies     = 400
+	defaultMaxProjectiles = 800
+
+	ceilingMaxTowers      = 500
+	ceilingMaxEnemies     = 2000
+	ceilingMaxProjectiles = 4000
+	ceilingMaxPlayers     = 64
+)
+
+// entityCaps is how many of each entity a room may have at once
+type entityCaps struct {
+	Towers      int
+	Enemies     int
+	Projectiles int
+}
+
+// caps returns the room's entity caps, with server defaults for any the
+// config leaves at zero
+func (c RoomConfig) caps() entityCaps {
+	caps := entityCaps{Towers: defaultMaxTowers, Enemies: defaultMaxEnemies, Projectiles: defaultMaxProjectiles}
+	if c.MaxTowers > 0 {
+		caps.Towers = c.MaxTowers
+	}
+	if c.MaxEnemies > 0 {
+		caps.Enemies = c.MaxEnemies
+	}
+	if c.MaxProjectiles > 0 {
+		caps.Projectiles = c.MaxProjectiles
+	}
+	return caps
+}
+
+// validateCaps checks that configured caps are within the server's ceilings
+func (c RoomConfig) validateCaps() error {
+	limits := []struct {
+		field   string
+		value   int
+		ceiling int
+	}{
+		{"max_towers", c.MaxTowers, ceilingMaxTowers},
+		{"max_enemies", c.MaxEnemies, ceilingMaxEnemies},
+		{"max_projectiles", c.MaxProjectiles, ceilingMaxProjectiles},
+		{"max_players", c.MaxPlayers, ceilingMaxPlayers},
+	}
+	for _, l := range limits {
+		if l.value < 0 || l.value > l.ceiling {
+			return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": l.field})
+		}
+	}
+	return nil
+}
+
+// checkTowerCap rejects a placement once the room has as many towers as it
+// may. Callers must hold the state lock.
+func (gs *GameStateWithShooting) checkTowerCap() error {
+	limit := gs.Config.caps().Towers
+	if len(gs.Towers) >= limit {
+		return i18n.NewError(i18n.ErrTowerLimit, map[string]interface{}{"limit": limit})
+	}
+	return nil
+}
+
+// enemyRoom reports whether count more enemies fit under the room's cap. An
+// empty room always has room, so a spawn bigger than the cap can't stall.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) enemyRoom(count int) bool {
+	return len(gs.Enemies) == 0 || len(gs.Enemies)+count <= gs.Config.caps().Enemies
+}
+
+// deferSpawn queues a spawn that didn't fit under the enemy cap, so it
+// enters once enough enemies have died or leaked. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) deferSpawn(senderID, enemyType string, from Position) {
+	gs.pendingSpawns = append(gs.pendingSpawns, scheduledSpawn{
+		at:        gs.GameTime,
+		enemyType: enemyType,
+		from:      from,
+		sender:    senderID,
+	})
+	sort.SliceStable(gs.pendingSpawns, func(i, j int) bool {
+		return gs.pendingSpawns[i].at < gs.pendingSpawns[j].at
+	})
+}
+
+// mergeProjectile folds a shot into one already in flight from the same
+// tower at the same target once the room is at its projectile cap, so the
+// damage still lands but there's one fewer projectile to simulate and
+// send. If there's nothing to merge with, the shot lands immediately
+// without a projectile. Callers must hold the state lock.
+func (gs *GameStateWithShooting) mergeProjectile(shot Projectile, target *Enemy) {
+	for i := range gs.Projectiles {
+		p := &gs.Projectiles[i]
+		if p.TowerID == shot.TowerID && p.TargetID == shot.TargetID {
+			p.Damage += shot.Damage
+			p.Merged++
+			gs.addPending(target, p, shot.Damage)
+			return
+		}
+	}
+
+	shot.Position = target.Position
+	gs.impactProjectile(&shot, target)
+}
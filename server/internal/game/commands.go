@@ -0,0 +1,424 @@
+package game
+
+import (
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// maxScheduleAhead is how far ahead a command can be scheduled, one
+// second
+const maxScheduleAhead = 60
+
+// command is a state change waiting for the next tick
+type command struct {
+	apply func()
+	done  chan struct{}
+	at    uint64 // scheduled commands only: the tick to apply it after
+}
+
+// commandQueue holds player actions until the game loop applies them at the
+// start of a tick, so only the loop ever writes to a running room and
+// actions never land halfway through a tick
+type commandQueue struct {
+	mu        sync.Mutex
+	pending   []command
+	scheduled []command // waiting for their tick, soonest first
+	running   bool      // a game loop is draining the queue
+}
+
+// exec runs apply at the start of the room's next tick and waits for it.
+// Rooms without a running game loop apply it right away.
+func (gs *GameStateWithShooting) exec(apply func()) {
+	q := &gs.commands
+	q.mu.Lock()
+	if !q.running {
+		q.mu.Unlock()
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+		apply()
+		return
+	}
+
+	cmd := command{apply: apply, done: make(chan struct{})}
+	q.pending = append(q.pending, cmd)
+	q.mu.Unlock()
+
+	<-cmd.done
+}
+
+// execAt queues apply to run after the given tick and returns a channel
+// closed once it has. Rooms without a game loop apply it right away.
+func (gs *GameStateWithShooting) execAt(tick uint64, apply func()) <-chan struct{} {
+	q := &gs.commands
+	q.mu.Lock()
+	if !q.running {
+		q.mu.Unlock()
+		gs.exec(apply)
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	cmd := command{apply: apply, done: make(chan struct{}), at: tick}
+	i := sort.Search(len(q.scheduled), func(i int) bool { return q.scheduled[i].at > tick })
+	q.scheduled = append(q.scheduled, command{})
+	copy(q.scheduled[i+1:], q.scheduled[i:])
+	q.scheduled[i] = cmd
+	q.mu.Unlock()
+
+	return cmd.done
+}
+
+// applyCommands runs the scheduled commands that are due, then the queued
+// ones in arrival order. Callers must hold the state lock.
+func (gs *GameStateWithShooting) applyCommands() {
+	q := &gs.commands
+	q.mu.Lock()
+	due := 0
+	for due < len(q.scheduled) && (q.scheduled[due].at <= gs.Tick || gs.Paused || gs.GameOver || !q.running) {
+		due++
+	}
+	pending := append(q.scheduled[:due:due], q.pending...)
+	q.scheduled = q.scheduled[due:]
+	q.pending = nil
+	q.mu.Unlock()
+
+	for _, cmd := range pending {
+		cmd.apply()
+		close(cmd.done)
+	}
+}
+
+// startCommands hands the queue to the room's game loop
+func (gs *GameStateWithShooting) startCommands() {
+	gs.commands.mu.Lock()
+	defer gs.commands.mu.Unlock()
+	gs.commands.running = true
+}
+
+// stopCommands is called when the game loop ends. Anything still queued is
+// applied so no caller is left waiting, and later commands apply directly.
+func (gs *GameStateWithShooting) stopCommands() {
+	gs.commands.mu.Lock()
+	gs.commands.running = false
+	gs.commands.mu.Unlock()
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.applyCommands()
+}
+
+// Command types that aren't lockstep inputs
+const (
+	CommandJoin   = "join"
+	CommandLeave  = "leave"
+	CommandReady  = "ready"
+	CommandKick   = "kick"
+	CommandRewind = "rewind"
+
+	// Recorded as its clear_towers and clear_enemies inputs
+	CommandClearAll = "clear_all"
+)
+
+// Command is a state change a player asks for. Commands are queued and
+// applied in arrival order at the start of a tick, so the same commands on
+// the same tick always produce the same state.
+type Command interface {
+	// Type names the command; for gameplay commands it's the lockstep input
+	// type
+	Type() string
+	apply(gs *GameStateWithShooting) (interface{}, error)
+}
+
+// ApplyCommand queues a command for the next tick and returns its result
+// once applied: a Tower, []Enemy, the wave number, or nil
+func (gs *GameStateWithShooting) ApplyCommand(cmd Command) (result interface{}, err error) {
+	gs.exec(func() {
+		result, err = gs.runCommand(cmd)
+	})
+	return result, err
+}
+
+// CommandTiming is when a scheduled command actually ran
+type CommandTiming struct {
+	Tick     uint64 `json:"tick"`     // it was applied after this tick
+	Adjusted bool   `json:"adjusted"` // not the tick asked for, e.g. one already past
+}
+
+// ScheduledCommand is a command waiting for the tick it was scheduled for
+type ScheduledCommand struct {
+	done   <-chan struct{}
+	result interface{}
+	timing CommandTiming
+	err    error
+}
+
+// Wait blocks until the command has been applied and returns its result
+func (s *ScheduledCommand) Wait() (interface{}, CommandTiming, error) {
+	<-s.done
+	return s.result, s.timing, s.err
+}
+
+// ScheduleCommand queues a command to be applied after a tick, so several
+// players' actions can land together, and returns right away. Commands for
+// ticks already past are applied on the next one instead, as are any sent
+// while the room is paused.
+func (gs *GameStateWithShooting) ScheduleCommand(cmd Command, tick uint64) (*ScheduledCommand, error) {
+	gs.mu.RLock()
+	current := gs.Tick
+	gs.mu.RUnlock()
+	if tick > current+maxScheduleAhead {
+		return nil, i18n.NewError(i18n.ErrTickTooFar, map[string]interface{}{"max": maxScheduleAhead, "current": current})
+	}
+
+	s := &ScheduledCommand{}
+	s.done = gs.execAt(tick, func() {
+		s.timing = CommandTiming{Tick: gs.Tick, Adjusted: gs.Tick != tick}
+		s.result, s.err = gs.runCommand(cmd)
+	})
+	return s, nil
+}
+
+// runCommand applies a command and logs it for replays. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) runCommand(cmd Command) (interface{}, error) {
+	// Keyed commands log themselves, and only if they actually run
+	if _, keyed := cmd.(Idempotent); !keyed {
+		gs.logStep(func(r *GameStateWithShooting) { cmd.apply(r) })
+		gs.logCommand(cmd)
+	}
+	return cmd.apply(gs)
+}
+
+// PlaceTower builds a tower
+type PlaceTower struct {
+	X         float64
+	Y         float64
+	TowerType string
+	OwnerID   string
+	Cosmetic  string // checked by the manager before the command is sent
+}
+
+func (PlaceTower) Type() string { return InputPlaceTower }
+
+func (c PlaceTower) apply(gs *GameStateWithShooting) (interface{}, error) {
+	tower, err := gs.addTower(c.X, c.Y, c.TowerType, c.OwnerID, c.Cosmetic)
+	return tower, err
+}
+
+// SpawnEnemy sends enemies along a path, or a server-chosen one if the
+// path isn't valid
+type SpawnEnemy struct {
+	SenderID  string
+	EnemyType string
+	Path      []Position
+}
+
+func (SpawnEnemy) Type() string { return InputSpawnEnemy }
+
+func (c SpawnEnemy) apply(gs *GameStateWithShooting) (interface{}, error) {
+	return gs.spawnEnemy(c.SenderID, c.EnemyType, c.Path), nil
+}
+
+// UpgradeTower starts upgrading a tower, along the named branch of its
+// upgrade tree
+type UpgradeTower struct {
+	TowerID  EntityID
+	Branch   string // may be left out when there's only one choice
+	PlayerID string // who asked, for strict ownership; empty skips the check
+}
+
+func (UpgradeTower) Type() string { return InputUpgradeTower }
+
+func (c UpgradeTower) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if err := gs.checkTowerOwner(c.TowerID, c.PlayerID); err != nil {
+		return Tower{}, err
+	}
+	tower, err := gs.upgradeTower(c.TowerID, c.Branch)
+	return tower, err
+}
+
+// SellTower starts selling a tower
+type SellTower struct {
+	TowerID  EntityID
+	PlayerID string
+}
+
+func (SellTower) Type() string { return InputSellTower }
+
+func (c SellTower) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if err := gs.checkTowerOwner(c.TowerID, c.PlayerID); err != nil {
+		return Tower{}, err
+	}
+	tower, err := gs.sellTower(c.TowerID)
+	return tower, err
+}
+
+// SetTargetMode changes how a tower picks targets
+type SetTargetMode struct {
+	TowerID  EntityID
+	Mode     string
+	PlayerID string
+}
+
+func (SetTargetMode) Type() string { return InputTargetMode }
+
+func (c SetTargetMode) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if err := gs.checkTowerOwner(c.TowerID, c.PlayerID); err != nil {
+		return Tower{}, err
+	}
+	tower, err := gs.setTargetMode(c.TowerID, c.Mode)
+	return tower, err
+}
+
+// StartWave begins the next wave
+type StartWave struct{}
+
+func (StartWave) Type() string { return InputStartWave }
+
+func (StartWave) apply(gs *GameStateWithShooting) (interface{}, error) {
+	return gs.startWave(), nil
+}
+
+// SetPaused pauses or resumes the room
+type SetPaused struct {
+	Paused bool
+}
+
+func (SetPaused) Type() string { return InputPause }
+
+func (c SetPaused) apply(gs *GameStateWithShooting) (interface{}, error) {
+	gs.setPaused(c.Paused)
+	return nil, nil
+}
+
+// ClearTowers removes every tower. In rooms with strict ownership that takes
+// in other players' towers, so only the host may.
+type ClearTowers struct {
+	By string // empty when the server clears the room itself
+}
+
+func (ClearTowers) Type() string { return InputClearTowers }
+
+func (c ClearTowers) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if gs.Config.StrictOwnership && c.By != "" && c.By != gs.Host {
+		return nil, i18n.NewError(i18n.ErrNotHost, nil)
+	}
+	gs.removeAllTowers()
+	return nil, nil
+}
+
+// ClearEnemies removes every enemy
+type ClearEnemies struct{}
+
+func (ClearEnemies) Type() string { return InputClearEnemies }
+
+func (ClearEnemies) apply(gs *GameStateWithShooting) (interface{}, error) {
+	gs.removeAllEnemies()
+	return nil, nil
+}
+
+// ClearAll removes every tower and enemy on the same tick. It's bound by
+// the same rule as ClearTowers.
+type ClearAll struct {
+	By string
+}
+
+func (ClearAll) Type() string { return CommandClearAll }
+
+func (c ClearAll) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if _, err := (ClearTowers{By: c.By}).apply(gs); err != nil {
+		return nil, err
+	}
+	return (ClearEnemies{}).apply(gs)
+}
+
+// JoinRoom adds players to the room together, or none of them. Players the
+// host kicked can't come back, full rooms take no more, practice rooms only
+// take one, and public co-op games under way only take strangers if they're
+// open to drop-ins.
+type JoinRoom struct {
+	PlayerIDs []string
+	Names     map[string]string // display names by player ID, if chosen
+}
+
+func (JoinRoom) Type() string { return CommandJoin }
+
+func (c JoinRoom) apply(gs *GameStateWithShooting) (interface{}, error) {
+	for _, playerID := range c.PlayerIDs {
+		if gs.kicked[playerID] {
+			return nil, i18n.NewError(i18n.ErrKicked, map[string]interface{}{"room_id": gs.RoomID})
+		}
+	}
+	if err := gs.checkRoomFull(c.PlayerIDs); err != nil {
+		return nil, err
+	}
+	if gs.Config.Mode == ModePractice && len(c.PlayerIDs) > 1 {
+		return nil, i18n.NewError(i18n.ErrPracticeParty, nil)
+	}
+	if !gs.IsPrivate() {
+		for _, playerID := range c.PlayerIDs {
+			if err := gs.checkDropIn(playerID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, playerID := range c.PlayerIDs {
+		gs.addPlayer(playerID, c.Names[playerID])
+	}
+	return nil, nil
+}
+
+// LeaveRoom removes a player from the room
+type LeaveRoom struct {
+	PlayerID string
+}
+
+func (LeaveRoom) Type() string { return CommandLeave }
+
+func (c LeaveRoom) apply(gs *GameStateWithShooting) (interface{}, error) {
+	gs.removePlayer(c.PlayerID)
+	return nil, nil
+}
+
+// maxRememberedCommands bounds how many keyed commands a room remembers
+// for deduplicating retries
+const maxRememberedCommands = 256
+
+// commandResult is the outcome of a keyed command
+type commandResult struct {
+	result interface{}
+	err    error
+}
+
+// Idempotent applies a command at most once per key. A retry with the same
+// key, e.g. after a dropped ack, gets the first attempt's result back.
+type Idempotent struct {
+	Key     string
+	Command Command
+}
+
+func (c Idempotent) Type() string { return c.Command.Type() }
+
+func (c Idempotent) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if r, ok := gs.appliedCommands[c.Key]; ok {
+		return r.result, r.err
+	}
+
+	gs.logStep(func(r *GameStateWithShooting) { c.Command.apply(r) })
+	gs.logCommand(c.Command)
+	result, err := c.Command.apply(gs)
+
+	if gs.appliedCommands == nil {
+		gs.appliedCommands = make(map[string]commandResult)
+	}
+	gs.appliedCommands[c.Key] = commandResult{result: result, err: err}
+	gs.appliedOrder = append(gs.appliedOrder, c.Key)
+	if len(gs.appliedOrder) > maxRememberedCommands {
+		delete(gs.appliedCommands, gs.appliedOrder[0])
+		gs.appliedOrder = gs.appliedOrder[1:]
+	}
+	return result, err
+}
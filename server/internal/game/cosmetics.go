@@ -0,0 +1,51 @@
+package game
+
+import (
+	"sort"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Cosmetic is a skin a player can unlock. Skins only change how towers and
+// their projectiles are drawn, never how they play.
+type Cosmetic struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	TowerType string `json:"tower_type,omitempty"` // empty fits every tower
+}
+
+// cosmeticCatalog lists every skin by ID
+var cosmeticCatalog = map[string]Cosmetic{
+	"rusted":         {ID: "rusted", Name: "Rusted"},
+	"chrome":         {ID: "chrome", Name: "Chrome"},
+	"basic_gold":     {ID: "basic_gold", Name: "Gilded Turret", TowerType: "basic"},
+	"sniper_ghillie": {ID: "sniper_ghillie", Name: "Ghillie Sniper", TowerType: "sniper"},
+	"splash_neon":    {ID: "splash_neon", Name: "Neon Mortar", TowerType: "splash"},
+}
+
+// Cosmetics returns every skin, sorted by ID
+func Cosmetics() []Cosmetic {
+	cosmetics := make([]Cosmetic, 0, len(cosmeticCatalog))
+	for _, c := range cosmeticCatalog {
+		cosmetics = append(cosmetics, c)
+	}
+	sort.Slice(cosmetics, func(i, j int) bool { return cosmetics[i].ID < cosmetics[j].ID })
+	return cosmetics
+}
+
+// CheckCosmetic makes sure a player has unlocked a skin and that it fits the
+// tower type. An empty ID is the default look and always allowed.
+func (m *Manager) CheckCosmetic(playerID, cosmeticID, towerType string) error {
+	if cosmeticID == "" {
+		return nil
+	}
+
+	cosmetic, ok := cosmeticCatalog[cosmeticID]
+	if !ok || (cosmetic.TowerType != "" && cosmetic.TowerType != towerType) {
+		return i18n.NewError(i18n.ErrUnknownCosmetic, map[string]interface{}{"cosmetic": cosmeticID})
+	}
+	if !m.profiles.Get(playerID).hasCosmetic(cosmeticID) {
+		return i18n.NewError(i18n.ErrCosmeticLocked, map[string]interface{}{"cosmetic": cosmetic.Name})
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// DemoRoomID is the always-on room visitors watch from the home page
+const DemoRoomID = "demo"
+
+// DefaultDemoReset is how often the demo room starts over
+const DefaultDemoReset = 10 * time.Minute
+
+// Demo room tuning
+const (
+	demoBotName        = "demo"
+	demoDisplayName    = "Demo Bot"
+	demoActionInterval = 1.5              // game seconds between the bot's decisions
+	demoCheckInterval  = 5 * time.Second  // how often the manager looks for a due reset
+	demoGameOverHold   = 10 * time.Second // the final frame stays up this long
+)
+
+// demoSpot is somewhere the demo bot builds, and what
+type demoSpot struct {
+	X, Y      float64
+	TowerType string
+}
+
+// demoLayout is where the demo bot builds, in order. The spots line both
+// sides of the default map's path, cheap towers first.
+var demoLayout = []demoSpot{
+	{4, 6, "basic"}, {4, 8, "basic"},
+	{7, 6, "slow"}, {7, 8, "splash"},
+	{10, 6, "basic"}, {10, 8, "sniper"},
+	{13, 6, "splash"}, {13, 8, "tesla"},
+	{16, 6, "sniper"}, {16, 8, "basic"},
+}
+
+// demoPilot is the bot playing the demo room. It builds the layout as
+// gold allows, upgrades once it's done, and calls each wave as soon as the
+// field is clear.
+type demoPilot struct {
+	PlayerID   string
+	next       float64   // game time of the bot's next decision
+	built      int       // layout spots tried so far
+	startedAt  time.Time // when the current game began
+	finishedAt time.Time // when the manager saw the game end, zero until then
+}
+
+// updateDemo lets the demo bot take its turn
+func (gs *GameStateWithShooting) updateDemo(deltaTime float64) {
+	pilot := gs.demo
+	if pilot == nil || gs.GameTime < pilot.next {
+		return
+	}
+	pilot.next = gs.GameTime + demoActionInterval
+
+	if !pilot.build(gs) {
+		pilot.upgrade(gs)
+	}
+	if gs.fieldClear() && len(gs.pendingSpawns) == 0 {
+		gs.startWave()
+	}
+}
+
+// build places the next tower of the layout, reporting false once the
+// layout is done. Spots that can't be built on are skipped. Callers must
+// hold the state lock.
+func (p *demoPilot) build(gs *GameStateWithShooting) bool {
+	for p.built < len(demoLayout) {
+		spot := demoLayout[p.built]
+		if gs.Gold < gs.mods.towerStats(spot.TowerType).Cost {
+			return true // save up for it
+		}
+		p.built++
+		if _, err := gs.addTower(spot.X, spot.Y, spot.TowerType, p.PlayerID, ""); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// upgrade takes the first tower that can afford it a level up its first
+// branch. Callers must hold the state lock.
+func (p *demoPilot) upgrade(gs *GameStateWithShooting) {
+	for i := range gs.Towers {
+		tower := &gs.Towers[i]
+		if tower.State != TowerStateActive {
+			continue
+		}
+		choices := upgradeChoices(gs.mods.towerStats(tower.TowerType), tower)
+		if len(choices) == 0 {
+			continue
+		}
+		if _, err := gs.upgradeTower(tower.ID, choices[0].Name); err == nil {
+			return
+		}
+	}
+}
+
+// IsDemo reports whether the room is the demo room, which only its bot
+// plays in
+func (gs *GameStateWithShooting) IsDemo() bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.demo != nil
+}
+
+// resetDemoIfDue starts the demo over once it has run for the interval,
+// or soon after its game ended
+func (gs *GameStateWithShooting) resetDemoIfDue(now time.Time, every time.Duration) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	pilot := gs.demo
+	if pilot == nil {
+		return
+	}
+	if gs.finished && pilot.finishedAt.IsZero() {
+		pilot.finishedAt = now
+	}
+	ended := !pilot.finishedAt.IsZero() && now.Sub(pilot.finishedAt) >= demoGameOverHold
+	if !ended && now.Sub(pilot.startedAt) < every {
+		return
+	}
+
+	gs.returnToLobby()
+	gs.demo = &demoPilot{PlayerID: pilot.PlayerID, startedAt: now}
+	log.Printf("🎬 Demo room %s reset", gs.RoomID)
+}
+
+// openDemoRoom opens the demo room with its bot in it. It starts over on
+// every reset and restart, so it keeps no write-ahead log.
+func (m *Manager) openDemoRoom() (*GameStateWithShooting, error) {
+	config := DefaultRoomConfig()
+	config.MaxPlayers = 1 // the bot; everyone else watches
+	room, err := m.CreateShootingRoomWithConfig(DemoRoomID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	botID := NewBotPlayerID(demoBotName)
+	room.Join([]string{botID}, map[string]string{botID: demoDisplayName})
+	room.demo = &demoPilot{PlayerID: botID, startedAt: time.Now()}
+
+	m.registerRoom(DemoRoomID)
+	go m.StartGameLoop(DemoRoomID)
+
+	log.Printf("🎬 Demo room %s opened", DemoRoomID)
+	return room, nil
+}
+
+// RunDemoRoom keeps the demo room open for visitors to watch, starting it
+// over every interval and soon after each game ends. It reopens the room
+// if it's ever closed.
+func (m *Manager) RunDemoRoom(every time.Duration) {
+	if _, err := m.openDemoRoom(); err != nil {
+		log.Printf("❌ Failed to open demo room: %v", err)
+	}
+
+	ticker := time.NewTicker(demoCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		room, exists := m.GetShootingRoom(DemoRoomID)
+		if !exists {
+			if _, err := m.openDemoRoom(); err != nil {
+				log.Printf("❌ Failed to reopen demo room: %v", err)
+			}
+			continue
+		}
+		room.resetDemoIfDue(now, every)
+	}
+}
+
+// DemoRoom returns the demo room, if one is running
+func (m *Manager) DemoRoom() (*GameStateWithShooting, error) {
+	room, exists := m.GetShootingRoom(DemoRoomID)
+	if !exists || !room.IsDemo() {
+		return nil, i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": DemoRoomID})
+	}
+	return room, nil
+}
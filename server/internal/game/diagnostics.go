@@ -0,0 +1,126 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Diagnostics tuning
+const (
+	diagnosticsInterval = 60 // ticks between metric samples, once a second
+	slowRoomFPS         = 50 // rooms below this get a warning in the log
+	slowRoomLogInterval = time.Minute
+)
+
+// TickMetrics is a room's game loop performance over the last sample
+type TickMetrics struct {
+	RoomID      string  `json:"room_id"`
+	Tick        uint64  `json:"tick"`
+	FPS         float64 `json:"fps"`
+	AvgUpdateMs float64 `json:"avg_update_ms"` // time spent in Update per tick
+	MaxUpdateMs float64 `json:"max_update_ms"`
+	Towers      int     `json:"towers"`
+	Enemies     int     `json:"enemies"`
+	Projectiles int     `json:"projectiles"`
+	MemoryBytes int     `json:"memory_bytes"` // approximate room footprint
+}
+
+// tickSampler accumulates a game loop's timings between samples
+type tickSampler struct {
+	frames      int
+	since       time.Time
+	updateTotal time.Duration
+	updateMax   time.Duration
+	lastSlowLog time.Time
+}
+
+func newTickSampler() *tickSampler {
+	return &tickSampler{since: time.Now()}
+}
+
+// record adds one tick's update time, returning the metrics once a sample
+// is complete
+func (s *tickSampler) record(room *GameStateWithShooting, update time.Duration) (TickMetrics, bool) {
+	s.frames++
+	s.updateTotal += update
+	if update > s.updateMax {
+		s.updateMax = update
+	}
+	if s.frames < diagnosticsInterval {
+		return TickMetrics{}, false
+	}
+
+	room.mu.RLock()
+	metrics := TickMetrics{
+		RoomID:      room.RoomID,
+		Tick:        room.Tick,
+		FPS:         float64(s.frames) / time.Since(s.since).Seconds(),
+		AvgUpdateMs: float64(s.updateTotal) / float64(s.frames) / float64(time.Millisecond),
+		MaxUpdateMs: float64(s.updateMax) / float64(time.Millisecond),
+		Towers:      len(room.Towers),
+		Enemies:     len(room.Enemies),
+		Projectiles: len(room.Projectiles),
+		MemoryBytes: room.memory().Bytes,
+	}
+	room.mu.RUnlock()
+
+	s.frames, s.updateTotal, s.updateMax = 0, 0, 0
+	s.since = time.Now()
+	return metrics, true
+}
+
+// logIfSlow warns about a room falling behind, at most once per interval
+// so a struggling server doesn't also flood its logs
+func (s *tickSampler) logIfSlow(metrics TickMetrics) {
+	if metrics.FPS >= slowRoomFPS || time.Since(s.lastSlowLog) < slowRoomLogInterval {
+		return
+	}
+	s.lastSlowLog = time.Now()
+	log.Printf("🐢 Room %s running slow - FPS: %.1f | Update: %.2fms avg, %.2fms max | Enemies: %d | Projectiles: %d",
+		metrics.RoomID, metrics.FPS, metrics.AvgUpdateMs, metrics.MaxUpdateMs, metrics.Enemies, metrics.Projectiles)
+}
+
+// WatchDiagnostics starts sending a room's tick metrics to the hub. Calls
+// are counted, so each watcher needs a matching UnwatchDiagnostics.
+func (m *Manager) WatchDiagnostics(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.diagnostics[roomID]++
+}
+
+// UnwatchDiagnostics drops one watcher of a room's tick metrics
+func (m *Manager) UnwatchDiagnostics(roomID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.diagnostics[roomID] <= 1 {
+		delete(m.diagnostics, roomID)
+		return
+	}
+	m.diagnostics[roomID]--
+}
+
+// watched reports whether anyone wants a room's tick metrics
+func (m *Manager) watched(roomID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.diagnostics[roomID] > 0
+}
+
+// publishDiagnostics sends tick metrics to the hub if anyone is watching
+func (m *Manager) publishDiagnostics(metrics TickMetrics) {
+	if !m.watched(metrics.RoomID) {
+		return
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return
+	}
+	select {
+	case m.broadcast <- BroadcastMessage{RoomID: metrics.RoomID, Kind: BroadcastDiagnostics, Data: data}:
+	default:
+		// Diagnostics never compete with game state for a full channel
+	}
+}
@@ -0,0 +1,69 @@
+package game
+
+import "math"
+
+// Dynamic difficulty tuning. The multiplier scales enemy health and moves
+// one step per wave, so a bad wave is answered gently and players can see
+// it coming in the snapshot.
+const (
+	minDifficulty  = 0.75
+	maxDifficulty  = 1.5
+	difficultyStep = 0.05
+
+	// overkillShare is the fraction of damage wasted on already dead enemies
+	// that counts as the towers having more than enough
+	overkillShare = 0.3
+)
+
+// EventDifficultyChanged tells clients the room's difficulty moved
+const EventDifficultyChanged = "difficulty_changed"
+
+// waveResult is what the difficulty controller sees of a wave
+type waveResult struct {
+	healthLost float64
+	damage     float64 // dealt to enemies, including overkill
+	overkill   float64 // dealt past an enemy's remaining health
+}
+
+// recordDamage adds a hit to the wave's totals. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) recordDamage(enemy *Enemy, damage float64) {
+	gs.waveResult.damage += damage
+	if enemy.Health < 0 {
+		gs.waveResult.overkill += math.Min(-enemy.Health, damage)
+	}
+}
+
+// adjustDifficulty nudges the room's difficulty after a wave: down when
+// players lost health, up when they lost none, and up twice as fast when
+// much of their damage was overkill. Callers must hold the state lock.
+func (gs *GameStateWithShooting) adjustDifficulty() {
+	result := gs.waveResult
+	result.healthLost = float64(gs.waveStartHealth - gs.Health)
+	gs.waveResult = waveResult{}
+	gs.waveStartHealth = gs.Health
+
+	if !gs.Config.AutoDifficulty {
+		return
+	}
+
+	step := difficultyStep
+	switch {
+	case result.healthLost > 0:
+		step = -difficultyStep
+	case result.damage > 0 && result.overkill/result.damage >= overkillShare:
+		step = 2 * difficultyStep
+	}
+
+	previous := gs.Difficulty
+	gs.Difficulty = math.Round(math.Max(minDifficulty, math.Min(maxDifficulty, previous+step))*100) / 100
+	if gs.Difficulty == previous {
+		return
+	}
+	gs.emitEvent(EventDifficultyChanged, nil, map[string]interface{}{
+		"from":        previous,
+		"to":          gs.Difficulty,
+		"health_lost": result.healthLost,
+		"overkill":    result.overkill,
+	})
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"rust-rush/server/internal/cluster"
+	"rust-rush/server/internal/i18n"
+)
+
+// UseDirectory makes this manager register its rooms in a shared directory
+// as the given instance, so other instances can send players here
+func (m *Manager) UseDirectory(directory cluster.Directory, instance cluster.Instance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.directory = directory
+	m.instance = instance
+}
+
+// Instance returns the instance this manager registers rooms as
+func (m *Manager) Instance() cluster.Instance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.instance
+}
+
+// registerRoom records a room as hosted here
+func (m *Manager) registerRoom(roomID string) {
+	m.mu.RLock()
+	directory, instance := m.directory, m.instance
+	m.mu.RUnlock()
+
+	if err := directory.Register(roomID, instance); err != nil {
+		log.Printf("⚠️ Failed to register room %s in directory: %v", roomID, err)
+	}
+}
+
+// reserveRoom claims a room ID in the directory before the room is opened,
+// so two instances can't both host it. If the directory can't be reached
+// the room opens anyway, as it would with a single instance.
+func (m *Manager) reserveRoom(roomID string) error {
+	m.mu.RLock()
+	directory, instance := m.directory, m.instance
+	m.mu.RUnlock()
+
+	reserved, err := directory.Reserve(roomID, instance)
+	if err != nil {
+		log.Printf("⚠️ Failed to reserve room %s in directory: %v", roomID, err)
+		return nil
+	}
+	if !reserved {
+		return i18n.NewError(i18n.ErrRoomExists, map[string]interface{}{"room_id": roomID})
+	}
+	return nil
+}
+
+// unregisterRoom removes a room from the directory
+func (m *Manager) unregisterRoom(roomID string) {
+	m.mu.RLock()
+	directory := m.directory
+	m.mu.RUnlock()
+
+	if err := directory.Unregister(roomID); err != nil {
+		log.Printf("⚠️ Failed to unregister room %s from directory: %v", roomID, err)
+	}
+}
+
+// Locate finds the instance hosting a room, reporting whether it's this one
+func (m *Manager) Locate(roomID string) (instance cluster.Instance, local bool, found bool) {
+	if _, exists := m.GetShootingRoom(roomID); exists {
+		return m.Instance(), true, true
+	}
+
+	m.mu.RLock()
+	directory := m.directory
+	m.mu.RUnlock()
+
+	instance, found, err := directory.Lookup(roomID)
+	if err != nil {
+		log.Printf("⚠️ Failed to look up room %s in directory: %v", roomID, err)
+		return cluster.Instance{}, false, false
+	}
+	return instance, found && instance.ID == m.Instance().ID, found
+}
+
+// StartDirectoryHeartbeat refreshes this instance's room registrations
+// before they expire
+func (m *Manager) StartDirectoryHeartbeat() {
+	ticker := time.NewTicker(cluster.RegistrationTTL / 3)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, room := range m.shootingRooms() {
+			m.registerRoom(room.RoomID)
+		}
+	}
+}
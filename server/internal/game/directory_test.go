@@ -0,0 +1,48 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"rust-rush/server/internal/cluster"
+	"rust-rush/server/internal/i18n"
+)
+
+func TestRoomIDsDontCollideAcrossInstances(t *testing.T) {
+	directory := cluster.NewMemoryDirectory()
+	first, second := NewManager(), NewManager()
+	first.UseDirectory(directory, cluster.Instance{ID: "first"})
+	second.UseDirectory(directory, cluster.Instance{ID: "second"})
+
+	room, err := first.OpenRoom("shared", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to open room: %v", err)
+	}
+	defer first.DeleteRoom(room.RoomID)
+
+	_, err = second.OpenRoom("shared", DefaultRoomConfig())
+	if !errors.Is(err, i18n.NewError(i18n.ErrRoomExists, nil)) {
+		t.Fatalf("second instance opened a room the first hosts: %v", err)
+	}
+	if _, exists := second.GetShootingRoom("shared"); exists {
+		t.Fatal("second instance kept a room it couldn't reserve")
+	}
+	if instance, local, found := second.Locate("shared"); !found || local || instance.ID != "first" {
+		t.Fatalf("room located at %+v (local %v, found %v), want the first instance", instance, local, found)
+	}
+}
+
+func TestFailedOpenReleasesTheReservation(t *testing.T) {
+	directory := cluster.NewMemoryDirectory()
+	m := NewManager()
+	m.UseDirectory(directory, cluster.Instance{ID: "only"})
+
+	config := DefaultRoomConfig()
+	config.Waves = "no-such-script"
+	if _, err := m.OpenRoom("broken", config); err == nil {
+		t.Fatal("opened a room with an unknown wave script")
+	}
+	if _, found, _ := directory.Lookup("broken"); found {
+		t.Fatal("failed open left the room reserved")
+	}
+}
@@ -0,0 +1,107 @@
+package game
+
+// Damage-over-time kinds
+const (
+	DotBurn   = "burn"
+	DotPoison = "poison"
+)
+
+// dotSpec describes the damage-over-time effect a tower applies on hit
+type dotSpec struct {
+	Kind         string  `json:"kind"`
+	Damage       float64 `json:"damage"`        // per tick, per stack
+	TickInterval float64 `json:"tick_interval"` // seconds between ticks
+	Duration     float64 `json:"duration"`      // seconds
+	MaxStacks    int     `json:"max_stacks"`    // 1 means a new application only refreshes the duration
+}
+
+// StatusEffect is an active damage-over-time effect on an enemy
+type StatusEffect struct {
+	Kind          string   `json:"kind"`
+	Stacks        int      `json:"stacks"`
+	Remaining     float64  `json:"remaining"` // seconds
+	SourceTowerID EntityID `json:"source_tower_id"`
+
+	damage       float64
+	tickInterval float64
+	tickTimer    float64
+}
+
+// applyDot adds a damage-over-time effect to an enemy following the stacking
+// rules: the same kind never appears twice, a reapplication refreshes the
+// duration, adds a stack up to MaxStacks, and takes over kill attribution
+func applyDot(enemy *Enemy, spec *dotSpec, towerID EntityID) {
+	for i := range enemy.Effects {
+		effect := &enemy.Effects[i]
+		if effect.Kind != spec.Kind {
+			continue
+		}
+
+		effect.Remaining = spec.Duration
+		effect.SourceTowerID = towerID
+		if effect.Stacks < spec.MaxStacks {
+			effect.Stacks++
+		}
+		return
+	}
+
+	enemy.Effects = append(enemy.Effects, StatusEffect{
+		Kind:          spec.Kind,
+		Stacks:        1,
+		Remaining:     spec.Duration,
+		SourceTowerID: towerID,
+		damage:        spec.Damage,
+		tickInterval:  spec.TickInterval,
+	})
+}
+
+// updateStatusEffects ticks an enemy's damage-over-time effects. Ticks are
+// driven by accumulated time rather than frames, so damage per second is the
+// same at any tick rate.
+func (gs *GameStateWithShooting) updateStatusEffects(enemy *Enemy, deltaTime float64) {
+	if len(enemy.Effects) == 0 {
+		return
+	}
+
+	enemy.Effects = updateEach(enemy.Effects, func(effect *StatusEffect) bool {
+		elapsed := deltaTime
+		if elapsed > effect.Remaining {
+			elapsed = effect.Remaining
+		}
+		effect.Remaining -= elapsed
+		effect.tickTimer += elapsed
+
+		for effect.tickInterval > 0 && effect.tickTimer >= effect.tickInterval {
+			effect.tickTimer -= effect.tickInterval
+			gs.damageEnemy(enemy, effect.damage*float64(effect.Stacks), effect.SourceTowerID)
+		}
+
+		return effect.Remaining > 0
+	})
+}
+
+// damageEnemy applies damage and remembers which tower dealt it, so the kill
+// can be credited to that tower
+func (gs *GameStateWithShooting) damageEnemy(enemy *Enemy, damage float64, towerID EntityID) {
+	damage *= gs.armorFactor(enemy, towerID)
+	enemy.Health -= damage
+	gs.recordDamage(enemy, damage)
+	gs.recordTowerDamage(towerID, enemy, damage)
+	if towerID != 0 {
+		enemy.lastHitBy = towerID
+	}
+	gs.audit(AuditDamage, towerID, enemy.ID, map[string]interface{}{
+		"damage": damage,
+		"health": enemy.Health,
+	})
+}
+
+// creditKill records a kill for the tower that dealt the final blow
+func (gs *GameStateWithShooting) creditKill(enemy *Enemy) {
+	if tower := gs.findTower(enemy.lastHitBy); tower != nil {
+		tower.Kills++
+	}
+	if enemy.lastHitBy != 0 {
+		gs.towerStatsFor(enemy.lastHitBy).Kills++
+	}
+}
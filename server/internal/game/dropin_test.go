@@ -0,0 +1,32 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"rust-rush/server/internal/i18n"
+)
+
+func TestClosedGamesStillTakeObservers(t *testing.T) {
+	room, err := NewManager().CreateShootingRoomWithConfig("dropin-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	if err := room.Join([]string{"host"}, nil); err != nil {
+		t.Fatalf("host couldn't join: %v", err)
+	}
+	room.mu.Lock()
+	room.wavesStarted = 1
+	room.mu.Unlock()
+
+	if err := room.CheckAccess("stranger", "", ""); err != nil {
+		t.Fatalf("stranger can't observe a game under way: %v", err)
+	}
+	err = room.Join([]string{"stranger"}, nil)
+	if !errors.Is(err, i18n.NewError(i18n.ErrDropInClosed, nil)) {
+		t.Fatalf("stranger joined a game closed to drop-ins, error %v", err)
+	}
+	if err := room.Join([]string{"host"}, nil); err != nil {
+		t.Fatalf("host couldn't rejoin: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package game
+
+import "sort"
+
+// Where gold comes from and goes to
+const (
+	GoldKill         = "kill"          // enemy killed
+	GoldTowerBuilt   = "tower_built"   // tower placed
+	GoldTowerUpgrade = "tower_upgrade" // tower upgrade started
+	GoldTowerRefund  = "tower_refund"  // unfinished tower sold, refunded in full
+	GoldTowerSold    = "tower_sold"    // finished tower sold
+	GoldIncome       = "income"        // paid out by a bank
+	GoldStipend      = "stipend"       // brought by a player dropping in
+	GoldPerfectWave  = "perfect_wave"  // wave cleared without a leak
+	GoldWaveSkip     = "wave_skip"     // the host skipped the wait for a spawn
+	GoldRewind       = "rewind"        // a practice room went back to a checkpoint
+	GoldAirstrike    = "airstrike"     // a player called in an airstrike
+)
+
+// maxLedgerEntries bounds how many gold changes a room remembers. Totals
+// keep counting after old entries are dropped.
+const maxLedgerEntries = 1000
+
+// LedgerEntry is a single change to a room's gold
+type LedgerEntry struct {
+	Tick     uint64   `json:"tick"`
+	Source   string   `json:"source"`
+	Amount   int      `json:"amount"` // negative when gold is spent
+	Balance  int      `json:"balance"`
+	PlayerID string   `json:"player_id,omitempty"` // who earned or spent it, if anyone
+	EntityID EntityID `json:"entity_id,omitempty"` // tower or enemy involved
+}
+
+// PlayerEconomy sums up a player's gold over a match
+type PlayerEconomy struct {
+	PlayerID string `json:"player_id"`
+	Spent    int    `json:"spent"`     // on towers and upgrades, less refunds
+	KillGold int    `json:"kill_gold"` // earned by enemies their towers killed
+	Sold     int    `json:"sold"`      // returned by selling finished towers
+	Income   int    `json:"income"`    // paid out by their banks
+
+	// Kill gold earned per gold spent, 0 if nothing was spent
+	Efficiency float64 `json:"efficiency"`
+}
+
+// EconomyLog is a room's recent gold changes and the totals so far
+type EconomyLog struct {
+	Gold    int             `json:"gold"`
+	Entries []LedgerEntry   `json:"entries"`
+	Players []PlayerEconomy `json:"players"`
+}
+
+// adjustGold changes the room's gold and records why. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) adjustGold(source string, amount int, playerID string, entityID EntityID) {
+	if amount == 0 {
+		return
+	}
+	gs.Gold += amount
+
+	gs.ledger = append(gs.ledger, LedgerEntry{
+		Tick:     gs.Tick,
+		Source:   source,
+		Amount:   amount,
+		Balance:  gs.Gold,
+		PlayerID: playerID,
+		EntityID: entityID,
+	})
+	if len(gs.ledger) > maxLedgerEntries {
+		gs.ledger = gs.ledger[len(gs.ledger)-maxLedgerEntries:]
+	}
+
+	if playerID == "" {
+		return
+	}
+	if gs.economy == nil {
+		gs.economy = make(map[string]*PlayerEconomy)
+	}
+	totals, ok := gs.economy[playerID]
+	if !ok {
+		totals = &PlayerEconomy{PlayerID: playerID}
+		gs.economy[playerID] = totals
+	}
+	switch source {
+	case GoldKill:
+		totals.KillGold += amount
+	case GoldTowerBuilt, GoldTowerUpgrade, GoldTowerRefund:
+		totals.Spent -= amount
+	case GoldTowerSold:
+		totals.Sold += amount
+	case GoldIncome:
+		totals.Income += amount
+	}
+}
+
+// playerEconomy returns every player's totals, including players who have
+// since left, sorted by player ID. Callers must hold the state lock.
+func (gs *GameStateWithShooting) playerEconomy() []PlayerEconomy {
+	players := make([]PlayerEconomy, 0, len(gs.economy))
+	for _, totals := range gs.economy {
+		summary := *totals
+		if summary.Spent > 0 {
+			summary.Efficiency = float64(summary.KillGold) / float64(summary.Spent)
+		}
+		players = append(players, summary)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].PlayerID < players[j].PlayerID })
+	return players
+}
+
+// EconomyLog returns the most recent gold changes, up to limit, with every
+// player's totals
+func (gs *GameStateWithShooting) EconomyLog(limit int) EconomyLog {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	entries := gs.ledger
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return EconomyLog{
+		Gold:    gs.Gold,
+		Entries: append([]LedgerEntry{}, entries...),
+		Players: gs.playerEconomy(),
+	}
+}
+
+// towerOwner returns who built a tower, if it still exists. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) towerOwner(towerID EntityID) string {
+	if tower := gs.findTower(towerID); tower != nil {
+		return tower.OwnerID
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package game
+
+import "math"
+
+// Entity is implemented by everything that lives on the map. New entity kinds
+// (traps, heroes, walls) implement it to reuse the generic helpers below
+// instead of hand-writing their own lookup and cleanup loops.
+type Entity interface {
+	EntityID() EntityID
+	EntityPosition() Position
+}
+
+func (t Tower) EntityID() EntityID       { return t.ID }
+func (t Tower) EntityPosition() Position { return t.Position }
+
+func (e Enemy) EntityID() EntityID       { return e.ID }
+func (e Enemy) EntityPosition() Position { return e.Position }
+
+func (p Projectile) EntityID() EntityID       { return p.ID }
+func (p Projectile) EntityPosition() Position { return p.Position }
+
+// updateEach calls update on every entity in place and drops the ones for
+// which it returns false. The slice is compacted without reallocating, so
+// the order of surviving entities (and therefore ID order) is preserved.
+func updateEach[T any](entities []T, update func(*T) bool) []T {
+	kept := entities[:0]
+	for i := range entities {
+		if update(&entities[i]) {
+			kept = append(kept, entities[i])
+		}
+	}
+
+	// Clear the tail so dropped entities can be garbage collected
+	var zero T
+	for i := len(kept); i < len(entities); i++ {
+		entities[i] = zero
+	}
+	return kept
+}
+
+// findByID returns a pointer to the entity with the given ID
+func findByID[T Entity](entities []T, id EntityID) *T {
+	for i := range entities {
+		if entities[i].EntityID() == id {
+			return &entities[i]
+		}
+	}
+	return nil
+}
+
+// nearestInRange returns the entity closest to pos within maxRange
+func nearestInRange[T Entity](entities []T, pos Position, maxRange float64) *T {
+	var nearest *T
+	minDist := math.MaxFloat64
+
+	for i := range entities {
+		dist := distance(pos, entities[i].EntityPosition())
+		if dist <= maxRange && dist < minDist {
+			minDist = dist
+			nearest = &entities[i]
+		}
+	}
+
+	return nearest
+}
+
+// inRange returns every entity within radius of pos
+func inRange[T Entity](entities []T, pos Position, radius float64) []*T {
+	found := make([]*T, 0)
+	for i := range entities {
+		if distance(pos, entities[i].EntityPosition()) <= radius {
+			found = append(found, &entities[i])
+		}
+	}
+	return found
+}
+
+// system is one step of the per-tick simulation
+type system struct {
+	name   string
+	update func(gs *GameStateWithShooting, deltaTime float64)
+}
+
+// systems run in order every tick. Visual effects are not simulated here;
+// they are emitted as events. Adding an entity kind means adding its
+// system here rather than editing Update.
+var systems = []system{
+	{"demo", (*GameStateWithShooting).updateDemo},
+	{"waves", (*GameStateWithShooting).updateWaves},
+	{"ghost", (*GameStateWithShooting).updateGhost},
+	{"towers", (*GameStateWithShooting).updateTowers},
+	{"projectiles", (*GameStateWithShooting).updateProjectiles},
+	{"enemies", (*GameStateWithShooting).updateEnemies},
+	{"perfect", (*GameStateWithShooting).updatePerfectWave},
+}
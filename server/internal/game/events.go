@@ -0,0 +1,40 @@
+package game
+
+// Game event types
+const (
+	EventTowerDisabled  = "tower_disabled"
+	EventMuzzleFlash    = "muzzle_flash"
+	EventImpact         = "impact"
+	EventChainLightning = "chain_lightning"
+	EventScoreboard     = "scoreboard_update"
+	EventBossWave       = "boss_wave"
+	EventBalanceUpdated = "balance_updated"
+	EventGhostMilestone = "ghost_milestone"
+)
+
+// GameEvent is a one-off occurrence during a tick, fired and forgotten: the
+// server doesn't track it after the tick. Events are collected while the tick
+// runs and sent with that tick's snapshot; IDs let clients ignore an event
+// they already handled. Visual effects like muzzle flashes and impact
+// explosions are events that clients animate locally for the given duration.
+type GameEvent struct {
+	ID       EntityID               `json:"id"`
+	Type     string                 `json:"type"`
+	GameTime float64                `json:"game_time"`
+	Position *Position              `json:"position,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// emitEvent records an event for the current tick
+func (gs *GameStateWithShooting) emitEvent(eventType string, pos *Position, data map[string]interface{}) {
+	event := GameEvent{
+		ID:       gs.ids.Next(),
+		Type:     eventType,
+		GameTime: gs.GameTime,
+		Position: pos,
+		Data:     data,
+	}
+	gs.Events = append(gs.Events, event)
+	gs.rememberEvent(event)
+	gs.logTelemetryEvent(event)
+}
@@ -0,0 +1,255 @@
+package game
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"os"
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Variant is one arm of an experiment. Its overrides are merged field by
+// field over the live stats, so a variant only names what it changes, e.g.
+// {"towers": {"sniper": {"damage": 60}}}.
+type Variant struct {
+	Name    string                     `json:"name"`
+	Weight  int                        `json:"weight"` // share of rooms, relative to the other variants
+	Towers  map[string]json.RawMessage `json:"towers,omitempty"`
+	Enemies map[string]json.RawMessage `json:"enemies,omitempty"`
+}
+
+// Experiment splits new rooms between variants of the balance. Rooms are
+// assigned as a whole, so everyone playing together sees the same stats.
+type Experiment struct {
+	Name     string    `json:"name"`
+	Variants []Variant `json:"variants"`
+}
+
+// Validate checks the experiment has uniquely named, weighted variants whose
+// overrides apply cleanly to the balance
+func (e Experiment) Validate(balance *Balance) error {
+	invalid := func(field string) error {
+		return i18n.NewError(i18n.ErrInvalidExperiment, map[string]interface{}{"name": e.Name, "field": field})
+	}
+
+	if e.Name == "" {
+		return invalid("name")
+	}
+	if len(e.Variants) == 0 {
+		return invalid("variants")
+	}
+	seen := make(map[string]bool)
+	total := 0
+	for _, v := range e.Variants {
+		if v.Name == "" || seen[v.Name] {
+			return invalid("variant")
+		}
+		seen[v.Name] = true
+		if v.Weight < 0 {
+			return invalid("weight")
+		}
+		total += v.Weight
+		if _, err := v.apply(balance); err != nil {
+			return invalid("overrides")
+		}
+	}
+	if total == 0 {
+		return invalid("weight")
+	}
+	return nil
+}
+
+// assign picks a room's variant. The pick depends only on the experiment
+// and room, so it's stable across restarts and instances.
+func (e Experiment) assign(roomID string) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + "/" + roomID))
+	pick := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if pick < v.Weight {
+			return v
+		}
+		pick -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// apply returns a copy of the balance with the variant's overrides merged
+// in. Only types the balance already has can be overridden.
+func (v Variant) apply(balance *Balance) (*Balance, error) {
+	patched := &Balance{
+		Version: balance.Version,
+		Towers:  make(map[string]towerStats, len(balance.Towers)),
+		Enemies: make(map[string]enemyStats, len(balance.Enemies)),
+	}
+	for towerType, stats := range balance.Towers {
+		patched.Towers[towerType] = stats
+	}
+	for enemyType, stats := range balance.Enemies {
+		patched.Enemies[enemyType] = stats
+	}
+
+	for towerType, raw := range v.Towers {
+		stats, ok := patched.Towers[towerType]
+		if !ok {
+			return nil, i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": towerType})
+		}
+		// Pointer and slice fields are shared with the live balance, so
+		// they're copied rather than merged into
+		stats.Dot = nil
+		if base := balance.Towers[towerType].Dot; base != nil {
+			dot := *base
+			stats.Dot = &dot
+		}
+		stats.Upgrades = copyBranches(balance.Towers[towerType].Upgrades)
+		if err := json.Unmarshal(raw, &stats); err != nil {
+			return nil, err
+		}
+		patched.Towers[towerType] = stats
+	}
+	for enemyType, raw := range v.Enemies {
+		stats, ok := patched.Enemies[enemyType]
+		if !ok {
+			return nil, i18n.NewError(i18n.ErrInvalidBalance, map[string]interface{}{"type": enemyType})
+		}
+		stats.Ability = nil
+		if base := balance.Enemies[enemyType].Ability; base != nil {
+			ability := *base
+			stats.Ability = &ability
+		}
+		if err := json.Unmarshal(raw, &stats); err != nil {
+			return nil, err
+		}
+		patched.Enemies[enemyType] = stats
+	}
+
+	if err := patched.validate(); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// ExperimentStore holds the running experiments, optionally loaded from a
+// JSON file holding a list of them
+type ExperimentStore struct {
+	mu          sync.RWMutex
+	experiments map[string]Experiment
+}
+
+// NewExperimentStore creates a store with no experiments running
+func NewExperimentStore() *ExperimentStore {
+	return &ExperimentStore{
+		experiments: make(map[string]Experiment),
+	}
+}
+
+// UseFile replaces the running experiments with the ones in a JSON file.
+// Every experiment is validated before any are used.
+func (s *ExperimentStore) UseFile(path string, balance *Balance) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var experiments []Experiment
+	if err := json.Unmarshal(data, &experiments); err != nil {
+		return err
+	}
+	byName := make(map[string]Experiment, len(experiments))
+	for _, e := range experiments {
+		if err := e.Validate(balance); err != nil {
+			return err
+		}
+		byName[e.Name] = e
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.experiments = byName
+	return nil
+}
+
+// List returns the running experiments, sorted by name
+func (s *ExperimentStore) List() []Experiment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	experiments := make([]Experiment, 0, len(s.experiments))
+	for _, e := range s.experiments {
+		experiments = append(experiments, e)
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].Name < experiments[j].Name
+	})
+	return experiments
+}
+
+// assign picks a variant of every running experiment for a room
+func (s *ExperimentStore) assign(roomID string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.experiments) == 0 {
+		return nil
+	}
+	assigned := make(map[string]string, len(s.experiments))
+	for name, e := range s.experiments {
+		assigned[name] = e.assign(roomID).Name
+	}
+	return assigned
+}
+
+// balanceFor returns the stats a room plays with: the live balance with the
+// overrides of each variant it was assigned. Experiments that have since
+// ended, or whose overrides no longer apply, are left out.
+func (s *ExperimentStore) balanceFor(balance *Balance, assigned map[string]string) *Balance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Sorted so overlapping overrides always resolve the same way
+	names := make([]string, 0, len(assigned))
+	for name := range assigned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range s.experiments[name].Variants {
+			if v.Name != assigned[name] {
+				continue
+			}
+			if patched, err := v.apply(balance); err == nil {
+				balance = patched
+			}
+		}
+	}
+	return balance
+}
+
+// Experiments returns the running balance experiments
+func (m *Manager) Experiments() *ExperimentStore {
+	return m.experiments
+}
+
+// roomBalance returns the stats for a room, given the variants it was
+// assigned and the live balance
+func (m *Manager) roomBalance(balance *Balance, assigned map[string]string) *Balance {
+	if len(assigned) == 0 {
+		return balance
+	}
+	return m.experiments.balanceFor(balance, assigned)
+}
+
+// Experiments returns the experiment variants the room was assigned
+func (gs *GameStateWithShooting) Experiments() map[string]string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.Config.Experiments
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rust-rush/server/internal/i18n"
+)
+
+const sniperExperiment = `[{"name": "sniper_damage", "variants": [
+	{"name": "control", "weight": 1},
+	{"name": "buffed", "weight": 1, "towers": {"sniper": {"damage": 60}}},
+	{"name": "retired", "weight": 0, "towers": {"sniper": {"damage": 10}}}
+]}]`
+
+// useExperiments runs the experiments in a JSON list on a manager
+func useExperiments(t *testing.T, m *Manager, experiments string) error {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "experiments.json")
+	if err := os.WriteFile(path, []byte(experiments), 0o644); err != nil {
+		t.Fatalf("failed to write experiments: %v", err)
+	}
+	return m.Experiments().UseFile(path, m.balance.Current())
+}
+
+func TestExperimentsSplitRoomsStably(t *testing.T) {
+	m := NewManager()
+	if err := useExperiments(t, m, sniperExperiment); err != nil {
+		t.Fatalf("failed to load experiments: %v", err)
+	}
+
+	counts := make(map[string]int)
+	buffedRoom := ""
+	for i := 0; i < 200; i++ {
+		roomID := fmt.Sprintf("room-%d", i)
+		variant := m.Experiments().assign(roomID)["sniper_damage"]
+		if again := m.Experiments().assign(roomID)["sniper_damage"]; again != variant {
+			t.Fatalf("room %s assigned %s, then %s", roomID, variant, again)
+		}
+		counts[variant]++
+		if variant == "buffed" && buffedRoom == "" {
+			buffedRoom = roomID
+		}
+	}
+	if counts["control"] == 0 || counts["buffed"] == 0 || counts["retired"] != 0 {
+		t.Fatalf("rooms split %v, want control and buffed only", counts)
+	}
+
+	// The room plays with its variant's stats; the live balance is untouched
+	room, err := m.CreateShootingRoomWithConfig(buffedRoom, DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	if variant := room.Experiments()["sniper_damage"]; variant != "buffed" {
+		t.Fatalf("room was assigned %q, want buffed", variant)
+	}
+	if damage := room.mods.balance.tower("sniper").Damage; damage != 60 {
+		t.Fatalf("buffed room's sniper does %v damage, want 60", damage)
+	}
+	if damage := m.balance.Current().tower("sniper").Damage; damage != 50 {
+		t.Fatalf("live sniper does %v damage, want it unchanged at 50", damage)
+	}
+
+	// Lockstep clients simulate with the published stats, so they sit out
+	config := DefaultRoomConfig()
+	config.Sync = SyncLockstep
+	lockstep, err := m.CreateShootingRoomWithConfig("lockstep-"+buffedRoom, config)
+	if err != nil {
+		t.Fatalf("failed to create lockstep room: %v", err)
+	}
+	if assigned := lockstep.Experiments(); len(assigned) != 0 {
+		t.Fatalf("lockstep room was assigned %v", assigned)
+	}
+}
+
+func TestInvalidExperimentsAreRefused(t *testing.T) {
+	m := NewManager()
+	if err := useExperiments(t, m, sniperExperiment); err != nil {
+		t.Fatalf("failed to load experiments: %v", err)
+	}
+
+	for name, experiments := range map[string]string{
+		"unnamed":         `[{"variants": [{"name": "a", "weight": 1}]}]`,
+		"no variants":     `[{"name": "e"}]`,
+		"duplicate":       `[{"name": "e", "variants": [{"name": "a", "weight": 1}, {"name": "a", "weight": 1}]}]`,
+		"no weight":       `[{"name": "e", "variants": [{"name": "a", "weight": 0}]}]`,
+		"negative weight": `[{"name": "e", "variants": [{"name": "a", "weight": 2}, {"name": "b", "weight": -1}]}]`,
+		"unknown tower":   `[{"name": "e", "variants": [{"name": "a", "weight": 1, "towers": {"laser": {"damage": 1}}}]}]`,
+	} {
+		err := useExperiments(t, m, experiments)
+		if !errors.Is(err, i18n.NewError(i18n.ErrInvalidExperiment, nil)) {
+			t.Errorf("%s: got %v, want an invalid experiment", name, err)
+		}
+	}
+
+	// The experiments already running carry on
+	if running := m.Experiments().List(); len(running) != 1 || running[0].Name != "sniper_damage" {
+		t.Fatalf("running %v after refused files, want sniper_damage", running)
+	}
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"math"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// fastForwardRate is how many times faster than real time a room runs
+// while its field is empty and the next scripted spawn is still a way off
+const fastForwardRate = 4.0
+
+// fastForward returns the time step for this tick. While nothing is on the
+// field and spawns are pending it's stretched, ending exactly at the next
+// spawn so enemies still enter on schedule; a host's skip covers the whole
+// wait in one tick. Every system runs on the stretched step, so tower
+// builds and cooldowns keep pace with the clock. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) fastForward(deltaTime float64) float64 {
+	skip := gs.skipWait
+	gs.skipWait = false
+	gs.FastForward = false
+
+	if !gs.fieldClear() || len(gs.pendingSpawns) == 0 {
+		return deltaTime
+	}
+	wait := gs.pendingSpawns[0].at - gs.GameTime
+	if wait <= deltaTime {
+		return deltaTime
+	}
+
+	gs.FastForward = true
+	if skip {
+		return wait
+	}
+	return math.Min(deltaTime*fastForwardRate, wait)
+}
+
+// fieldClear reports whether no enemies or projectiles are in play.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) fieldClear() bool {
+	return len(gs.Enemies) == 0 && len(gs.Projectiles) == 0
+}
+
+// SkipToNextWave ends the wait before the next wave while the field is
+// clear. If spawns are scheduled the room jumps to the first of them on the
+// next tick; otherwise the next wave starts now. Only the host may skip.
+type SkipToNextWave struct {
+	PlayerID string
+}
+
+func (SkipToNextWave) Type() string { return InputSkipWait }
+
+func (c SkipToNextWave) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if gs.Host != c.PlayerID {
+		return nil, i18n.NewError(i18n.ErrNotHost, nil)
+	}
+	if !gs.fieldClear() {
+		return nil, i18n.NewError(i18n.ErrFieldNotClear, nil)
+	}
+
+	if len(gs.pendingSpawns) == 0 {
+		return gs.startWave(), nil
+	}
+	gs.rewardSkip(c.PlayerID)
+	gs.skipWait = true
+	gs.recordInput(InputSkipWait, nil)
+	return gs.Wave, nil
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// Formation tuning. Pack members keep formationSpacing apart along the
+// path, moving faster or slower by formationCohesion of the pack's pace for
+// every cell they're out of place, within the pace limits.
+const (
+	formationSpacing  = 0.4
+	formationCohesion = 1.0
+	formationMinPace  = 0.5
+	formationMaxPace  = 1.5
+)
+
+// packState is how a formation moves this tick
+type packState struct {
+	speed     float64 // of its slowest living member
+	frontSlot int     // lowest slot still alive
+	frontDist float64 // how far the front member has walked
+}
+
+// packName names a wave's pack, so packs from different waves never merge
+func packName(wave int, pack string) string {
+	return fmt.Sprintf("%d:%s", wave, pack)
+}
+
+// packStates gathers each formation's pace and front member. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) packStates() map[string]*packState {
+	var packs map[string]*packState
+	for i := range gs.Enemies {
+		enemy := &gs.Enemies[i]
+		if enemy.Pack == "" || enemy.Health <= 0 {
+			continue
+		}
+		if packs == nil {
+			packs = make(map[string]*packState)
+		}
+
+		p, ok := packs[enemy.Pack]
+		if !ok {
+			packs[enemy.Pack] = &packState{
+				speed:     enemy.Speed,
+				frontSlot: enemy.PackSlot,
+				frontDist: enemy.distanceTraveled,
+			}
+			continue
+		}
+		p.speed = math.Min(p.speed, enemy.Speed)
+		if enemy.PackSlot < p.frontSlot {
+			p.frontSlot = enemy.PackSlot
+			p.frontDist = enemy.distanceTraveled
+		}
+	}
+	return packs
+}
+
+// paceOf is how fast an enemy moves this tick: its own speed, or for pack
+// members the pack's shared speed, nudged to hold their place in formation
+func paceOf(enemy *Enemy, packs map[string]*packState) float64 {
+	p, ok := packs[enemy.Pack]
+	if !ok {
+		return enemy.Speed
+	}
+
+	place := p.frontDist - float64(enemy.PackSlot-p.frontSlot)*formationSpacing
+	pace := 1 + (place-enemy.distanceTraveled)*formationCohesion
+	return p.speed * math.Max(formationMinPace, math.Min(formationMaxPace, pace))
+}
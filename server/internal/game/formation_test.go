@@ -0,0 +1,74 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// packMember is an enemy in a pack, at a slot and distance along the path
+func packMember(slot int, speed, traveled float64) Enemy {
+	return Enemy{
+		EnemyType:        "basic",
+		Health:           10,
+		Speed:            speed,
+		Pack:             packName(1, "escort"),
+		PackSlot:         slot,
+		distanceTraveled: traveled,
+	}
+}
+
+func TestPacksMoveAtTheirSlowestMembersPace(t *testing.T) {
+	gs := &GameStateWithShooting{Enemies: []Enemy{
+		packMember(0, 2, 1.0),
+		packMember(1, 1, 1.0-formationSpacing),
+		packMember(2, 3, 1.0-2*formationSpacing),
+		{EnemyType: "fast", Health: 10, Speed: 4},
+	}}
+
+	packs := gs.packStates()
+	for i := 0; i < 3; i++ {
+		if pace := paceOf(&gs.Enemies[i], packs); math.Abs(pace-1) > 1e-9 {
+			t.Fatalf("member in slot %d moves at %v, want the slowest member's 1", i, pace)
+		}
+	}
+	if pace := paceOf(&gs.Enemies[3], packs); pace != 4 {
+		t.Fatalf("enemy outside the pack moves at %v, want its own 4", pace)
+	}
+}
+
+func TestPackMembersCatchUpWithinLimits(t *testing.T) {
+	gs := &GameStateWithShooting{Enemies: []Enemy{
+		packMember(0, 1, 2.0),
+		packMember(1, 1, 2.0-formationSpacing-0.2), // a little behind
+		packMember(2, 1, 0),                        // far behind
+		packMember(3, 1, 3.0),                      // far ahead
+	}}
+
+	packs := gs.packStates()
+	want := []float64{1, 1.2, formationMaxPace, formationMinPace}
+	for i, w := range want {
+		if pace := paceOf(&gs.Enemies[i], packs); math.Abs(pace-w) > 1e-9 {
+			t.Fatalf("member in slot %d moves at %v, want %v", i, pace, w)
+		}
+	}
+}
+
+func TestPackCloseRanksWhenItsFrontFalls(t *testing.T) {
+	gs := &GameStateWithShooting{Enemies: []Enemy{
+		packMember(0, 0.5, 2.0),
+		packMember(1, 1, 1.5),
+		packMember(2, 1, 1.5-formationSpacing),
+	}}
+	gs.Enemies[0].Health = 0
+
+	p := gs.packStates()[packName(1, "escort")]
+	if p.frontSlot != 1 || p.frontDist != 1.5 || p.speed != 1 {
+		t.Fatalf("pack is %+v, want it led by slot 1 at the living members' speed", *p)
+	}
+
+	// Packs of the same name from different waves stay apart
+	gs.Enemies[2].Pack = packName(2, "escort")
+	if packs := gs.packStates(); len(packs) != 2 {
+		t.Fatalf("found %d packs, want one per wave", len(packs))
+	}
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// FriendStore keeps friendships and pending friend requests in memory
+type FriendStore struct {
+	mu       sync.RWMutex
+	friends  map[string]map[string]bool
+	requests map[string]map[string]bool // recipient -> senders
+}
+
+// NewFriendStore creates an empty friend store
+func NewFriendStore() *FriendStore {
+	return &FriendStore{
+		friends:  make(map[string]map[string]bool),
+		requests: make(map[string]map[string]bool),
+	}
+}
+
+// Request sends a friend request. If the other player already asked, the
+// two become friends straight away and accepted is true.
+func (s *FriendStore) Request(fromID, toID string) (accepted bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.friends[fromID][toID] {
+		return false, i18n.NewError(i18n.ErrAlreadyFriends, map[string]interface{}{"player_id": toID})
+	}
+
+	if s.requests[fromID][toID] {
+		s.befriend(fromID, toID)
+		return true, nil
+	}
+
+	if s.requests[toID] == nil {
+		s.requests[toID] = make(map[string]bool)
+	}
+	s.requests[toID][fromID] = true
+	return false, nil
+}
+
+// Accept accepts a pending friend request
+func (s *FriendStore) Accept(playerID, fromID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requests[playerID][fromID] {
+		return i18n.NewError(i18n.ErrNoFriendRequest, map[string]interface{}{"player_id": fromID})
+	}
+
+	s.befriend(playerID, fromID)
+	return nil
+}
+
+// Remove ends a friendship
+func (s *FriendStore) Remove(playerID, friendID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.friends[playerID][friendID] {
+		return false
+	}
+	delete(s.friends[playerID], friendID)
+	delete(s.friends[friendID], playerID)
+	return true
+}
+
+// AreFriends reports whether two players are friends
+func (s *FriendStore) AreFriends(a, b string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.friends[a][b]
+}
+
+// Friends returns a player's friends, sorted
+func (s *FriendStore) Friends(playerID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedKeys(s.friends[playerID])
+}
+
+// Requests returns the players waiting for this player to accept them
+func (s *FriendStore) Requests(playerID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedKeys(s.requests[playerID])
+}
+
+// befriend links two players and clears requests between them. Callers must
+// hold the lock.
+func (s *FriendStore) befriend(a, b string) {
+	delete(s.requests[a], b)
+	delete(s.requests[b], a)
+
+	if s.friends[a] == nil {
+		s.friends[a] = make(map[string]bool)
+	}
+	if s.friends[b] == nil {
+		s.friends[b] = make(map[string]bool)
+	}
+	s.friends[a][b] = true
+	s.friends[b][a] = true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"sync"
+	"time"
+)
+
+// Milestone kinds
+const (
+	MilestoneWaveStarted = "wave_started"
+	MilestoneTowerPlaced = "tower_placed"
+	MilestoneFinished    = "finished"
+)
+
+// maxMilestones caps how much of a run is kept for racing against
+const maxMilestones = 500
+
+// Milestone is a key moment of a run, kept so later runs can race it
+type Milestone struct {
+	Time      float64   `json:"time"` // game time
+	Kind      string    `json:"kind"`
+	Wave      int       `json:"wave,omitempty"`
+	TowerType string    `json:"tower_type,omitempty"`
+	Position  *Position `json:"position,omitempty"`
+}
+
+// GhostRun is a recorded run players can race against. There's no daily
+// challenge yet, so runs are keyed by campaign level, the one setup that
+// is the same every time it's played.
+type GhostRun struct {
+	Key        string      `json:"key"`
+	RoomID     string      `json:"room_id"`
+	Players    []string    `json:"players"`
+	Score      int         `json:"score"`
+	Stars      int         `json:"stars"`
+	Duration   float64     `json:"duration"`
+	Milestones []Milestone `json:"milestones"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// GhostStore keeps the fastest winning run of each campaign level
+type GhostStore struct {
+	mu   sync.RWMutex
+	best map[string]GhostRun
+}
+
+// NewGhostStore creates an empty ghost store
+func NewGhostStore() *GhostStore {
+	return &GhostStore{best: make(map[string]GhostRun)}
+}
+
+// Record keeps a run if it's the fastest for its key
+func (s *GhostStore) Record(run GhostRun) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if best, ok := s.best[run.Key]; ok && best.Duration <= run.Duration {
+		return false
+	}
+	s.best[run.Key] = run
+	return true
+}
+
+// Get returns the best run for a key
+func (s *GhostStore) Get(key string) (GhostRun, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.best[key]
+	return run, ok
+}
+
+// GhostKey identifies the challenge a campaign level's runs race on
+func GhostKey(campaignID, levelID string) string {
+	return campaignID + "/" + levelID
+}
+
+// recordMilestone notes a key moment in campaign rooms. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) recordMilestone(m Milestone) {
+	if gs.campaignLevel == nil || len(gs.milestones) >= maxMilestones {
+		return
+	}
+	m.Time = gs.GameTime
+	gs.milestones = append(gs.milestones, m)
+}
+
+// updateGhost emits the ghost's milestones as the live game reaches the
+// same game time, with the live wave for comparison
+func (gs *GameStateWithShooting) updateGhost(deltaTime float64) {
+	if gs.ghost == nil {
+		return
+	}
+
+	for gs.ghostNext < len(gs.ghost.Milestones) && gs.ghost.Milestones[gs.ghostNext].Time <= gs.GameTime {
+		m := gs.ghost.Milestones[gs.ghostNext]
+		gs.emitEvent(EventGhostMilestone, m.Position, map[string]interface{}{
+			"milestone": m,
+			"live_wave": gs.Wave,
+		})
+		gs.ghostNext++
+	}
+}
+
+// ghostRun packages a won campaign room's run for the ghost store
+func (gs *GameStateWithShooting) ghostRun(match MatchResult) GhostRun {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	milestones := make([]Milestone, len(gs.milestones), len(gs.milestones)+1)
+	copy(milestones, gs.milestones)
+	milestones = append(milestones, Milestone{Time: gs.GameTime, Kind: MilestoneFinished, Wave: gs.Wave})
+
+	return GhostRun{
+		Key:        GhostKey(gs.Config.Campaign, gs.Config.Level),
+		RoomID:     gs.RoomID,
+		Players:    match.Players,
+		Score:      match.Score.Total,
+		Stars:      match.Stars,
+		Duration:   match.Duration,
+		Milestones: milestones,
+		RecordedAt: match.EndedAt,
+	}
+}
@@ -0,0 +1,117 @@
+package game
+
+// EventGoalDamaged is sent when an enemy reaches a goal
+const EventGoalDamaged = "goal_damaged"
+
+// Goal is a structure enemies walk to and damage. The room's Health is its
+// goals' combined health, and the room is lost once every goal is
+// destroyed. Versus players keep their own health instead.
+type Goal struct {
+	ID        EntityID `json:"id"`
+	Position  Position `json:"position"`
+	Health    int      `json:"health"`
+	MaxHealth int      `json:"max_health"`
+}
+
+func (g Goal) EntityID() EntityID       { return g.ID }
+func (g Goal) EntityPosition() Position { return g.Position }
+
+// useGoals builds the room's goals, splitting the starting health between
+// them. The first one is also the room's GoalPoint. Callers must hold the
+// state lock, or not have shared the state yet.
+func (gs *GameStateWithShooting) useGoals(positions []Position) {
+	gs.Goals = make([]Goal, 0, len(positions))
+	for i, pos := range positions {
+		health := startingHealth / len(positions)
+		if i == 0 {
+			health += startingHealth % len(positions)
+		}
+		gs.Goals = append(gs.Goals, Goal{
+			ID:        gs.ids.Next(),
+			Position:  pos,
+			Health:    health,
+			MaxHealth: health,
+		})
+	}
+
+	first := positions[0]
+	gs.GoalPoint = &first
+	gs.Health = gs.goalHealth()
+}
+
+// goalHealth is the goals' combined health. Callers must hold the state
+// lock.
+func (gs *GameStateWithShooting) goalHealth() int {
+	total := 0
+	for _, g := range gs.Goals {
+		total += g.Health
+	}
+	return total
+}
+
+// goalTargets returns where enemies may head: every goal still standing,
+// or the GoalPoint in rooms without goal structures. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) goalTargets() []Position {
+	if len(gs.Goals) == 0 {
+		if gs.GoalPoint == nil {
+			return nil
+		}
+		return []Position{*gs.GoalPoint}
+	}
+
+	targets := make([]Position, 0, len(gs.Goals))
+	for _, g := range gs.Goals {
+		if g.Health > 0 {
+			targets = append(targets, g.Position)
+		}
+	}
+	return targets
+}
+
+// containsPosition reports whether positions includes pos
+func containsPosition(positions []Position, pos Position) bool {
+	for _, p := range positions {
+		if p == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// damageGoal charges a leak to the goal the enemy reached. Damage past a
+// goal's health is lost; once it's destroyed, enemies on the way re-route
+// to the goals still standing. Callers must hold the state lock.
+func (gs *GameStateWithShooting) damageGoal(enemy *Enemy, damage int) {
+	var goal *Goal
+	minDist := 0.0
+	for i := range gs.Goals {
+		g := &gs.Goals[i]
+		if d := distance(g.Position, enemy.Position); g.Health > 0 && (goal == nil || d < minDist) {
+			goal, minDist = g, d
+		}
+	}
+	if goal == nil {
+		gs.Health -= damage
+		return
+	}
+
+	goal.Health -= damage
+	if goal.Health < 0 {
+		goal.Health = 0
+	}
+	gs.Health = gs.goalHealth()
+
+	pos := goal.Position
+	gs.emitEvent(EventGoalDamaged, &pos, map[string]interface{}{
+		"goal_id":    goal.ID,
+		"enemy_id":   enemy.ID,
+		"enemy_type": enemy.EnemyType,
+		"damage":     damage,
+		"health":     goal.Health,
+	})
+
+	if goal.Health == 0 {
+		gs.RecalculateEnemyPaths()
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+import "testing"
+
+func TestGoalsTakeLeaksSeparately(t *testing.T) {
+	room, err := NewManager().CreateShootingRoomWithConfig("goals-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	near, far := *room.GoalPoint, *room.SpawnPoint
+	room.useGoals([]Position{near, far})
+	if total := room.Goals[0].Health + room.Goals[1].Health; total != startingHealth || room.Health != startingHealth {
+		t.Fatalf("goals have %d health between them and the room %d, want %d", total, room.Health, startingHealth)
+	}
+
+	// A leak hits the goal the enemy reached, and only that one
+	leaker := Enemy{ID: room.ids.Next(), EnemyType: "basic", Position: near}
+	room.damageGoal(&leaker, 5)
+	if room.Goals[0].Health != room.Goals[0].MaxHealth-5 || room.Goals[1].Health != room.Goals[1].MaxHealth {
+		t.Fatalf("goal health %d and %d after a leak of 5 at the first", room.Goals[0].Health, room.Goals[1].Health)
+	}
+
+	// Damage past a goal's health is lost, and enemies stop heading for it
+	room.damageGoal(&leaker, startingHealth)
+	if room.Goals[0].Health != 0 || room.Health != room.Goals[1].Health {
+		t.Fatalf("destroyed goal has %d health and the room %d, want 0 and the other goal's %d",
+			room.Goals[0].Health, room.Health, room.Goals[1].Health)
+	}
+	if targets := room.goalTargets(); len(targets) != 1 || targets[0] != far {
+		t.Fatalf("enemies head for %v, want only the goal still standing", targets)
+	}
+
+	// Later leaks fall on the goal still standing, wherever they happen
+	room.damageGoal(&leaker, 1)
+	if room.Goals[1].Health != room.Goals[1].MaxHealth-1 {
+		t.Fatalf("standing goal has %d health, want %d", room.Goals[1].Health, room.Goals[1].MaxHealth-1)
+	}
+}
@@ -0,0 +1,333 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"rust-rush/server/internal/cluster"
+	"rust-rush/server/internal/i18n"
+)
+
+// roomArchive is everything needed to resume a room on another instance:
+// the exported state plus the bookkeeping snapshots leave out
+type roomArchive struct {
+	State         *Snapshot                     `json:"state"`
+	Password      string                        `json:"password,omitempty"`
+	EntityEpoch   uint64                        `json:"entity_epoch"`
+	NextEntity    uint64                        `json:"next_entity"`
+	BuildQueue    []EntityID                    `json:"build_queue,omitempty"`
+	WavesStarted  int                           `json:"waves_started"`
+	JoinCode      string                        `json:"join_code"`
+	Invested      map[EntityID]int              `json:"invested,omitempty"` // tower ID -> gold spent
+	Traveled      map[EntityID]float64          `json:"traveled,omitempty"` // enemy ID -> distance walked
+	LastHitBy     map[EntityID]EntityID         `json:"last_hit_by,omitempty"`
+	Effects       map[EntityID][]archivedEffect `json:"effects,omitempty"` // in the order of each enemy's Effects
+	PendingSpawns []archivedSpawn               `json:"pending_spawns,omitempty"`
+	WaveScript    *WaveScript                   `json:"wave_script,omitempty"`
+	CampaignLevel *CampaignLevel                `json:"campaign_level,omitempty"`
+	Tutorial      *tutorialRun                  `json:"tutorial,omitempty"`
+	Milestones    []Milestone                   `json:"milestones,omitempty"`
+	Ghost         *GhostRun                     `json:"ghost,omitempty"`
+	GhostNext     int                           `json:"ghost_next,omitempty"`
+	RecentEvents  []GameEvent                   `json:"recent_events,omitempty"`
+	Teams         map[string]int                `json:"teams,omitempty"`
+	Kicked        map[string]bool               `json:"kicked,omitempty"`
+	Joined        map[string]bool               `json:"joined,omitempty"`
+	Returning     map[string]bool               `json:"returning,omitempty"`
+	DropInVetoed  bool                          `json:"drop_in_vetoed,omitempty"`
+	Names         map[string]string             `json:"names,omitempty"`
+	Colors        map[string]string             `json:"colors,omitempty"`
+	Ledger        []LedgerEntry                 `json:"ledger,omitempty"`
+	Economy       map[string]*PlayerEconomy     `json:"economy,omitempty"`
+	Samples       []TelemetrySample             `json:"samples,omitempty"`
+	EventLog      []GameEvent                   `json:"event_log,omitempty"`
+	Built         map[string]int                `json:"built,omitempty"`
+	WaveSpawned   int                           `json:"wave_spawned,omitempty"`
+	WaveLeaks     int                           `json:"wave_leaks,omitempty"`
+	SettledWave   int                           `json:"settled_wave,omitempty"`
+}
+
+type archivedEffect struct {
+	Damage       float64 `json:"damage"`
+	TickInterval float64 `json:"tick_interval"`
+	TickTimer    float64 `json:"tick_timer"`
+}
+
+type archivedSpawn struct {
+	At        float64  `json:"at"`
+	EnemyType string   `json:"enemy_type"`
+	From      Position `json:"from"`
+	Sender    string   `json:"sender,omitempty"`
+	Pack      string   `json:"pack,omitempty"`
+	PackSlot  int      `json:"pack_slot,omitempty"`
+}
+
+// archive captures the room for handoff
+func (gs *GameStateWithShooting) archive() roomArchive {
+	snapshot := gs.GetSnapshot()
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.archiveWith(snapshot)
+}
+
+// archiveWith captures the room around an already taken snapshot. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) archiveWith(snapshot *Snapshot) roomArchive {
+	a := roomArchive{
+		State:         snapshot,
+		Password:      gs.Config.Password,
+		EntityEpoch:   gs.ids.epoch,
+		NextEntity:    gs.ids.next,
+		BuildQueue:    gs.buildQueue,
+		WavesStarted:  gs.wavesStarted,
+		JoinCode:      gs.joinCode,
+		Invested:      make(map[EntityID]int, len(gs.Towers)),
+		Traveled:      make(map[EntityID]float64, len(gs.Enemies)),
+		LastHitBy:     make(map[EntityID]EntityID),
+		Effects:       make(map[EntityID][]archivedEffect),
+		WaveScript:    gs.waveScript,
+		CampaignLevel: gs.campaignLevel,
+		Tutorial:      gs.tutorial,
+		Milestones:    gs.milestones,
+		Ghost:         gs.ghost,
+		GhostNext:     gs.ghostNext,
+		RecentEvents:  gs.recentEvents,
+		Kicked:        gs.kicked,
+		Joined:        gs.joined,
+		Returning:     gs.returning,
+		DropInVetoed:  gs.dropInVetoed,
+		Names:         gs.names,
+		Colors:        gs.colors,
+		Ledger:        gs.ledger,
+		Economy:       gs.economy,
+		Samples:       gs.samples,
+		EventLog:      gs.eventLog,
+		Built:         gs.built,
+		WaveSpawned:   gs.waveSpawned,
+		WaveLeaks:     gs.waveLeaks,
+		SettledWave:   gs.settledWave,
+	}
+
+	for _, t := range gs.Towers {
+		a.Invested[t.ID] = t.invested
+	}
+	for _, e := range gs.Enemies {
+		a.Traveled[e.ID] = e.distanceTraveled
+		if e.lastHitBy != 0 {
+			a.LastHitBy[e.ID] = e.lastHitBy
+		}
+		for _, effect := range e.Effects {
+			a.Effects[e.ID] = append(a.Effects[e.ID], archivedEffect{
+				Damage:       effect.damage,
+				TickInterval: effect.tickInterval,
+				TickTimer:    effect.tickTimer,
+			})
+		}
+	}
+	for _, s := range gs.pendingSpawns {
+		a.PendingSpawns = append(a.PendingSpawns, archivedSpawn{At: s.at, EnemyType: s.enemyType, From: s.from, Sender: s.sender, Pack: s.pack, PackSlot: s.packSlot})
+	}
+	if gs.Versus != nil {
+		a.Teams = gs.Versus.teams
+	}
+	return a
+}
+
+// restoreRoom rebuilds a room from an archive. Entity IDs move to a new
+// epoch so anything allocated after the handoff can't collide.
+func restoreRoom(a roomArchive, balance *Balance) *GameStateWithShooting {
+	gs := a.State.restore()
+	gs.Config.Password = a.Password
+
+	gs.ids = idAllocator{epoch: a.EntityEpoch, next: a.NextEntity}
+	gs.ids.NewEpoch()
+	gs.buildQueue = a.BuildQueue
+	gs.wavesStarted = a.WavesStarted
+	gs.joinCode = a.JoinCode
+	gs.waveScript = a.WaveScript
+	gs.campaignLevel = a.CampaignLevel
+	gs.tutorial = a.Tutorial
+	gs.milestones = a.Milestones
+	gs.ghost = a.Ghost
+	gs.ghostNext = a.GhostNext
+	gs.recentEvents = a.RecentEvents
+	gs.kicked = a.Kicked
+	gs.joined = a.Joined
+	gs.returning = a.Returning
+	gs.dropInVetoed = a.DropInVetoed
+	gs.names = a.Names
+	gs.colors = a.Colors
+	gs.ledger = a.Ledger
+	gs.economy = a.Economy
+	gs.samples = a.Samples
+	gs.eventLog = a.EventLog
+	gs.built = a.Built
+	gs.waveSpawned = a.WaveSpawned
+	gs.waveLeaks = a.WaveLeaks
+	gs.settledWave = a.SettledWave
+	if gs.Versus != nil {
+		gs.Versus.teams = a.Teams
+	}
+
+	// Rooms resume on the adopting instance's balance
+	gs.useBalance(balance)
+
+	for i := range gs.Towers {
+		gs.Towers[i].invested = a.Invested[gs.Towers[i].ID]
+	}
+	for i := range gs.Enemies {
+		gs.Enemies[i].distanceTraveled = a.Traveled[gs.Enemies[i].ID]
+		gs.Enemies[i].lastHitBy = a.LastHitBy[gs.Enemies[i].ID]
+		effects := a.Effects[gs.Enemies[i].ID]
+		for j := range gs.Enemies[i].Effects {
+			if j < len(effects) {
+				gs.Enemies[i].Effects[j].damage = effects[j].Damage
+				gs.Enemies[i].Effects[j].tickInterval = effects[j].TickInterval
+				gs.Enemies[i].Effects[j].tickTimer = effects[j].TickTimer
+			}
+		}
+	}
+	for i := range gs.Projectiles {
+		// Damage over time comes from the tower type that fired it
+		if tower := gs.findTower(gs.Projectiles[i].TowerID); tower != nil {
+			gs.Projectiles[i].dot = gs.mods.towerStats(tower.TowerType).Dot
+		}
+	}
+	for _, s := range a.PendingSpawns {
+		gs.pendingSpawns = append(gs.pendingSpawns, scheduledSpawn{at: s.At, enemyType: s.EnemyType, from: s.From, sender: s.Sender, pack: s.Pack, packSlot: s.PackSlot})
+	}
+	return gs
+}
+
+// awaitReturn empties a restored room of the players it was archived with,
+// since they were connected to another instance or before a crash. Each
+// comes back by rejoining with their identity token, which gives them
+// their player ID and so their gold, team and name back; until then they
+// don't hold the room open or host it. The first to return hosts. The room
+// must not be shared yet.
+func (gs *GameStateWithShooting) awaitReturn() {
+	if gs.returning == nil {
+		gs.returning = make(map[string]bool, len(gs.Players))
+	}
+	for _, playerID := range gs.Players {
+		gs.returning[playerID] = true
+	}
+	gs.Players = nil
+	gs.Host = ""
+	gs.ready = nil
+}
+
+// UseHandoffStore sets where drained rooms are parked for other instances
+func (m *Manager) UseHandoffStore(store cluster.HandoffStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handoff = store
+}
+
+// IsDraining reports whether this instance has stopped taking new rooms
+func (m *Manager) IsDraining() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.draining
+}
+
+// Drain stops this instance from opening rooms and hands every active room
+// to the shared store, returning the IDs of the rooms handed off. Their
+// game loops stop once the rooms are removed.
+func (m *Manager) Drain() []string {
+	m.mu.Lock()
+	m.draining = true
+	rooms := make(map[string]*GameStateWithShooting)
+	for roomID, room := range m.rooms {
+		if shooting, ok := room.(*GameStateWithShooting); ok {
+			rooms[roomID] = shooting
+			delete(m.rooms, roomID)
+		}
+	}
+	store := m.handoff
+	m.mu.Unlock()
+
+	handedOff := make([]string, 0, len(rooms))
+	for roomID, room := range rooms {
+		m.unregisterRoom(roomID)
+		room.closeWAL() // the adopting instance keeps its own
+
+		data, err := json.Marshal(room.archive())
+		if err == nil {
+			err = store.Put(roomID, data)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to hand off room %s: %v", roomID, err)
+			m.recordMatch(room, ResultAbandoned)
+			m.roomClosed(room, RoomClosedDeleted)
+			continue
+		}
+		handedOff = append(handedOff, roomID)
+		m.roomClosed(room, RoomClosedHandedOff)
+	}
+
+	log.Printf("🚚 Drained %d rooms", len(handedOff))
+	return handedOff
+}
+
+// Adopt resumes a room another instance handed off, if there is one. A
+// room whose instance died without handing it off resumes from its newest
+// autosave instead.
+func (m *Manager) Adopt(roomID string) (*GameStateWithShooting, bool) {
+	if m.IsDraining() {
+		return nil, false
+	}
+
+	m.mu.RLock()
+	store := m.handoff
+	m.mu.RUnlock()
+
+	data, handedOff, err := store.Take(roomID)
+	if err != nil {
+		log.Printf("⚠️ Failed to check handoff store for room %s: %v", roomID, err)
+		return nil, false
+	}
+	if !handedOff {
+		var ok bool
+		data, ok, err = m.latestAutosave(roomID)
+		if err != nil {
+			log.Printf("⚠️ Failed to check autosaves for room %s: %v", roomID, err)
+			return nil, false
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	var a roomArchive
+	if err := json.Unmarshal(data, &a); err != nil || a.State == nil {
+		log.Printf("❌ Handed off room %s is corrupt: %v", roomID, err)
+		return nil, false
+	}
+
+	room := restoreRoom(a, m.roomBalance(m.balance.Current(), a.State.Config.Experiments))
+	room.awaitReturn()
+	if err := m.insertRoom(roomID, room); err != nil {
+		if errors.Is(err, i18n.NewError(i18n.ErrRoomExists, nil)) {
+			return m.GetShootingRoom(roomID) // adopted by a concurrent join
+		}
+		// Park it again, to be adopted once the realm has space for it
+		if handedOff {
+			if err := store.Put(roomID, data); err != nil {
+				log.Printf("❌ Failed to return room %s to the handoff store: %v", roomID, err)
+			}
+		}
+		log.Printf("⚠️ Can't adopt room %s: %v", roomID, err)
+		return nil, false
+	}
+
+	m.attachWAL(room)
+	m.registerRoom(roomID)
+	m.roomCreated(roomID)
+	go m.StartGameLoop(roomID)
+
+	log.Printf("📦 Adopted room %s at tick %d", roomID, room.Tick)
+	return room, true
+}
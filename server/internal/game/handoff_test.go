@@ -0,0 +1,49 @@
+package game
+
+import (
+	"testing"
+
+	"rust-rush/server/internal/cluster"
+)
+
+func TestAdoptedRoomWaitsForItsPlayers(t *testing.T) {
+	store := cluster.NewMemoryHandoffStore()
+	draining := NewManager()
+	draining.UseHandoffStore(store)
+
+	config := DefaultRoomConfig()
+	config.Visibility = VisibilityPrivate
+	room, err := draining.CreateShootingRoomWithConfig("room-1", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Join([]string{"host", "guest"}, nil)
+	if handedOff := draining.Drain(); len(handedOff) != 1 {
+		t.Fatalf("handed off %v, want room-1", handedOff)
+	}
+
+	adopting := NewManager()
+	adopting.UseHandoffStore(store)
+	adopted, ok := adopting.Adopt("room-1")
+	if !ok {
+		t.Fatal("room wasn't adopted")
+	}
+	if n := adopted.PlayerCount(); n != 0 {
+		t.Fatalf("adopted room has %d players before anyone reconnected", n)
+	}
+
+	// Players come back as themselves, without the join code
+	if err := adopted.CheckAccess("guest", "", ""); err != nil {
+		t.Fatalf("returning player was refused: %v", err)
+	}
+	if err := adopted.CheckAccess("stranger", "", ""); err == nil {
+		t.Fatal("stranger got into the private room")
+	}
+	adopted.Join([]string{"guest"}, nil)
+	if host := adopted.GetSnapshot().Host; host != "guest" {
+		t.Fatalf("host is %q, want the first player back", host)
+	}
+	if err := adopted.CheckAccess("host", "", ""); err != nil {
+		t.Fatalf("returning host was refused: %v", err)
+	}
+}
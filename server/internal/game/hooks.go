@@ -0,0 +1,49 @@
+package game
+
+// Reasons a room closed
+const (
+	RoomClosedDeleted   = "deleted"    // removed from the manager
+	RoomClosedHandedOff = "handed_off" // parked for another instance while draining
+)
+
+// RoomHooks are called as rooms open and close on this instance. Either may
+// be nil. Hooks run on whichever goroutine changed the room, without the
+// manager lock held, so they must return quickly and not block. A closed
+// room's instance tells it apart from a room opened with the same ID since.
+type RoomHooks struct {
+	OnRoomCreated func(roomID string)
+	OnRoomClosed  func(roomID string, instance uint64, reason string)
+}
+
+// Subscribe registers hooks for room lifecycle events, so other subsystems
+// hear about rooms without polling the manager
+func (m *Manager) Subscribe(hooks RoomHooks) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hooks)
+}
+
+// subscribers returns the registered hooks
+func (m *Manager) subscribers() []RoomHooks {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.hooks
+}
+
+// roomCreated tells subscribers a room opened
+func (m *Manager) roomCreated(roomID string) {
+	for _, hooks := range m.subscribers() {
+		if hooks.OnRoomCreated != nil {
+			hooks.OnRoomCreated(roomID)
+		}
+	}
+}
+
+// roomClosed tells subscribers a room closed and why
+func (m *Manager) roomClosed(room Room, reason string) {
+	for _, hooks := range m.subscribers() {
+		if hooks.OnRoomClosed != nil {
+			hooks.OnRoomClosed(room.ID(), room.instanceNumber(), reason)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"rust-rush/server/internal/ids"
+)
+
+// identitySeparator splits an identity token into its player ID and
+// signature. Player IDs never contain it.
+const identitySeparator = "."
+
+// Identities signs player IDs into tokens that clients present when they
+// reconnect, to be given the same player ID back. Anything keyed by player
+// ID, such as ratings, settings and inboxes, then follows the player across
+// connections. Instances sharing a key accept each other's tokens.
+type Identities struct {
+	mu  sync.RWMutex
+	key []byte
+}
+
+// NewIdentities creates an identity signer with a random key, whose tokens
+// only last as long as the process
+func NewIdentities() *Identities {
+	return &Identities{key: []byte(ids.Token(32))}
+}
+
+// UseKey signs tokens with a fixed key, so they survive restarts and are
+// accepted by every instance given the same key
+func (i *Identities) UseKey(key string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.key = []byte(key)
+}
+
+// Issue returns the token for a player ID
+func (i *Identities) Issue(playerID string) string {
+	return playerID + identitySeparator + i.sign(playerID)
+}
+
+// Verify returns the player ID a token was issued for, if its signature
+// checks out
+func (i *Identities) Verify(token string) (string, bool) {
+	j := strings.LastIndex(token, identitySeparator)
+	if j <= 0 {
+		return "", false
+	}
+	playerID, signature := token[:j], token[j+len(identitySeparator):]
+	if !hmac.Equal([]byte(signature), []byte(i.sign(playerID))) {
+		return "", false
+	}
+	return playerID, true
+}
+
+// sign returns a player ID's signature
+func (i *Identities) sign(playerID string) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(playerID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Identities returns the signer of the tokens clients reconnect with
+func (m *Manager) Identities() *Identities {
+	return m.identities
+}
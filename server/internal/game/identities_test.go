@@ -0,0 +1,34 @@
+package game
+
+import "testing"
+
+func TestIdentityTokens(t *testing.T) {
+	identities := NewIdentities()
+	identities.UseKey("secret")
+
+	token := identities.Issue("acme/client-1-abc")
+	if playerID, ok := identities.Verify(token); !ok || playerID != "acme/client-1-abc" {
+		t.Fatalf("Verify(%q) = %q, %v", token, playerID, ok)
+	}
+
+	// Another instance with the same key accepts it
+	other := NewIdentities()
+	other.UseKey("secret")
+	if _, ok := other.Verify(token); !ok {
+		t.Fatal("instance sharing the key refused the token")
+	}
+
+	for _, forged := range []string{
+		"",
+		"client-2-abc",
+		"client-2-abc." + token[len("acme/client-1-abc."):],
+		token + "0",
+	} {
+		if playerID, ok := identities.Verify(forged); ok {
+			t.Errorf("Verify(%q) accepted a forged token as %q", forged, playerID)
+		}
+	}
+	if _, ok := NewIdentities().Verify(token); ok {
+		t.Fatal("instance with another key accepted the token")
+	}
+}
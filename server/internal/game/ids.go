@@ -0,0 +1,74 @@
+package game
+
+import "fmt"
+
+// EntityID identifies any entity in a room (tower, enemy, projectile,
+// effect or event). IDs are built from a room epoch and a sequence number:
+//
+//	id = epoch<<32 | sequence
+//
+// The epoch changes every time a room's simulation is (re)started, so IDs
+// from before a restart or restore can never collide with new ones, and all
+// entity kinds share one sequence so an ID is unique across kinds too. The
+// epoch is limited to 21 bits to keep IDs below 2^53, the largest integer
+// JavaScript clients can represent exactly.
+type EntityID uint64
+
+const (
+	entitySequenceBits = 32
+	maxEntityEpoch     = 1<<21 - 1
+)
+
+// Epoch returns the room epoch the ID was allocated in
+func (id EntityID) Epoch() uint32 {
+	return uint32(id >> entitySequenceBits)
+}
+
+// Sequence returns the ID's position within its epoch
+func (id EntityID) Sequence() uint32 {
+	return uint32(id)
+}
+
+func (id EntityID) String() string {
+	return fmt.Sprintf("%d:%d", id.Epoch(), id.Sequence())
+}
+
+// idAllocator hands out entity IDs for a single room. Because IDs only grow,
+// entity slices kept in allocation order are also sorted by ID, which gives
+// snapshots a stable ordering.
+type idAllocator struct {
+	epoch uint64
+	next  uint64
+}
+
+// newIDAllocator starts at the epoch the room's seed picks. Seeds are
+// random unless chosen, so separate rooms are unlikely to reuse the same
+// IDs, while rooms sharing a seed hand out the same ones.
+func newIDAllocator(seed int64) idAllocator {
+	return idAllocator{
+		epoch: seedEpoch(seed),
+		next:  1,
+	}
+}
+
+// Next allocates a new ID
+func (a *idAllocator) Next() EntityID {
+	id := EntityID(a.epoch<<entitySequenceBits | a.next)
+	a.next++
+	if a.next >= 1<<entitySequenceBits {
+		a.NewEpoch()
+	}
+	return id
+}
+
+// peek returns the ID Next will hand out without allocating it
+func (a *idAllocator) peek() EntityID {
+	return EntityID(a.epoch<<entitySequenceBits | a.next)
+}
+
+// NewEpoch moves to the next epoch, e.g. after restoring a saved room, so new
+// IDs never collide with any allocated before
+func (a *idAllocator) NewEpoch() {
+	a.epoch = a.epoch%maxEntityEpoch + 1
+	a.next = 1
+}
@@ -0,0 +1,252 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Notification kinds a player's inbox can hold
+const (
+	InboxFriendInvite    = "friend_invite"
+	InboxTournamentRound = "tournament_round"
+	InboxDailyReset      = "daily_reset"
+)
+
+// inboxNotices maps each kind to the message clients show for it
+var inboxNotices = map[string]i18n.Code{
+	InboxFriendInvite:    i18n.NoticeFriendInvite,
+	InboxTournamentRound: i18n.NoticeRoundStart,
+	InboxDailyReset:      i18n.NoticeDailyReset,
+}
+
+// maxInboxSize is how many notifications a player keeps; older ones are
+// dropped first
+const maxInboxSize = 50
+
+// Notification is a personal message kept until the player reads it
+type Notification struct {
+	ID        string                 `json:"id"`
+	PlayerID  string                 `json:"player_id"`
+	Kind      string                 `json:"kind"`
+	Code      i18n.Code              `json:"code"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	Read      bool                   `json:"read"`
+}
+
+// ValidInboxKind reports whether kind is a known notification kind
+func ValidInboxKind(kind string) bool {
+	_, ok := inboxNotices[kind]
+	return ok
+}
+
+// InboxStore keeps each player's notifications in memory and, once given a
+// file, writes them through to disk so unread ones survive restarts. Inboxes
+// are keyed by player ID, which players keep across connections with their
+// identity token.
+type InboxStore struct {
+	mu    sync.RWMutex
+	inbox map[string][]Notification // player -> oldest first
+	path  string
+}
+
+// NewInboxStore creates an empty in-memory inbox store
+func NewInboxStore() *InboxStore {
+	return &InboxStore{
+		inbox: make(map[string][]Notification),
+	}
+}
+
+// UseFile loads notifications from a JSON file, if it exists, and saves
+// every later change back to it
+func (s *InboxStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var notifications []Notification
+	if err := json.Unmarshal(data, &notifications); err != nil {
+		return err
+	}
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+	for _, n := range notifications {
+		s.inbox[n.PlayerID] = append(s.inbox[n.PlayerID], n)
+	}
+	return nil
+}
+
+// Push adds a notification to a player's inbox
+func (s *InboxStore) Push(playerID, kind string, params map[string]interface{}) (Notification, error) {
+	notifications, err := s.PushAll([]string{playerID}, kind, params)
+	if len(notifications) == 0 {
+		return Notification{}, err
+	}
+	return notifications[0], err
+}
+
+// PushAll adds the same notification to several players' inboxes, saving
+// them to disk once. It returns each player's notification, in order.
+func (s *InboxStore) PushAll(playerIDs []string, kind string, params map[string]interface{}) ([]Notification, error) {
+	code, ok := inboxNotices[kind]
+	if !ok {
+		return nil, i18n.NewError(i18n.ErrUnknownInboxKind, map[string]interface{}{"kind": kind})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notifications := make([]Notification, 0, len(playerIDs))
+	for _, playerID := range playerIDs {
+		n := Notification{
+			ID:        ids.New("notif"),
+			PlayerID:  playerID,
+			Kind:      kind,
+			Code:      code,
+			Params:    params,
+			CreatedAt: time.Now(),
+		}
+
+		inbox := append(s.inbox[playerID], n)
+		if len(inbox) > maxInboxSize {
+			inbox = inbox[len(inbox)-maxInboxSize:]
+		}
+		s.inbox[playerID] = inbox
+		notifications = append(notifications, n)
+	}
+	return notifications, s.persist()
+}
+
+// List returns a player's notifications, newest first
+func (s *InboxStore) List(playerID string) []Notification {
+	return s.collect(playerID, false)
+}
+
+// Unread returns a player's unread notifications, newest first
+func (s *InboxStore) Unread(playerID string) []Notification {
+	return s.collect(playerID, true)
+}
+
+// collect copies a player's notifications, newest first
+func (s *InboxStore) collect(playerID string, unreadOnly bool) []Notification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inbox := s.inbox[playerID]
+	notifications := make([]Notification, 0, len(inbox))
+	for i := len(inbox) - 1; i >= 0; i-- {
+		if unreadOnly && inbox[i].Read {
+			continue
+		}
+		notifications = append(notifications, inbox[i])
+	}
+	return notifications
+}
+
+// UnreadCount returns how many notifications a player hasn't read
+func (s *InboxStore) UnreadCount(playerID string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, n := range s.inbox[playerID] {
+		if !n.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkRead marks a player's notifications as read, or all of them if no IDs
+// are given, and returns how many changed
+func (s *InboxStore) MarkRead(playerID string, notificationIDs []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := 0
+	inbox := s.inbox[playerID]
+	for i := range inbox {
+		if inbox[i].Read {
+			continue
+		}
+		if len(notificationIDs) > 0 && !containsString(notificationIDs, inbox[i].ID) {
+			continue
+		}
+		inbox[i].Read = true
+		marked++
+	}
+	if marked == 0 {
+		return 0, nil
+	}
+	return marked, s.persist()
+}
+
+// persist writes every inbox to disk if a file is configured. Callers must
+// hold the lock.
+func (s *InboxStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	notifications := make([]Notification, 0)
+	for _, inbox := range s.inbox {
+		notifications = append(notifications, inbox...)
+	}
+
+	data, err := json.MarshalIndent(notifications, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Deliver stores a notification in a player's inbox and pushes it to them
+// if they're connected, wherever they are. A notification that couldn't be
+// saved to disk is still delivered.
+func (m *Manager) Deliver(playerID, kind string, params map[string]interface{}) (Notification, error) {
+	notifications, err := m.DeliverAll([]string{playerID}, kind, params)
+	if len(notifications) == 0 {
+		return Notification{}, err
+	}
+	return notifications[0], err
+}
+
+// DeliverAll delivers the same notification to several players, saving
+// their inboxes to disk once
+func (m *Manager) DeliverAll(playerIDs []string, kind string, params map[string]interface{}) ([]Notification, error) {
+	notifications, err := m.inbox.PushAll(playerIDs, kind, params)
+	for _, n := range notifications {
+		m.notify(n.PlayerID, NotifyInbox, map[string]interface{}{
+			"notification": n,
+			"unread":       m.inbox.UnreadCount(n.PlayerID),
+		})
+	}
+	return notifications, err
+}
+
+// Inbox returns the per-player notification store
+func (m *Manager) Inbox() *InboxStore {
+	return m.inbox
+}
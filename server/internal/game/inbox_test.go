@@ -0,0 +1,60 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPushAllSavesEveryInbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.json")
+	store := NewInboxStore()
+	if err := store.UseFile(path); err != nil {
+		t.Fatalf("failed to use file: %v", err)
+	}
+
+	players := []string{"a", "b", "c"}
+	notifications, err := store.PushAll(players, InboxDailyReset, nil)
+	if err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+	if len(notifications) != len(players) {
+		t.Fatalf("pushed %d notifications, want %d", len(notifications), len(players))
+	}
+
+	restarted := NewInboxStore()
+	if err := restarted.UseFile(path); err != nil {
+		t.Fatalf("failed to load notifications: %v", err)
+	}
+	for i, playerID := range players {
+		unread := restarted.Unread(playerID)
+		if len(unread) != 1 || unread[0].ID != notifications[i].ID {
+			t.Errorf("%s has unread %v after restart, want %s", playerID, unread, notifications[i].ID)
+		}
+	}
+}
+
+func TestInboxKeepsNewest(t *testing.T) {
+	store := NewInboxStore()
+	var last Notification
+	for i := 0; i < maxInboxSize+5; i++ {
+		n, err := store.Push("player", InboxFriendInvite, nil)
+		if err != nil {
+			t.Fatalf("failed to push: %v", err)
+		}
+		last = n
+	}
+
+	list := store.List("player")
+	if len(list) != maxInboxSize {
+		t.Fatalf("inbox holds %d notifications, want %d", len(list), maxInboxSize)
+	}
+	if list[0].ID != last.ID {
+		t.Fatal("newest notification isn't listed first")
+	}
+}
+
+func TestUnknownInboxKind(t *testing.T) {
+	if _, err := NewInboxStore().Push("player", "nope", nil); err == nil {
+		t.Fatal("unknown kind was pushed")
+	}
+}
@@ -0,0 +1,82 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// keyframeCacheTTL is how long an encoded keyframe is reused for pollers
+const keyframeCacheTTL = 250 * time.Millisecond
+
+// RequestKeyframe asks the room's game loop for a personal keyframe for a
+// player about to receive its broadcasts. Lockstep rooms only send inputs,
+// so a newcomer needs the exact state the next frame builds on; the loop
+// takes it between frames.
+func (gs *GameStateWithShooting) RequestKeyframe(playerIDs ...string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.keyframeFor = append(gs.keyframeFor, playerIDs...)
+}
+
+// takeKeyframe encodes a snapshot for everyone waiting on a keyframe
+func (gs *GameStateWithShooting) takeKeyframe(precision Precision) ([]string, []byte, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if len(gs.keyframeFor) == 0 {
+		return nil, nil, false
+	}
+
+	snapshot := gs.clientSnapshot(precision)
+	data, err := json.Marshal(&snapshot)
+	if err != nil {
+		log.Printf("❌ Failed to marshal keyframe for room %s: %v", gs.RoomID, err)
+		return nil, nil, false
+	}
+
+	playerIDs := gs.keyframeFor
+	gs.keyframeFor = nil
+	return playerIDs, data, true
+}
+
+// CachedKeyframe returns the room's state encoded as a keyframe for
+// clients polling over HTTP. It's encoded at most once per
+// keyframeCacheTTL, so many pollers cost the room no more than one.
+// Positions are encoded at the given precision.
+func (gs *GameStateWithShooting) CachedKeyframe(precision Precision) ([]byte, error) {
+	gs.pollMu.Lock()
+	defer gs.pollMu.Unlock()
+
+	if gs.polled != nil && time.Since(gs.polledAt) < keyframeCacheTTL {
+		return gs.polled, nil
+	}
+
+	gs.mu.RLock()
+	snapshot := gs.clientSnapshot(precision)
+	data, err := json.Marshal(&snapshot)
+	gs.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	gs.polled = data
+	gs.polledAt = time.Now()
+	return data, nil
+}
+
+// publishKeyframe sends pending keyframes to the hub. If the channel is
+// full the requests are put back for the next frame, since a player
+// waiting on a keyframe gets nothing else.
+func (m *Manager) publishKeyframe(room *GameStateWithShooting) {
+	playerIDs, data, ok := room.takeKeyframe(m.Precision())
+	if !ok {
+		return
+	}
+
+	select {
+	case m.broadcast <- BroadcastMessage{RoomID: room.RoomID, Kind: BroadcastKeyframe, Data: data, To: playerIDs}:
+	default:
+		room.RequestKeyframe(playerIDs...)
+	}
+}
@@ -0,0 +1,29 @@
+package game
+
+import "time"
+
+// latencyStep is how much a player's latency must change before the lobby
+// is resent, so jitter doesn't flood rooms with rosters
+const latencyStep = 10 * time.Millisecond
+
+// SetLatency records a player's measured one-way latency, shown to the
+// room in the lobby
+func (gs *GameStateWithShooting) SetLatency(playerID string, latency time.Duration) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !containsString(gs.Players, playerID) {
+		return
+	}
+
+	previous, ok := gs.latency[playerID]
+	change := latency - previous
+	if ok && change < latencyStep && change > -latencyStep {
+		return
+	}
+	if gs.latency == nil {
+		gs.latency = make(map[string]time.Duration)
+	}
+	gs.latency[playerID] = latency
+	gs.lobbyChanged()
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	maxLeaderboardEntries = 100
+	maxMatchHistory       = 500
+)
+
+// Match results
+const (
+	ResultVictory   = "victory"
+	ResultDefeat    = "defeat"
+	ResultAbandoned = "abandoned"
+	ResultForfeit   = "forfeit"
+)
+
+// MatchResult records the outcome of a finished game
+type MatchResult struct {
+	RoomID   string    `json:"room_id"`
+	Players  []string  `json:"players"`
+	Mutators []string  `json:"mutators"`
+	Seed     int64     `json:"seed"` // replays the game with the same inputs
+	Score    Score     `json:"score"`
+	Result   string    `json:"result"`
+	Winners  []string  `json:"winners,omitempty"` // versus only
+	Losers   []string  `json:"losers,omitempty"`  // versus only
+	Wave     int       `json:"wave"`
+	Stars    int       `json:"stars,omitempty"` // won campaign levels only
+	Duration float64   `json:"duration"`        // game time in seconds
+	EndedAt  time.Time `json:"ended_at"`
+
+	// Gold spent and earned by each player, for post-game analytics
+	Economy []PlayerEconomy `json:"economy,omitempty"`
+}
+
+// Leaderboard keeps the best results and the recent match history of each
+// realm in memory
+type Leaderboard struct {
+	mu      sync.RWMutex
+	top     map[string][]MatchResult // by tenant
+	history map[string][]MatchResult // by tenant
+}
+
+// NewLeaderboard creates an empty leaderboard
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		top:     make(map[string][]MatchResult),
+		history: make(map[string][]MatchResult),
+	}
+}
+
+// Record adds a finished match to its realm's history and, if good enough,
+// top scores
+func (l *Leaderboard) Record(result MatchResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tenant := TenantOf(result.RoomID)
+
+	history := append(l.history[tenant], result)
+	if len(history) > maxMatchHistory {
+		history = history[len(history)-maxMatchHistory:]
+	}
+	l.history[tenant] = history
+
+	// Giving up forfeits a place among the top scores
+	if result.Result == ResultForfeit {
+		return
+	}
+	top := append(l.top[tenant], result)
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Score.Total > top[j].Score.Total
+	})
+	if len(top) > maxLeaderboardEntries {
+		top = top[:maxLeaderboardEntries]
+	}
+	l.top[tenant] = top
+}
+
+// Top returns up to limit of the highest scoring matches in a realm
+func (l *Leaderboard) Top(tenant string, limit int) []MatchResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	top := l.top[tenant]
+	if limit <= 0 || limit > len(top) {
+		limit = len(top)
+	}
+
+	results := make([]MatchResult, limit)
+	copy(results, top[:limit])
+	return results
+}
+
+// History returns up to limit of a realm's most recent matches, newest
+// first
+func (l *Leaderboard) History(tenant string, limit int) []MatchResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	history := l.history[tenant]
+	if limit <= 0 || limit > len(history) {
+		limit = len(history)
+	}
+
+	results := make([]MatchResult, 0, limit)
+	for i := len(history) - 1; i >= 0 && len(results) < limit; i-- {
+		results = append(results, history[i])
+	}
+	return results
+}
@@ -0,0 +1,100 @@
+package game
+
+import "math"
+
+// Leak modes decide what an enemy reaching the goal costs. Rooms use the
+// one in their config, or else their map's, or else LeakFlat.
+const (
+	LeakFlat      = "flat"      // lose leakDamage health
+	LeakRespawn   = "respawn"   // lose leakDamage health, and the enemy starts over weakened
+	LeakRemaining = "remaining" // lose as much health as the enemy had left
+	LeakEndWave   = "end_wave"  // lose leakDamage health, and the rest of the wave is called off
+)
+
+// leakRespawnHealth is the share of its health a respawning enemy keeps.
+// Enemies left with less than minRespawnHealth leak for good.
+const (
+	leakRespawnHealth = 0.5
+	minRespawnHealth  = 1.0
+)
+
+// EventWaveEnded is sent when a leak calls off the rest of a wave
+const EventWaveEnded = "wave_ended"
+
+// validLeakMode reports whether a room config may ask for a leak mode
+func validLeakMode(mode string) bool {
+	switch mode {
+	case "", LeakFlat, LeakRespawn, LeakRemaining, LeakEndWave:
+		return true
+	}
+	return false
+}
+
+// leak charges for an enemy reaching the goal. It reports whether the enemy
+// stays on the map, and whether the rest of the wave should be called off.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) leak(enemy *Enemy) (keep, endWave bool) {
+	damage := leakDamage
+	if gs.Config.LeakMode == LeakRemaining {
+		damage = int(math.Max(1, math.Ceil(enemy.Health)))
+	}
+
+	if gs.isVersus() {
+		gs.versusLeak(enemy, damage)
+	} else {
+		gs.damageGoal(enemy, damage)
+	}
+	gs.Score.recordLeak()
+	gs.waveLeaks++
+
+	switch gs.Config.LeakMode {
+	case LeakRespawn:
+		return gs.respawnLeaked(enemy), false
+	case LeakEndWave:
+		return false, true
+	}
+	return false, false
+}
+
+// respawnLeaked sends a leaked enemy back to the spawn point it entered at
+// with part of its health, reporting false if it's too weak to go again.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) respawnLeaked(enemy *Enemy) bool {
+	enemy.Health *= leakRespawnHealth
+	if enemy.Health < minRespawnHealth {
+		return false
+	}
+
+	// Its path starts wherever it was last rerouted, so that's no guide.
+	// Enemies restored from snapshots don't know where they spawned and go
+	// back to the room's spawn point.
+	from := enemy.spawnedAt
+	if from == nil {
+		from = gs.SpawnPoint
+	}
+	if from == nil {
+		from = &enemy.Path[0]
+	}
+	enemy.Path = gs.pathFrom(*from)
+	enemy.PathIndex = 0
+	enemy.Position = enemy.Path[0]
+	enemy.distanceTraveled = 0
+	enemy.Progress = 0
+	enemy.Pack = "" // it's lost its place
+	return true
+}
+
+// endWaveEarly calls off the rest of the wave: enemies still on the map
+// leave without paying out, and spawns still to come are dropped. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) endWaveEarly() {
+	removed := len(gs.Enemies) + len(gs.pendingSpawns)
+	gs.Enemies = updateEach(gs.Enemies, func(*Enemy) bool { return false })
+	gs.pendingSpawns = nil
+
+	gs.emitEvent(EventWaveEnded, nil, map[string]interface{}{
+		"wave":    gs.Wave,
+		"reason":  LeakEndWave,
+		"removed": removed,
+	})
+}
@@ -0,0 +1,26 @@
+package game
+
+import "testing"
+
+func TestRespawnedEnemyReturnsToItsSpawn(t *testing.T) {
+	room, err := NewManager().CreateShootingRoomWithConfig("leak-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	// A group with its own spawn point, rerouted once it's on the map
+	spawn := Position{X: 0, Y: 2}
+	room.addEnemy("basic", []Position{spawn}, "")
+	enemy := &room.Enemies[0]
+	enemy.Health = enemy.MaxHealth
+	enemy.Path = []Position{{X: 5, Y: 5}, {X: 6, Y: 5}}
+
+	if !room.respawnLeaked(enemy) {
+		t.Fatal("healthy enemy wasn't respawned")
+	}
+	if enemy.Position != spawn {
+		t.Fatalf("enemy respawned at %+v, want its spawn %+v", enemy.Position, spawn)
+	}
+}
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Player roles
+const (
+	RoleHost   = "host"
+	RolePlayer = "player"
+)
+
+// PlayerInfo is what other players see of someone in the room
+type PlayerInfo struct {
+	PlayerID string `json:"player_id"`
+	Name     string `json:"name"`
+	Color    string `json:"color,omitempty"`
+	Role     string `json:"role"`
+}
+
+// LobbyPlayer is a player's place in the room's roster
+type LobbyPlayer struct {
+	PlayerInfo
+	Ready     bool `json:"ready"`
+	LatencyMs int  `json:"latency_ms,omitempty"` // one way, measured by the server
+}
+
+// Lobby is the room's roster and settings. It changes rarely, so it's sent
+// when it changes rather than with every simulation frame.
+type Lobby struct {
+	RoomID     string        `json:"room_id"`
+	Host       string        `json:"host,omitempty"`
+	Players    []LobbyPlayer `json:"players"`
+	Config     RoomConfig    `json:"config"`
+	InProgress bool          `json:"in_progress"`
+	DropIn     bool          `json:"drop_in"` // strangers may join right now
+	AllReady   bool          `json:"all_ready"`
+}
+
+// lobbyChanged marks the roster as needing to be resent. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) lobbyChanged() {
+	gs.lobbyVersion++
+}
+
+// lobby builds the roster. Callers must hold the state lock.
+func (gs *GameStateWithShooting) lobby() Lobby {
+	lobby := Lobby{
+		RoomID:     gs.RoomID,
+		Host:       gs.Host,
+		Players:    make([]LobbyPlayer, 0, len(gs.Players)),
+		Config:     gs.Config,
+		InProgress: gs.inProgress(),
+		DropIn:     gs.dropInOpen(),
+		AllReady:   len(gs.Players) > 0,
+	}
+	for _, playerID := range gs.Players {
+		ready := gs.ready[playerID]
+		lobby.Players = append(lobby.Players, LobbyPlayer{
+			PlayerInfo: gs.playerInfo(playerID),
+			Ready:      ready,
+			LatencyMs:  int(gs.latency[playerID] / time.Millisecond),
+		})
+		lobby.AllReady = lobby.AllReady && ready
+	}
+	return lobby
+}
+
+// playerInfo describes a player to the rest of the room. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) playerInfo(playerID string) PlayerInfo {
+	role := RolePlayer
+	if playerID == gs.Host {
+		role = RoleHost
+	}
+	name, ok := gs.names[playerID]
+	if !ok {
+		name = playerID
+	}
+	return PlayerInfo{PlayerID: playerID, Name: name, Color: gs.colors[playerID], Role: role}
+}
+
+// PlayerInfo describes a player to the rest of the room
+func (gs *GameStateWithShooting) PlayerInfo(playerID string) PlayerInfo {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.playerInfo(playerID)
+}
+
+// HostID returns the player hosting the room, if anyone
+func (gs *GameStateWithShooting) HostID() string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.Host
+}
+
+// Lobby returns the room's roster and settings
+func (gs *GameStateWithShooting) Lobby() Lobby {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.lobby()
+}
+
+// LobbyUpdate returns the roster if it changed since the last update was
+// taken
+func (gs *GameStateWithShooting) LobbyUpdate() (Lobby, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.lobbyVersion == gs.lobbySent {
+		return Lobby{}, false
+	}
+	gs.lobbySent = gs.lobbyVersion
+	return gs.lobby(), true
+}
+
+// resendLobby makes the next LobbyUpdate return the roster again, for when
+// an update couldn't be delivered
+func (gs *GameStateWithShooting) resendLobby() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.lobbyChanged()
+}
+
+// SetReady marks a player ready or not
+type SetReady struct {
+	PlayerID string
+	Ready    bool
+}
+
+func (SetReady) Type() string { return CommandReady }
+
+func (c SetReady) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if !containsString(gs.Players, c.PlayerID) {
+		return nil, i18n.NewError(i18n.ErrNotInRoom, nil)
+	}
+	if gs.ready == nil {
+		gs.ready = make(map[string]bool)
+	}
+	if gs.ready[c.PlayerID] != c.Ready {
+		gs.ready[c.PlayerID] = c.Ready
+		gs.lobbyChanged()
+	}
+	return nil, nil
+}
+
+// KickPlayer removes a player from the room and keeps them out. Only the
+// host may kick, and not themselves.
+type KickPlayer struct {
+	By       string
+	PlayerID string
+}
+
+func (KickPlayer) Type() string { return CommandKick }
+
+func (c KickPlayer) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if gs.Host != c.By {
+		return nil, i18n.NewError(i18n.ErrNotHost, nil)
+	}
+	if c.PlayerID == c.By || !containsString(gs.Players, c.PlayerID) {
+		return nil, i18n.NewError(i18n.ErrCannotKick, map[string]interface{}{"player_id": c.PlayerID})
+	}
+
+	info := gs.playerInfo(c.PlayerID)
+	gs.removePlayer(c.PlayerID)
+	if gs.kicked == nil {
+		gs.kicked = make(map[string]bool)
+	}
+	gs.kicked[c.PlayerID] = true
+	return info, nil
+}
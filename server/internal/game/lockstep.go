@@ -0,0 +1,90 @@
+package game
+
+// Room sync modes
+const (
+	SyncState    = "state"    // the full state is broadcast every frame
+	SyncLockstep = "lockstep" // only inputs and periodic state hashes are broadcast
+)
+
+// Lockstep frames are sent every few ticks with the inputs applied since the
+// last one; a state hash is attached every stateHashInterval ticks so clients
+// running the simulation locally can detect divergence
+const (
+	lockstepFrameTicks = 3
+	stateHashInterval  = 30
+)
+
+// Input types recorded in lockstep rooms
+const (
+	InputPlaceTower   = "place_tower"
+	InputSellTower    = "sell_tower"
+	InputUpgradeTower = "upgrade_tower"
+	InputTargetMode   = "set_target_mode"
+	InputSpawnEnemy   = "spawn_enemy"
+	InputStartWave    = "start_wave"
+	InputPause        = "pause"
+	InputClearTowers  = "clear_towers"
+	InputClearEnemies = "clear_enemies"
+	InputSkipWait     = "skip_wait"
+)
+
+// InputRecord is a state-changing action. It was applied after tick Tick
+// finished, so it first affects tick Tick+1.
+type InputRecord struct {
+	Tick uint64                 `json:"tick"`
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// LockstepFrame is what lockstep rooms broadcast instead of the state
+type LockstepFrame struct {
+	Tick   uint64        `json:"tick"`
+	Inputs []InputRecord `json:"inputs"`
+	Hash   string        `json:"hash,omitempty"`
+}
+
+// IsLockstep reports whether the room broadcasts inputs instead of state
+func (gs *GameStateWithShooting) IsLockstep() bool {
+	return gs.Config.Sync == SyncLockstep
+}
+
+// recordInput queues an input for the next lockstep frame. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) recordInput(inputType string, data map[string]interface{}) {
+	if !gs.IsLockstep() {
+		return
+	}
+
+	gs.pendingInputs = append(gs.pendingInputs, InputRecord{
+		Tick: gs.Tick,
+		Type: inputType,
+		Data: data,
+	})
+}
+
+// LockstepFrame returns the frame due after the current tick, if any, and
+// clears the queued inputs
+func (gs *GameStateWithShooting) LockstepFrame() (LockstepFrame, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	// Paused rooms don't advance, so don't resend the same tick
+	if gs.Tick%lockstepFrameTicks != 0 || gs.Tick == gs.lastFrameTick {
+		return LockstepFrame{}, false
+	}
+	gs.lastFrameTick = gs.Tick
+
+	frame := LockstepFrame{
+		Tick:   gs.Tick,
+		Inputs: gs.pendingInputs,
+	}
+	if frame.Inputs == nil {
+		frame.Inputs = make([]InputRecord, 0)
+	}
+	if gs.Tick%stateHashInterval == 0 {
+		frame.Hash = formatStateHash(gs.stateHash())
+	}
+
+	gs.pendingInputs = nil
+	return frame, true
+}
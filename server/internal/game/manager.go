@@ -1,149 +1,551 @@
 package game
 
 import (
+	"bytes"
 	"encoding/json"
 	"log"
 	"sync"
 	"time"
-)
 
-// GameState represents the current state of a game room (legacy)
-type GameState struct {
-	RoomID   string          `json:"room_id"`
-	Players  []string        `json:"players"`
-	GameData json.RawMessage `json:"game_data"` // Raw JSON from Rust engine
-}
+	"rust-rush/server/internal/cluster"
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
 
 // Manager handles multiple game rooms
 type Manager struct {
-	rooms         map[string]*GameState
-	shootingRooms map[string]*GameStateWithShooting
+	rooms         map[string]Room
 	mu            sync.RWMutex
+	instances     uint64     // rooms opened so far, numbering each one
+	joinMu        sync.Mutex // held through realm quota checks and the joins they allow
 	broadcast     chan BroadcastMessage
+	analysisFeed  chan BroadcastMessage // analysis frames, kept apart so they can't crowd out game state
+	notifications chan PlayerNotification
+	leaderboard   *Leaderboard
+	profiles      *ProfileStore
+	matchmaker    *Matchmaker
+	parties       *PartyStore
+	friends       *FriendStore
+	experiments   *ExperimentStore
+	inbox         *InboxStore
+	events        *EventScheduler
+	templates     *TemplateStore
+	balance       *BalanceStore
+	moderation    *ModerationStore
+	names         *NameStore
+	telemetry     *TelemetryStore
+	static        StaticCache
+	waves         *WaveLibrary
+	tutorials     *TutorialLibrary
+	ghosts        *GhostStore
+	stats         *BalanceStatsStore
+	tenants       *TenantResolver
+	identities    *Identities
+	quotas        *QuotaStore
+	botKeys       *BotKeyStore
+	directory     cluster.Directory
+	instance      cluster.Instance
+	handoff       cluster.HandoffStore
+	region        string         // tag rooms here are listed under, e.g. eu-west
+	walDir        string         // where rooms' write-ahead logs go, empty for none
+	walCheckpoint uint64         // ticks between checkpoints in the write-ahead logs
+	autosaves     *autosaver     // nil unless rooms are autosaved
+	precision     Precision      // how positions are encoded for clients
+	draining      bool           // no new rooms once rooms are being handed off
+	diagnostics   map[string]int // watchers of each room's tick metrics
+	analysis      map[string]int // watchers of each room's analysis stream
+	hooks         []RoomHooks    // room lifecycle subscribers
 }
 
+// Broadcast kinds
+const (
+	BroadcastState       = "state"       // Data is a full snapshot
+	BroadcastLockstep    = "lockstep"    // Data is a LockstepFrame
+	BroadcastDiagnostics = "diagnostics" // Data is TickMetrics, for watchers only
+	BroadcastLobby       = "lobby"       // Data is a Lobby, sent when it changes
+	BroadcastAnalysis    = "analysis"    // Data is an AnalysisFrame, for analysts only
+	BroadcastMinimap     = "minimap"     // Data is a Minimap, once a second
+	BroadcastKeyframe    = "keyframe"    // Data is a Snapshot for the players in To only
+	BroadcastGameOver    = "game_over"   // Data is the MatchResult, once per game
+)
+
 // BroadcastMessage contains room ID and data to broadcast
 type BroadcastMessage struct {
 	RoomID string
+	Kind   string
 	Data   []byte
+	To     []string // players a personal message is for, empty for the whole room
+}
+
+// Player notification types
+const (
+	NotifyMatchFound    = "match_found"
+	NotifyRatingChanged = "rating_changed"
+	NotifyPartyInvite   = "party_invite"
+	NotifyPartyUpdate   = "party_update"
+	NotifyLevelComplete = "level_complete"
+	NotifyInbox         = "notification"
+)
+
+// PlayerNotification is a message for one specific player rather than a room
+type PlayerNotification struct {
+	PlayerID string
+	Type     string
+	Payload  map[string]interface{}
 }
 
 // NewManager creates a new game manager
 func NewManager() *Manager {
 	return &Manager{
-		rooms:         make(map[string]*GameState),
-		shootingRooms: make(map[string]*GameStateWithShooting),
+		rooms:         make(map[string]Room),
 		broadcast:     make(chan BroadcastMessage, 256),
+		analysisFeed:  make(chan BroadcastMessage, 64),
+		notifications: make(chan PlayerNotification, 256),
+		leaderboard:   NewLeaderboard(),
+		profiles:      NewProfileStore(),
+		matchmaker:    NewMatchmaker(),
+		parties:       NewPartyStore(),
+		friends:       NewFriendStore(),
+		experiments:   NewExperimentStore(),
+		inbox:         NewInboxStore(),
+		events:        NewEventScheduler(),
+		templates:     NewTemplateStore(),
+		balance:       NewBalanceStore(),
+		moderation:    NewModerationStore(),
+		names:         NewNameStore(),
+		telemetry:     NewTelemetryStore(),
+		static:        NewMemoryStaticCache(),
+		waves:         NewWaveLibrary(),
+		tutorials:     NewTutorialLibrary(),
+		ghosts:        NewGhostStore(),
+		stats:         NewBalanceStatsStore(),
+		tenants:       NewTenantResolver(),
+		identities:    NewIdentities(),
+		quotas:        NewQuotaStore(),
+		botKeys:       NewBotKeyStore(),
+		directory:     cluster.NewMemoryDirectory(),
+		instance:      cluster.Instance{ID: "local"},
+		handoff:       cluster.NewMemoryHandoffStore(),
+		diagnostics:   make(map[string]int),
+		analysis:      make(map[string]int),
+		precision:     DefaultPrecision,
 	}
 }
 
-// CreateRoom creates a new game room
+// CreateRoom creates a new legacy room
 func (m *Manager) CreateRoom(roomID string) *GameState {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	state := &GameState{
 		RoomID:  roomID,
 		Players: make([]string, 0),
 	}
 
+	m.mu.Lock()
+	m.instances++
+	state.instance = m.instances
 	m.rooms[roomID] = state
+	m.mu.Unlock()
+
+	m.roomCreated(roomID)
 	return state
 }
 
 // CreateShootingRoom creates a new game room with shooting mechanics
 func (m *Manager) CreateShootingRoom(roomID string) *GameStateWithShooting {
+	state, _ := m.CreateShootingRoomWithConfig(roomID, DefaultRoomConfig())
+	return state
+}
+
+// CreateShootingRoomWithConfig creates a shooting room with the given mutators
+func (m *Manager) CreateShootingRoomWithConfig(roomID string, config RoomConfig) (*GameStateWithShooting, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var level *CampaignLevel
+	if config.Campaign != "" {
+		var err error
+		if config, level, err = applyCampaignLevel(config); err != nil {
+			return nil, err
+		}
+	}
+
+	var script *WaveScript
+	if config.Waves != "" {
+		s, err := m.waves.Get(DefaultMap, config.Waves)
+		if err != nil {
+			return nil, err
+		}
+		script = &s
+	}
+
+	var tutorial *tutorialRun
+	if config.Tutorial != "" {
+		s, err := m.tutorials.Get(config.Tutorial)
+		if err != nil {
+			return nil, err
+		}
+		tutorial = &tutorialRun{Script: s}
+	}
+
+	config = applyServerEvents(config, m.events.Active(time.Now()))
+	// Lockstep clients simulate with the published stats, so they can't
+	// take part
+	if config.Sync != SyncLockstep {
+		config.Experiments = m.experiments.assign(roomID)
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	if m.draining {
+		m.mu.Unlock()
+		return nil, i18n.NewError(i18n.ErrServerDraining, nil)
+	}
+	// Checked under the lock, so concurrent creates can't replace a live room
+	if _, exists := m.rooms[roomID]; exists {
+		m.mu.Unlock()
+		return nil, i18n.NewError(i18n.ErrRoomExists, map[string]interface{}{"room_id": roomID})
+	}
+	if err := m.checkRoomQuota(roomID); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
 
-	state := NewGameStateWithShooting(roomID)
-	m.shootingRooms[roomID] = state
+	state := NewGameStateWithShooting(roomID, config)
+	state.useBalance(m.roomBalance(m.balance.Current(), config.Experiments))
+	state.waveScript = script
+	state.campaignLevel = level
+	state.tutorial = tutorial
+	if level != nil && config.Ghost {
+		if run, ok := m.ghosts.Get(GhostKey(config.Campaign, config.Level)); ok {
+			state.ghost = &run
+		}
+	}
+	layout := mapCatalog[DefaultMap]
+	state.SpawnPoint = &layout.Spawn
+	state.useGoals(append([]Position{layout.Goal}, layout.Goals...))
+	state.useWalls(layout.Walls)
+	if state.Config.LeakMode == "" {
+		state.Config.LeakMode = layout.Leak
+	}
+	m.instances++
+	state.instance = m.instances
+	m.rooms[roomID] = state
+	m.mu.Unlock()
 
-	return state
+	m.roomCreated(roomID)
+	return state, nil
+}
+
+// insertRoom adds a restored room, unless a room with its ID was opened in
+// the meantime or its realm already has all the rooms it may have
+func (m *Manager) insertRoom(roomID string, room Room) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.rooms[roomID]; exists {
+		return i18n.NewError(i18n.ErrRoomExists, map[string]interface{}{"room_id": roomID})
+	}
+	if err := m.checkRoomQuota(roomID); err != nil {
+		return err
+	}
+	if shooting, ok := room.(*GameStateWithShooting); ok {
+		m.instances++
+		shooting.instance = m.instances
+	}
+	m.rooms[roomID] = room
+	return nil
 }
 
-// GetRoom retrieves a game room by ID
-func (m *Manager) GetRoom(roomID string) (*GameState, bool) {
+// RoomInstance returns the instance number of the room open under an ID
+func (m *Manager) RoomInstance(roomID string) (uint64, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	room, exists := m.rooms[roomID]
-	return room, exists
+	if !exists {
+		return 0, false
+	}
+	return room.instanceNumber(), true
 }
 
-// GetShootingRoom retrieves a shooting game room by ID
-func (m *Manager) GetShootingRoom(roomID string) (*GameStateWithShooting, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// OpenRoom claims a room ID in the directory, creates a shooting room and
+// starts its game loop
+func (m *Manager) OpenRoom(roomID string, config RoomConfig) (*GameStateWithShooting, error) {
+	if err := m.reserveRoom(roomID); err != nil {
+		return nil, err
+	}
+	room, err := m.CreateShootingRoomWithConfig(roomID, config)
+	if err != nil {
+		// Give the reservation back, unless it belongs to a room already
+		// open here
+		if _, exists := m.GetShootingRoom(roomID); !exists {
+			m.unregisterRoom(roomID)
+		}
+		return nil, err
+	}
 
-	room, exists := m.shootingRooms[roomID]
-	return room, exists
+	m.attachWAL(room)
+	go m.StartGameLoop(roomID)
+	return room, nil
 }
 
-// DeleteRoom removes a game room
-func (m *Manager) DeleteRoom(roomID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// CreateRoomFromTemplate opens a room using a saved template's config. An
+// empty room ID gets a generated one.
+func (m *Manager) CreateRoomFromTemplate(roomID, templateName string) (*GameStateWithShooting, error) {
+	t, err := m.templates.Get(templateName)
+	if err != nil {
+		return nil, err
+	}
 
-	delete(m.rooms, roomID)
-	delete(m.shootingRooms, roomID)
+	if roomID == "" {
+		roomID = m.NextRoomID("room")
+	}
+	if _, exists := m.GetShootingRoom(roomID); exists {
+		return nil, i18n.NewError(i18n.ErrRoomExists, map[string]interface{}{"room_id": roomID})
+	}
+
+	log.Printf("🧩 Creating room %s from template %s", roomID, templateName)
+	return m.OpenRoom(roomID, templateConfig(t.Config))
 }
 
-// AddPlayer adds a player to a room
-func (m *Manager) AddPlayer(roomID, playerID string) bool {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// NextRoomID generates a room ID with the given prefix. IDs are unique
+// across instances, so rooms can move between them.
+func (m *Manager) NextRoomID(prefix string) string {
+	return ids.New(prefix)
+}
 
-	// Try shooting room first
-	if room, exists := m.shootingRooms[roomID]; exists {
-		room.mu.Lock()
-		room.Players = append(room.Players, playerID)
-		room.mu.Unlock()
-		return true
+// Balance returns the live balance store
+func (m *Manager) Balance() *BalanceStore {
+	return m.balance
+}
+
+// ReloadBalance re-reads the balance file and stages the new stats in every
+// running room for their next wave
+func (m *Manager) ReloadBalance() (*Balance, error) {
+	balance, err := m.balance.Reload()
+	if err != nil {
+		return nil, err
 	}
 
-	// Fall back to legacy room
-	room, exists := m.rooms[roomID]
+	rooms := m.shootingRooms()
+	for _, room := range rooms {
+		room.QueueBalance(m.roomBalance(balance, room.Experiments()))
+	}
+
+	log.Printf("⚖️ Balance v%d staged for %d rooms", balance.Version, len(rooms))
+	return balance, nil
+}
+
+// AuditLog returns a debug room's newest audit records
+func (m *Manager) AuditLog(roomID string, limit int) ([]AuditRecord, error) {
+	room, exists := m.GetShootingRoom(roomID)
 	if !exists {
-		return false
+		return nil, i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
 	}
 
-	room.Players = append(room.Players, playerID)
-	return true
+	records, ok := room.AuditLog(limit)
+	if !ok {
+		return nil, i18n.NewError(i18n.ErrAuditDisabled, map[string]interface{}{"room_id": roomID})
+	}
+	return records, nil
 }
 
-// RemovePlayer removes a player from a room
-func (m *Manager) RemovePlayer(roomID, playerID string) {
+// Templates returns the room template store
+func (m *Manager) Templates() *TemplateStore {
+	return m.templates
+}
+
+// Ghosts returns the store of recorded runs players can race
+func (m *Manager) Ghosts() *GhostStore {
+	return m.ghosts
+}
+
+// Waves returns the wave script library
+func (m *Manager) Waves() *WaveLibrary {
+	return m.waves
+}
+
+// Tutorials returns the tutorial script library
+func (m *Manager) Tutorials() *TutorialLibrary {
+	return m.tutorials
+}
+
+// Moderation returns the chat and moderation history store
+func (m *Manager) Moderation() *ModerationStore {
+	return m.moderation
+}
+
+// Names returns the players' chosen display names
+func (m *Manager) Names() *NameStore {
+	return m.names
+}
+
+// ReportPlayer files a report against a player in the reporter's room
+func (m *Manager) ReportPlayer(roomID, reporter, target, reason, details string) (Report, error) {
+	room, exists := m.GetShootingRoom(roomID)
+	if !exists {
+		return Report{}, i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
+	}
+	if !room.HasPlayer(target) {
+		return Report{}, i18n.NewError(i18n.ErrInvalidReport, nil)
+	}
+
+	return m.moderation.FileReport(Report{
+		RoomID:   roomID,
+		Reporter: reporter,
+		Target:   target,
+		Reason:   reason,
+		Details:  details,
+	})
+}
+
+// DeleteRoom removes a game room
+func (m *Manager) DeleteRoom(roomID string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	existing, found := m.rooms[roomID]
+	room, exists := existing.(*GameStateWithShooting)
+	delete(m.rooms, roomID)
+	m.mu.Unlock()
 
-	// Try shooting room first
-	if room, exists := m.shootingRooms[roomID]; exists {
-		room.mu.Lock()
-		for i, id := range room.Players {
-			if id == playerID {
-				room.Players = append(room.Players[:i], room.Players[i+1:]...)
-				break
-			}
+	if exists {
+		m.unregisterRoom(roomID)
+		room.closeWAL()
+	}
+	if found {
+		m.roomClosed(existing, RoomClosedDeleted)
+	}
+
+	// Rooms closed mid-game still count towards match history
+	if exists && room.GetSnapshot().GameTime > 0 {
+		m.recordMatch(room, ResultAbandoned)
+	}
+}
+
+// recordMatch feeds a room's final score into the leaderboard
+func (m *Manager) recordMatch(room *GameStateWithShooting, result string) {
+	match, ok := room.finishMatch(result)
+	if !ok {
+		return
+	}
+
+	// Rewinds make practice scores meaningless, and bots' scores aren't
+	// players'
+	if room.Config.Mode != ModePractice && !anyBot(match.Players) {
+		m.leaderboard.Record(match)
+	}
+	telemetry := room.Telemetry()
+	telemetry.Result = match.Result
+	m.telemetry.put(telemetry)
+	log.Printf("🏁 Room %s finished (%s) - Score: %d | Wave: %d",
+		match.RoomID, match.Result, match.Score.Total, match.Wave)
+	m.publishGameOver(room, match)
+	m.recordLevel(room, match)
+	if room.Config.Mode == ModeCoop && !anyBot(match.Players) {
+		m.recordBalanceStats(match, room.balanceSample(match.Result))
+	}
+
+	if len(match.Winners) > 0 && len(match.Losers) > 0 {
+		for _, change := range m.profiles.RecordResult(match.RoomID, match.Winners, match.Losers) {
+			log.Printf("📈 %s rating %.1f -> %.1f", change.PlayerID, change.OldRating, change.NewRating)
+			m.notify(change.PlayerID, NotifyRatingChanged, map[string]interface{}{
+				"room_id":    change.RoomID,
+				"old_rating": change.OldRating,
+				"new_rating": change.NewRating,
+				"delta":      change.Delta,
+				"won":        change.Won,
+			})
 		}
-		room.mu.Unlock()
+	}
+}
+
+// publishGameOver tells the room how its game ended. If the channel is
+// full the room holds on to it, and its game loop tries again every tick
+// until it's through.
+func (m *Manager) publishGameOver(room *GameStateWithShooting, match MatchResult) {
+	data, err := json.Marshal(match)
+	if err != nil {
+		log.Printf("❌ Failed to marshal match result: %v", err)
 		return
 	}
+	room.holdGameOver(data)
+	m.resendGameOver(room)
+}
 
-	// Fall back to legacy room
-	room, exists := m.rooms[roomID]
+// resendGameOver sends a room's game over if it hasn't gone out yet
+func (m *Manager) resendGameOver(room *GameStateWithShooting) {
+	data, ok := room.heldGameOver()
+	if !ok {
+		return
+	}
+
+	select {
+	case m.broadcast <- BroadcastMessage{RoomID: room.RoomID, Kind: BroadcastGameOver, Data: data}:
+		room.holdGameOver(nil)
+	default:
+	}
+}
+
+// Leaderboard returns the server-wide leaderboard and match history
+func (m *Manager) Leaderboard() *Leaderboard {
+	return m.leaderboard
+}
+
+// AddPlayer adds a player to a room
+func (m *Manager) AddPlayer(roomID, playerID string) error {
+	return m.AddPlayers(roomID, []string{playerID})
+}
+
+// AddPlayers adds a group of players to a room in one step, so a party
+// never ends up split between rooms. The whole group is turned away if it
+// doesn't fit under the room's or its realm's player quota. The manager
+// lock is released before they're added, since a room's game loop needs it
+// to tick.
+func (m *Manager) AddPlayers(roomID string, playerIDs []string) error {
+	room, exists := m.GetRoom(roomID)
+	if !exists {
+		return i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
+	}
+	if err := checkBots(room, playerIDs); err != nil {
+		return err
+	}
+
+	m.joinMu.Lock()
+	defer m.joinMu.Unlock()
+	if err := m.checkPlayerQuota(room, playerIDs); err != nil {
+		return err
+	}
+	return room.Join(playerIDs, m.names.lookup(playerIDs))
+}
+
+// RemovePlayer removes a player from a room. Legacy rooms have no game loop
+// to outlive their players, so they're deleted once empty.
+func (m *Manager) RemovePlayer(roomID, playerID string) {
+	room, exists := m.GetRoom(roomID)
 	if !exists {
 		return
 	}
 
-	for i, id := range room.Players {
-		if id == playerID {
-			room.Players = append(room.Players[:i], room.Players[i+1:]...)
-			break
-		}
+	room.RemovePlayer(playerID)
+	if legacy, ok := room.(*GameState); ok && m.collectIfEmpty(roomID, legacy) {
+		log.Printf("🧹 Collected empty legacy room %s", roomID)
 	}
 }
 
+// collectIfEmpty deletes a legacy room nobody is in. It's checked and
+// deleted under the manager lock, and the room refuses joins from then on,
+// so a player joining meanwhile either keeps it open or is told it's gone.
+func (m *Manager) collectIfEmpty(roomID string, room *GameState) bool {
+	m.mu.Lock()
+	if m.rooms[roomID] != Room(room) || !room.closeIfEmpty() {
+		m.mu.Unlock()
+		return false
+	}
+	delete(m.rooms, roomID)
+	m.mu.Unlock()
+
+	m.roomClosed(room, RoomClosedDeleted)
+	return true
+}
+
 // StartGameLoop starts the 60 FPS game loop for a room
 func (m *Manager) StartGameLoop(roomID string) {
 	log.Printf("🎮 Starting game loop for room: %s", roomID)
@@ -152,45 +554,86 @@ func (m *Manager) StartGameLoop(roomID string) {
 	defer ticker.Stop()
 
 	frameCount := 0
-	lastLog := time.Now()
+	sampler := newTickSampler()
+	var buf bytes.Buffer // reused for every snapshot
 
-	for range ticker.C {
-		m.mu.RLock()
-		room, exists := m.shootingRooms[roomID]
-		m.mu.RUnlock()
+	// Player actions are queued for this loop to apply between ticks
+	if room, exists := m.GetShootingRoom(roomID); exists {
+		room.startCommands()
+		defer room.stopCommands()
+	}
 
+	for range ticker.C {
+		room, exists := m.GetShootingRoom(roomID)
 		if !exists {
 			log.Printf("⚠️ Room %s deleted, stopping game loop", roomID)
 			return
 		}
 
-		// Update game state
+		// Update game state, recording every decision while analysts watch
+		room.setAnalyzing(m.analyzed(roomID))
+		started := time.Now()
 		room.Update(1.0 / 60.0) // deltaTime in seconds
+		updateTime := time.Since(started)
+		if frame, ok := room.takeAnalysis(); ok {
+			m.publishAnalysis(frame)
+		}
+		m.paceIfCongested(room, updateTime)
 
-		// Get snapshot for broadcasting
-		snapshot := room.GetSnapshot()
+		if room.IsGameOver() {
+			// finishMatch turns this into a victory for cleared campaign levels
+			m.recordMatch(room, ResultDefeat)
+		}
+		m.resendGameOver(room)
 
-		// Log every 60 frames (once per second)
+		// Sample tick metrics once a second for watchers; only slow rooms
+		// make it into the log
 		frameCount++
-		if frameCount%60 == 0 {
-			elapsed := time.Since(lastLog)
-			fps := float64(60) / elapsed.Seconds()
-			log.Printf("📊 Room %s - FPS: %.1f | Towers: %d | Enemies: %d | Projectiles: %d",
-				roomID, fps, len(snapshot.Towers), len(snapshot.Enemies), len(snapshot.Projectiles))
-			lastLog = time.Now()
+		if metrics, ok := sampler.record(room, updateTime); ok {
+			sampler.logIfSlow(metrics)
+			m.publishDiagnostics(metrics)
 		}
 
-		// Marshal to JSON
-		data, err := json.Marshal(snapshot)
-		if err != nil {
-			log.Printf("❌ Failed to marshal game state: %v", err)
-			continue
+		// Minimaps are coarse enough to send once a second
+		if frameCount%minimapInterval == 0 {
+			m.publishMinimap(room)
+		}
+
+		// The roster only goes out when it changes
+		if lobby, ok := room.LobbyUpdate(); ok {
+			m.publishLobby(room, lobby)
+		}
+
+		// Lockstep rooms only send inputs and hashes, every few ticks
+		var data []byte
+		kind := BroadcastState
+		if room.IsLockstep() {
+			frame, ok := room.LockstepFrame()
+			if !ok {
+				continue
+			}
+			kind = BroadcastLockstep
+
+			var err error
+			if data, err = json.Marshal(frame); err != nil {
+				log.Printf("❌ Failed to marshal lockstep frame: %v", err)
+				continue
+			}
+		} else {
+			buf.Reset()
+			if err := room.WriteFrame(&buf, m.Precision()); err != nil {
+				log.Printf("❌ Failed to marshal game state: %v", err)
+				continue
+			}
+			// The hub keeps the bytes, so they can't share the buffer
+			data = append([]byte(nil), buf.Bytes()...)
 		}
 
 		// Send to broadcast channel
 		select {
 		case m.broadcast <- BroadcastMessage{
 			RoomID: roomID,
+			Kind:   kind,
 			Data:   data,
 		}:
 		default:
@@ -199,10 +642,236 @@ func (m *Manager) StartGameLoop(roomID string) {
 				log.Printf("⚠️ Broadcast channel full for room %s", roomID)
 			}
 		}
+
+		// Newcomers to a lockstep room start from the state this frame
+		// left behind
+		if kind == BroadcastLockstep {
+			m.publishKeyframe(room)
+		}
+	}
+}
+
+// publishLobby sends a room's roster to the hub, trying again next tick if
+// the channel is full
+func (m *Manager) publishLobby(room *GameStateWithShooting, lobby Lobby) {
+	data, err := json.Marshal(lobby)
+	if err != nil {
+		log.Printf("❌ Failed to marshal lobby: %v", err)
+		return
+	}
+
+	select {
+	case m.broadcast <- BroadcastMessage{RoomID: lobby.RoomID, Kind: BroadcastLobby, Data: data}:
+	default:
+		room.resendLobby()
+	}
+}
+
+// Profiles returns the player profile store
+func (m *Manager) Profiles() *ProfileStore {
+	return m.profiles
+}
+
+// QueueForMatch puts a player, or the party they lead, in the versus
+// matchmaking queue and returns the rating they were queued with
+func (m *Manager) QueueForMatch(playerID string) (float64, error) {
+	members := []string{playerID}
+	if party, ok := m.parties.Get(playerID); ok {
+		if party.Leader != playerID {
+			return 0, i18n.NewError(i18n.ErrNotPartyLeader, nil)
+		}
+		members = party.Members
+	}
+	if anyBot(members) {
+		return 0, i18n.NewError(i18n.ErrBotForbidden, nil)
+	}
+
+	rating := m.profiles.AverageRating(members)
+	if !m.matchmaker.Enqueue(members, rating) {
+		return 0, i18n.NewError(i18n.ErrAlreadyQueued, nil)
+	}
+	return rating, nil
+}
+
+// LeaveMatchQueue takes a player out of the matchmaking queue
+func (m *Manager) LeaveMatchQueue(playerID string) bool {
+	return m.matchmaker.Dequeue(playerID)
+}
+
+// StartMatchmaking periodically pairs queued players into versus rooms
+func (m *Manager) StartMatchmaking(interval time.Duration) {
+	log.Printf("🎯 Starting matchmaking every %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, pair := range m.matchmaker.Match(now) {
+			m.startMatch(pair)
+		}
 	}
 }
 
+// startMatch creates a versus room for two matched sides, moves every player
+// into it and tells them where they are
+func (m *Manager) startMatch(sides [2][]string) {
+	roomID := Qualify(TenantOf(sides[0][0]), m.NextRoomID("match"))
+
+	config := DefaultRoomConfig()
+	config.Mode = ModeVersus
+
+	room, err := m.OpenRoom(roomID, config)
+	if err != nil {
+		log.Printf("❌ Failed to create match room %s: %v", roomID, err)
+		return
+	}
+	room.AssignTeams(sides[:])
+
+	players := append(append([]string{}, sides[0]...), sides[1]...)
+	if err := m.AddPlayers(roomID, players); err != nil {
+		log.Printf("❌ Failed to seat match in room %s: %v", roomID, err)
+		m.DeleteRoom(roomID)
+		return
+	}
+
+	log.Printf("🤝 Matched %v vs %v in room %s", sides[0], sides[1], roomID)
+
+	for i, side := range sides {
+		opponents := sides[1-i]
+		for _, playerID := range side {
+			m.notify(playerID, NotifyMatchFound, map[string]interface{}{
+				"room_id":         roomID,
+				"teammates":       side,
+				"opponents":       opponents,
+				"rating":          m.profiles.Get(playerID).Rating,
+				"opponent_rating": m.profiles.AverageRating(opponents),
+			})
+		}
+	}
+}
+
+// Events returns the server event scheduler
+func (m *Manager) Events() *EventScheduler {
+	return m.events
+}
+
+// Friends returns the friend list store
+func (m *Manager) Friends() *FriendStore {
+	return m.friends
+}
+
+// CreateParty starts a party led by the player
+func (m *Manager) CreateParty(playerID string) (Party, error) {
+	return m.parties.Create(playerID)
+}
+
+// InviteToParty lets a party leader invite another player
+func (m *Manager) InviteToParty(leaderID, inviteeID string) error {
+	party, err := m.parties.Invite(leaderID, inviteeID)
+	if err != nil {
+		return err
+	}
+
+	m.notify(inviteeID, NotifyPartyInvite, map[string]interface{}{
+		"party_id": party.ID,
+		"from":     leaderID,
+		"party":    party,
+	})
+	return nil
+}
+
+// AcceptPartyInvite adds a player to a party they were invited to. Parties
+// can't be in the match queue while the roster changes.
+func (m *Manager) AcceptPartyInvite(playerID, partyID string) (Party, error) {
+	party, err := m.parties.Accept(playerID, partyID)
+	if err != nil {
+		return Party{}, err
+	}
+
+	m.matchmaker.Dequeue(party.Leader)
+	m.notifyParty(party)
+	return party, nil
+}
+
+// LeaveParty removes a player from their party and takes the party out of
+// the match queue
+func (m *Manager) LeaveParty(playerID string) bool {
+	m.matchmaker.Dequeue(playerID)
+
+	party, ok := m.parties.Leave(playerID)
+	if !ok {
+		return false
+	}
+
+	if len(party.Members) > 0 {
+		m.matchmaker.Dequeue(party.Leader)
+		m.notifyParty(party)
+	}
+	return true
+}
+
+// PartyGroup returns who moves with a player: the whole party if they lead
+// one, otherwise just them
+func (m *Manager) PartyGroup(playerID string) []string {
+	if party, ok := m.parties.Get(playerID); ok && party.Leader == playerID {
+		return party.Members
+	}
+	return []string{playerID}
+}
+
+// Waiting reports whether a player outside any room is still busy: queued
+// for a match or in a party, waiting on its leader
+func (m *Manager) Waiting(playerID string) bool {
+	if _, ok := m.parties.Get(playerID); ok {
+		return true
+	}
+	return m.matchmaker.Queued(playerID)
+}
+
+// notifyParty sends the current roster to every member
+func (m *Manager) notifyParty(party Party) {
+	for _, member := range party.Members {
+		m.notify(member, NotifyPartyUpdate, map[string]interface{}{
+			"party": party,
+		})
+	}
+}
+
+// notify queues a message for a single player
+func (m *Manager) notify(playerID, notificationType string, payload map[string]interface{}) {
+	select {
+	case m.notifications <- PlayerNotification{
+		PlayerID: playerID,
+		Type:     notificationType,
+		Payload:  payload,
+	}:
+	default:
+		log.Printf("⚠️ Notification channel full, dropping %s for %s", notificationType, playerID)
+	}
+}
+
+// GetNotificationChannel returns the per-player notification channel for the
+// hub to read from
+func (m *Manager) GetNotificationChannel() <-chan PlayerNotification {
+	return m.notifications
+}
+
 // GetBroadcastChannel returns the broadcast channel for the hub to read from
 func (m *Manager) GetBroadcastChannel() <-chan BroadcastMessage {
 	return m.broadcast
 }
+
+// GetAnalysisChannel returns the analysis frames for the hub to read from
+func (m *Manager) GetAnalysisChannel() <-chan BroadcastMessage {
+	return m.analysisFeed
+}
+
+// containsString reports whether a slice holds the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
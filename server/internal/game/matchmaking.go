@@ -0,0 +1,145 @@
+package game
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Matchmaking tuning. The acceptable rating gap widens the longer a player
+// waits so nobody sits in the queue forever.
+const (
+	matchBaseWindow   = 100.0
+	matchWindowGrowth = 50.0 // per 10 seconds waited
+	matchMaxWindow    = 500.0
+)
+
+// queueEntry is a player or party waiting for a match
+type queueEntry struct {
+	Members  []string
+	Rating   float64 // average of the members
+	QueuedAt time.Time
+}
+
+// has reports whether a player is part of the entry
+func (e queueEntry) has(playerID string) bool {
+	for _, member := range e.Members {
+		if member == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// tenant returns the realm the entry's players are in
+func (e queueEntry) tenant() string {
+	return TenantOf(e.Members[0])
+}
+
+// window returns the rating gap the player accepts after waiting
+func (e queueEntry) window(now time.Time) float64 {
+	waited := now.Sub(e.QueuedAt).Seconds() / 10
+	return math.Min(matchBaseWindow+waited*matchWindowGrowth, matchMaxWindow)
+}
+
+// Matchmaker pairs queued players or equally sized parties of similar rating
+type Matchmaker struct {
+	mu    sync.Mutex
+	queue []queueEntry
+}
+
+// NewMatchmaker creates an empty matchmaking queue
+func NewMatchmaker() *Matchmaker {
+	return &Matchmaker{
+		queue: make([]queueEntry, 0),
+	}
+}
+
+// Enqueue adds a group of players to the queue as one entry. It returns
+// false if any of them is already queued.
+func (mm *Matchmaker) Enqueue(members []string, rating float64) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for _, e := range mm.queue {
+		for _, playerID := range members {
+			if e.has(playerID) {
+				return false
+			}
+		}
+	}
+
+	mm.queue = append(mm.queue, queueEntry{
+		Members:  members,
+		Rating:   rating,
+		QueuedAt: time.Now(),
+	})
+	return true
+}
+
+// Dequeue removes the entry containing a player, along with the rest of
+// their party
+func (mm *Matchmaker) Dequeue(playerID string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for i, e := range mm.queue {
+		if e.has(playerID) {
+			mm.queue = append(mm.queue[:i], mm.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Queued reports whether a player is waiting in the queue
+func (mm *Matchmaker) Queued(playerID string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for _, e := range mm.queue {
+		if e.has(playerID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match removes and returns every pair of sides close enough in rating.
+// Entries are grouped by party size, sorted by rating and paired with their
+// neighbour when the gap fits both of their windows.
+func (mm *Matchmaker) Match(now time.Time) [][2][]string {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	// Players are only matched within their realm
+	sort.SliceStable(mm.queue, func(i, j int) bool {
+		if ti, tj := mm.queue[i].tenant(), mm.queue[j].tenant(); ti != tj {
+			return ti < tj
+		}
+		if len(mm.queue[i].Members) != len(mm.queue[j].Members) {
+			return len(mm.queue[i].Members) < len(mm.queue[j].Members)
+		}
+		return mm.queue[i].Rating < mm.queue[j].Rating
+	})
+
+	pairs := make([][2][]string, 0)
+	remaining := make([]queueEntry, 0, len(mm.queue))
+
+	for i := 0; i < len(mm.queue); i++ {
+		if i+1 < len(mm.queue) {
+			a, b := mm.queue[i], mm.queue[i+1]
+			gap := b.Rating - a.Rating
+			if a.tenant() == b.tenant() && len(a.Members) == len(b.Members) && gap <= a.window(now) && gap <= b.window(now) {
+				pairs = append(pairs, [2][]string{a.Members, b.Members})
+				i++
+				continue
+			}
+		}
+		remaining = append(remaining, mm.queue[i])
+	}
+
+	mm.queue = remaining
+	return pairs
+}
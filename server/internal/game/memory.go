@@ -0,0 +1,97 @@
+package game
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// Rough heap cost of things whose size isn't fixed, in bytes. Estimates are
+// meant for spotting trends and leaks, not exact accounting.
+const (
+	eventDataBytes   = 256 // an event's data map
+	commandKeyBytes  = 128 // a retry key and its stored result
+	mapEntryBytes    = 48  // one player-keyed map entry
+	auditRecordBytes = 192 // an audit record's detail map
+)
+
+// RoomMemory is a room's approximate memory footprint
+type RoomMemory struct {
+	RoomID      string         `json:"room_id"`
+	Towers      int            `json:"towers"`
+	Enemies     int            `json:"enemies"`
+	Projectiles int            `json:"projectiles"`
+	EventLog    int            `json:"event_log"` // events kept for telemetry and late joiners
+	Replay      int            `json:"replay"`    // milestones, audit records and keyed commands
+	Bytes       int            `json:"approx_bytes"`
+	Breakdown   map[string]int `json:"breakdown"` // approximate bytes by category
+}
+
+// memory estimates the room's footprint. Callers must hold the state lock.
+func (gs *GameStateWithShooting) memory() RoomMemory {
+	breakdown := make(map[string]int)
+
+	breakdown["towers"] = len(gs.Towers) * int(unsafe.Sizeof(Tower{}))
+	enemies := len(gs.Enemies) * int(unsafe.Sizeof(Enemy{}))
+	for _, e := range gs.Enemies {
+		enemies += len(e.Path) * int(unsafe.Sizeof(Position{}))
+	}
+	breakdown["enemies"] = enemies
+	breakdown["projectiles"] = len(gs.Projectiles) * int(unsafe.Sizeof(Projectile{}))
+
+	events := len(gs.Events) + len(gs.recentEvents) + len(gs.eventLog)
+	breakdown["events"] = events * (int(unsafe.Sizeof(GameEvent{})) + eventDataBytes)
+	breakdown["telemetry"] = len(gs.samples)*int(unsafe.Sizeof(TelemetrySample{})) +
+		len(gs.ledger)*int(unsafe.Sizeof(LedgerEntry{}))
+
+	replay := len(gs.milestones) + len(gs.appliedOrder)
+	replayBytes := len(gs.milestones)*int(unsafe.Sizeof(Milestone{})) +
+		len(gs.appliedOrder)*commandKeyBytes
+	if gs.auditLog != nil {
+		replay += len(gs.auditLog.records)
+		replayBytes += len(gs.auditLog.records) * (int(unsafe.Sizeof(AuditRecord{})) + auditRecordBytes)
+	}
+	breakdown["replay"] = replayBytes
+
+	players := len(gs.Players) + len(gs.names) + len(gs.colors) + len(gs.economy) + len(gs.ready) + len(gs.kicked)
+	breakdown["players"] = players * mapEntryBytes
+
+	usage := RoomMemory{
+		RoomID:      gs.RoomID,
+		Towers:      len(gs.Towers),
+		Enemies:     len(gs.Enemies),
+		Projectiles: len(gs.Projectiles),
+		EventLog:    len(gs.eventLog),
+		Replay:      replay,
+		Breakdown:   breakdown,
+	}
+	usage.Bytes = int(unsafe.Sizeof(*gs))
+	for _, bytes := range breakdown {
+		usage.Bytes += bytes
+	}
+	return usage
+}
+
+// MemoryUsage estimates how much memory the room is holding on to
+func (gs *GameStateWithShooting) MemoryUsage() RoomMemory {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.memory()
+}
+
+// MemoryReport estimates the footprint of every room on this instance,
+// largest first
+func (m *Manager) MemoryReport() []RoomMemory {
+	rooms := m.shootingRooms()
+
+	report := make([]RoomMemory, 0, len(rooms))
+	for _, room := range rooms {
+		report = append(report, room.MemoryUsage())
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Bytes != report[j].Bytes {
+			return report[i].Bytes > report[j].Bytes
+		}
+		return report[i].RoomID < report[j].RoomID
+	})
+	return report
+}
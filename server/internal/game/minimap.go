@@ -0,0 +1,91 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+)
+
+// Minimap tuning
+const (
+	minimapInterval   = 60 // ticks between minimaps, once a second
+	minimapRegionSize = 4  // cells per region side
+)
+
+// Minimap is a coarse view of the map for drawing a minimap without the
+// full entity lists. Regions are minimapRegionSize cells square and listed
+// row by row.
+type Minimap struct {
+	Tick       uint64 `json:"tick"`
+	RegionSize int    `json:"region_size"`
+	Cols       int    `json:"cols"`
+	Rows       int    `json:"rows"`
+	Enemies    []int  `json:"enemies"`  // live enemies in each region
+	Coverage   []int  `json:"coverage"` // active towers that reach each region's center
+}
+
+// minimap builds the room's minimap. Callers must hold the state lock.
+func (gs *GameStateWithShooting) minimap() Minimap {
+	cols := int(math.Ceil(float64(GridWidth) / minimapRegionSize))
+	rows := int(math.Ceil(float64(GridHeight) / minimapRegionSize))
+	mm := Minimap{
+		Tick:       gs.Tick,
+		RegionSize: minimapRegionSize,
+		Cols:       cols,
+		Rows:       rows,
+		Enemies:    make([]int, cols*rows),
+		Coverage:   make([]int, cols*rows),
+	}
+
+	region := func(pos Position) (int, bool) {
+		col, row := int(pos.X)/minimapRegionSize, int(pos.Y)/minimapRegionSize
+		if pos.X < 0 || pos.Y < 0 || col >= cols || row >= rows {
+			return 0, false
+		}
+		return row*cols + col, true
+	}
+
+	for _, enemy := range gs.Enemies {
+		if i, ok := region(enemy.Position); ok {
+			mm.Enemies[i]++
+		}
+	}
+
+	for _, tower := range gs.Towers {
+		if tower.State != TowerStateActive {
+			continue
+		}
+		for i := range mm.Coverage {
+			center := Position{
+				X: (float64(i%cols) + 0.5) * minimapRegionSize,
+				Y: (float64(i/cols) + 0.5) * minimapRegionSize,
+			}
+			if distance(tower.Position, center) <= tower.Range {
+				mm.Coverage[i]++
+			}
+		}
+	}
+	return mm
+}
+
+// Minimap returns the room's current minimap
+func (gs *GameStateWithShooting) Minimap() Minimap {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.minimap()
+}
+
+// publishMinimap sends a room's minimap to the hub. Minimaps are skipped
+// rather than retried when the channel is full; the next one is a second
+// away.
+func (m *Manager) publishMinimap(room *GameStateWithShooting) {
+	data, err := json.Marshal(room.Minimap())
+	if err != nil {
+		log.Printf("❌ Failed to marshal minimap: %v", err)
+		return
+	}
+	select {
+	case m.broadcast <- BroadcastMessage{RoomID: room.RoomID, Kind: BroadcastMinimap, Data: data}:
+	default:
+	}
+}
@@ -0,0 +1,314 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Retention limits for room history and reports
+const (
+	maxChatPerRoom       = 200
+	maxModerationPerRoom = 200
+	maxReports           = 1000
+	historyRetention     = 7 * 24 * time.Hour
+	maxReportDetails     = 500
+)
+
+// Moderation actions
+const (
+	ModActionReport         = "report"
+	ModActionRotateJoinCode = "rotate_join_code"
+	ModActionKick           = "kick"
+	ModActionSetDropIn      = "set_drop_in"
+)
+
+// Report reasons
+var reportReasons = map[string]bool{
+	"cheating":   true,
+	"harassment": true,
+	"spam":       true,
+	"griefing":   true,
+	"other":      true,
+}
+
+// ChatRecord is a quick chat message sent in a room
+type ChatRecord struct {
+	Time     time.Time `json:"time"`
+	PlayerID string    `json:"player_id"`
+	ChatID   string    `json:"chat_id"`
+}
+
+// ModerationRecord is an action taken in a room that admins may need to see
+type ModerationRecord struct {
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"`
+	Action  string    `json:"action"`
+	Target  string    `json:"target,omitempty"`
+	Details string    `json:"details,omitempty"`
+}
+
+// Report is a player's complaint about another player
+type Report struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	RoomID   string    `json:"room_id"`
+	Reporter string    `json:"reporter"`
+	Target   string    `json:"target"`
+	Reason   string    `json:"reason"`
+	Details  string    `json:"details,omitempty"`
+}
+
+// RoomHistory is the retained chat and moderation log of a room
+type RoomHistory struct {
+	RoomID     string             `json:"room_id"`
+	Chat       []ChatRecord       `json:"chat"`
+	Moderation []ModerationRecord `json:"moderation"`
+}
+
+// moderationFile is the on-disk layout of the moderation store
+type moderationFile struct {
+	Rooms   []RoomHistory `json:"rooms"`
+	Reports []Report      `json:"reports"`
+}
+
+// ModerationStore keeps per-room chat and moderation history plus player
+// reports in memory and, once given a file, writes them through to disk
+type ModerationStore struct {
+	mu         sync.RWMutex
+	rooms      map[string]*RoomHistory
+	reports    []Report
+	nextReport int
+	path       string
+}
+
+// NewModerationStore creates an empty in-memory moderation store
+func NewModerationStore() *ModerationStore {
+	return &ModerationStore{
+		rooms:      make(map[string]*RoomHistory),
+		nextReport: 1,
+	}
+}
+
+// UseFile loads history from a JSON file, if it exists, and saves every
+// later change back to it
+func (s *ModerationStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file moderationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	for i := range file.Rooms {
+		s.rooms[file.Rooms[i].RoomID] = &file.Rooms[i]
+	}
+	s.reports = file.Reports
+	for _, r := range s.reports {
+		if r.ID >= s.nextReport {
+			s.nextReport = r.ID + 1
+		}
+	}
+	s.prune(time.Now())
+	return nil
+}
+
+// RecordChat logs a quick chat message
+func (s *ModerationStore) RecordChat(roomID, playerID, chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	h := s.room(roomID)
+	h.Chat = append(h.Chat, ChatRecord{Time: now, PlayerID: playerID, ChatID: chatID})
+	if len(h.Chat) > maxChatPerRoom {
+		h.Chat = h.Chat[len(h.Chat)-maxChatPerRoom:]
+	}
+	s.prune(now)
+	return s.persist()
+}
+
+// RecordAction logs a moderation action
+func (s *ModerationStore) RecordAction(roomID, actor, action, target, details string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.recordAction(roomID, ModerationRecord{
+		Time:    now,
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	})
+	s.prune(now)
+	return s.persist()
+}
+
+// FileReport validates and stores a report. The report is also logged as a
+// moderation action in its room.
+func (s *ModerationStore) FileReport(r Report) (Report, error) {
+	if r.Target == "" || r.Target == r.Reporter || !reportReasons[r.Reason] {
+		return Report{}, i18n.NewError(i18n.ErrInvalidReport, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.reports {
+		if existing.RoomID == r.RoomID && existing.Reporter == r.Reporter && existing.Target == r.Target {
+			return Report{}, i18n.NewError(i18n.ErrAlreadyReported, map[string]interface{}{"player_id": r.Target})
+		}
+	}
+
+	if len(r.Details) > maxReportDetails {
+		r.Details = r.Details[:maxReportDetails]
+	}
+	r.ID = s.nextReport
+	s.nextReport++
+	r.Time = time.Now()
+
+	s.reports = append(s.reports, r)
+	if len(s.reports) > maxReports {
+		s.reports = s.reports[len(s.reports)-maxReports:]
+	}
+	s.recordAction(r.RoomID, ModerationRecord{
+		Time:    r.Time,
+		Actor:   r.Reporter,
+		Action:  ModActionReport,
+		Target:  r.Target,
+		Details: r.Reason,
+	})
+	s.prune(r.Time)
+	return r, s.persist()
+}
+
+// History returns the retained history of a room
+func (s *ModerationStore) History(roomID string) RoomHistory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := RoomHistory{
+		RoomID:     roomID,
+		Chat:       []ChatRecord{},
+		Moderation: []ModerationRecord{},
+	}
+	if h, ok := s.rooms[roomID]; ok {
+		history.Chat = append(history.Chat, h.Chat...)
+		history.Moderation = append(history.Moderation, h.Moderation...)
+	}
+	return history
+}
+
+// Reports returns the most recent reports, newest first, optionally only
+// those filed in one room
+func (s *ModerationStore) Reports(roomID string, limit int) []Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := make([]Report, 0, limit)
+	for i := len(s.reports) - 1; i >= 0 && len(reports) < limit; i-- {
+		if roomID == "" || s.reports[i].RoomID == roomID {
+			reports = append(reports, s.reports[i])
+		}
+	}
+	return reports
+}
+
+// room returns a room's history, creating it if needed. Callers must hold
+// the lock.
+func (s *ModerationStore) room(roomID string) *RoomHistory {
+	h, ok := s.rooms[roomID]
+	if !ok {
+		h = &RoomHistory{RoomID: roomID}
+		s.rooms[roomID] = h
+	}
+	return h
+}
+
+// recordAction appends to a room's moderation log. Callers must hold the
+// lock.
+func (s *ModerationStore) recordAction(roomID string, record ModerationRecord) {
+	h := s.room(roomID)
+	h.Moderation = append(h.Moderation, record)
+	if len(h.Moderation) > maxModerationPerRoom {
+		h.Moderation = h.Moderation[len(h.Moderation)-maxModerationPerRoom:]
+	}
+}
+
+// prune drops records older than the retention window. Callers must hold
+// the lock.
+func (s *ModerationStore) prune(now time.Time) {
+	cutoff := now.Add(-historyRetention)
+
+	for roomID, h := range s.rooms {
+		chat := h.Chat[:0]
+		for _, c := range h.Chat {
+			if c.Time.After(cutoff) {
+				chat = append(chat, c)
+			}
+		}
+		h.Chat = chat
+
+		moderation := h.Moderation[:0]
+		for _, m := range h.Moderation {
+			if m.Time.After(cutoff) {
+				moderation = append(moderation, m)
+			}
+		}
+		h.Moderation = moderation
+
+		if len(h.Chat) == 0 && len(h.Moderation) == 0 {
+			delete(s.rooms, roomID)
+		}
+	}
+
+	reports := s.reports[:0]
+	for _, r := range s.reports {
+		if r.Time.After(cutoff) {
+			reports = append(reports, r)
+		}
+	}
+	s.reports = reports
+}
+
+// persist writes the store to disk if a file is configured. Callers must
+// hold the lock.
+func (s *ModerationStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := moderationFile{
+		Rooms:   make([]RoomHistory, 0, len(s.rooms)),
+		Reports: s.reports,
+	}
+	for _, h := range s.rooms {
+		file.Rooms = append(file.Rooms, *h)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,272 @@
+package game
+
+import (
+	"math"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Mutator IDs selectable at room creation
+const (
+	MutatorTankyEnemies    = "tanky_enemies"
+	MutatorExpensiveTowers = "expensive_towers"
+	MutatorNoSniper        = "no_sniper"
+	MutatorDoubleBosses    = "double_bosses"
+	MutatorCombatLock      = "combat_lock"
+)
+
+// Mutator is a composable difficulty modifier
+type Mutator struct {
+	ID              string  `json:"id"`
+	Description     string  `json:"description"`
+	ScoreMultiplier float64 `json:"score_multiplier"`
+	apply           func(m *modifiers)
+}
+
+// mutators lists every mutator a room can enable
+var mutators = map[string]Mutator{
+	MutatorTankyEnemies: {
+		ID:              MutatorTankyEnemies,
+		Description:     "Enemies have 20% more health",
+		ScoreMultiplier: 1.2,
+		apply: func(m *modifiers) {
+			m.enemyHealth *= 1.2
+		},
+	},
+	MutatorExpensiveTowers: {
+		ID:              MutatorExpensiveTowers,
+		Description:     "Towers cost 25% more",
+		ScoreMultiplier: 1.15,
+		apply: func(m *modifiers) {
+			m.towerCost *= 1.25
+		},
+	},
+	MutatorNoSniper: {
+		ID:              MutatorNoSniper,
+		Description:     "Sniper towers cannot be built",
+		ScoreMultiplier: 1.1,
+		apply: func(m *modifiers) {
+			m.disabledTowers["sniper"] = true
+		},
+	},
+	MutatorDoubleBosses: {
+		ID:              MutatorDoubleBosses,
+		Description:     "Bosses spawn in pairs",
+		ScoreMultiplier: 1.3,
+		apply: func(m *modifiers) {
+			m.spawnCount["boss"] *= 2
+		},
+	},
+	MutatorCombatLock: {
+		ID:              MutatorCombatLock,
+		Description:     "Towers can't be sold or upgraded while enemies are on the field",
+		ScoreMultiplier: 1.1,
+		apply: func(m *modifiers) {
+			m.combatLock = true
+		},
+	},
+}
+
+// GetMutator looks up a mutator by ID
+func GetMutator(id string) (Mutator, bool) {
+	m, ok := mutators[id]
+	return m, ok
+}
+
+// RoomConfig holds the settings chosen when a room is created
+type RoomConfig struct {
+	Mode            string   `json:"mode"`
+	Visibility      string   `json:"visibility"`
+	Password        string   `json:"-"` // never sent to clients
+	Mutators        []string `json:"mutators"`
+	SuddenDeathWave int      `json:"sudden_death_wave,omitempty"` // versus only, 0 disables
+	Debug           bool     `json:"debug,omitempty"`             // record a simulation audit log
+	StrictOwnership bool     `json:"strict_ownership,omitempty"`  // only builders and the host may change towers
+	AdaptivePacing  bool     `json:"adaptive_pacing,omitempty"`   // stagger spawns when ticks run long
+	AutoDifficulty  bool     `json:"auto_difficulty,omitempty"`   // scale enemy health by how players are doing
+	MaxTowers       int      `json:"max_towers,omitempty"`        // 0 for the server default
+	MaxEnemies      int      `json:"max_enemies,omitempty"`       // live at once, extra spawns wait
+	MaxProjectiles  int      `json:"max_projectiles,omitempty"`   // in flight, extra shots merge
+	MaxPlayers      int      `json:"max_players,omitempty"`       // 0 for no limit
+	DropIn          bool     `json:"drop_in,omitempty"`           // strangers may join co-op games under way
+	SmartShots      bool     `json:"smart_shots,omitempty"`       // retarget shots whose target dies mid-flight
+	Predictive      bool     `json:"predictive,omitempty"`        // skip enemies shots in flight will already kill
+	SolidTerrain    bool     `json:"solid_terrain,omitempty"`     // tall walls stop projectiles
+	LeakMode        string   `json:"leak_mode,omitempty"`         // what leaks cost, empty for the map's
+	Sync            string   `json:"sync"`                        // state or lockstep broadcasting
+	Waves           string   `json:"waves,omitempty"`             // wave script name, empty for manual waves
+	Tutorial        string   `json:"tutorial,omitempty"`          // tutorial script, tutorial mode only
+	Campaign        string   `json:"campaign,omitempty"`          // set with Level for campaign rooms
+	Level           string   `json:"level,omitempty"`
+	Ghost           bool     `json:"ghost,omitempty"` // race the level's best recorded run
+	Seed            int64    `json:"seed"`            // simulation seed, random unless the host picks one
+
+	// Set by server events active when the room was created
+	ServerEvents   []string `json:"server_events,omitempty"`
+	GoldMultiplier float64  `json:"gold_multiplier,omitempty"`
+	BossWaves      bool     `json:"boss_waves,omitempty"`
+
+	// Experiment -> variant, assigned when the room was created
+	Experiments map[string]string `json:"experiments,omitempty"`
+}
+
+// DefaultRoomConfig returns a config with no mutators enabled
+func DefaultRoomConfig() RoomConfig {
+	return RoomConfig{
+		Mode:       ModeCoop,
+		Visibility: VisibilityPublic,
+		Sync:       SyncState,
+		Mutators:   make([]string, 0),
+	}
+}
+
+// Validate checks the mode and visibility and that every mutator is known
+// and listed once
+func (c RoomConfig) Validate() error {
+	switch c.Mode {
+	case ModeCoop, ModeVersus, ModeTutorial, ModePractice:
+	default:
+		return i18n.NewError(i18n.ErrUnknownMode, map[string]interface{}{"mode": c.Mode})
+	}
+	if c.Visibility != VisibilityPublic && c.Visibility != VisibilityPrivate {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "visibility"})
+	}
+	if c.Sync != SyncState && c.Sync != SyncLockstep {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "sync"})
+	}
+	if !validLeakMode(c.LeakMode) {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "leak_mode"})
+	}
+	if c.SuddenDeathWave < 0 {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "sudden_death_wave"})
+	}
+	if c.Seed < 0 || c.Seed > maxSeed {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "seed"})
+	}
+	// Pacing depends on how fast this server runs, which lockstep clients
+	// can't reproduce
+	if c.AdaptivePacing && c.Sync == SyncLockstep {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "adaptive_pacing"})
+	}
+	// Tutorials drive the room themselves, so they don't mix with scripted
+	// waves, and their steps run on the server only
+	if (c.Mode == ModeTutorial) != (c.Tutorial != "") {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "tutorial"})
+	}
+	if c.Tutorial != "" && (c.Waves != "" || c.Campaign != "" || c.Sync == SyncLockstep) {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "tutorial"})
+	}
+	// Rewinding replaces the state lockstep clients simulate, and would make
+	// campaign stars meaningless
+	if c.Mode == ModePractice && (c.Sync == SyncLockstep || c.Campaign != "") {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "mode"})
+	}
+	// Versus players have their own health, so there's no shared performance
+	// to react to
+	if c.AutoDifficulty && c.Mode == ModeVersus {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "auto_difficulty"})
+	}
+	// Only public co-op games have strangers to let in
+	if c.DropIn && (c.Mode != ModeCoop || c.Visibility != VisibilityPublic) {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "drop_in"})
+	}
+	if err := c.validateCaps(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range c.Mutators {
+		if _, ok := mutators[id]; !ok {
+			return i18n.NewError(i18n.ErrUnknownMutator, map[string]interface{}{"mutator": id})
+		}
+		if seen[id] {
+			return i18n.NewError(i18n.ErrDuplicateMutator, map[string]interface{}{"mutator": id})
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// ScoreMultiplier returns the combined score multiplier of all mutators
+func (c RoomConfig) ScoreMultiplier() float64 {
+	multiplier := 1.0
+	for _, id := range c.Mutators {
+		if m, ok := mutators[id]; ok {
+			multiplier *= m.ScoreMultiplier
+		}
+	}
+	return math.Round(multiplier*100) / 100
+}
+
+// modifiers is the combined effect of a room's mutators. All stat lookups
+// go through it so mutators never need to touch gameplay code directly.
+type modifiers struct {
+	balance        *Balance
+	enemyHealth    float64
+	gold           float64
+	towerCost      float64
+	disabledTowers map[string]bool
+	spawnCount     map[string]int
+	combatLock     bool // no selling or upgrading with enemies on the field
+}
+
+// buildModifiers runs every mutator in the config through the pipeline
+func buildModifiers(config RoomConfig, balance *Balance) modifiers {
+	m := modifiers{
+		balance:        balance,
+		enemyHealth:    1.0,
+		gold:           1.0,
+		towerCost:      1.0,
+		disabledTowers: make(map[string]bool),
+		spawnCount: map[string]int{
+			"boss": 1,
+		},
+	}
+
+	for _, id := range config.Mutators {
+		if mutator, ok := mutators[id]; ok {
+			mutator.apply(&m)
+		}
+	}
+
+	if config.GoldMultiplier > 0 {
+		m.gold *= config.GoldMultiplier
+	}
+
+	return m
+}
+
+// towerStats returns tower stats after modifiers are applied
+func (m modifiers) towerStats(towerType string) towerStats {
+	stats := m.balance.tower(towerType)
+	stats.Cost = int(math.Ceil(float64(stats.Cost) * m.towerCost))
+	return stats
+}
+
+// enemyStats returns enemy stats after modifiers are applied
+func (m modifiers) enemyStats(enemyType string) enemyStats {
+	stats := m.balance.enemy(enemyType)
+	stats.Health *= m.enemyHealth
+	return stats
+}
+
+// killGoldReward is the base gold awarded per kill
+const killGoldReward = 10
+
+// killGold returns the gold awarded for a kill
+func (m modifiers) killGold() int {
+	return int(math.Round(killGoldReward * m.gold))
+}
+
+// towerAllowed reports whether a tower type may be built
+func (m modifiers) towerAllowed(towerType string) bool {
+	return !m.disabledTowers[towerType]
+}
+
+// enemiesPerSpawn returns how many enemies a single spawn produces
+func (m modifiers) enemiesPerSpawn(enemyType string) int {
+	if n, ok := m.spawnCount[enemyType]; ok && n > 0 {
+		return n
+	}
+	return 1
+}
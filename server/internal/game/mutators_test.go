@@ -0,0 +1,23 @@
+package game
+
+import "testing"
+
+func TestCombatLockHoldsBetweenSpawns(t *testing.T) {
+	room, err := NewManager().CreateShootingRoomWithConfig("lock-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	room.mods.combatLock = true
+	if err := room.checkCombatLock(); err != nil {
+		t.Fatalf("locked between waves: %v", err)
+	}
+
+	// Every enemy spawned so far is dead, but the wave isn't over
+	room.pendingSpawns = append(room.pendingSpawns, scheduledSpawn{enemyType: "basic"})
+	if err := room.checkCombatLock(); err == nil {
+		t.Fatal("towers could be changed between a wave's spawns")
+	}
+}
@@ -0,0 +1,179 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Display name limits
+const (
+	minNameLength = 2
+	maxNameLength = 20
+)
+
+// playerColors are handed out to players in join order, skipping colors
+// already taken in the room
+var playerColors = []string{
+	"#e6194b", "#3cb44b", "#4363d8", "#f58231",
+	"#911eb4", "#42d4f4", "#f032e6", "#bfef45",
+}
+
+// NameFilter reports whether a display name is acceptable, e.g. to keep
+// profanity out of rooms
+type NameFilter func(name string) bool
+
+// NameStore remembers the display name each connected player asked for
+type NameStore struct {
+	mu     sync.RWMutex
+	names  map[string]string
+	filter NameFilter // nil accepts every well-formed name
+}
+
+// NewNameStore creates an empty name store
+func NewNameStore() *NameStore {
+	return &NameStore{names: make(map[string]string)}
+}
+
+// UseFilter sets the filter names must pass
+func (s *NameStore) UseFilter(filter NameFilter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = filter
+}
+
+// Set validates a display name and remembers it for the player, returning
+// the name as it will be shown
+func (s *NameStore) Set(playerID, name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if !validName(name) {
+		return "", i18n.NewError(i18n.ErrInvalidName, map[string]interface{}{"min": minNameLength, "max": maxNameLength})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.filter != nil && !s.filter(name) {
+		return "", i18n.NewError(i18n.ErrNameRejected, nil)
+	}
+	s.names[playerID] = name
+	return name, nil
+}
+
+// Get returns a player's display name, if they chose one
+func (s *NameStore) Get(playerID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.names[playerID]
+}
+
+// Forget drops a player's display name once they disconnect
+func (s *NameStore) Forget(playerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.names, playerID)
+}
+
+// lookup returns the display names chosen by any of the players
+func (s *NameStore) lookup(playerIDs []string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make(map[string]string, len(playerIDs))
+	for _, playerID := range playerIDs {
+		if name, ok := s.names[playerID]; ok {
+			names[playerID] = name
+		}
+	}
+	return names
+}
+
+// validName checks a display name's length and characters
+func validName(name string) bool {
+	length := utf8.RuneCountInString(name)
+	if length < minNameLength || length > maxNameLength {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// nameTaken reports whether another player in the room goes by the name.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) nameTaken(playerID, name string) bool {
+	for id, taken := range gs.names {
+		if id != playerID && strings.EqualFold(taken, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// assignIdentity gives a joining player a name that's unique in the room and
+// a color nobody else has. Players without a display name go by their ID.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) assignIdentity(playerID, name string) {
+	if gs.names == nil {
+		gs.names = make(map[string]string)
+		gs.colors = make(map[string]string)
+	}
+
+	if name == "" {
+		name = playerID
+	}
+	unique := name
+	for n := 2; gs.nameTaken(playerID, unique); n++ {
+		unique = name + " " + strconv.Itoa(n)
+	}
+	gs.names[playerID] = unique
+
+	if _, ok := gs.colors[playerID]; ok {
+		return
+	}
+	used := make(map[string]bool, len(gs.colors))
+	for _, color := range gs.colors {
+		used[color] = true
+	}
+	color := playerColors[len(gs.colors)%len(playerColors)]
+	for _, c := range playerColors {
+		if !used[c] {
+			color = c
+			break
+		}
+	}
+	gs.colors[playerID] = color
+}
+
+// releaseIdentity frees a leaving player's name and color. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) releaseIdentity(playerID string) {
+	delete(gs.names, playerID)
+	delete(gs.colors, playerID)
+}
+
+// BlocklistFilter rejects names containing any of the words, ignoring case
+func BlocklistFilter(words []string) NameFilter {
+	blocked := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			blocked = append(blocked, word)
+		}
+	}
+	return func(name string) bool {
+		name = strings.ToLower(name)
+		for _, word := range blocked {
+			if strings.Contains(name, word) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,96 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// retargetRadius is how far from a projectile a smart projectile looks for
+// a new target once its own dies
+const retargetRadius = 2.0
+
+// TowerStats is how well a tower's damage was spent over a match. Stats
+// outlive the tower, so sold towers still count.
+type TowerStats struct {
+	TowerID     EntityID `json:"tower_id"`
+	TowerType   string   `json:"tower_type"`
+	OwnerID     string   `json:"owner_id,omitempty"`
+	Shots       int      `json:"shots"`
+	Damage      float64  `json:"damage"`       // dealt, overkill included
+	Overkill    float64  `json:"overkill"`     // dealt past the killing blow
+	WastedShots int      `json:"wasted_shots"` // landed after their target died and hit nothing
+	Retargeted  int      `json:"retargeted"`   // smart projectiles that found a new target
+	Kills       int      `json:"kills"`
+}
+
+// towerStatsFor returns a tower's stats, starting them on its first shot.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) towerStatsFor(towerID EntityID) *TowerStats {
+	if stats, ok := gs.towerStats[towerID]; ok {
+		return stats
+	}
+
+	stats := &TowerStats{TowerID: towerID}
+	if tower := gs.findTower(towerID); tower != nil {
+		stats.TowerType = tower.TowerType
+		stats.OwnerID = tower.OwnerID
+	}
+	if gs.towerStats == nil {
+		gs.towerStats = make(map[EntityID]*TowerStats)
+	}
+	gs.towerStats[towerID] = stats
+	return stats
+}
+
+// recordTowerDamage credits a hit to the tower that dealt it, splitting off
+// whatever went past the enemy's remaining health. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) recordTowerDamage(towerID EntityID, enemy *Enemy, damage float64) {
+	if towerID == 0 {
+		return
+	}
+
+	stats := gs.towerStatsFor(towerID)
+	stats.Damage += damage
+	if enemy.Health < 0 {
+		stats.Overkill += math.Min(-enemy.Health, damage)
+	}
+}
+
+// retargetProjectile points a projectile whose target died at the nearest
+// living enemy near it, if there is one. Callers must hold the state lock.
+func (gs *GameStateWithShooting) retargetProjectile(proj *Projectile) *Enemy {
+	var nearest *Enemy
+	minDist := retargetRadius
+	for i := range gs.Enemies {
+		enemy := &gs.Enemies[i]
+		if enemy.Health <= 0 {
+			continue
+		}
+		if dist := distance(proj.Position, enemy.Position); dist <= minDist {
+			minDist = dist
+			nearest = enemy
+		}
+	}
+
+	if nearest != nil {
+		proj.TargetID = nearest.ID
+		proj.pending = 0
+		gs.addPending(nearest, proj, proj.Damage)
+		gs.towerStatsFor(proj.TowerID).Retargeted++
+	}
+	return nearest
+}
+
+// towerStatsList returns every tower's stats, ordered by tower ID. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) towerStatsList() []TowerStats {
+	list := make([]TowerStats, 0, len(gs.towerStats))
+	for _, stats := range gs.towerStats {
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].TowerID < list[j].TowerID
+	})
+	return list
+}
@@ -0,0 +1,31 @@
+package game
+
+import "testing"
+
+func TestArmorLeavesShotEnemiesTargetable(t *testing.T) {
+	room, err := NewManager().CreateShootingRoomWithConfig("overkill-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	room.Towers = append(room.Towers, Tower{ID: room.ids.Next(), TowerType: "basic", Damage: 10, State: TowerStateActive})
+	room.Enemies = append(room.Enemies, Enemy{ID: room.ids.Next(), Health: 10, Armor: 0.5})
+	tower, enemy := &room.Towers[0], &room.Enemies[0]
+
+	room.shootProjectile(tower, enemy)
+	if enemy.doomed() {
+		t.Fatal("armored enemy written off by a shot that only takes half its health")
+	}
+	room.shootProjectile(tower, enemy)
+	if !enemy.doomed() {
+		t.Fatal("armored enemy still targeted with enough damage in flight to kill it")
+	}
+
+	releasePending(enemy, &room.Projectiles[0])
+	releasePending(enemy, &room.Projectiles[1])
+	if enemy.pendingDamage != 0 {
+		t.Fatalf("%v damage still in flight after every shot landed", enemy.pendingDamage)
+	}
+}
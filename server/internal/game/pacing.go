@@ -0,0 +1,55 @@
+package game
+
+import (
+	"log"
+	"time"
+)
+
+// Adaptive pacing tuning
+const (
+	tickBudget         = time.Second / 60 * 3 / 4 // leaves headroom for broadcasting
+	congestedSpawnGap  = 0.5                      // game seconds between spawns while congested
+	congestionCooldown = 1.0                      // game seconds between adjustments
+)
+
+// easeCongestion spaces out the wave's remaining spawns when a tick ran over
+// budget, so a crowded room slows its waves slightly instead of its whole
+// simulation. Only rooms with adaptive pacing do this. It reports whether
+// any spawn was pushed back.
+func (gs *GameStateWithShooting) easeCongestion() bool {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !gs.Config.AdaptivePacing || len(gs.pendingSpawns) == 0 {
+		return false
+	}
+	if gs.eased && gs.GameTime-gs.lastEased < congestionCooldown {
+		return false
+	}
+
+	// Pending spawns are sorted by time, so pushing each one to at least a
+	// gap after the one before keeps them sorted
+	staggered := false
+	next := gs.GameTime + congestedSpawnGap
+	for i := range gs.pendingSpawns {
+		if gs.pendingSpawns[i].at < next {
+			gs.pendingSpawns[i].at = next
+			staggered = true
+		}
+		next = gs.pendingSpawns[i].at + congestedSpawnGap
+	}
+
+	gs.eased, gs.lastEased = true, gs.GameTime
+	return staggered
+}
+
+// paceIfCongested eases a room's spawns if its last tick went over budget
+func (m *Manager) paceIfCongested(room *GameStateWithShooting, update time.Duration) {
+	if update <= tickBudget {
+		return
+	}
+	if room.easeCongestion() {
+		log.Printf("🚦 Room %s over tick budget (%.2fms), staggering remaining spawns",
+			room.RoomID, float64(update)/float64(time.Millisecond))
+	}
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+const maxPartySize = 4
+
+// Party is a group of players who queue and join rooms together
+type Party struct {
+	ID      string   `json:"id"`
+	Leader  string   `json:"leader"`
+	Members []string `json:"members"`
+}
+
+// copyParty returns a copy safe to hand out of the store
+func copyParty(p *Party) Party {
+	members := make([]string, len(p.Members))
+	copy(members, p.Members)
+	return Party{ID: p.ID, Leader: p.Leader, Members: members}
+}
+
+// PartyStore tracks parties and pending invites in memory
+type PartyStore struct {
+	mu       sync.Mutex
+	parties  map[string]*Party
+	byPlayer map[string]string          // player ID -> party ID
+	invites  map[string]map[string]bool // party ID -> invited player IDs
+	count    int
+}
+
+// NewPartyStore creates an empty party store
+func NewPartyStore() *PartyStore {
+	return &PartyStore{
+		parties:  make(map[string]*Party),
+		byPlayer: make(map[string]string),
+		invites:  make(map[string]map[string]bool),
+	}
+}
+
+// Get returns the party a player belongs to
+func (s *PartyStore) Get(playerID string) (Party, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	party, ok := s.parties[s.byPlayer[playerID]]
+	if !ok {
+		return Party{}, false
+	}
+	return copyParty(party), true
+}
+
+// Create starts a new party led by the player
+func (s *PartyStore) Create(leaderID string) (Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byPlayer[leaderID]; ok {
+		return Party{}, i18n.NewError(i18n.ErrAlreadyInParty, nil)
+	}
+
+	s.count++
+	party := &Party{
+		ID:      Qualify(TenantOf(leaderID), fmt.Sprintf("party-%d", s.count)),
+		Leader:  leaderID,
+		Members: []string{leaderID},
+	}
+	s.parties[party.ID] = party
+	s.byPlayer[leaderID] = party.ID
+
+	return copyParty(party), nil
+}
+
+// Invite lets a party leader invite another player
+func (s *PartyStore) Invite(leaderID, inviteeID string) (Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	party, err := s.ledBy(leaderID)
+	if err != nil {
+		return Party{}, err
+	}
+	if _, ok := s.byPlayer[inviteeID]; ok {
+		return Party{}, i18n.NewError(i18n.ErrAlreadyInParty, nil)
+	}
+	if len(party.Members) >= maxPartySize {
+		return Party{}, i18n.NewError(i18n.ErrPartyFull, map[string]interface{}{"max": maxPartySize})
+	}
+
+	if s.invites[party.ID] == nil {
+		s.invites[party.ID] = make(map[string]bool)
+	}
+	s.invites[party.ID][inviteeID] = true
+
+	return copyParty(party), nil
+}
+
+// Accept adds an invited player to the party
+func (s *PartyStore) Accept(playerID, partyID string) (Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	party, ok := s.parties[partyID]
+	if !ok || !s.invites[partyID][playerID] {
+		return Party{}, i18n.NewError(i18n.ErrNoPartyInvite, nil)
+	}
+	if _, ok := s.byPlayer[playerID]; ok {
+		return Party{}, i18n.NewError(i18n.ErrAlreadyInParty, nil)
+	}
+	if len(party.Members) >= maxPartySize {
+		return Party{}, i18n.NewError(i18n.ErrPartyFull, map[string]interface{}{"max": maxPartySize})
+	}
+
+	delete(s.invites[partyID], playerID)
+	party.Members = append(party.Members, playerID)
+	s.byPlayer[playerID] = partyID
+
+	return copyParty(party), nil
+}
+
+// Leave removes a player from their party. Leadership passes to the next
+// member and empty parties are disbanded. It returns the party as it is
+// afterwards.
+func (s *PartyStore) Leave(playerID string) (Party, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	partyID, ok := s.byPlayer[playerID]
+	if !ok {
+		return Party{}, false
+	}
+	party := s.parties[partyID]
+	delete(s.byPlayer, playerID)
+
+	for i, member := range party.Members {
+		if member == playerID {
+			party.Members = append(party.Members[:i], party.Members[i+1:]...)
+			break
+		}
+	}
+
+	if len(party.Members) == 0 {
+		delete(s.parties, partyID)
+		delete(s.invites, partyID)
+		return copyParty(party), true
+	}
+
+	if party.Leader == playerID {
+		party.Leader = party.Members[0]
+	}
+	return copyParty(party), true
+}
+
+// ledBy returns the party led by the player. Callers must hold the lock.
+func (s *PartyStore) ledBy(playerID string) (*Party, error) {
+	party, ok := s.parties[s.byPlayer[playerID]]
+	if !ok {
+		return nil, i18n.NewError(i18n.ErrNotInParty, nil)
+	}
+	if party.Leader != playerID {
+		return nil, i18n.NewError(i18n.ErrNotPartyLeader, nil)
+	}
+	return party, nil
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// spawnPath returns the path a newly spawned enemy should walk. A submitted
+// path is kept if it's valid; otherwise the shortest route from the spawn
+// point to the goal is used. It returns nil if the room has no spawn point
+// or goal. Callers must hold the state lock.
+func (gs *GameStateWithShooting) spawnPath(submitted []Position) []Position {
+	if gs.SpawnPoint == nil || gs.GoalPoint == nil {
+		return nil
+	}
+	if len(submitted) > 0 && gs.validSpawnPath(submitted) {
+		return submitted
+	}
+
+	if path := gs.findPath(*gs.SpawnPoint, gs.goalTargets()...); path != nil {
+		return path
+	}
+	// The goal is walled off, so the enemy waits at the spawn point like
+	// any other trapped enemy
+	return []Position{*gs.SpawnPoint}
+}
+
+// validSpawnPath reports whether a path starts at the spawn point, ends at
+// the goal and steps between adjacent, open, in-bounds cells, so enemies
+// can't be sent across the map or through towers. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) validSpawnPath(path []Position) bool {
+	if path[0] != *gs.SpawnPoint || !containsPosition(gs.goalTargets(), path[len(path)-1]) {
+		return false
+	}
+
+	blocked := gs.blockedCells()
+	for i, p := range path {
+		if p.X != math.Round(p.X) || p.Y != math.Round(p.Y) || !InBounds(p) {
+			return false
+		}
+		if blocked[fmt.Sprintf("%d,%d", int(p.X), int(p.Y))] {
+			return false
+		}
+		if i > 0 && math.Abs(p.X-path[i-1].X)+math.Abs(p.Y-path[i-1].Y) != 1 {
+			return false
+		}
+	}
+	return true
+}
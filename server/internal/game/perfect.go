@@ -0,0 +1,86 @@
+package game
+
+import "math"
+
+// Perfect wave and skip reward tuning
+const (
+	perfectWaveGold   = 20 // bonus for a wave cleared without a leak
+	perfectStreakGold = 10 // extra for each perfect wave before it in a row
+	maxPerfectStreak  = 5  // streak length past which the bonus stops growing
+	skipGoldPerSecond = 2  // paid for each second of wait the host skips
+)
+
+// Perfect wave events
+const (
+	EventPerfectWave  = "perfect_wave"
+	EventStreakBroken = "perfect_streak_broken"
+	EventWaitSkipped  = "wait_skipped"
+)
+
+// updatePerfectWave settles a wave once the field is empty and nothing is
+// left to spawn: cleared without a leak it pays a bonus that grows with the
+// streak of perfect waves, and with a leak it ends the streak. Waves called
+// before the last one cleared are settled together. Versus leaks are
+// already scored per player, so only shared-base rooms take part.
+func (gs *GameStateWithShooting) updatePerfectWave(deltaTime float64) {
+	if gs.isVersus() || gs.wavesStarted == 0 || gs.settledWave == gs.Wave {
+		return
+	}
+	if gs.waveSpawned == 0 || !gs.fieldClear() || len(gs.pendingSpawns) > 0 {
+		return
+	}
+
+	leaks := gs.waveLeaks
+	gs.settledWave = gs.Wave
+	gs.waveSpawned = 0
+	gs.waveLeaks = 0
+
+	if leaks > 0 {
+		if gs.PerfectStreak > 0 {
+			gs.emitEvent(EventStreakBroken, nil, map[string]interface{}{
+				"wave":   gs.Wave,
+				"streak": gs.PerfectStreak,
+				"leaks":  leaks,
+			})
+		}
+		gs.PerfectStreak = 0
+		return
+	}
+
+	gs.PerfectStreak++
+	gold := gs.perfectWaveBonus()
+	gs.adjustGold(GoldPerfectWave, gold, "", 0)
+	gs.emitEvent(EventPerfectWave, nil, map[string]interface{}{
+		"wave":   gs.Wave,
+		"streak": gs.PerfectStreak,
+		"gold":   gold,
+	})
+}
+
+// perfectWaveBonus is the gold paid for the current streak. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) perfectWaveBonus() int {
+	streak := math.Min(float64(gs.PerfectStreak), maxPerfectStreak)
+	return int(math.Round((perfectWaveGold + perfectStreakGold*(streak-1)) * gs.mods.gold))
+}
+
+// rewardSkip pays the host for calling the next spawn early, by the seconds
+// of wait they gave up. A skip already waiting for the next tick pays
+// nothing more. Callers must hold the state lock.
+func (gs *GameStateWithShooting) rewardSkip(playerID string) {
+	if gs.isVersus() || gs.skipWait || len(gs.pendingSpawns) == 0 {
+		return
+	}
+
+	wait := gs.pendingSpawns[0].at - gs.GameTime
+	gold := int(math.Round(wait * skipGoldPerSecond * gs.mods.gold))
+	if gold <= 0 {
+		return
+	}
+	gs.adjustGold(GoldWaveSkip, gold, playerID, 0)
+	gs.emitEvent(EventWaitSkipped, nil, map[string]interface{}{
+		"player_id": playerID,
+		"seconds":   wait,
+		"gold":      gold,
+	})
+}
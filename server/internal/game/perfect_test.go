@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// startedWave puts a room mid-wave with one enemy on the field
+func startedWave(t *testing.T) *GameStateWithShooting {
+	t.Helper()
+	room, err := NewManager().CreateShootingRoomWithConfig("perfect-room", DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.wavesStarted = 1
+	room.Wave = 1
+	room.waveSpawned = 1
+	room.Enemies = append(room.Enemies, Enemy{ID: room.ids.Next()})
+	return room
+}
+
+func TestKilledWaveIsPerfect(t *testing.T) {
+	room := startedWave(t)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	gold := room.Gold
+	room.Enemies = room.Enemies[:0] // killed
+	room.updatePerfectWave(0)
+	if room.PerfectStreak != 1 || room.Gold <= gold {
+		t.Fatalf("streak %d and %d gold earned, want a perfect wave", room.PerfectStreak, room.Gold-gold)
+	}
+}
+
+func TestClearedWaveIsntPerfect(t *testing.T) {
+	room := startedWave(t)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	gold := room.Gold
+	room.removeAllEnemies()
+	room.updatePerfectWave(0)
+	if room.PerfectStreak != 0 || room.Gold != gold {
+		t.Fatalf("clearing the field earned streak %d and %d gold", room.PerfectStreak, room.Gold-gold)
+	}
+}
@@ -0,0 +1,152 @@
+package game
+
+import "rust-rush/server/internal/i18n"
+
+// Practice rooms keep a rolling window of checkpoints the player can rewind
+// to, a few seconds of game time apart
+const (
+	practiceCheckpointInterval = 5.0 // seconds of game time
+	maxPracticeCheckpoints     = 24  // two minutes back
+)
+
+// practiceCheckpoint is a copy of the parts of a room a rewind restores:
+// the field, the economy, where the waves are and how they've gone
+type practiceCheckpoint struct {
+	gameTime        float64
+	wave            int
+	wavesStarted    int
+	gold            int
+	health          int
+	victory         bool
+	goals           []Goal
+	score           Score
+	threat          float64
+	towers          []Tower
+	enemies         []Enemy
+	projectiles     []Projectile
+	pendingSpawns   []scheduledSpawn
+	buildQueue      []EntityID
+	economy         map[string]PlayerEconomy
+	perfectStreak   int
+	waveSpawned     int
+	waveLeaks       int
+	settledWave     int
+	waveResult      waveResult
+	waveStartHealth int
+}
+
+// takeCheckpoint records a practice checkpoint once an interval has passed
+// since the last one, dropping the oldest past the limit. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) takeCheckpoint() {
+	if gs.Config.Mode != ModePractice {
+		return
+	}
+	if n := len(gs.checkpoints); n > 0 && gs.GameTime-gs.checkpoints[n-1].gameTime < practiceCheckpointInterval {
+		return
+	}
+
+	enemies := append([]Enemy(nil), gs.Enemies...)
+	for i := range enemies {
+		enemies[i].Effects = append([]StatusEffect(nil), enemies[i].Effects...)
+	}
+	economy := make(map[string]PlayerEconomy, len(gs.economy))
+	for playerID, totals := range gs.economy {
+		economy[playerID] = *totals
+	}
+	gs.checkpoints = append(gs.checkpoints, practiceCheckpoint{
+		gameTime:        gs.GameTime,
+		wave:            gs.Wave,
+		wavesStarted:    gs.wavesStarted,
+		gold:            gs.Gold,
+		health:          gs.Health,
+		victory:         gs.Victory,
+		goals:           append([]Goal(nil), gs.Goals...),
+		score:           gs.Score,
+		threat:          gs.Threat,
+		towers:          append([]Tower(nil), gs.Towers...),
+		enemies:         enemies,
+		projectiles:     append([]Projectile(nil), gs.Projectiles...),
+		pendingSpawns:   append([]scheduledSpawn(nil), gs.pendingSpawns...),
+		buildQueue:      append([]EntityID(nil), gs.buildQueue...),
+		economy:         economy,
+		perfectStreak:   gs.PerfectStreak,
+		waveSpawned:     gs.waveSpawned,
+		waveLeaks:       gs.waveLeaks,
+		settledWave:     gs.settledWave,
+		waveResult:      gs.waveResult,
+		waveStartHealth: gs.waveStartHealth,
+	})
+	if len(gs.checkpoints) > maxPracticeCheckpoints {
+		gs.checkpoints = gs.checkpoints[1:]
+	}
+}
+
+// Rewind restores a practice room to the newest checkpoint at least
+// Seconds of game time back, and forgets the checkpoints after it. The
+// tick count and entity IDs carry on from where they were, so clients
+// treat the restored state as the next frame. The gold ledger keeps what
+// happened since and records the rewind, so its balances still add up.
+// Rewinding out of a defeat is allowed; the match has already been recorded.
+type Rewind struct {
+	PlayerID string
+	Seconds  float64
+}
+
+func (Rewind) Type() string { return CommandRewind }
+
+func (c Rewind) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if gs.Config.Mode != ModePractice {
+		return nil, i18n.NewError(i18n.ErrNotPractice, nil)
+	}
+	if !containsString(gs.Players, c.PlayerID) {
+		return nil, i18n.NewError(i18n.ErrNotInRoom, nil)
+	}
+
+	target := gs.GameTime - c.Seconds
+	i := len(gs.checkpoints) - 1
+	for i >= 0 && gs.checkpoints[i].gameTime > target {
+		i--
+	}
+	if i < 0 {
+		return nil, i18n.NewError(i18n.ErrNoCheckpoint, map[string]interface{}{"seconds": c.Seconds})
+	}
+
+	// The checkpoint stays for rewinding to again, so the room gets copies
+	cp := gs.checkpoints[i]
+	gs.checkpoints = gs.checkpoints[:i+1]
+	gs.GameTime = cp.gameTime
+	gs.Wave = cp.wave
+	gs.wavesStarted = cp.wavesStarted
+	gs.adjustGold(GoldRewind, cp.gold-gs.Gold, c.PlayerID, 0)
+	gs.Health = cp.health
+	gs.Victory = cp.victory
+	gs.Goals = append([]Goal(nil), cp.goals...)
+	gs.Score = cp.score
+	gs.Threat = cp.threat
+	gs.Towers = append([]Tower(nil), cp.towers...)
+	gs.Enemies = append([]Enemy(nil), cp.enemies...)
+	for j := range gs.Enemies {
+		gs.Enemies[j].Effects = append([]StatusEffect(nil), gs.Enemies[j].Effects...)
+	}
+	gs.Projectiles = append([]Projectile(nil), cp.projectiles...)
+	gs.pendingSpawns = append([]scheduledSpawn(nil), cp.pendingSpawns...)
+	gs.buildQueue = append([]EntityID(nil), cp.buildQueue...)
+	gs.economy = make(map[string]*PlayerEconomy, len(cp.economy))
+	for playerID, totals := range cp.economy {
+		totals := totals
+		gs.economy[playerID] = &totals
+	}
+	gs.PerfectStreak = cp.perfectStreak
+	gs.waveSpawned = cp.waveSpawned
+	gs.waveLeaks = cp.waveLeaks
+	gs.settledWave = cp.settledWave
+	gs.waveResult = cp.waveResult
+	gs.waveStartHealth = cp.waveStartHealth
+	if gs.GameOver {
+		gs.GameOver = false
+		gs.lobbyChanged() // in progress again
+	}
+
+	return cp.gameTime, nil
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// newPracticeRoom opens a practice room with one player in it
+func newPracticeRoom(t *testing.T) *GameStateWithShooting {
+	t.Helper()
+	config := DefaultRoomConfig()
+	config.Mode = ModePractice
+	room, err := NewManager().CreateShootingRoomWithConfig("practice-room", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	if err := room.Join([]string{"player"}, nil); err != nil {
+		t.Fatalf("player couldn't join: %v", err)
+	}
+	return room
+}
+
+func TestPracticeRoomsRefuseParties(t *testing.T) {
+	config := DefaultRoomConfig()
+	config.Mode = ModePractice
+	room, err := NewManager().CreateShootingRoomWithConfig("practice-room", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+
+	err = room.Join([]string{"leader", "member"}, nil)
+	if !errors.Is(err, i18n.NewError(i18n.ErrPracticeParty, nil)) {
+		t.Fatalf("party joined a practice room, error %v", err)
+	}
+	if n := room.PlayerCount(); n != 0 {
+		t.Fatalf("practice room has %d players after refusing the party", n)
+	}
+}
+
+func TestRewindRestoresTheWholeCheckpoint(t *testing.T) {
+	room := newPracticeRoom(t)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	room.adjustGold(GoldKill, 10, "player", 0)
+	room.takeCheckpoint()
+	gold := room.Gold
+
+	// The run goes on: gold is earned, an enemy leaks and the level is won
+	room.adjustGold(GoldKill, 50, "player", 0)
+	room.waveSpawned, room.waveLeaks = 3, 1
+	room.PerfectStreak = 4
+	room.Victory = true
+
+	if _, err := (Rewind{PlayerID: "player"}).apply(room); err != nil {
+		t.Fatalf("rewind failed: %v", err)
+	}
+	if room.Gold != gold || room.Victory || room.waveLeaks != 0 || room.waveSpawned != 0 || room.PerfectStreak != 0 {
+		t.Fatalf("rewind left gold %d, victory %v, %d/%d leaked and streak %d",
+			room.Gold, room.Victory, room.waveLeaks, room.waveSpawned, room.PerfectStreak)
+	}
+	if kills := room.economy["player"].KillGold; kills != 10 {
+		t.Fatalf("player's kill gold is %d after the rewind, want 10", kills)
+	}
+	last := room.ledger[len(room.ledger)-1]
+	if last.Source != GoldRewind || last.Amount != -50 || last.Balance != gold {
+		t.Fatalf("ledger ends with %+v, want the rewind", last)
+	}
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Position encodings for the state sent to clients
+const (
+	PositionsFloat   = "float"   // full float64s
+	PositionsDecimal = "decimal" // rounded to some decimal places
+	PositionsFixed   = "fixed"   // integers counting 1/Scale of a tile
+)
+
+// Precision limits
+const (
+	maxPositionDecimals = 6
+	maxPositionScale    = 1 << 16
+)
+
+// Precision is how positions are encoded in the state sent to clients.
+// Rounding keeps payloads small and stops sub-pixel deltas from jittering
+// the renderer. Walls stay in whole cells whatever the encoding, and
+// lockstep rooms always get full floats, since clients simulate from them.
+type Precision struct {
+	Encoding string `json:"encoding"`
+	Decimals int    `json:"decimals,omitempty"` // decimal only
+	Scale    int    `json:"scale,omitempty"`    // fixed only: units per tile
+}
+
+// DefaultPrecision rounds positions to hundredths of a tile
+var DefaultPrecision = Precision{Encoding: PositionsDecimal, Decimals: 2}
+
+// ParsePrecision reads a position encoding: "float", "decimal:2" or
+// "fixed:100". An empty spec is the default.
+func ParsePrecision(spec string) (Precision, error) {
+	if spec == "" {
+		return DefaultPrecision, nil
+	}
+
+	encoding, arg, _ := strings.Cut(spec, ":")
+	n, err := strconv.Atoi(arg)
+	switch {
+	case encoding == PositionsFloat && arg == "":
+		return Precision{Encoding: PositionsFloat}, nil
+	case encoding == PositionsDecimal && err == nil && n >= 0 && n <= maxPositionDecimals:
+		return Precision{Encoding: PositionsDecimal, Decimals: n}, nil
+	case encoding == PositionsFixed && err == nil && n > 0 && n <= maxPositionScale:
+		return Precision{Encoding: PositionsFixed, Scale: n}, nil
+	}
+	return Precision{}, fmt.Errorf("invalid position precision %q", spec)
+}
+
+// exact reports whether positions are sent as they are
+func (p Precision) exact() bool {
+	return p.Encoding == "" || p.Encoding == PositionsFloat
+}
+
+// quantize encodes one coordinate
+func (p Precision) quantize(v float64) float64 {
+	switch p.Encoding {
+	case PositionsDecimal:
+		scale := math.Pow10(p.Decimals)
+		return math.Round(v*scale) / scale
+	case PositionsFixed:
+		return math.Round(v * float64(p.Scale))
+	}
+	return v
+}
+
+func (p Precision) position(pos Position) Position {
+	return Position{X: p.quantize(pos.X), Y: p.quantize(pos.Y)}
+}
+
+// towers returns a copy of the towers with positions encoded
+func (p Precision) towers(towers []Tower) []Tower {
+	encoded := append([]Tower{}, towers...)
+	for i := range encoded {
+		encoded[i].Position = p.position(encoded[i].Position)
+	}
+	return encoded
+}
+
+// enemies returns a copy of the enemies with positions and paths encoded
+func (p Precision) enemies(enemies []Enemy) []Enemy {
+	encoded := append([]Enemy{}, enemies...)
+	for i := range encoded {
+		e := &encoded[i]
+		e.Position = p.position(e.Position)
+		if e.Path != nil {
+			path := make([]Position, len(e.Path))
+			for j, waypoint := range e.Path {
+				path[j] = p.position(waypoint)
+			}
+			e.Path = path
+		}
+	}
+	return encoded
+}
+
+// projectiles returns a copy of the projectiles with positions encoded
+func (p Precision) projectiles(projectiles []Projectile) []Projectile {
+	encoded := append([]Projectile{}, projectiles...)
+	for i := range encoded {
+		encoded[i].Position = p.position(encoded[i].Position)
+		encoded[i].TargetPosition = p.position(encoded[i].TargetPosition)
+	}
+	return encoded
+}
+
+// goals returns a copy of the goals with positions encoded
+func (p Precision) goals(goals []Goal) []Goal {
+	if goals == nil {
+		return nil
+	}
+	encoded := append([]Goal{}, goals...)
+	for i := range encoded {
+		encoded[i].Position = p.position(encoded[i].Position)
+	}
+	return encoded
+}
+
+// point encodes a spawn or goal point, which snapshots share with the room
+func (p Precision) point(pos *Position) *Position {
+	if pos == nil {
+		return nil
+	}
+	encoded := p.position(*pos)
+	return &encoded
+}
+
+// quantize encodes the frame's positions. Its lists are replaced rather
+// than changed, since they're shared with the room.
+func (f *Frame) quantize(p Precision) {
+	if p.exact() {
+		return
+	}
+	f.Towers = p.towers(f.Towers)
+	f.Enemies = p.enemies(f.Enemies)
+	f.Projectiles = p.projectiles(f.Projectiles)
+	f.Goals = p.goals(f.Goals)
+}
+
+// quantize encodes the snapshot's positions
+func (s *Snapshot) quantize(p Precision) {
+	if p.exact() {
+		return
+	}
+	s.Towers = p.towers(s.Towers)
+	s.Enemies = p.enemies(s.Enemies)
+	s.Projectiles = p.projectiles(s.Projectiles)
+	s.Goals = p.goals(s.Goals)
+	s.SpawnPoint = p.point(s.SpawnPoint)
+	s.GoalPoint = p.point(s.GoalPoint)
+}
+
+// SetPrecision sets how positions are encoded in the state sent to clients
+func (m *Manager) SetPrecision(p Precision) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.precision = p
+}
+
+// Precision returns how positions are encoded in the state sent to clients
+func (m *Manager) Precision() Precision {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.precision
+}
+
+// clientSnapshot fills in a snapshot to send to clients, with positions
+// encoded at the given precision. Like snapshot, it's only safe to use
+// while the state lock is held. Callers must hold the state lock.
+func (gs *GameStateWithShooting) clientSnapshot(p Precision) Snapshot {
+	s := gs.snapshot()
+	if !gs.IsLockstep() {
+		s.quantize(p)
+	}
+	return s
+}
+
+// ClientSnapshot returns a copy of a room's state to send to a client,
+// with positions encoded at the negotiated precision
+func (m *Manager) ClientSnapshot(room *GameStateWithShooting) *Snapshot {
+	snapshot := room.GetSnapshot()
+	if !room.IsLockstep() {
+		snapshot.quantize(m.Precision())
+	}
+	return snapshot
+}
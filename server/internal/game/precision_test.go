@@ -0,0 +1,79 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// positionedRoom has a tower at (3.456, 7.891)
+func positionedRoom(t *testing.T, config RoomConfig) *GameStateWithShooting {
+	t.Helper()
+	room, err := NewManager().CreateShootingRoomWithConfig("precision-room", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.mu.Lock()
+	room.Towers = append(room.Towers, Tower{ID: 1, Position: Position{X: 3.456, Y: 7.891}})
+	room.mu.Unlock()
+	return room
+}
+
+func towerPosition(t *testing.T, data []byte) Position {
+	t.Helper()
+	var state struct {
+		Towers []Tower `json:"towers"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("failed to decode state: %v", err)
+	}
+	if len(state.Towers) != 1 {
+		t.Fatalf("state has %d towers, want 1", len(state.Towers))
+	}
+	return state.Towers[0].Position
+}
+
+func TestEveryClientEncodingUsesThePrecision(t *testing.T) {
+	fixed, err := ParsePrecision("fixed:100")
+	if err != nil {
+		t.Fatalf("failed to parse precision: %v", err)
+	}
+	room := positionedRoom(t, DefaultRoomConfig())
+	want := Position{X: 346, Y: 789}
+
+	var frame, snapshot bytes.Buffer
+	if err := room.WriteFrame(&frame, fixed); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+	if err := room.WriteSnapshot(&snapshot, fixed); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	keyframe, err := room.CachedKeyframe(fixed)
+	if err != nil {
+		t.Fatalf("failed to encode keyframe: %v", err)
+	}
+
+	for name, data := range map[string][]byte{"frame": frame.Bytes(), "snapshot": snapshot.Bytes(), "keyframe": keyframe} {
+		if got := towerPosition(t, data); got != want {
+			t.Errorf("%s has the tower at %+v, want %+v", name, got, want)
+		}
+	}
+	if room.Towers[0].Position.X != 3.456 {
+		t.Fatalf("encoding changed the room's own tower to %+v", room.Towers[0].Position)
+	}
+}
+
+func TestLockstepSnapshotsKeepFullPrecision(t *testing.T) {
+	fixed, _ := ParsePrecision("fixed:100")
+	config := DefaultRoomConfig()
+	config.Sync = SyncLockstep
+	room := positionedRoom(t, config)
+
+	var snapshot bytes.Buffer
+	if err := room.WriteSnapshot(&snapshot, fixed); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	if got := towerPosition(t, snapshot.Bytes()); got != (Position{X: 3.456, Y: 7.891}) {
+		t.Fatalf("lockstep snapshot has the tower at %+v", got)
+	}
+}
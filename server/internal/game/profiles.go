@@ -0,0 +1,276 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"os"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Rating tuning
+const (
+	DefaultRating = 1200.0
+	ratingK       = 32.0  // maximum rating change per match
+	ratingScale   = 400.0 // rating difference for 10x expected odds
+)
+
+// Profile holds a player's persistent stats
+type Profile struct {
+	PlayerID string  `json:"player_id"`
+	Rating   float64 `json:"rating"`
+	Matches  int     `json:"matches"`
+	Wins     int     `json:"wins"`
+	Losses   int     `json:"losses"`
+
+	// Best stars per campaign level: campaign -> level -> stars
+	Campaigns map[string]map[string]int `json:"campaigns,omitempty"`
+
+	// Unlocked cosmetic IDs
+	Cosmetics []string `json:"cosmetics,omitempty"`
+
+	// Keeps the player's matches out of the balance stats
+	NoTelemetry bool `json:"no_telemetry,omitempty"`
+
+	// Client preferences, keybinds and loadouts, nil until first saved
+	Settings *Settings `json:"settings,omitempty"`
+}
+
+// levelStars returns the best stars earned on a campaign level, 0 if it
+// hasn't been completed
+func (p Profile) levelStars(campaignID, levelID string) int {
+	return p.Campaigns[campaignID][levelID]
+}
+
+// hasCosmetic reports whether the player has unlocked a skin
+func (p Profile) hasCosmetic(cosmeticID string) bool {
+	return containsString(p.Cosmetics, cosmeticID)
+}
+
+// RatingChange describes how a match moved a player's rating
+type RatingChange struct {
+	PlayerID  string  `json:"player_id"`
+	RoomID    string  `json:"room_id"`
+	OldRating float64 `json:"old_rating"`
+	NewRating float64 `json:"new_rating"`
+	Delta     float64 `json:"delta"`
+	Won       bool    `json:"won"`
+}
+
+// ProfileStore keeps player profiles in memory and, once given a file,
+// writes them through to disk. Profiles are keyed by player ID, which
+// players keep across connections and restarts with their identity token.
+type ProfileStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*Profile
+	path     string
+}
+
+// NewProfileStore creates an empty profile store
+func NewProfileStore() *ProfileStore {
+	return &ProfileStore{
+		profiles: make(map[string]*Profile),
+	}
+}
+
+// UseFile loads profiles from a JSON file, if it exists, and saves every
+// later change back to it
+func (s *ProfileStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var profiles []*Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		s.profiles[p.PlayerID] = p
+	}
+	return nil
+}
+
+// Get returns a player's profile, or a fresh one if they haven't played
+func (s *ProfileStore) Get(playerID string) Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if p, ok := s.profiles[playerID]; ok {
+		profile := *p
+		// Copy campaign progress so callers can't modify the store
+		if p.Campaigns != nil {
+			profile.Campaigns = make(map[string]map[string]int, len(p.Campaigns))
+			for campaignID, levels := range p.Campaigns {
+				profile.Campaigns[campaignID] = make(map[string]int, len(levels))
+				for levelID, stars := range levels {
+					profile.Campaigns[campaignID][levelID] = stars
+				}
+			}
+		}
+		profile.Cosmetics = append([]string(nil), p.Cosmetics...)
+		profile.Settings = p.Settings.clone()
+		return profile
+	}
+	return Profile{PlayerID: playerID, Rating: DefaultRating}
+}
+
+// UnlockCosmetic adds a skin to a player's collection
+func (s *ProfileStore) UnlockCosmetic(playerID, cosmeticID string) error {
+	if _, ok := cosmeticCatalog[cosmeticID]; !ok {
+		return i18n.NewError(i18n.ErrUnknownCosmetic, map[string]interface{}{"cosmetic": cosmeticID})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.profile(playerID)
+	if p.hasCosmetic(cosmeticID) {
+		return nil
+	}
+	p.Cosmetics = append(p.Cosmetics, cosmeticID)
+	return s.persist()
+}
+
+// SetTelemetryOptOut keeps a player's future matches out of the balance
+// stats, or lets them back in
+func (s *ProfileStore) SetTelemetryOptOut(playerID string, optOut bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile(playerID).NoTelemetry = optOut
+	return s.persist()
+}
+
+// RecordLevel saves the stars earned on a campaign level if they beat the
+// player's best, and returns the best
+func (s *ProfileStore) RecordLevel(playerID, campaignID, levelID string, stars int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.profile(playerID)
+	if p.Campaigns == nil {
+		p.Campaigns = make(map[string]map[string]int)
+	}
+	if p.Campaigns[campaignID] == nil {
+		p.Campaigns[campaignID] = make(map[string]int)
+	}
+	if stars > p.Campaigns[campaignID][levelID] {
+		p.Campaigns[campaignID][levelID] = stars
+		s.save()
+	}
+	return p.Campaigns[campaignID][levelID]
+}
+
+// profile returns the stored profile, creating it if needed. Callers must
+// hold the lock.
+func (s *ProfileStore) profile(playerID string) *Profile {
+	p, ok := s.profiles[playerID]
+	if !ok {
+		p = &Profile{PlayerID: playerID, Rating: DefaultRating}
+		s.profiles[playerID] = p
+	}
+	return p
+}
+
+// RecordResult applies an ELO update for a match between two sides. Teams
+// are rated by their average and every member moves by the same amount.
+func (s *ProfileStore) RecordResult(roomID string, winners, losers []string) []RatingChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expected := expectedScore(s.averageRating(winners), s.averageRating(losers))
+	delta := math.Round(ratingK*(1-expected)*10) / 10
+
+	changes := make([]RatingChange, 0, len(winners)+len(losers))
+	for _, playerID := range winners {
+		p := s.profile(playerID)
+		old := p.Rating
+		p.Rating += delta
+		p.Matches++
+		p.Wins++
+		changes = append(changes, RatingChange{
+			PlayerID: playerID, RoomID: roomID, OldRating: old, NewRating: p.Rating, Delta: delta, Won: true,
+		})
+	}
+	for _, playerID := range losers {
+		p := s.profile(playerID)
+		old := p.Rating
+		p.Rating -= delta
+		p.Matches++
+		p.Losses++
+		changes = append(changes, RatingChange{
+			PlayerID: playerID, RoomID: roomID, OldRating: old, NewRating: p.Rating, Delta: -delta,
+		})
+	}
+
+	s.save()
+	return changes
+}
+
+// persist writes every profile to disk if a file is configured. Callers
+// must hold the lock.
+func (s *ProfileStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	profiles := make([]*Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		profiles = append(profiles, p)
+	}
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// save persists the profiles after a change nobody can be told about
+// failing, such as a match result. Callers must hold the lock.
+func (s *ProfileStore) save() {
+	if err := s.persist(); err != nil {
+		log.Printf("⚠️ Failed to save profiles: %v", err)
+	}
+}
+
+// averageRating returns the mean rating of a group. Callers must hold the lock.
+func (s *ProfileStore) averageRating(playerIDs []string) float64 {
+	if len(playerIDs) == 0 {
+		return DefaultRating
+	}
+	total := 0.0
+	for _, playerID := range playerIDs {
+		total += s.profile(playerID).Rating
+	}
+	return total / float64(len(playerIDs))
+}
+
+// AverageRating returns the mean rating of a group of players
+func (s *ProfileStore) AverageRating(playerIDs []string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.averageRating(playerIDs)
+}
+
+// expectedScore is the ELO win probability of a player rated a against b
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/ratingScale))
+}
@@ -0,0 +1,48 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProfilesSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+
+	store := NewProfileStore()
+	if err := store.UseFile(path); err != nil {
+		t.Fatalf("failed to use file: %v", err)
+	}
+	store.RecordResult("match-1", []string{"winner"}, []string{"loser"})
+	if err := store.UnlockCosmetic("winner", "chrome"); err != nil {
+		t.Fatalf("failed to unlock cosmetic: %v", err)
+	}
+	if err := store.SetTelemetryOptOut("winner", true); err != nil {
+		t.Fatalf("failed to opt out: %v", err)
+	}
+	if err := store.SetSettings("winner", Settings{Keybinds: map[string]string{"sell": "S"}}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	want := store.Get("winner")
+
+	// A restarted server loads what was saved
+	restarted := NewProfileStore()
+	if err := restarted.UseFile(path); err != nil {
+		t.Fatalf("failed to load profiles: %v", err)
+	}
+	got := restarted.Get("winner")
+	if got.Rating != want.Rating || got.Wins != 1 {
+		t.Errorf("rating %.1f with %d wins, want %.1f with 1", got.Rating, got.Wins, want.Rating)
+	}
+	if !got.hasCosmetic("chrome") {
+		t.Error("unlocked cosmetic was lost")
+	}
+	if !got.NoTelemetry {
+		t.Error("telemetry opt-out was lost")
+	}
+	if got.Settings == nil || got.Settings.Keybinds["sell"] != "S" {
+		t.Errorf("settings were lost: %+v", got.Settings)
+	}
+	if loser := restarted.Get("loser"); loser.Losses != 1 {
+		t.Errorf("loser has %d losses, want 1", loser.Losses)
+	}
+}
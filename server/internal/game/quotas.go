@@ -0,0 +1,268 @@
+package game
+
+import (
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Quota limits what one realm may use at once. Zero leaves a resource
+// unlimited.
+type Quota struct {
+	Rooms   int `json:"rooms"`   // open rooms
+	Players int `json:"players"` // players in its rooms
+	Storage int `json:"storage"` // saved room templates
+}
+
+// Validate checks that no limit is negative
+func (q Quota) Validate() error {
+	if q.Rooms < 0 || q.Players < 0 || q.Storage < 0 {
+		return i18n.NewError(i18n.ErrInvalidQuota, nil)
+	}
+	return nil
+}
+
+// QuotaUsage is a realm's quota alongside what it's using
+type QuotaUsage struct {
+	Tenant  string `json:"tenant"`
+	Quota   Quota  `json:"quota"`
+	Rooms   int    `json:"rooms"`
+	Players int    `json:"players"`
+	Storage int    `json:"storage"`
+}
+
+// QuotaStore holds each realm's quota. Realms without one fall back to the
+// default quota, which starts out unlimited.
+type QuotaStore struct {
+	mu       sync.RWMutex
+	fallback Quota
+	tenants  map[string]Quota
+}
+
+// NewQuotaStore creates a store where every realm is unlimited
+func NewQuotaStore() *QuotaStore {
+	return &QuotaStore{
+		tenants: make(map[string]Quota),
+	}
+}
+
+// Get returns the quota a realm is held to
+func (s *QuotaStore) Get(tenant string) Quota {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if q, ok := s.tenants[tenant]; ok {
+		return q
+	}
+	return s.fallback
+}
+
+// Set replaces a realm's quota
+func (s *QuotaStore) Set(tenant string, q Quota) error {
+	if err := q.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[tenant] = q
+	return nil
+}
+
+// SetDefault replaces the quota of realms that have none of their own
+func (s *QuotaStore) SetDefault(q Quota) error {
+	if err := q.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = q
+	return nil
+}
+
+// Reset drops a realm's own quota, putting it back on the default
+func (s *QuotaStore) Reset(tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tenants, tenant)
+}
+
+// configured lists the realms with a quota of their own
+func (s *QuotaStore) configured() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenants := make([]string, 0, len(s.tenants))
+	for tenant := range s.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
+
+// Quotas returns the per-realm quota store
+func (m *Manager) Quotas() *QuotaStore {
+	return m.quotas
+}
+
+// checkRoomQuota rejects a new room in a realm that already has as many as
+// it may. Callers must hold the manager lock.
+func (m *Manager) checkRoomQuota(roomID string) error {
+	tenant := TenantOf(roomID)
+	limit := m.quotas.Get(tenant).Rooms
+	if limit == 0 {
+		return nil
+	}
+
+	count := 0
+	for id := range m.rooms {
+		if TenantOf(id) == tenant {
+			count++
+		}
+	}
+	if count >= limit {
+		return i18n.NewError(i18n.ErrRoomQuota, map[string]interface{}{"limit": limit})
+	}
+	return nil
+}
+
+// tenantPlayers returns everyone playing in a realm's rooms
+func (m *Manager) tenantPlayers(tenant string) map[string]bool {
+	players := make(map[string]bool)
+	for _, room := range m.shootingRooms() {
+		if TenantOf(room.RoomID) != tenant {
+			continue
+		}
+		room.mu.RLock()
+		for _, playerID := range room.Players {
+			players[playerID] = true
+		}
+		room.mu.RUnlock()
+	}
+	return players
+}
+
+// checkPlayerQuota rejects players joining a room if its realm would end
+// up with more players than it may have. Players already in one of the
+// realm's rooms are only moving, so they don't count again. Callers must
+// hold joinMu until the players have joined, so the count stays true.
+func (m *Manager) checkPlayerQuota(room Room, playerIDs []string) error {
+	tenant := TenantOf(room.ID())
+	limit := m.quotas.Get(tenant).Players
+	if limit == 0 {
+		return nil
+	}
+
+	players := m.tenantPlayers(tenant)
+	count := len(players)
+	for _, playerID := range playerIDs {
+		if !players[playerID] {
+			count++
+		}
+	}
+	if count > limit {
+		return i18n.NewError(i18n.ErrPlayerQuota, map[string]interface{}{"limit": limit})
+	}
+	return nil
+}
+
+// checkRoomFull rejects players joining once the room has as many as its
+// config allows. Drop-in games under way are capped even without a limit.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) checkRoomFull(playerIDs []string) error {
+	limit := gs.playerLimit()
+	if limit == 0 {
+		return nil
+	}
+
+	count := len(gs.Players)
+	for _, playerID := range playerIDs {
+		if !containsString(gs.Players, playerID) {
+			count++
+		}
+	}
+	if count > limit {
+		return i18n.NewError(i18n.ErrRoomFull, map[string]interface{}{"limit": limit})
+	}
+	return nil
+}
+
+// playerLimit is how many players the room takes right now, 0 for no
+// limit. Callers must hold the state lock.
+func (gs *GameStateWithShooting) playerLimit() int {
+	if gs.Config.MaxPlayers == 0 && gs.Config.DropIn && gs.inProgress() {
+		return dropInCapacity
+	}
+	return gs.Config.MaxPlayers
+}
+
+// SetMaxPlayers changes how many players the room may have, 0 for no
+// limit. Players already over a lowered limit stay; only new ones are
+// turned away.
+func (gs *GameStateWithShooting) SetMaxPlayers(limit int) error {
+	if limit < 0 || limit > ceilingMaxPlayers {
+		return i18n.NewError(i18n.ErrInvalidConfig, map[string]interface{}{"field": "max_players"})
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.Config.MaxPlayers = limit
+	return nil
+}
+
+// SaveTemplate saves a room template, holding its realm to its storage
+// quota. Replacing one of the realm's templates takes no extra space.
+func (m *Manager) SaveTemplate(t RoomTemplate) (RoomTemplate, error) {
+	tenant := TenantOf(t.Name)
+	if limit := m.quotas.Get(tenant).Storage; limit > 0 {
+		if _, err := m.templates.Get(t.Name); err != nil && m.templates.count(tenant) >= limit {
+			return RoomTemplate{}, i18n.NewError(i18n.ErrStorageQuota, map[string]interface{}{"limit": limit})
+		}
+	}
+	return m.templates.Save(t)
+}
+
+// QuotaUsage reports every realm with rooms, players, saved templates or a
+// quota of its own, sorted by realm
+func (m *Manager) QuotaUsage() []QuotaUsage {
+	usage := make(map[string]*QuotaUsage)
+	entry := func(tenant string) *QuotaUsage {
+		u, ok := usage[tenant]
+		if !ok {
+			u = &QuotaUsage{Tenant: tenant, Quota: m.quotas.Get(tenant)}
+			usage[tenant] = u
+		}
+		return u
+	}
+
+	for _, tenant := range m.quotas.configured() {
+		entry(tenant)
+	}
+	m.mu.RLock()
+	rooms := make([]Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	// Counted outside the manager lock, since a ticking room holds its own
+	// lock while it waits on the manager's
+	for _, room := range rooms {
+		u := entry(TenantOf(room.ID()))
+		u.Rooms++
+		u.Players += room.PlayerCount()
+	}
+	for tenant, count := range m.templates.realms() {
+		entry(tenant).Storage += count
+	}
+
+	report := make([]QuotaUsage, 0, len(usage))
+	for _, u := range usage {
+		report = append(report, *u)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Tenant < report[j].Tenant
+	})
+	return report
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"rust-rush/server/internal/cluster"
+)
+
+func TestConcurrentJoinsDontOverfillRooms(t *testing.T) {
+	m := NewManager()
+	config := DefaultRoomConfig()
+	config.MaxPlayers = 2
+	room, err := m.OpenRoom("full-room", config)
+	if err != nil {
+		t.Fatalf("failed to open room: %v", err)
+	}
+	defer m.DeleteRoom(room.RoomID)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.AddPlayers(room.RoomID, []string{fmt.Sprintf("player-%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	if n := room.PlayerCount(); n != 2 {
+		t.Fatalf("room with space for 2 has %d players", n)
+	}
+}
+
+func TestAdoptHonorsTheRoomQuota(t *testing.T) {
+	store := cluster.NewMemoryHandoffStore()
+	draining := NewManager()
+	draining.UseHandoffStore(store)
+	if _, err := draining.CreateShootingRoomWithConfig("acme/room-1", DefaultRoomConfig()); err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	if handedOff := draining.Drain(); len(handedOff) != 1 {
+		t.Fatalf("handed off %v, want acme/room-1", handedOff)
+	}
+
+	m := NewManager()
+	m.UseHandoffStore(store)
+	if err := m.Quotas().Set("acme", Quota{Rooms: 1}); err != nil {
+		t.Fatalf("failed to set quota: %v", err)
+	}
+	if _, err := m.CreateShootingRoomWithConfig("acme/room-2", DefaultRoomConfig()); err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+
+	if _, ok := m.Adopt("acme/room-1"); ok {
+		t.Fatal("adopted a room past the realm's quota")
+	}
+	if _, ok, _ := store.Take("acme/room-1"); !ok {
+		t.Fatal("room refused for the quota wasn't parked again")
+	}
+}
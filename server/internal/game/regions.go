@@ -0,0 +1,149 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// regionSwitchMargin is how much closer another region must be before
+// quick match sends a player there instead of matching them here, so
+// jittery probes don't bounce players between regions
+const regionSwitchMargin = 40 * time.Millisecond
+
+// ClientLatency is the round trip a client can expect: to this instance,
+// measured by the server when the client connects, and to other regions,
+// as the client reported probing them
+type ClientLatency struct {
+	Measured time.Duration            // 0 until the first pong
+	Regions  map[string]time.Duration // round trip by region
+}
+
+// ParseRegionRTTs reads the round trips a client probed in milliseconds,
+// e.g. "eu-west:32,us-east:110". Malformed entries are skipped.
+func ParseRegionRTTs(spec string) map[string]time.Duration {
+	rtts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		region, ms, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || region == "" {
+			continue
+		}
+		n, err := strconv.Atoi(ms)
+		if err != nil || n < 0 {
+			continue
+		}
+		rtts[region] = time.Duration(n) * time.Millisecond
+	}
+	return rtts
+}
+
+// expected returns the round trip to rooms in a region. Rooms in the
+// instance's own region are as far as the measured round trip; others go
+// by what the client reported. ok is false when neither is known.
+func (l ClientLatency) expected(region, local string) (time.Duration, bool) {
+	if region == local && l.Measured > 0 {
+		return l.Measured, true
+	}
+	rtt, ok := l.Regions[region]
+	return rtt, ok
+}
+
+// closerRegion returns the reported region with the lowest round trip, if
+// it beats the local region by more than the switch margin
+func (l ClientLatency) closerRegion(local string) (string, time.Duration, bool) {
+	localRTT, known := l.expected(local, local)
+	if !known {
+		return "", 0, false
+	}
+
+	best, bestRTT, found := "", time.Duration(0), false
+	for region, rtt := range l.Regions {
+		if region == local {
+			continue
+		}
+		if !found || rtt < bestRTT || (rtt == bestRTT && region < best) {
+			best, bestRTT, found = region, rtt, true
+		}
+	}
+	if !found || bestRTT+regionSwitchMargin >= localRTT {
+		return "", 0, false
+	}
+	return best, bestRTT, true
+}
+
+// QuickMatch is where quick match sends a player: a room here to join, or
+// a closer region to reconnect to and try again
+type QuickMatch struct {
+	RoomID string `json:"room_id,omitempty"`
+	Region string `json:"region,omitempty"` // set instead of RoomID
+	RTTMs  int    `json:"rtt_ms,omitempty"` // expected round trip, if known
+}
+
+// QuickMatch finds a public co-op room a player and their party can join
+// straight away. If the client reported a region much closer than this
+// one it's sent there instead; otherwise the fullest room here wins, so
+// games fill up.
+func (m *Manager) QuickMatch(tenant string, playerIDs []string, latency ClientLatency) (QuickMatch, error) {
+	region := m.Region()
+	if closer, rtt, ok := latency.closerRegion(region); ok {
+		return QuickMatch{Region: closer, RTTMs: int(rtt / time.Millisecond)}, nil
+	}
+
+	candidates := make([]*GameStateWithShooting, 0)
+	for _, room := range m.shootingRooms() {
+		if TenantOf(room.RoomID) != tenant {
+			continue
+		}
+		room.mu.RLock()
+		open := !room.IsPrivate() && room.Config.Mode == ModeCoop && !room.GameOver && room.hasSpace(len(playerIDs))
+		room.mu.RUnlock()
+		if open && room.admits(playerIDs) {
+			candidates = append(candidates, room)
+		}
+	}
+	if len(candidates) == 0 {
+		return QuickMatch{}, i18n.NewError(i18n.ErrNoOpenRooms, nil)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := candidates[i].PlayerCount(), candidates[j].PlayerCount()
+		if pi != pj {
+			return pi > pj
+		}
+		return candidates[i].RoomID < candidates[j].RoomID
+	})
+
+	match := QuickMatch{RoomID: candidates[0].RoomID}
+	if local, ok := latency.expected(region, region); ok {
+		match.RTTMs = int(local / time.Millisecond)
+	}
+	return match, nil
+}
+
+// hasSpace reports whether the room can take some more players. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) hasSpace(players int) bool {
+	limit := gs.playerLimit()
+	return limit == 0 || len(gs.Players)+players <= limit
+}
+
+// admits reports whether every player may join the room
+func (gs *GameStateWithShooting) admits(playerIDs []string) bool {
+	for _, playerID := range playerIDs {
+		if gs.CheckAccess(playerID, "", "") != nil {
+			return false
+		}
+	}
+
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	for _, playerID := range playerIDs {
+		if gs.checkDropIn(playerID) != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Room is a room the manager hosts. Shooting rooms run the game here;
+// legacy rooms only relay state from the Rust engine.
+type Room interface {
+	ID() string
+	Join(playerIDs []string, names map[string]string) error
+	RemovePlayer(playerID string)
+	PlayerCount() int
+	WriteSnapshot(buf *bytes.Buffer, precision Precision) error
+	instanceNumber() uint64
+}
+
+// GameState is a legacy room: a player list and whatever JSON the Rust
+// engine last sent for it. Nothing ticks it, so the manager collects it
+// once its last player leaves.
+type GameState struct {
+	RoomID   string          `json:"room_id"`
+	Players  []string        `json:"players"`
+	GameData json.RawMessage `json:"game_data"` // Raw JSON from Rust engine
+	mu       sync.RWMutex
+	closed   bool   // collected once empty, so it takes no more players
+	instance uint64 // tells it apart from other rooms that had its ID
+}
+
+// ID returns the room's ID
+func (gs *GameState) ID() string {
+	return gs.RoomID
+}
+
+func (gs *GameState) instanceNumber() uint64 {
+	return gs.instance
+}
+
+// Join adds players to the room. Legacy rooms have no display names.
+func (gs *GameState) Join(playerIDs []string, names map[string]string) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.closed {
+		return i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": gs.RoomID})
+	}
+
+	for _, id := range playerIDs {
+		if !containsString(gs.Players, id) {
+			gs.Players = append(gs.Players, id)
+		}
+	}
+	return nil
+}
+
+// RemovePlayer takes a player out of the room
+func (gs *GameState) RemovePlayer(playerID string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for i, id := range gs.Players {
+		if id == playerID {
+			gs.Players = append(gs.Players[:i], gs.Players[i+1:]...)
+			break
+		}
+	}
+}
+
+// closeIfEmpty closes the room to joins if nobody is in it
+func (gs *GameState) closeIfEmpty() bool {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if len(gs.Players) > 0 {
+		return false
+	}
+	gs.closed = true
+	return true
+}
+
+// PlayerCount returns how many players are in the room
+func (gs *GameState) PlayerCount() int {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return len(gs.Players)
+}
+
+// SetGameData replaces the engine state relayed to the room's players
+func (gs *GameState) SetGameData(data json.RawMessage) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.GameData = data
+}
+
+// WriteSnapshot writes the room's players and engine state as JSON. Legacy
+// rooms have no positions of their own, so precision doesn't apply.
+func (gs *GameState) WriteSnapshot(buf *bytes.Buffer, _ Precision) error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return json.NewEncoder(buf).Encode(gs)
+}
+
+// GetRoom retrieves a room of either kind by ID
+func (m *Manager) GetRoom(roomID string) (Room, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	room, exists := m.rooms[roomID]
+	return room, exists
+}
+
+// GetShootingRoom retrieves a shooting game room by ID
+func (m *Manager) GetShootingRoom(roomID string) (*GameStateWithShooting, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	room, exists := m.rooms[roomID].(*GameStateWithShooting)
+	return room, exists
+}
+
+// shootingRooms lists the shooting rooms on this instance
+func (m *Manager) shootingRooms() []*GameStateWithShooting {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rooms := make([]*GameStateWithShooting, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		if shooting, ok := room.(*GameStateWithShooting); ok {
+			rooms = append(rooms, shooting)
+		}
+	}
+	return rooms
+}
@@ -0,0 +1,34 @@
+package game
+
+import "testing"
+
+func TestCollectedLegacyRoomRefusesJoins(t *testing.T) {
+	m := NewManager()
+	room := m.CreateRoom("legacy-1")
+	if err := m.AddPlayers("legacy-1", []string{"player"}); err != nil {
+		t.Fatalf("player couldn't join: %v", err)
+	}
+
+	m.RemovePlayer("legacy-1", "player")
+	if _, exists := m.GetRoom("legacy-1"); exists {
+		t.Fatal("empty legacy room wasn't collected")
+	}
+
+	// A join that found the room just before it was collected
+	if err := room.Join([]string{"late"}, nil); err == nil {
+		t.Fatal("collected room took a player")
+	}
+}
+
+func TestOccupiedLegacyRoomIsKept(t *testing.T) {
+	m := NewManager()
+	m.CreateRoom("legacy-1")
+	if err := m.AddPlayers("legacy-1", []string{"leaving", "staying"}); err != nil {
+		t.Fatalf("players couldn't join: %v", err)
+	}
+
+	m.RemovePlayer("legacy-1", "leaving")
+	if _, exists := m.GetRoom("legacy-1"); !exists {
+		t.Fatal("legacy room was collected with a player still in it")
+	}
+}
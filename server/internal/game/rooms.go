@@ -0,0 +1,230 @@
+package game
+
+import (
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Room visibility settings
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// Join codes skip characters that are easy to confuse (0/O, 1/I/L)
+const (
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+)
+
+// RoomSummary is the public listing of a room
+type RoomSummary struct {
+	RoomID   string   `json:"room_id"`
+	Mode     string   `json:"mode"`
+	Mutators []string `json:"mutators"`
+	Host     string   `json:"host,omitempty"`
+	Players  int      `json:"players"`
+	Wave     int      `json:"wave"`
+
+	Map        string  `json:"map"`
+	Difficulty float64 `json:"difficulty"`            // the mutators' score multiplier
+	MaxPlayers int     `json:"max_players,omitempty"` // 0 for no limit
+	Region     string  `json:"region,omitempty"`
+	RTTMs      int     `json:"rtt_ms,omitempty"` // expected round trip for the client listing rooms
+}
+
+// newJoinCode generates a short random join code
+func newJoinCode() string {
+	return ids.Code(joinCodeAlphabet, joinCodeLength)
+}
+
+// AddPlayers adds players to the room together, so a party is never split
+func (gs *GameStateWithShooting) AddPlayers(playerIDs ...string) {
+	gs.ApplyCommand(JoinRoom{PlayerIDs: playerIDs})
+}
+
+// Join adds players to the room together under their display names
+func (gs *GameStateWithShooting) Join(playerIDs []string, names map[string]string) error {
+	_, err := gs.ApplyCommand(JoinRoom{PlayerIDs: playerIDs, Names: names})
+	return err
+}
+
+// RemovePlayer takes a player out of the room
+func (gs *GameStateWithShooting) RemovePlayer(playerID string) {
+	gs.ApplyCommand(LeaveRoom{PlayerID: playerID})
+}
+
+// addPlayer adds a player to the room once under the given display name.
+// The first player to join hosts the room. Callers must hold the state lock.
+func (gs *GameStateWithShooting) addPlayer(playerID, name string) {
+	gs.welcomeDropIn(playerID)
+	if gs.joined == nil {
+		gs.joined = make(map[string]bool)
+	}
+	gs.joined[playerID] = true
+	delete(gs.returning, playerID)
+	gs.assignIdentity(playerID, name)
+	if !containsString(gs.Players, playerID) {
+		gs.Players = append(gs.Players, playerID)
+	}
+	if gs.Host == "" {
+		gs.Host = playerID
+	}
+	gs.addVersusPlayer(playerID)
+	gs.lobbyChanged()
+}
+
+// removePlayer removes a player from the room, handing hosting on if they
+// were the host. Callers must hold the state lock.
+func (gs *GameStateWithShooting) removePlayer(playerID string) {
+	for i, id := range gs.Players {
+		if id == playerID {
+			gs.Players = append(gs.Players[:i], gs.Players[i+1:]...)
+			break
+		}
+	}
+
+	if gs.Host == playerID {
+		gs.migrateHost()
+	}
+	delete(gs.ready, playerID)
+	gs.releaseIdentity(playerID)
+	gs.lobbyChanged()
+}
+
+// migrateHost hands hosting to the player who has been in the room longest.
+// Players are kept in join order and leave the list when they disconnect, so
+// that's the first one. Everything the old host set up, like a pause, the
+// build queue or the join code, is room state and carries over. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) migrateHost() {
+	gs.Host = ""
+	if len(gs.Players) > 0 {
+		gs.Host = gs.Players[0]
+	}
+}
+
+// ID returns the room's ID
+func (gs *GameStateWithShooting) ID() string {
+	return gs.RoomID
+}
+
+func (gs *GameStateWithShooting) instanceNumber() uint64 {
+	return gs.instance
+}
+
+// PlayerCount returns how many players are in the room
+func (gs *GameStateWithShooting) PlayerCount() int {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return len(gs.Players)
+}
+
+// HasPlayer reports whether a player is in the room
+func (gs *GameStateWithShooting) HasPlayer(playerID string) bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return containsString(gs.Players, playerID)
+}
+
+// IsPrivate reports whether the room is hidden and needs a code to join
+func (gs *GameStateWithShooting) IsPrivate() bool {
+	return gs.Config.Visibility == VisibilityPrivate
+}
+
+// CheckAccess verifies a join or observe attempt. Public rooms are open to
+// everyone; private rooms need the join code or, if one is set, the
+// password. Players already in the room, or returning to it after a
+// handoff, can always rejoin; players the host kicked can't. Whether a game
+// under way takes new players is checked as they join.
+func (gs *GameStateWithShooting) CheckAccess(playerID, code, password string) error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	seated := containsString(gs.Players, playerID) || gs.returning[playerID]
+	if gs.kicked[playerID] {
+		return i18n.NewError(i18n.ErrKicked, map[string]interface{}{"room_id": gs.RoomID})
+	}
+	if gs.Config.Mode == ModePractice && len(gs.Players)+len(gs.returning) > 0 && !seated {
+		return i18n.NewError(i18n.ErrPracticeSolo, map[string]interface{}{"room_id": gs.RoomID})
+	}
+	if seated {
+		return nil
+	}
+	if code != "" && code == gs.joinCode {
+		return nil
+	}
+	if !gs.IsPrivate() {
+		return nil
+	}
+	if gs.Config.Password != "" && password == gs.Config.Password {
+		return nil
+	}
+	return i18n.NewError(i18n.ErrRoomPrivate, map[string]interface{}{"room_id": gs.RoomID})
+}
+
+// JoinCode returns the room's join code to its host
+func (gs *GameStateWithShooting) JoinCode(playerID string) (string, bool) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	if gs.Host != playerID {
+		return "", false
+	}
+	return gs.joinCode, true
+}
+
+// RotateJoinCode replaces the join code so old codes stop working. Only the
+// host may do this.
+func (gs *GameStateWithShooting) RotateJoinCode(playerID string) (string, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.Host != playerID {
+		return "", i18n.NewError(i18n.ErrNotHost, nil)
+	}
+
+	gs.joinCode = newJoinCode()
+	return gs.joinCode, nil
+}
+
+// summary returns the room's public listing. Callers must hold the state lock.
+func (gs *GameStateWithShooting) summary() RoomSummary {
+	return RoomSummary{
+		RoomID:   gs.RoomID,
+		Mode:     gs.Config.Mode,
+		Mutators: gs.Config.Mutators,
+		Host:     gs.Host,
+		Players:  len(gs.Players),
+		Wave:     gs.Wave,
+
+		Map:        gs.mapName(),
+		Difficulty: gs.Config.ScoreMultiplier(),
+		MaxPlayers: gs.playerLimit(),
+	}
+}
+
+// mapName is the map the room is played on. Callers must hold the state
+// lock.
+func (gs *GameStateWithShooting) mapName() string {
+	if gs.campaignLevel != nil && gs.campaignLevel.Map != "" {
+		return gs.campaignLevel.Map
+	}
+	return DefaultMap
+}
+
+// FindRoomByCode looks up a room by its join code
+func (m *Manager) FindRoomByCode(tenant, code string) (string, bool) {
+	for _, room := range m.shootingRooms() {
+		if TenantOf(room.RoomID) != tenant {
+			continue
+		}
+		room.mu.RLock()
+		match := room.joinCode == code
+		room.mu.RUnlock()
+		if match {
+			return room.RoomID, true
+		}
+	}
+	return "", false
+}
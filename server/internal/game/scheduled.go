@@ -0,0 +1,140 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// bossWaveInterval is how often a special boss joins the wave while a
+// server event has unlocked boss waves
+const bossWaveInterval = 5
+
+// ServerEvent is a temporary server-wide modifier such as a double-gold
+// weekend. Rooms created while it's active pick up its modifiers.
+type ServerEvent struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	GoldMultiplier float64   `json:"gold_multiplier,omitempty"`
+	BossWaves      bool      `json:"boss_waves,omitempty"`
+}
+
+// activeAt reports whether the event is running at t
+func (e ServerEvent) activeAt(t time.Time) bool {
+	return !t.Before(e.Start) && t.Before(e.End)
+}
+
+// EventScheduler holds the configured server events
+type EventScheduler struct {
+	mu     sync.RWMutex
+	events []ServerEvent
+	count  int
+}
+
+// NewEventScheduler creates an empty scheduler
+func NewEventScheduler() *EventScheduler {
+	return &EventScheduler{
+		events: make([]ServerEvent, 0),
+	}
+}
+
+// Add schedules an event and returns it with its assigned ID
+func (s *EventScheduler) Add(event ServerEvent) (ServerEvent, error) {
+	if event.Name == "" || !event.End.After(event.Start) || event.GoldMultiplier < 0 {
+		return ServerEvent{}, i18n.NewError(i18n.ErrInvalidServerEvent, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	event.ID = fmt.Sprintf("event-%d", s.count)
+	s.events = append(s.events, event)
+	sort.SliceStable(s.events, func(i, j int) bool {
+		return s.events[i].Start.Before(s.events[j].Start)
+	})
+	return event, nil
+}
+
+// Remove cancels an event
+func (s *EventScheduler) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.events {
+		if e.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the events running at t
+func (s *EventScheduler) Active(t time.Time) []ServerEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	active := make([]ServerEvent, 0)
+	for _, e := range s.events {
+		if e.activeAt(t) {
+			active = append(active, e)
+		}
+	}
+	return active
+}
+
+// Upcoming returns events that are running or haven't started yet at t
+func (s *EventScheduler) Upcoming(t time.Time) []ServerEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	upcoming := make([]ServerEvent, 0)
+	for _, e := range s.events {
+		if t.Before(e.End) {
+			upcoming = append(upcoming, e)
+		}
+	}
+	return upcoming
+}
+
+// applyServerEvents folds the active events into a room config
+func applyServerEvents(config RoomConfig, events []ServerEvent) RoomConfig {
+	for _, e := range events {
+		config.ServerEvents = append(config.ServerEvents, e.ID)
+		if e.GoldMultiplier > 0 {
+			if config.GoldMultiplier == 0 {
+				config.GoldMultiplier = 1
+			}
+			config.GoldMultiplier *= e.GoldMultiplier
+		}
+		if e.BossWaves {
+			config.BossWaves = true
+		}
+	}
+	return config
+}
+
+// spawnBossWave adds the event boss when a boss wave starts. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) spawnBossWave(wave int) {
+	if !gs.Config.BossWaves || wave%bossWaveInterval != 0 {
+		return
+	}
+	path := gs.spawnPath(nil)
+	if path == nil {
+		return
+	}
+
+	boss := gs.addEnemy("boss", path, "")
+	gs.emitEvent(EventBossWave, &boss.Position, map[string]interface{}{
+		"wave":     wave,
+		"enemy_id": boss.ID,
+	})
+}
@@ -0,0 +1,70 @@
+package game
+
+import "math"
+
+// Score weights
+const (
+	pointsPerWave      = 100
+	pointsPerLeak      = 50
+	pointsPerSecond    = 1
+	defaultKillPoints  = 10
+	maxTimeBonusPoints = 1800 // caps the survival bonus at 30 minutes
+)
+
+// killPoints is the score awarded per enemy type
+var killPoints = map[string]int{
+	"basic":  10,
+	"fast":   15,
+	"tank":   30,
+	"flying": 20,
+	"boss":   200,
+	"emp":    25,
+}
+
+// Score is the running score of a room, broken down by component
+type Score struct {
+	Kills       int     `json:"kills"`
+	KillPoints  int     `json:"kill_points"`
+	WaveReached int     `json:"wave_reached"`
+	WavePoints  int     `json:"wave_points"`
+	Leaks       int     `json:"leaks"`
+	LeakPenalty int     `json:"leak_penalty"`
+	TimePoints  int     `json:"time_points"`
+	Multiplier  float64 `json:"multiplier"`
+	Total       int     `json:"total"`
+}
+
+// recordKill adds an enemy kill to the score
+func (s *Score) recordKill(enemyType string) {
+	points, ok := killPoints[enemyType]
+	if !ok {
+		points = defaultKillPoints
+	}
+	s.Kills++
+	s.KillPoints += points
+}
+
+// recordLeak adds an enemy reaching the goal to the score
+func (s *Score) recordLeak() {
+	s.Leaks++
+	s.LeakPenalty += pointsPerLeak
+}
+
+// recalculate refreshes the derived components and total
+func (s *Score) recalculate(wave int, gameTime, multiplier float64) {
+	s.WaveReached = wave
+	s.WavePoints = wave * pointsPerWave
+
+	s.TimePoints = int(gameTime) * pointsPerSecond
+	if s.TimePoints > maxTimeBonusPoints {
+		s.TimePoints = maxTimeBonusPoints
+	}
+
+	s.Multiplier = multiplier
+
+	base := s.KillPoints + s.WavePoints + s.TimePoints - s.LeakPenalty
+	if base < 0 {
+		base = 0
+	}
+	s.Total = int(math.Round(float64(base) * multiplier))
+}
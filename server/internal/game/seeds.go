@@ -0,0 +1,24 @@
+package game
+
+import "math/rand"
+
+// maxSeed keeps seeds exact in JavaScript clients, whose numbers are
+// doubles
+const maxSeed = 1<<53 - 1
+
+// A room's seed is the only thing the simulation draws on that isn't in
+// its inputs: it picks the epoch entity IDs start from, and with it every
+// tie broken by ID. Two rooms with the same seed, config and balance that
+// are sent the same commands on the same ticks play out identically, which
+// is what lets players share a seed as a challenge. Adaptive pacing reacts
+// to how fast the server runs, so rooms using it are the exception.
+
+// newSeed picks a seed for a room whose host didn't choose one
+func newSeed() int64 {
+	return rand.Int63n(maxSeed) + 1
+}
+
+// seedEpoch is the entity ID epoch a seed starts from
+func seedEpoch(seed int64) uint64 {
+	return uint64(seed%maxEntityEpoch) + 1
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Settings limits. The whole blob is capped, since preferences are free-form.
+const (
+	maxSettingsSize    = 4096 // encoded bytes
+	maxKeybinds        = 64
+	maxLoadouts        = 8
+	maxLoadoutTowers   = 10 // one hotbar
+	maxSettingNameSize = 32
+)
+
+// Settings is what a player's client remembers for them between sessions
+type Settings struct {
+	Preferences map[string]interface{} `json:"preferences,omitempty"` // client-defined, e.g. volume or colorblind mode
+	Keybinds    map[string]string      `json:"keybinds,omitempty"`    // action -> key
+	Loadouts    []Loadout              `json:"loadouts,omitempty"`
+}
+
+// Loadout is a favorite hotbar of tower types
+type Loadout struct {
+	Name   string   `json:"name"`
+	Towers []string `json:"towers"`
+}
+
+// ParseSettings decodes settings a client sent, rejecting fields the server
+// doesn't know so typos aren't silently dropped
+func ParseSettings(data []byte) (Settings, error) {
+	if len(data) > maxSettingsSize {
+		return Settings{}, invalidSettings("size")
+	}
+
+	var s Settings
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return Settings{}, invalidSettings("format")
+	}
+	return s, nil
+}
+
+// Validate checks the settings fit their limits and only name towers the
+// balance has
+func (s Settings) Validate(balance *Balance) error {
+	data, err := json.Marshal(s)
+	if err != nil || len(data) > maxSettingsSize {
+		return invalidSettings("size")
+	}
+
+	if len(s.Keybinds) > maxKeybinds {
+		return invalidSettings("keybinds")
+	}
+	for action, key := range s.Keybinds {
+		if !validSettingName(action) || !validSettingName(key) {
+			return invalidSettings("keybinds")
+		}
+	}
+
+	if len(s.Loadouts) > maxLoadouts {
+		return invalidSettings("loadouts")
+	}
+	for _, l := range s.Loadouts {
+		if !validSettingName(l.Name) || len(l.Towers) > maxLoadoutTowers {
+			return invalidSettings("loadouts")
+		}
+		for _, towerType := range l.Towers {
+			if _, ok := balance.Towers[towerType]; !ok {
+				return invalidSettings("loadouts")
+			}
+		}
+	}
+	return nil
+}
+
+// clone deep copies the settings so callers can't modify a stored copy.
+// Preferences are copied through JSON, since they can nest.
+func (s *Settings) clone() *Settings {
+	if s == nil {
+		return nil
+	}
+
+	copied := &Settings{}
+	if s.Preferences != nil {
+		data, _ := json.Marshal(s.Preferences)
+		json.Unmarshal(data, &copied.Preferences)
+	}
+	if s.Keybinds != nil {
+		copied.Keybinds = make(map[string]string, len(s.Keybinds))
+		for action, key := range s.Keybinds {
+			copied.Keybinds[action] = key
+		}
+	}
+	for _, l := range s.Loadouts {
+		copied.Loadouts = append(copied.Loadouts, Loadout{Name: l.Name, Towers: append([]string(nil), l.Towers...)})
+	}
+	return copied
+}
+
+func validSettingName(name string) bool {
+	return name != "" && len(name) <= maxSettingNameSize
+}
+
+func invalidSettings(field string) error {
+	return i18n.NewError(i18n.ErrInvalidSettings, map[string]interface{}{"field": field})
+}
+
+// SetSettings replaces a player's saved settings
+func (s *ProfileStore) SetSettings(playerID string, settings Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile(playerID).Settings = settings.clone()
+	return s.persist()
+}
+
+// SaveSettings validates and saves a player's settings
+func (m *Manager) SaveSettings(playerID string, settings Settings) error {
+	if err := settings.Validate(m.balance.Current()); err != nil {
+		return err
+	}
+	return m.profiles.SetSettings(playerID, settings)
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Snapshot is the serializable form of a room's state sent to clients. It
+// holds only exported game data, never the room's lock or bookkeeping.
+type Snapshot struct {
+	RoomID          string       `json:"room_id"`
+	Players         []string     `json:"players"`
+	Towers          []Tower      `json:"towers"`
+	Enemies         []Enemy      `json:"enemies"`
+	Projectiles     []Projectile `json:"projectiles"`
+	Gold            int          `json:"gold"`
+	Health          int          `json:"health"`
+	Wave            int          `json:"wave"`
+	GameTime        float64      `json:"game_time"`
+	SpawnPoint      *Position    `json:"spawn_point,omitempty"`
+	GoalPoint       *Position    `json:"goal_point,omitempty"`
+	Walls           []Wall       `json:"walls,omitempty"`
+	Goals           []Goal       `json:"goals,omitempty"`
+	Config          RoomConfig   `json:"config"`
+	ScoreMultiplier float64      `json:"score_multiplier"`
+	Score           Score        `json:"score"`
+	GameOver        bool         `json:"game_over"`
+	Victory         bool         `json:"victory,omitempty"`
+	Paused          bool         `json:"paused"`
+	Threat          float64      `json:"threat"`
+	Events          []GameEvent  `json:"events"`
+	Versus          *VersusState `json:"versus,omitempty"`
+	Host            string       `json:"host,omitempty"`
+	ConfigVersion   int          `json:"config_version"`
+	Tick            uint64       `json:"tick"`
+	StateHash       string       `json:"state_hash"`
+	NextEntityID    EntityID     `json:"next_entity_id,omitempty"`
+	FastForward     bool         `json:"fast_forward,omitempty"`
+	Difficulty      float64      `json:"difficulty,omitempty"`
+	PerfectStreak   int          `json:"perfect_streak"`
+
+	// Tutorial rooms only
+	Tutorial *TutorialProgress `json:"tutorial,omitempty"`
+}
+
+// Frame is the simulation part of the state, broadcast every tick. The
+// roster and settings are left out; they're sent as a Lobby when they
+// change.
+type Frame struct {
+	Towers          []Tower      `json:"towers"`
+	Enemies         []Enemy      `json:"enemies"`
+	Projectiles     []Projectile `json:"projectiles"`
+	Gold            int          `json:"gold"`
+	Health          int          `json:"health"`
+	Goals           []Goal       `json:"goals,omitempty"`
+	Wave            int          `json:"wave"`
+	GameTime        float64      `json:"game_time"`
+	ScoreMultiplier float64      `json:"score_multiplier"`
+	Score           Score        `json:"score"`
+	GameOver        bool         `json:"game_over"`
+	Victory         bool         `json:"victory,omitempty"`
+	Paused          bool         `json:"paused"`
+	Threat          float64      `json:"threat"`
+	Events          []GameEvent  `json:"events"`
+	Versus          *VersusState `json:"versus,omitempty"`
+	ConfigVersion   int          `json:"config_version"`
+	Tick            uint64       `json:"tick"`
+	StateHash       string       `json:"state_hash"`
+	FastForward     bool         `json:"fast_forward,omitempty"`
+	Difficulty      float64      `json:"difficulty,omitempty"`
+	PerfectStreak   int          `json:"perfect_streak"`
+
+	// Tutorial rooms only
+	Tutorial *TutorialProgress `json:"tutorial,omitempty"`
+}
+
+// WriteFrame encodes the simulation frame as JSON straight into buf, with
+// positions encoded at the given precision
+func (gs *GameStateWithShooting) WriteFrame(buf *bytes.Buffer, precision Precision) error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	frame := Frame{
+		Towers:          gs.Towers,
+		Enemies:         gs.Enemies,
+		Projectiles:     gs.Projectiles,
+		Gold:            gs.Gold,
+		Health:          gs.Health,
+		Goals:           gs.Goals,
+		Wave:            gs.Wave,
+		GameTime:        gs.GameTime,
+		ScoreMultiplier: gs.ScoreMultiplier,
+		Score:           gs.Score,
+		GameOver:        gs.GameOver,
+		Victory:         gs.Victory,
+		Paused:          gs.Paused,
+		Threat:          gs.Threat,
+		Events:          gs.Events,
+		Versus:          gs.Versus,
+		ConfigVersion:   gs.ConfigVersion,
+		Tick:            gs.Tick,
+		StateHash:       formatStateHash(gs.stateHash()),
+		FastForward:     gs.FastForward,
+		Difficulty:      gs.Difficulty,
+		PerfectStreak:   gs.PerfectStreak,
+		Tutorial:        gs.tutorial.progress(),
+	}
+	frame.quantize(precision)
+	return json.NewEncoder(buf).Encode(&frame)
+}
+
+// snapshot fills in a Snapshot that shares the room's slices. It's only
+// safe to use while the state lock is held. Callers must hold the state
+// lock.
+func (gs *GameStateWithShooting) snapshot() Snapshot {
+	s := Snapshot{
+		RoomID:          gs.RoomID,
+		Players:         gs.Players,
+		Towers:          gs.Towers,
+		Enemies:         gs.Enemies,
+		Projectiles:     gs.Projectiles,
+		Events:          gs.Events,
+		Gold:            gs.Gold,
+		Health:          gs.Health,
+		Wave:            gs.Wave,
+		GameTime:        gs.GameTime,
+		SpawnPoint:      gs.SpawnPoint,
+		GoalPoint:       gs.GoalPoint,
+		Walls:           gs.Walls,
+		Goals:           gs.Goals,
+		Config:          gs.Config,
+		ScoreMultiplier: gs.ScoreMultiplier,
+		Score:           gs.Score,
+		GameOver:        gs.GameOver,
+		Victory:         gs.Victory,
+		Paused:          gs.Paused,
+		Threat:          gs.Threat,
+		Versus:          gs.Versus,
+		Host:            gs.Host,
+		ConfigVersion:   gs.ConfigVersion,
+		Tick:            gs.Tick,
+		StateHash:       formatStateHash(gs.stateHash()),
+		FastForward:     gs.FastForward,
+		Difficulty:      gs.Difficulty,
+		PerfectStreak:   gs.PerfectStreak,
+		Tutorial:        gs.tutorial.progress(),
+	}
+	if gs.IsLockstep() {
+		s.NextEntityID = gs.ids.peek()
+	}
+	return s
+}
+
+// GetSnapshot returns a copy of the game state that's safe to keep
+func (gs *GameStateWithShooting) GetSnapshot() *Snapshot {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	snapshot := gs.snapshot()
+	snapshot.Players = append([]string{}, gs.Players...)
+	snapshot.Towers = append([]Tower{}, gs.Towers...)
+	snapshot.Enemies = append([]Enemy{}, gs.Enemies...)
+	for i := range snapshot.Enemies {
+		// Effects are updated in place, so the snapshot needs its own copy
+		snapshot.Enemies[i].Effects = append([]StatusEffect{}, snapshot.Enemies[i].Effects...)
+	}
+	snapshot.Projectiles = append([]Projectile{}, gs.Projectiles...)
+	snapshot.Goals = append([]Goal(nil), gs.Goals...)
+	snapshot.Events = append([]GameEvent{}, gs.Events...)
+	snapshot.Versus = copyVersus(gs.Versus)
+
+	return &snapshot
+}
+
+// WriteSnapshot encodes the state as JSON straight into buf, with
+// positions encoded at the given precision. Only the lists it quantizes
+// are copied. buf can be reused between ticks.
+func (gs *GameStateWithShooting) WriteSnapshot(buf *bytes.Buffer, precision Precision) error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	snapshot := gs.clientSnapshot(precision)
+	return json.NewEncoder(buf).Encode(&snapshot)
+}
+
+// restore rebuilds a room from a snapshot. Anything the snapshot doesn't
+// carry starts out as it does in a new room.
+func (s *Snapshot) restore() *GameStateWithShooting {
+	gs := NewGameStateWithShooting(s.RoomID, s.Config)
+	gs.Players = s.Players
+	gs.Towers = s.Towers
+	gs.Enemies = s.Enemies
+	gs.Projectiles = s.Projectiles
+	gs.Events = s.Events
+	gs.Gold = s.Gold
+	gs.Health = s.Health
+	gs.Wave = s.Wave
+	gs.GameTime = s.GameTime
+	gs.SpawnPoint = s.SpawnPoint
+	gs.GoalPoint = s.GoalPoint
+	gs.useWalls(s.Walls)
+	gs.Goals = s.Goals
+	gs.ScoreMultiplier = s.ScoreMultiplier
+	gs.Score = s.Score
+	gs.GameOver = s.GameOver
+	gs.Victory = s.Victory
+	gs.Paused = s.Paused
+	gs.Threat = s.Threat
+	gs.Versus = s.Versus
+	gs.Host = s.Host
+	gs.ConfigVersion = s.ConfigVersion
+	gs.Tick = s.Tick
+	gs.Difficulty = s.Difficulty
+	gs.PerfectStreak = s.PerfectStreak
+	return gs
+}
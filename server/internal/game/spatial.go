@@ -0,0 +1,82 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// spatialCellSize is the bucket size of the enemy spatial index, in grid cells
+const spatialCellSize = 2.0
+
+// spatialIndex buckets enemies by area so range queries only look at nearby
+// enemies instead of scanning the whole list. It stores indices into the
+// enemy slice, so it is only valid until that slice changes; it is rebuilt at
+// the start of the tower system each tick.
+type spatialIndex struct {
+	cellSize float64
+	cells    map[[2]int][]int
+}
+
+func newSpatialIndex(cellSize float64) spatialIndex {
+	return spatialIndex{
+		cellSize: cellSize,
+		cells:    make(map[[2]int][]int),
+	}
+}
+
+func (si *spatialIndex) cellOf(pos Position) [2]int {
+	return [2]int{
+		int(math.Floor(pos.X / si.cellSize)),
+		int(math.Floor(pos.Y / si.cellSize)),
+	}
+}
+
+// rebuild re-buckets every enemy
+func (si *spatialIndex) rebuild(enemies []Enemy) {
+	for key := range si.cells {
+		delete(si.cells, key)
+	}
+	for i := range enemies {
+		key := si.cellOf(enemies[i].Position)
+		si.cells[key] = append(si.cells[key], i)
+	}
+}
+
+// query returns the enemies within radius of pos, in slice order
+func (si *spatialIndex) query(enemies []Enemy, pos Position, radius float64) []*Enemy {
+	lo := si.cellOf(Position{X: pos.X - radius, Y: pos.Y - radius})
+	hi := si.cellOf(Position{X: pos.X + radius, Y: pos.Y + radius})
+
+	indices := make([]int, 0)
+	for cx := lo[0]; cx <= hi[0]; cx++ {
+		for cy := lo[1]; cy <= hi[1]; cy++ {
+			for _, i := range si.cells[[2]int{cx, cy}] {
+				if i < len(enemies) && distance(pos, enemies[i].Position) <= radius {
+					indices = append(indices, i)
+				}
+			}
+		}
+	}
+
+	// Keep slice order so results don't depend on map iteration
+	sort.Ints(indices)
+
+	found := make([]*Enemy, len(indices))
+	for n, i := range indices {
+		found[n] = &enemies[i]
+	}
+	return found
+}
+
+// nearest returns the closest enemy within radius of pos
+func (si *spatialIndex) nearest(enemies []Enemy, pos Position, radius float64) *Enemy {
+	var nearest *Enemy
+	minDist := math.MaxFloat64
+	for _, enemy := range si.query(enemies, pos, radius) {
+		if dist := distance(pos, enemy.Position); dist < minDist {
+			minDist = dist
+			nearest = enemy
+		}
+	}
+	return nearest
+}
@@ -4,6 +4,15 @@ import (
 	"fmt"
 	"math"
 	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Errors returned by game actions, matchable with errors.Is
+var (
+	ErrTowerNotAllowed  = i18n.NewError(i18n.ErrTowerNotAllowed, nil)
+	ErrInsufficientGold = i18n.NewError(i18n.ErrInsufficientGold, nil)
 )
 
 // Position represents a 2D coordinate
@@ -14,7 +23,7 @@ type Position struct {
 
 // Tower represents a defensive structure
 type Tower struct {
-	ID            int      `json:"id"`
+	ID            EntityID `json:"id"`
 	Position      Position `json:"position"`
 	TowerType     string   `json:"tower_type"`
 	Level         int      `json:"level"`
@@ -23,87 +32,217 @@ type Tower struct {
 	FireRate      float64  `json:"fire_rate"`                // shots per second
 	Cooldown      float64  `json:"cooldown"`                 // time until next shot
 	Rotation      float64  `json:"rotation"`                 // radians, for rendering
-	CurrentTarget int      `json:"current_target,omitempty"` // enemy ID being targeted
+	CurrentTarget EntityID `json:"current_target,omitempty"` // enemy ID being targeted
+	OwnerID       string   `json:"owner_id,omitempty"`       // player who built it
+	OwnerName     string   `json:"owner_name,omitempty"`     // builder's display name
+	OwnerColor    string   `json:"owner_color,omitempty"`    // builder's player color
+	Cosmetic      string   `json:"cosmetic,omitempty"`       // skin chosen by the builder
+	Kills         int      `json:"kills"`
+	BuildTime     float64  `json:"build_time"`     // seconds to construct
+	BuildProgress float64  `json:"build_progress"` // 0..1, 1 when built
+	TargetMode    string   `json:"target_mode"`    // nearest, first or last
+	State         string   `json:"state"`          // lifecycle state
+	StateTimer    float64  `json:"state_timer"`    // seconds left in current state
+	invested      int      // gold spent on this tower, for sell refunds
+	sold          bool
+
+	Branches    []string `json:"branches,omitempty"`     // upgrade branches taken, in order
+	ArmorPierce float64  `json:"armor_pierce,omitempty"` // share of enemy armor ignored
 }
 
 // Enemy represents a hostile unit
 type Enemy struct {
-	ID        int        `json:"id"`
+	ID        EntityID   `json:"id"`
 	Position  Position   `json:"position"`
 	EnemyType string     `json:"enemy_type"`
 	Health    float64    `json:"health"`
 	MaxHealth float64    `json:"max_health"`
 	Speed     float64    `json:"speed"`
+	Armor     float64    `json:"armor,omitempty"`
 	Path      []Position `json:"path,omitempty"`
 	PathIndex int        `json:"path_index"`
+
+	AbilityCooldown float64        `json:"ability_cooldown,omitempty"` // seconds until ability is ready
+	Progress        float64        `json:"progress"`                   // 0..1 along the path to the goal
+	Effects         []StatusEffect `json:"effects,omitempty"`          // active damage over time
+	SenderID        string         `json:"sender_id,omitempty"`        // versus: player who sent it
+	TargetPlayer    string         `json:"target_player,omitempty"`    // versus: player it attacks
+	Pack            string         `json:"pack,omitempty"`             // formation it moves with
+	PackSlot        int            `json:"pack_slot,omitempty"`        // place in the formation, 0 at the front
+
+	distanceTraveled float64
+	spawnedAt        *Position // where it entered the map, nil if not known
+	lastHitBy        EntityID  // tower credited with the kill
+	pendingDamage    float64   // carried by projectiles flying at it
+	trail            trail     // recent positions, for actions aimed at the past
 }
 
 // Projectile represents a bullet/missile
 type Projectile struct {
-	ID       int      `json:"id"`
-	Position Position `json:"position"`
-	TargetID int      `json:"target_id"` // enemy ID
-	Speed    float64  `json:"speed"`
-	Damage   float64  `json:"damage"`
-	TowerID  int      `json:"tower_id"`
-}
-
-// MuzzleFlash represents a visual effect when tower shoots
-type MuzzleFlash struct {
-	ID       int      `json:"id"`
-	Position Position `json:"position"`
-	Duration float64  `json:"duration"` // seconds remaining
+	ID             EntityID `json:"id"`
+	Position       Position `json:"position"`
+	TargetID       EntityID `json:"target_id"`       // enemy ID, 0 once the target is gone
+	TargetPosition Position `json:"target_position"` // last known target position
+	Speed          float64  `json:"speed"`
+	Damage         float64  `json:"damage"`
+	SplashRadius   float64  `json:"splash_radius,omitempty"`
+	TowerID        EntityID `json:"tower_id"`
+	Cosmetic       string   `json:"cosmetic,omitempty"` // the firing tower's skin
+	Age            float64  `json:"age"`                // seconds since fired
+	Merged         int      `json:"merged,omitempty"`   // shots folded in at the projectile cap
+
+	dot     *dotSpec // damage over time applied on hit
+	pending float64  // what it adds to its target's pendingDamage
 }
 
-// Explosion represents a visual effect when projectile hits
-type Explosion struct {
-	ID       int      `json:"id"`
-	Position Position `json:"position"`
-	Duration float64  `json:"duration"` // seconds remaining
-	Radius   float64  `json:"radius"`
-}
+// Projectile tuning
+const (
+	projectileHitRadius   = 0.3
+	maxProjectileLifetime = 5.0 // seconds
+	impactEffectRadius    = 0.5
+)
 
 // GameStateWithShooting extends GameState with shooting mechanics
 type GameStateWithShooting struct {
-	RoomID           string        `json:"room_id"`
-	Players          []string      `json:"players"`
-	Towers           []Tower       `json:"towers"`
-	Enemies          []Enemy       `json:"enemies"`
-	Projectiles      []Projectile  `json:"projectiles"`
-	MuzzleFlashes    []MuzzleFlash `json:"muzzle_flashes"`
-	Explosions       []Explosion   `json:"explosions"`
-	Gold             int           `json:"gold"`
-	Health           int           `json:"health"`
-	Wave             int           `json:"wave"`
-	GameTime         float64       `json:"game_time"`
-	SpawnPoint       *Position     `json:"spawn_point,omitempty"`
-	GoalPoint        *Position     `json:"goal_point,omitempty"`
-	mu               sync.RWMutex
-	nextTowerID      int
-	nextEnemyID      int
-	nextProjectileID int
-	nextEffectID     int
+	RoomID          string       `json:"room_id"`
+	Players         []string     `json:"players"`
+	Towers          []Tower      `json:"towers"`
+	Enemies         []Enemy      `json:"enemies"`
+	Projectiles     []Projectile `json:"projectiles"`
+	Gold            int          `json:"gold"`
+	Health          int          `json:"health"`
+	Wave            int          `json:"wave"`
+	GameTime        float64      `json:"game_time"`
+	SpawnPoint      *Position    `json:"spawn_point,omitempty"`
+	GoalPoint       *Position    `json:"goal_point,omitempty"`
+	Walls           []Wall       `json:"walls,omitempty"`
+	Goals           []Goal       `json:"goals,omitempty"`
+	Config          RoomConfig   `json:"config"`
+	ScoreMultiplier float64      `json:"score_multiplier"`
+	Score           Score        `json:"score"`
+	GameOver        bool         `json:"game_over"`
+	Victory         bool         `json:"victory,omitempty"` // campaign level cleared
+	Paused          bool         `json:"paused"`
+	Threat          float64      `json:"threat"` // progress of the most advanced enemy
+	Events          []GameEvent  `json:"events"`
+	Versus          *VersusState `json:"versus,omitempty"`
+	Host            string       `json:"host,omitempty"`
+	ConfigVersion   int          `json:"config_version"` // balance version the room is running
+	Tick            uint64       `json:"tick"`
+	StateHash       string       `json:"state_hash"`               // lets predicting clients detect a desync
+	NextEntityID    EntityID     `json:"next_entity_id,omitempty"` // lockstep only, so clients allocate the same IDs
+	FastForward     bool         `json:"fast_forward,omitempty"`   // the clock is running ahead through an empty wait
+	Difficulty      float64      `json:"difficulty,omitempty"`     // enemy health multiplier, with auto difficulty
+	PerfectStreak   int          `json:"perfect_streak"`           // waves cleared in a row without a leak
+	mu              sync.RWMutex
+	mods            modifiers
+	ids             idAllocator
+	finished        bool
+	unsentGameOver  []byte     // match result the broadcast channel had no room for
+	buildQueue      []EntityID // tower IDs waiting for construction
+	wavesStarted    int
+	joinCode        string
+	pendingBalance  *Balance      // applied at the next wave boundary
+	pendingInputs   []InputRecord // lockstep inputs not yet broadcast
+	keyframeFor     []string      // players waiting on a lockstep keyframe
+	lastFrameTick   uint64
+	recentEvents    []GameEvent // notable events for late joiners
+	waveScript      *WaveScript // nil when waves are sent manually
+	pendingSpawns   []scheduledSpawn
+	skipWait        bool           // the host skipped to the next spawn
+	waveResult      waveResult     // how the current wave is going, for auto difficulty
+	waveStartHealth int            // health when the current wave began
+	eased           bool           // spawns have been staggered for congestion
+	lastEased       float64        // game time of the last stagger
+	campaignLevel   *CampaignLevel // nil outside campaign rooms
+	tutorial        *tutorialRun   // nil outside tutorial rooms
+	milestones      []Milestone    // this run, for racing against later
+	ghost           *GhostRun      // the run this room races against
+	ghostNext       int
+	auditLog        *auditLog     // only set in debug rooms
+	analyzing       bool          // an analyst is watching, so decisions are kept
+	decisions       []AuditRecord // this tick's decisions, for the analysis stream
+	analyzedTick    uint64
+	enemyIndex      spatialIndex
+	commands        commandQueue
+	appliedCommands map[string]commandResult // keyed commands, for retries
+	appliedOrder    []string
+	ready           map[string]bool // lobby ready flags by player
+	lobbyVersion    uint64          // bumped whenever the lobby changes
+	lobbySent       uint64
+	ledger          []LedgerEntry             // recent gold changes
+	economy         map[string]*PlayerEconomy // gold totals by player
+	samples         []TelemetrySample         // once a second, for telemetry export
+	eventLog        []GameEvent               // gameplay events, for telemetry export
+	names           map[string]string         // display names, unique in the room
+	colors          map[string]string         // player colors, unique in the room
+	kicked          map[string]bool           // players the host removed, who can't rejoin
+	joined          map[string]bool           // everyone who has ever been in the room
+	returning       map[string]bool           // players of an adopted room yet to reconnect
+	dropInVetoed    bool                      // the host closed the room to drop-ins
+	latency         map[string]time.Duration  // measured one-way latency by player
+	checkpoints     []practiceCheckpoint      // practice rooms only, oldest first
+	built           map[string]int            // towers placed by type, for balance stats
+	towerStats      map[EntityID]*TowerStats  // shots, overkill and waste by tower
+	wallIndex       map[[2]int]Wall           // Walls by cell
+	pollMu          sync.Mutex                // guards the keyframe cached for pollers
+	polled          []byte
+	polledAt        time.Time
+	travel          []travelCheckpoint // debug rooms only, oldest first
+	replay          []replayStep       // actions since the oldest checkpoint
+	surrenderVotes  map[string]bool    // players who voted to give up this game
+	forfeited       bool               // a side gave up; back to the lobby once recorded
+	waveSpawned     int                // enemies spawned since the last wave was settled
+	waveLeaks       int                // and how many of them leaked
+	settledWave     int                // the last wave judged for a perfect clear
+	demo            *demoPilot         // the bot playing the demo room, nil elsewhere
+	wal             *walWriter         // nil unless the manager logs rooms to disk
+	instance        uint64             // tells it apart from other rooms that had its ID
+	walGap          bool               // the log missed a record; checkpoint on the next tick
 }
 
+// What every room begins with
+const (
+	startingHealth = 100
+	startingGold   = 200
+)
+
 // NewGameStateWithShooting creates a new game state
-func NewGameStateWithShooting(roomID string) *GameStateWithShooting {
-	return &GameStateWithShooting{
-		RoomID:           roomID,
-		Players:          make([]string, 0),
-		Towers:           make([]Tower, 0),
-		Enemies:          make([]Enemy, 0),
-		Projectiles:      make([]Projectile, 0),
-		MuzzleFlashes:    make([]MuzzleFlash, 0),
-		Explosions:       make([]Explosion, 0),
-		Gold:             200,
-		Health:           100,
-		Wave:             1,
-		GameTime:         0,
-		nextTowerID:      1,
-		nextEnemyID:      1,
-		nextProjectileID: 1,
-		nextEffectID:     1,
+func NewGameStateWithShooting(roomID string, config RoomConfig) *GameStateWithShooting {
+	if config.Seed == 0 {
+		config.Seed = newSeed()
 	}
+	state := &GameStateWithShooting{
+		RoomID:          roomID,
+		Players:         make([]string, 0),
+		Towers:          make([]Tower, 0),
+		Enemies:         make([]Enemy, 0),
+		Projectiles:     make([]Projectile, 0),
+		Events:          make([]GameEvent, 0),
+		Gold:            startingGold,
+		Health:          startingHealth,
+		Wave:            1,
+		GameTime:        0,
+		Config:          config,
+		ScoreMultiplier: config.ScoreMultiplier(),
+		mods:            buildModifiers(config, defaultBalance()),
+		ids:             newIDAllocator(config.Seed),
+		enemyIndex:      newSpatialIndex(spatialCellSize),
+		joinCode:        newJoinCode(),
+		waveStartHealth: startingHealth,
+	}
+
+	if config.Mode == ModeVersus {
+		state.Versus = &VersusState{Players: make([]VersusPlayer, 0)}
+	}
+	if config.AutoDifficulty {
+		state.Difficulty = 1.0
+	}
+	if config.Debug {
+		state.auditLog = newAuditLog(auditCapacity)
+	}
+
+	return state
 }
 
 // Update runs game logic for one frame (60 FPS = ~16.67ms per frame)
@@ -111,38 +250,92 @@ func (gs *GameStateWithShooting) Update(deltaTime float64) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
-	gs.GameTime += deltaTime
+	// Player actions land between ticks, including unpausing
+	gs.applyCommands()
+	gs.updateTutorial()
 
-	// Update towers (cooldowns, targeting, shooting)
-	gs.updateTowers(deltaTime)
+	// A forfeit is shown as the final frame until it's recorded
+	if gs.forfeited && gs.finished {
+		gs.returnToLobby()
+	}
 
-	// Update projectiles (movement, collision)
-	gs.updateProjectiles(deltaTime)
+	if gs.GameOver || gs.Paused {
+		return
+	}
 
-	// Update enemies (movement, health)
-	gs.updateEnemies(deltaTime)
+	gs.Tick++
+	deltaTime = gs.fastForward(deltaTime)
+	gs.GameTime += deltaTime
+	gs.Events = make([]GameEvent, 0)
+
+	// Run each simulation system: towers, projectiles, enemies
+	for _, sys := range systems {
+		sys.update(gs, deltaTime)
+	}
+
+	if gs.isVersus() {
+		gs.updateVersus()
+	} else if gs.Health <= 0 {
+		gs.Health = 0
+		gs.GameOver = true
+	}
+	gs.checkLevelComplete()
+	if gs.GameOver {
+		gs.lobbyChanged() // no longer in progress
+	}
 
-	// Update visual effects (decay)
-	gs.updateEffects(deltaTime)
+	gs.Score.recalculate(gs.Wave, gs.GameTime, gs.ScoreMultiplier)
+	gs.sampleTelemetry()
+	gs.takeCheckpoint()
+	gs.takeTravelCheckpoint()
+	gs.takeWALCheckpoint()
 }
 
 // updateTowers handles tower logic
 func (gs *GameStateWithShooting) updateTowers(deltaTime float64) {
+	gs.enemyIndex.rebuild(gs.Enemies)
+
+	banks := 0
 	for i := range gs.Towers {
 		tower := &gs.Towers[i]
 
+		// Only active towers can shoot
+		if !gs.updateTowerState(tower, deltaTime) {
+			continue
+		}
+
 		// Reduce cooldown
 		if tower.Cooldown > 0 {
 			tower.Cooldown -= deltaTime
 		}
 
+		// Banks make gold instead of shooting
+		if stats := gs.mods.towerStats(tower.TowerType); stats.Attack == attackIncome {
+			gs.updateBank(tower, stats, banks)
+			banks++
+			continue
+		}
+
 		// Find target
-		target := gs.findNearestEnemy(tower.Position, tower.Range)
+		target := gs.findTarget(tower)
 		if target == nil {
+			if tower.CurrentTarget != 0 {
+				gs.audit(AuditNoTarget, tower.ID, tower.CurrentTarget, map[string]interface{}{
+					"range":       tower.Range,
+					"target_mode": tower.TargetMode,
+				})
+			}
 			tower.CurrentTarget = 0
 			continue
 		}
 
+		if tower.CurrentTarget != target.ID {
+			gs.audit(AuditTarget, tower.ID, target.ID, map[string]interface{}{
+				"target_mode": tower.TargetMode,
+				"distance":    distance(tower.Position, target.Position),
+				"progress":    target.Progress,
+			})
+		}
 		tower.CurrentTarget = target.ID
 
 		// Update rotation to face target
@@ -152,36 +345,25 @@ func (gs *GameStateWithShooting) updateTowers(deltaTime float64) {
 
 		// Shoot if ready
 		if tower.Cooldown <= 0 {
-			gs.shootProjectile(tower, target)
+			gs.audit(AuditFire, tower.ID, target.ID, map[string]interface{}{
+				"damage":    tower.Damage,
+				"cooldown":  tower.Cooldown,
+				"fire_rate": tower.FireRate,
+			})
+			gs.fire(tower, target)
 			tower.Cooldown = 1.0 / tower.FireRate
 
-			// Create muzzle flash effect
-			gs.MuzzleFlashes = append(gs.MuzzleFlashes, MuzzleFlash{
-				ID:       gs.nextEffectID,
-				Position: tower.Position,
-				Duration: 0.1, // 100ms flash
+			// Muzzle flash is purely visual, so it is sent as an event
+			pos := tower.Position
+			gs.emitEvent(EventMuzzleFlash, &pos, map[string]interface{}{
+				"tower_id": tower.ID,
+				"duration": 0.1, // 100ms flash
 			})
-			gs.nextEffectID++
 		}
 	}
-}
-
-// findNearestEnemy finds the closest enemy within range
-func (gs *GameStateWithShooting) findNearestEnemy(pos Position, maxRange float64) *Enemy {
-	var nearest *Enemy
-	minDist := math.MaxFloat64
 
-	for i := range gs.Enemies {
-		enemy := &gs.Enemies[i]
-		dist := distance(pos, enemy.Position)
-
-		if dist <= maxRange && dist < minDist {
-			minDist = dist
-			nearest = enemy
-		}
-	}
-
-	return nearest
+	// Drop towers that finished selling
+	gs.removeSoldTowers()
 }
 
 // shootProjectile creates a new projectile
@@ -192,61 +374,63 @@ func (gs *GameStateWithShooting) shootProjectile(tower *Tower, target *Enemy) {
 		speed = 12.0
 	}
 
+	stats := gs.mods.towerStats(tower.TowerType)
 	projectile := Projectile{
-		ID:       gs.nextProjectileID,
-		Position: tower.Position,
-		TargetID: target.ID,
-		Speed:    speed,
-		Damage:   tower.Damage,
-		TowerID:  tower.ID,
+		ID:             gs.ids.Next(),
+		Position:       tower.Position,
+		TargetID:       target.ID,
+		TargetPosition: target.Position,
+		Speed:          speed,
+		Damage:         tower.Damage,
+		SplashRadius:   stats.SplashRadius,
+		TowerID:        tower.ID,
+		Cosmetic:       tower.Cosmetic,
+		dot:            stats.Dot,
 	}
 
+	if len(gs.Projectiles) >= gs.Config.caps().Projectiles {
+		gs.mergeProjectile(projectile, target)
+		return
+	}
+	gs.addPending(target, &projectile, projectile.Damage)
 	gs.Projectiles = append(gs.Projectiles, projectile)
-	gs.nextProjectileID++
 }
 
 // updateProjectiles moves projectiles and checks collisions
 func (gs *GameStateWithShooting) updateProjectiles(deltaTime float64) {
-	activeProjectiles := make([]Projectile, 0)
-
-	for i := range gs.Projectiles {
-		proj := &gs.Projectiles[i]
-
-		// Find target enemy
-		var target *Enemy
-		for j := range gs.Enemies {
-			if gs.Enemies[j].ID == proj.TargetID {
-				target = &gs.Enemies[j]
-				break
-			}
+	gs.Projectiles = updateEach(gs.Projectiles, func(proj *Projectile) bool {
+		// Drop projectiles that have been flying too long
+		proj.Age += deltaTime
+		if proj.Age > maxProjectileLifetime {
+			releasePending(findByID(gs.Enemies, proj.TargetID), proj)
+			gs.towerStatsFor(proj.TowerID).WastedShots++
+			return false
 		}
 
-		// Remove projectile if target is gone
-		if target == nil {
-			continue
+		// Track the target while it's alive; once it's gone, keep flying
+		// to where it was last seen, unless smart projectiles find another
+		target := findByID(gs.Enemies, proj.TargetID)
+		if target == nil && gs.Config.SmartShots && proj.SplashRadius == 0 {
+			target = gs.retargetProjectile(proj)
+		}
+		if target != nil {
+			proj.TargetPosition = target.Position
+		} else {
+			proj.TargetID = 0
 		}
 
 		// Move projectile toward target
-		dx := target.Position.X - proj.Position.X
-		dy := target.Position.Y - proj.Position.Y
+		dx := proj.TargetPosition.X - proj.Position.X
+		dy := proj.TargetPosition.Y - proj.Position.Y
 		dist := math.Sqrt(dx*dx + dy*dy)
 
 		// Check if hit
-		if dist < 0.3 { // Hit radius
-			// Deal damage
-			target.Health -= proj.Damage
-
-			// Create explosion effect
-			gs.Explosions = append(gs.Explosions, Explosion{
-				ID:       gs.nextEffectID,
-				Position: proj.Position,
-				Duration: 0.3, // 300ms explosion
-				Radius:   0.5,
-			})
-			gs.nextEffectID++
+		if dist < projectileHitRadius {
+			releasePending(target, proj)
+			gs.impactProjectile(proj, target)
 
 			// Don't keep this projectile
-			continue
+			return false
 		}
 
 		// Move projectile
@@ -257,30 +441,99 @@ func (gs *GameStateWithShooting) updateProjectiles(deltaTime float64) {
 				ratio = 1.0
 			}
 
+			from := proj.Position
 			proj.Position.X += dx * ratio
 			proj.Position.Y += dy * ratio
+
+			// Tall walls stop projectiles in rooms with solid terrain
+			if gs.Config.SolidTerrain && len(gs.wallIndex) > 0 {
+				if at, clear := Raycast(from, proj.Position, gs.tallAt); !clear {
+					proj.Position = at
+					releasePending(target, proj)
+					gs.impactProjectile(proj, nil)
+					return false
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+// impactProjectile applies a projectile's damage where it lands. Splash
+// projectiles damage every enemy in their radius; others hit their target,
+// or whichever enemy is at the impact point if the target died mid-flight.
+func (gs *GameStateWithShooting) impactProjectile(proj *Projectile, target *Enemy) {
+	var victims []*Enemy
+
+	switch {
+	case proj.SplashRadius > 0:
+		victims = inRange(gs.Enemies, proj.Position, proj.SplashRadius)
+
+	case target != nil:
+		victims = []*Enemy{target}
+
+	default:
+		if enemy := nearestInRange(gs.Enemies, proj.Position, projectileHitRadius); enemy != nil {
+			victims = []*Enemy{enemy}
 		}
+	}
 
-		activeProjectiles = append(activeProjectiles, *proj)
+	if len(victims) == 0 && target == nil {
+		gs.towerStatsFor(proj.TowerID).WastedShots++
 	}
 
-	gs.Projectiles = activeProjectiles
+	hit := make([]EntityID, 0, len(victims))
+	for _, enemy := range victims {
+		gs.damageEnemy(enemy, proj.Damage, proj.TowerID)
+		if proj.dot != nil {
+			applyDot(enemy, proj.dot, proj.TowerID)
+		}
+		hit = append(hit, enemy.ID)
+	}
+
+	radius := impactEffectRadius
+	if proj.SplashRadius > radius {
+		radius = proj.SplashRadius
+	}
+
+	pos := proj.Position
+	gs.emitEvent(EventImpact, &pos, map[string]interface{}{
+		"projectile_id": proj.ID,
+		"tower_id":      proj.TowerID,
+		"enemy_ids":     hit,
+		"damage":        proj.Damage,
+		"duration":      0.3, // 300ms explosion
+		"radius":        radius,
+	})
 }
 
 // updateEnemies moves enemies along paths and removes dead ones
 func (gs *GameStateWithShooting) updateEnemies(deltaTime float64) {
-	aliveEnemies := make([]Enemy, 0)
+	gs.Threat = 0
+	packs := gs.packStates()
+	endWave := false
 
-	for i := range gs.Enemies {
-		enemy := &gs.Enemies[i]
+	gs.Enemies = updateEach(gs.Enemies, func(enemy *Enemy) bool {
+		// Apply damage over time before checking for death
+		gs.updateStatusEffects(enemy, deltaTime)
 
 		// Remove if dead
 		if enemy.Health <= 0 {
-			// Award gold
-			gs.Gold += 10
-			continue
+			// Award gold to the owner of the tower that landed the last hit
+			gs.adjustGold(GoldKill, gs.mods.killGold(), gs.towerOwner(enemy.lastHitBy), enemy.ID)
+			gs.Score.recordKill(enemy.EnemyType)
+			gs.creditKill(enemy)
+			gs.audit(AuditEnemyKilled, enemy.lastHitBy, enemy.ID, nil)
+			if gs.isVersus() {
+				gs.versusKill(enemy)
+			}
+			return false
 		}
 
+		// Use special abilities
+		gs.updateEnemyAbility(enemy, deltaTime)
+
 		// Move enemy along path
 		if enemy.Path != nil && len(enemy.Path) > 0 {
 			if enemy.PathIndex < len(enemy.Path) {
@@ -304,7 +557,7 @@ func (gs *GameStateWithShooting) updateEnemies(deltaTime float64) {
 
 				// Move toward target
 				if distance > 0 && enemy.PathIndex < len(enemy.Path) {
-					moveDistance := enemy.Speed * deltaTime
+					moveDistance := paceOf(enemy, packs) * deltaTime
 					ratio := moveDistance / distance
 					if ratio > 1.0 {
 						ratio = 1.0
@@ -312,235 +565,353 @@ func (gs *GameStateWithShooting) updateEnemies(deltaTime float64) {
 
 					enemy.Position.X += dx * ratio
 					enemy.Position.Y += dy * ratio
+					enemy.distanceTraveled += distance * ratio
 				}
 			}
 		}
+		enemy.trail.record(gs.GameTime, enemy.Position)
 
 		// Only keep enemies that haven't reached the end
 		if enemy.PathIndex < len(enemy.Path) {
-			aliveEnemies = append(aliveEnemies, *enemy)
-		} else {
-			// Enemy reached goal - player loses health
-			gs.Health -= 10
+			updateProgress(enemy)
+			if enemy.Progress > gs.Threat {
+				gs.Threat = enemy.Progress
+			}
+			return true
 		}
+
+		// Enemy reached goal - player loses health
+		keep, end := gs.leak(enemy)
+		endWave = endWave || end
+		return keep
+	})
+
+	if endWave {
+		gs.endWaveEarly()
 	}
+}
 
-	gs.Enemies = aliveEnemies
+// AddTower adds a tower to the game and charges its cost. New towers start
+// out constructing; while paused or between waves they join the build queue
+// and are constructed one at a time, with their gold reserved up front.
+func (gs *GameStateWithShooting) AddTower(x, y float64, towerType, ownerID string) (Tower, error) {
+	result, err := gs.ApplyCommand(PlaceTower{X: x, Y: y, TowerType: towerType, OwnerID: ownerID})
+	tower, _ := result.(Tower)
+	return tower, err
 }
 
-// updateEffects decays visual effects
-func (gs *GameStateWithShooting) updateEffects(deltaTime float64) {
-	// Update muzzle flashes
-	activeFlashes := make([]MuzzleFlash, 0)
-	for i := range gs.MuzzleFlashes {
-		flash := &gs.MuzzleFlashes[i]
-		flash.Duration -= deltaTime
-		if flash.Duration > 0 {
-			activeFlashes = append(activeFlashes, *flash)
-		}
+// addTower places a tower. Callers must hold the state lock.
+func (gs *GameStateWithShooting) addTower(x, y float64, towerType, ownerID, cosmetic string) (Tower, error) {
+	if !gs.mods.towerAllowed(towerType) {
+		return Tower{}, i18n.NewError(i18n.ErrTowerNotAllowed, map[string]interface{}{
+			"tower_type": towerType,
+		})
 	}
-	gs.MuzzleFlashes = activeFlashes
 
-	// Update explosions
-	activeExplosions := make([]Explosion, 0)
-	for i := range gs.Explosions {
-		explosion := &gs.Explosions[i]
-		explosion.Duration -= deltaTime
-		if explosion.Duration > 0 {
-			activeExplosions = append(activeExplosions, *explosion)
-		}
+	if err := gs.checkTowerCap(); err != nil {
+		return Tower{}, err
 	}
-	gs.Explosions = activeExplosions
-}
 
-// AddTower adds a tower to the game
-func (gs *GameStateWithShooting) AddTower(x, y float64, towerType string) Tower {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
+	if gs.wallAt(Position{X: x, Y: y}) {
+		return Tower{}, i18n.NewError(i18n.ErrWallCell, map[string]interface{}{"x": x, "y": y})
+	}
 
-	// Tower stats based on type
-	stats := getTowerStats(towerType)
+	// Tower stats based on type, after room mutators
+	stats := gs.mods.towerStats(towerType)
+	if gs.Gold < stats.Cost {
+		return Tower{}, i18n.NewError(i18n.ErrInsufficientGold, map[string]interface{}{
+			"cost": stats.Cost,
+			"gold": gs.Gold,
+		})
+	}
+	id := gs.ids.Next()
+	gs.adjustGold(GoldTowerBuilt, -stats.Cost, ownerID, id)
+	if gs.built == nil {
+		gs.built = make(map[string]int)
+	}
+	gs.built[towerType]++
 
 	tower := Tower{
-		ID:        gs.nextTowerID,
-		Position:  Position{X: x, Y: y},
-		TowerType: towerType,
-		Level:     1,
-		Range:     stats.Range,
-		Damage:    stats.Damage,
-		FireRate:  stats.FireRate,
-		Cooldown:  0,
-		Rotation:  0,
+		ID:         id,
+		Position:   Position{X: x, Y: y},
+		TowerType:  towerType,
+		OwnerID:    ownerID,
+		OwnerName:  gs.names[ownerID],
+		OwnerColor: gs.colors[ownerID],
+		Cosmetic:   cosmetic,
+		Level:      1,
+		Range:      stats.Range,
+		Damage:     stats.Damage,
+		FireRate:   stats.FireRate,
+		Cooldown:   0,
+		Rotation:   0,
+		TargetMode: TargetNearest,
+		BuildTime:  stats.BuildTime,
+		State:      TowerStateConstructing,
+		StateTimer: stats.BuildTime,
+		invested:   stats.Cost,
+	}
+
+	if stats.Attack == attackIncome {
+		tower.Cooldown = 1.0 / tower.FireRate // banks pay out a full interval after they're built
+	}
+
+	if gs.canQueueBuilds() {
+		gs.buildQueue = append(gs.buildQueue, tower.ID)
 	}
 
 	gs.Towers = append(gs.Towers, tower)
-	gs.nextTowerID++
 
 	// Recalculate paths for all active enemies
 	gs.RecalculateEnemyPaths()
 
-	return tower
+	gs.recordInput(InputPlaceTower, map[string]interface{}{
+		"x":          x,
+		"y":          y,
+		"tower_type": towerType,
+		"owner_id":   ownerID,
+		"cosmetic":   cosmetic,
+	})
+	gs.recordMilestone(Milestone{Kind: MilestoneTowerPlaced, TowerType: towerType, Position: &tower.Position})
+
+	return tower, nil
 }
 
 // AddEnemy adds an enemy to the game
-func (gs *GameStateWithShooting) AddEnemy(enemyType string, path []Position) Enemy {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
+func (gs *GameStateWithShooting) AddEnemy(enemyType string, path []Position) (enemy Enemy) {
+	gs.exec(func() {
+		gs.recordInput(InputSpawnEnemy, map[string]interface{}{
+			"enemy_type": enemyType,
+			"path":       path,
+			"count":      1,
+		})
+		gs.logStep(func(r *GameStateWithShooting) { r.addEnemy(enemyType, path, "") })
+		gs.logCommand(addEnemyCommand{EnemyType: enemyType, Path: path})
+		enemy = gs.addEnemy(enemyType, path, "")
+	})
+	return enemy
+}
 
-	stats := getEnemyStats(enemyType)
+// addEnemy adds an enemy sent by senderID. In versus mode it attacks the
+// sender's opponent. Callers must hold the state lock.
+func (gs *GameStateWithShooting) addEnemy(enemyType string, path []Position, senderID string) Enemy {
+	stats := gs.mods.enemyStats(enemyType)
+	if gs.Difficulty > 0 {
+		stats.Health *= gs.Difficulty
+	}
 
 	enemy := Enemy{
-		ID:        gs.nextEnemyID,
+		ID:        gs.ids.Next(),
 		Position:  path[0],
 		EnemyType: enemyType,
 		Health:    stats.Health,
 		MaxHealth: stats.Health,
 		Speed:     stats.Speed,
+		Armor:     stats.Armor,
 		Path:      path,
 		PathIndex: 0,
 	}
+	if stats.Ability != nil {
+		enemy.AbilityCooldown = stats.Ability.Cooldown
+	}
+	spawnedAt := path[0]
+	enemy.spawnedAt = &spawnedAt
+	enemy.trail.record(gs.GameTime, enemy.Position)
+	if gs.isVersus() && senderID != "" {
+		enemy.SenderID = senderID
+		if opponent := gs.opponentOf(senderID); opponent != nil {
+			enemy.TargetPlayer = opponent.PlayerID
+		}
+	}
 
 	gs.Enemies = append(gs.Enemies, enemy)
-	gs.nextEnemyID++
+	gs.waveSpawned++
 
 	return enemy
 }
 
+// SpawnEnemy adds as many enemies as the room's mutators call for, sent by
+// the given player. A client-provided path is only used if it's a valid
+// walk from the spawn point to the goal; otherwise the server computes one.
+func (gs *GameStateWithShooting) SpawnEnemy(senderID, enemyType string, path []Position) []Enemy {
+	result, _ := gs.ApplyCommand(SpawnEnemy{SenderID: senderID, EnemyType: enemyType, Path: path})
+	enemies, _ := result.([]Enemy)
+	return enemies
+}
+
+// spawnEnemy sends enemies. Callers must hold the state lock.
+func (gs *GameStateWithShooting) spawnEnemy(senderID, enemyType string, path []Position) []Enemy {
+	path = gs.spawnPath(path)
+	if path == nil {
+		return nil
+	}
+
+	count := gs.mods.enemiesPerSpawn(enemyType)
+	gs.recordInput(InputSpawnEnemy, map[string]interface{}{
+		"sender_id":  senderID,
+		"enemy_type": enemyType,
+		"path":       path,
+		"count":      count,
+	})
+
+	// Sends past the enemy cap enter once there's room
+	if !gs.enemyRoom(count) {
+		gs.deferSpawn(senderID, enemyType, path[0])
+		return nil
+	}
+
+	enemies := make([]Enemy, 0, count)
+	for i := 0; i < count; i++ {
+		enemies = append(enemies, gs.addEnemy(enemyType, path, senderID))
+	}
+	return enemies
+}
+
+// StartWave begins the next wave and returns its number
+func (gs *GameStateWithShooting) StartWave() int {
+	result, _ := gs.ApplyCommand(StartWave{})
+	wave, _ := result.(int)
+	return wave
+}
+
+// startWave begins the next wave. Callers must hold the state lock.
+func (gs *GameStateWithShooting) startWave() int {
+	if gs.wavesStarted > 0 {
+		gs.adjustDifficulty()
+		gs.Wave++
+	} else {
+		gs.lobbyChanged() // now in progress
+	}
+	gs.wavesStarted++
+	gs.recordInput(InputStartWave, map[string]interface{}{"wave": gs.Wave})
+	gs.applyPendingBalance()
+	gs.spawnBossWave(gs.Wave)
+	gs.queueWave(gs.Wave)
+	gs.recordMilestone(Milestone{Kind: MilestoneWaveStarted, Wave: gs.Wave})
+
+	return gs.Wave
+}
+
 // RemoveAllTowers clears all towers
 func (gs *GameStateWithShooting) RemoveAllTowers() {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
+	gs.ApplyCommand(ClearTowers{})
+}
 
+// removeAllTowers clears all towers. Callers must hold the state lock.
+func (gs *GameStateWithShooting) removeAllTowers() {
 	gs.Towers = make([]Tower, 0)
 	gs.Projectiles = make([]Projectile, 0)
+	gs.buildQueue = nil
+	gs.recordInput(InputClearTowers, nil)
 }
 
 // RemoveAllEnemies clears all enemies
 func (gs *GameStateWithShooting) RemoveAllEnemies() {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
+	gs.ApplyCommand(ClearEnemies{})
+}
 
+// removeAllEnemies clears all enemies. Enemies cleared rather than killed
+// count as leaks, so clearing the field can't earn a perfect wave. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) removeAllEnemies() {
+	gs.waveLeaks += len(gs.Enemies)
 	gs.Enemies = make([]Enemy, 0)
 	gs.Projectiles = make([]Projectile, 0)
+	gs.recordInput(InputClearEnemies, nil)
 }
 
-// GetSnapshot returns a safe copy of the game state
-func (gs *GameStateWithShooting) GetSnapshot() *GameStateWithShooting {
+// IsGameOver reports whether the room has been defeated
+func (gs *GameStateWithShooting) IsGameOver() bool {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
 
-	// Create a copy
-	snapshot := &GameStateWithShooting{
-		RoomID:        gs.RoomID,
-		Players:       make([]string, len(gs.Players)),
-		Towers:        make([]Tower, len(gs.Towers)),
-		Enemies:       make([]Enemy, len(gs.Enemies)),
-		Projectiles:   make([]Projectile, len(gs.Projectiles)),
-		MuzzleFlashes: make([]MuzzleFlash, len(gs.MuzzleFlashes)),
-		Explosions:    make([]Explosion, len(gs.Explosions)),
-		Gold:          gs.Gold,
-		Health:        gs.Health,
-		Wave:          gs.Wave,
-		GameTime:      gs.GameTime,
-		SpawnPoint:    gs.SpawnPoint,
-		GoalPoint:     gs.GoalPoint,
-	}
-
-	copy(snapshot.Players, gs.Players)
-	copy(snapshot.Towers, gs.Towers)
-	copy(snapshot.Enemies, gs.Enemies)
-	copy(snapshot.Projectiles, gs.Projectiles)
-	copy(snapshot.MuzzleFlashes, gs.MuzzleFlashes)
-	copy(snapshot.Explosions, gs.Explosions)
-
-	return snapshot
+	return gs.GameOver
 }
 
-// Helper functions
+// holdGameOver keeps the encoded match result until it's been sent; nil
+// once it has
+func (gs *GameStateWithShooting) holdGameOver(data []byte) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.unsentGameOver = data
+}
+
+// heldGameOver returns the match result still waiting to be sent
+func (gs *GameStateWithShooting) heldGameOver() ([]byte, bool) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.unsentGameOver, gs.unsentGameOver != nil
+}
+
+// finishMatch builds the final match result. It only succeeds once per room
+// so a match is never recorded twice.
+func (gs *GameStateWithShooting) finishMatch(result string) (MatchResult, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
 
-type towerStats struct {
-	Range    float64
-	Damage   float64
-	FireRate float64
-}
-
-func getTowerStats(towerType string) towerStats {
-	stats := map[string]towerStats{
-		"basic": {
-			Range:    3.0,
-			Damage:   15.0,
-			FireRate: 1.0, // 1 shot per second
-		},
-		"sniper": {
-			Range:    6.0,
-			Damage:   50.0,
-			FireRate: 0.5, // 1 shot every 2 seconds
-		},
-		"splash": {
-			Range:    2.5,
-			Damage:   10.0,
-			FireRate: 1.5, // 1.5 shots per second
-		},
-		"slow": {
-			Range:    3.5,
-			Damage:   8.0,
-			FireRate: 0.8,
-		},
-	}
-
-	if s, ok := stats[towerType]; ok {
-		return s
-	}
-	return stats["basic"]
-}
-
-type enemyStats struct {
-	Health float64
-	Speed  float64
-}
-
-func getEnemyStats(enemyType string) enemyStats {
-	stats := map[string]enemyStats{
-		"basic": {
-			Health: 100.0,
-			Speed:  2.0,
-		},
-		"fast": {
-			Health: 50.0,
-			Speed:  4.0,
-		},
-		"tank": {
-			Health: 300.0,
-			Speed:  1.0,
-		},
-		"flying": {
-			Health: 80.0,
-			Speed:  3.0,
-		},
-		"boss": {
-			Health: 1000.0,
-			Speed:  0.5,
-		},
-	}
-
-	if s, ok := stats[enemyType]; ok {
-		return s
-	}
-	return stats["basic"]
+	if gs.finished {
+		return MatchResult{}, false
+	}
+	gs.finished = true
+
+	gs.Score.recalculate(gs.Wave, gs.GameTime, gs.ScoreMultiplier)
+
+	players := make([]string, len(gs.Players))
+	copy(players, gs.Players)
+
+	var winners, losers []string
+	if gs.isVersus() {
+		winners, losers = gs.versusSides()
+	}
+
+	stars := 0
+	if gs.forfeited {
+		result = ResultForfeit
+	}
+	if gs.Victory {
+		result = ResultVictory
+		stars = levelStars(gs.Health)
+	}
+
+	return MatchResult{
+		RoomID:   gs.RoomID,
+		Players:  players,
+		Mutators: gs.Config.Mutators,
+		Seed:     gs.Config.Seed,
+		Score:    gs.Score,
+		Result:   result,
+		Winners:  winners,
+		Losers:   losers,
+		Wave:     gs.Wave,
+		Stars:    stars,
+		Duration: gs.GameTime,
+		Economy:  gs.playerEconomy(),
+		EndedAt:  time.Now(),
+	}, true
 }
 
+// Helper functions
+
 func distance(a, b Position) float64 {
 	dx := a.X - b.X
 	dy := a.Y - b.Y
 	return math.Sqrt(dx*dx + dy*dy)
 }
 
-// BFS pathfinding around towers
-func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
-	const gridWidth = 20
-	const gridHeight = 15
+// Map dimensions in grid cells
+const (
+	GridWidth  = 20
+	GridHeight = 15
+)
 
-	// Create set of blocked cells (tower positions)
+// InBounds reports whether a position lies on the map grid
+func InBounds(pos Position) bool {
+	return pos.X >= 0 && pos.X <= GridWidth-1 && pos.Y >= 0 && pos.Y <= GridHeight-1
+}
+
+// blockedCells returns the set of cells enemies can't walk through, keyed
+// "x,y"
+func (gs *GameStateWithShooting) blockedCells() map[string]bool {
 	blocked := make(map[string]bool)
 	for _, tower := range gs.Towers {
 		tx := int(math.Round(tower.Position.X))
@@ -548,6 +919,16 @@ func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
 		key := fmt.Sprintf("%d,%d", tx, ty)
 		blocked[key] = true
 	}
+	for _, w := range gs.Walls {
+		blocked[fmt.Sprintf("%d,%d", w.X, w.Y)] = true
+	}
+	return blocked
+}
+
+// BFS pathfinding around towers, to whichever goal is nearest
+func (gs *GameStateWithShooting) findPath(start Position, goals ...Position) []Position {
+
+	blocked := gs.blockedCells()
 
 	// BFS queue
 	type queueItem struct {
@@ -556,7 +937,10 @@ func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
 	}
 
 	startKey := fmt.Sprintf("%d,%d", int(math.Round(start.X)), int(math.Round(start.Y)))
-	goalKey := fmt.Sprintf("%d,%d", int(math.Round(goal.X)), int(math.Round(goal.Y)))
+	goalKeys := make(map[string]bool, len(goals))
+	for _, goal := range goals {
+		goalKeys[fmt.Sprintf("%d,%d", int(math.Round(goal.X)), int(math.Round(goal.Y)))] = true
+	}
 
 	queue := []queueItem{{pos: start, path: []Position{start}}}
 	visited := make(map[string]bool)
@@ -571,7 +955,7 @@ func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
 		currentKey := fmt.Sprintf("%d,%d", px, py)
 
 		// Check if reached goal
-		if currentKey == goalKey {
+		if goalKeys[currentKey] {
 			return current.path
 		}
 
@@ -589,7 +973,7 @@ func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
 			key := fmt.Sprintf("%d,%d", nx, ny)
 
 			// Check bounds
-			if nx < 0 || nx >= gridWidth || ny < 0 || ny >= gridHeight {
+			if nx < 0 || nx >= GridWidth || ny < 0 || ny >= GridHeight {
 				continue
 			}
 
@@ -612,7 +996,8 @@ func (gs *GameStateWithShooting) findPath(start, goal Position) []Position {
 
 // RecalculateEnemyPaths recalculates paths for all active enemies
 func (gs *GameStateWithShooting) RecalculateEnemyPaths() {
-	if gs.GoalPoint == nil {
+	goals := gs.goalTargets()
+	if len(goals) == 0 {
 		return
 	}
 
@@ -626,7 +1011,7 @@ func (gs *GameStateWithShooting) RecalculateEnemyPaths() {
 		}
 
 		// Calculate new path from current position to goal
-		newPath := gs.findPath(currentPos, *gs.GoalPoint)
+		newPath := gs.findPath(currentPos, goals...)
 
 		if newPath != nil {
 			enemy.Path = newPath
@@ -636,5 +1021,10 @@ func (gs *GameStateWithShooting) RecalculateEnemyPaths() {
 			enemy.Path = []Position{currentPos}
 			enemy.PathIndex = 0
 		}
+		gs.audit(AuditPathRecalc, 0, enemy.ID, map[string]interface{}{
+			"from":    currentPos,
+			"length":  len(enemy.Path),
+			"trapped": newPath == nil,
+		})
 	}
 }
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+// stateHasher feeds values into a hash in a fixed binary encoding
+type stateHasher struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+func (s *stateHasher) u64(v uint64) {
+	binary.LittleEndian.PutUint64(s.buf[:], v)
+	s.h.Write(s.buf[:])
+}
+
+func (s *stateHasher) int(v int) {
+	s.u64(uint64(int64(v)))
+}
+
+func (s *stateHasher) float(v float64) {
+	s.u64(math.Float64bits(v))
+}
+
+func (s *stateHasher) str(v string) {
+	s.int(len(v))
+	s.h.Write([]byte(v))
+}
+
+func (s *stateHasher) pos(p Position) {
+	s.float(p.X)
+	s.float(p.Y)
+}
+
+// stateHash is an FNV-1a hash of the simulation state. Entity slices are
+// kept in ID order, so walking them gives a canonical ordering. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) stateHash() uint64 {
+	s := &stateHasher{h: fnv.New64a()}
+
+	s.u64(gs.Tick)
+	s.int(gs.Gold)
+	s.int(gs.Health)
+	s.int(gs.Wave)
+
+	s.int(len(gs.Towers))
+	for _, t := range gs.Towers {
+		s.u64(uint64(t.ID))
+		s.pos(t.Position)
+		s.str(t.TowerType)
+		s.int(t.Level)
+		s.str(t.State)
+		s.float(t.StateTimer)
+		s.float(t.Cooldown)
+		s.u64(uint64(t.CurrentTarget))
+	}
+
+	s.int(len(gs.Enemies))
+	for _, e := range gs.Enemies {
+		s.u64(uint64(e.ID))
+		s.pos(e.Position)
+		s.float(e.Health)
+		s.int(e.PathIndex)
+		s.int(len(e.Effects))
+	}
+
+	s.int(len(gs.Projectiles))
+	for _, p := range gs.Projectiles {
+		s.u64(uint64(p.ID))
+		s.pos(p.Position)
+		s.u64(uint64(p.TargetID))
+	}
+
+	return s.h.Sum64()
+}
+
+// formatStateHash renders a hash the way clients receive it
+func formatStateHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
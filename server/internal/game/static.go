@@ -0,0 +1,142 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// mapsVersion changes whenever the built-in maps do
+const mapsVersion = 1
+
+// Static data names
+const (
+	StaticMaps    = "maps"
+	StaticBalance = "balance"
+)
+
+// MapDefinition is a map's layout
+type MapDefinition struct {
+	ID     string   `json:"id"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Spawn  Position `json:"spawn"`
+	Goal   Position `json:"goal"`
+	Walls  []Wall   `json:"walls,omitempty"`
+	Leak   string   `json:"leak_mode,omitempty"` // for rooms that don't choose one
+
+	// Maps with several goals list the rest here
+	Goals []Position `json:"goals,omitempty"`
+}
+
+// mapCatalog lists every map by ID
+var mapCatalog = map[string]MapDefinition{
+	DefaultMap: {
+		ID:     DefaultMap,
+		Width:  GridWidth,
+		Height: GridHeight,
+		Spawn:  Position{X: 0, Y: 7},
+		Goal:   Position{X: GridWidth - 1, Y: 7},
+	},
+}
+
+// Maps returns every map, sorted by ID
+func Maps() []MapDefinition {
+	maps := make([]MapDefinition, 0, len(mapCatalog))
+	for _, m := range mapCatalog {
+		maps = append(maps, m)
+	}
+	sort.Slice(maps, func(i, j int) bool { return maps[i].ID < maps[j].ID })
+	return maps
+}
+
+// StaticAsset is an encoded piece of static data, ready to serve
+type StaticAsset struct {
+	Version string
+	ETag    string
+	Body    []byte
+}
+
+// StaticCache keeps encoded static data so it's only built once per
+// version. Keys include the version, so stale entries are never served.
+type StaticCache interface {
+	Get(key string) (StaticAsset, bool)
+	Put(key string, asset StaticAsset)
+}
+
+// MemoryStaticCache is a StaticCache for a single instance
+type MemoryStaticCache struct {
+	mu     sync.RWMutex
+	assets map[string]StaticAsset
+}
+
+// NewMemoryStaticCache creates an empty cache
+func NewMemoryStaticCache() *MemoryStaticCache {
+	return &MemoryStaticCache{assets: make(map[string]StaticAsset)}
+}
+
+// Get returns a cached asset
+func (c *MemoryStaticCache) Get(key string) (StaticAsset, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	asset, ok := c.assets[key]
+	return asset, ok
+}
+
+// Put caches an asset
+func (c *MemoryStaticCache) Put(key string, asset StaticAsset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.assets[key] = asset
+}
+
+// UseStaticCache sets where encoded static data is kept
+func (m *Manager) UseStaticCache(cache StaticCache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.static = cache
+}
+
+// Static returns the named static data, encoded at its current version. It
+// returns false for unknown names.
+func (m *Manager) Static(name string) (StaticAsset, bool) {
+	var version int
+	var data interface{}
+	switch name {
+	case StaticMaps:
+		version, data = mapsVersion, Maps()
+	case StaticBalance:
+		balance := m.balance.Current()
+		version, data = balance.Version, balance
+	default:
+		return StaticAsset{}, false
+	}
+
+	m.mu.RLock()
+	cache := m.static
+	m.mu.RUnlock()
+
+	key := name + ":" + strconv.Itoa(version)
+	if asset, ok := cache.Get(key); ok {
+		return asset, true
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"version": version,
+		name:      data,
+	})
+	if err != nil {
+		return StaticAsset{}, false
+	}
+	sum := sha256.Sum256(body)
+	asset := StaticAsset{
+		Version: strconv.Itoa(version),
+		ETag:    `"` + hex.EncodeToString(sum[:8]) + `"`,
+		Body:    body,
+	}
+	cache.Put(key, asset)
+	return asset, true
+}
@@ -0,0 +1,174 @@
+package game
+
+import (
+	"rust-rush/server/internal/i18n"
+)
+
+// CommandSurrender is a player conceding the game, or voting to
+const CommandSurrender = "surrender"
+
+// Surrender events
+const (
+	EventSurrenderVote = "surrender_vote"
+	EventSurrendered   = "surrendered"
+)
+
+// SurrenderVote is where a surrender stands after a player asks for one
+type SurrenderVote struct {
+	PlayerID    string `json:"player_id"`
+	Votes       int    `json:"votes"`
+	Needed      int    `json:"needed"`
+	Surrendered bool   `json:"surrendered"`
+}
+
+// Surrender concedes the game for the player's side: everyone in co-op, or
+// their team in versus, which hands the other side the win. The host gives
+// up at once; anyone else casts a vote, and the side gives up once most of
+// it has voted.
+type Surrender struct {
+	PlayerID string
+}
+
+func (Surrender) Type() string { return CommandSurrender }
+
+func (c Surrender) apply(gs *GameStateWithShooting) (interface{}, error) {
+	if !containsString(gs.Players, c.PlayerID) {
+		return nil, i18n.NewError(i18n.ErrNotInRoom, nil)
+	}
+	if !gs.inProgress() {
+		return nil, i18n.NewError(i18n.ErrNotInProgress, map[string]interface{}{"room_id": gs.RoomID})
+	}
+
+	if gs.surrenderVotes == nil {
+		gs.surrenderVotes = make(map[string]bool)
+	}
+	gs.surrenderVotes[c.PlayerID] = true
+
+	// Only votes from the side still in the room count
+	side := gs.surrenderSide(c.PlayerID)
+	vote := SurrenderVote{PlayerID: c.PlayerID, Needed: len(side)/2 + 1}
+	for _, playerID := range side {
+		if gs.surrenderVotes[playerID] {
+			vote.Votes++
+		}
+	}
+
+	if c.PlayerID == gs.Host || vote.Votes >= vote.Needed {
+		gs.forfeit(c.PlayerID)
+		vote.Surrendered = true
+		return vote, nil
+	}
+	gs.emitEvent(EventSurrenderVote, nil, map[string]interface{}{
+		"player_id": c.PlayerID,
+		"votes":     vote.Votes,
+		"needed":    vote.Needed,
+	})
+	return vote, nil
+}
+
+// surrenderSide lists the players in the room who give up along with one
+// who surrenders. Callers must hold the state lock.
+func (gs *GameStateWithShooting) surrenderSide(playerID string) []string {
+	if !gs.isVersus() {
+		return gs.Players
+	}
+
+	player := gs.versusPlayer(playerID)
+	var side []string
+	for i := range gs.Versus.Players {
+		other := &gs.Versus.Players[i]
+		if player != nil && sameSide(player, other) && containsString(gs.Players, other.PlayerID) {
+			side = append(side, other.PlayerID)
+		}
+	}
+	return side
+}
+
+// forfeit ends the game with the player's side giving up. The match is
+// recorded as a forfeit and the room goes back to its lobby on the tick
+// after. Callers must hold the state lock.
+func (gs *GameStateWithShooting) forfeit(playerID string) {
+	gs.forfeited = true
+	gs.surrenderVotes = nil
+	if gs.isVersus() {
+		if opponent := gs.opponentOf(playerID); opponent != nil {
+			gs.Versus.Winner = opponent.PlayerID
+		}
+		gs.emitScoreboard()
+	}
+	gs.GameOver = true
+	gs.lobbyChanged() // no longer in progress
+
+	gs.emitEvent(EventSurrendered, nil, map[string]interface{}{
+		"player_id": playerID,
+		"wave":      gs.Wave,
+	})
+}
+
+// returnToLobby clears a forfeited game once its result has been recorded,
+// leaving the room as it was before the first wave with everyone still in
+// it. Callers must hold the state lock.
+func (gs *GameStateWithShooting) returnToLobby() {
+	gs.Towers = make([]Tower, 0)
+	gs.Enemies = make([]Enemy, 0)
+	gs.Projectiles = make([]Projectile, 0)
+	gs.Events = make([]GameEvent, 0)
+	gs.Gold = startingGold
+	gs.Health = startingHealth
+	gs.Wave = 1
+	gs.GameTime = 0
+	gs.Score = Score{}
+	gs.GameOver = false
+	gs.Victory = false
+	gs.Paused = false
+	gs.Threat = 0
+	gs.FastForward = false
+	gs.PerfectStreak = 0
+	if gs.Config.AutoDifficulty {
+		gs.Difficulty = 1.0
+	}
+
+	positions := make([]Position, len(gs.Goals))
+	for i, goal := range gs.Goals {
+		positions[i] = goal.Position
+	}
+	gs.useGoals(positions)
+
+	if gs.isVersus() {
+		for i := range gs.Versus.Players {
+			player := &gs.Versus.Players[i]
+			*player = VersusPlayer{PlayerID: player.PlayerID, Health: versusStartingHealth, Team: player.Team}
+		}
+		gs.Versus.SuddenDeath = false
+		gs.Versus.Winner = ""
+	}
+	if gs.tutorial != nil {
+		gs.tutorial = &tutorialRun{Script: gs.tutorial.Script}
+	}
+
+	gs.finished = false
+	gs.forfeited = false
+	gs.buildQueue = nil
+	gs.wavesStarted = 0
+	gs.recentEvents = nil
+	gs.pendingSpawns = nil
+	gs.skipWait = false
+	gs.waveSpawned = 0
+	gs.waveLeaks = 0
+	gs.settledWave = 0
+	gs.waveResult = waveResult{}
+	gs.waveStartHealth = startingHealth
+	gs.eased = false
+	gs.lastEased = 0
+	gs.milestones = nil
+	gs.ghostNext = 0
+	gs.ready = nil
+	gs.ledger = nil
+	gs.economy = nil
+	gs.samples = nil
+	gs.eventLog = nil
+	gs.checkpoints = nil
+	gs.built = nil
+	gs.towerStats = nil
+	gs.lobbyChanged()
+}
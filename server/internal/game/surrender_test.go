@@ -0,0 +1,93 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// newSurrenderRoom opens a room with its players in it and the first wave
+// started
+func newSurrenderRoom(t *testing.T, mode string, players ...string) *GameStateWithShooting {
+	t.Helper()
+	config := DefaultRoomConfig()
+	config.Mode = mode
+	room, err := NewManager().CreateShootingRoomWithConfig("surrender-room", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Join(players, nil)
+	room.StartWave()
+	return room
+}
+
+func surrender(t *testing.T, room *GameStateWithShooting, playerID string) SurrenderVote {
+	t.Helper()
+	result, err := room.ApplyCommand(Surrender{PlayerID: playerID})
+	if err != nil {
+		t.Fatalf("%s couldn't surrender: %v", playerID, err)
+	}
+	return result.(SurrenderVote)
+}
+
+func TestSurrenderNeedsMostOfTheRoom(t *testing.T) {
+	room := newSurrenderRoom(t, ModeCoop, "host", "a", "b", "c")
+
+	for _, playerID := range []string{"a", "b"} {
+		if vote := surrender(t, room, playerID); vote.Surrendered || vote.Needed != 3 {
+			t.Fatalf("after %s's vote: %+v, want 3 votes needed and no surrender yet", playerID, vote)
+		}
+	}
+	if room.GetSnapshot().GameOver {
+		t.Fatal("the game ended on a minority vote")
+	}
+
+	if vote := surrender(t, room, "c"); !vote.Surrendered || vote.Votes != 3 {
+		t.Fatalf("after the third vote: %+v, want a surrender", vote)
+	}
+	if !room.GetSnapshot().GameOver {
+		t.Fatal("the game went on after the room surrendered")
+	}
+}
+
+func TestHostSurrenderReturnsToTheLobby(t *testing.T) {
+	room := newSurrenderRoom(t, ModeCoop, "host", "guest")
+	if _, err := room.ApplyCommand(Surrender{PlayerID: "stranger"}); !errors.Is(err, i18n.NewError(i18n.ErrNotInRoom, nil)) {
+		t.Fatalf("a stranger surrendering got %v, want not in room", err)
+	}
+
+	if vote := surrender(t, room, "host"); !vote.Surrendered {
+		t.Fatalf("host's surrender was only a vote: %+v", vote)
+	}
+	match, ok := room.finishMatch(ResultDefeat)
+	if !ok || match.Result != ResultForfeit {
+		t.Fatalf("match recorded as %q, want a forfeit", match.Result)
+	}
+
+	// Back to the lobby on the next tick, with everyone still there
+	room.Update(1.0 / 60.0)
+	snapshot := room.GetSnapshot()
+	if snapshot.GameOver || len(snapshot.Players) != 2 || snapshot.Gold != startingGold {
+		t.Fatalf("after the forfeit: game over %v, players %v, gold %d; want a fresh lobby",
+			snapshot.GameOver, snapshot.Players, snapshot.Gold)
+	}
+	if _, err := room.ApplyCommand(Surrender{PlayerID: "host"}); !errors.Is(err, i18n.NewError(i18n.ErrNotInProgress, nil)) {
+		t.Fatalf("surrendering in the lobby got %v, want not in progress", err)
+	}
+}
+
+func TestVersusSurrenderHandsTheOpponentTheWin(t *testing.T) {
+	room := newSurrenderRoom(t, ModeVersus, "host", "rival")
+
+	// The rival's side is just the rival, so their vote is a majority
+	if vote := surrender(t, room, "rival"); !vote.Surrendered || vote.Needed != 1 {
+		t.Fatalf("rival's surrender: %+v, want it to end the game", vote)
+	}
+	room.mu.RLock()
+	winner := room.Versus.Winner
+	room.mu.RUnlock()
+	if winner != "host" {
+		t.Fatalf("winner is %q, want the rival's opponent", winner)
+	}
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"math"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Tower targeting modes
+const (
+	TargetNearest = "nearest"
+	TargetFirst   = "first" // furthest along the path
+	TargetLast    = "last"  // least far along the path
+)
+
+var validTargetModes = map[string]bool{
+	TargetNearest: true,
+	TargetFirst:   true,
+	TargetLast:    true,
+}
+
+// findTarget picks an enemy in range according to the tower's targeting
+// mode. With predictive targeting, enemies that shots already in flight will
+// kill are passed over. Chain towers strike instantly, like a beam, so they
+// only target enemies they can see.
+func (gs *GameStateWithShooting) findTarget(tower *Tower) *Enemy {
+	nearest := tower.TargetMode == TargetNearest || tower.TargetMode == ""
+	sight := len(gs.wallIndex) > 0 && gs.mods.towerStats(tower.TowerType).Attack == attackChain
+	if nearest && !gs.Config.Predictive && !sight {
+		return gs.enemyIndex.nearest(gs.Enemies, tower.Position, tower.Range)
+	}
+
+	var best *Enemy
+	minDist := math.MaxFloat64
+	for _, enemy := range gs.enemyIndex.query(gs.Enemies, tower.Position, tower.Range) {
+		if gs.Config.Predictive && enemy.doomed() {
+			continue
+		}
+		if sight && !gs.lineOfSight(tower.Position, enemy.Position) {
+			continue
+		}
+		if nearest {
+			if dist := distance(tower.Position, enemy.Position); dist < minDist {
+				minDist = dist
+				best = enemy
+			}
+			continue
+		}
+		if best == nil ||
+			(tower.TargetMode == TargetFirst && enemy.Progress > best.Progress) ||
+			(tower.TargetMode == TargetLast && enemy.Progress < best.Progress) {
+			best = enemy
+		}
+	}
+
+	return best
+}
+
+// doomed reports whether damage already flying at the enemy will kill it
+func (e *Enemy) doomed() bool {
+	return e.pendingDamage >= e.Health
+}
+
+// addPending counts damage a projectile carries towards what's in flight at
+// its target. It's counted after armor, as that's what the hit will take off
+// the target's health. Callers must hold the state lock.
+func (gs *GameStateWithShooting) addPending(target *Enemy, proj *Projectile, damage float64) {
+	damage *= gs.armorFactor(target, proj.TowerID)
+	target.pendingDamage += damage
+	proj.pending += damage
+}
+
+// releasePending takes a projectile's damage off what's in flight towards
+// its target, once it lands, expires or changes target. Callers must hold
+// the state lock.
+func releasePending(target *Enemy, proj *Projectile) {
+	if target == nil {
+		return
+	}
+	target.pendingDamage = math.Max(0, target.pendingDamage-proj.pending)
+}
+
+// remainingPathDistance is how far an enemy still has to walk along its path
+func remainingPathDistance(enemy *Enemy) float64 {
+	if enemy.PathIndex >= len(enemy.Path) {
+		return 0
+	}
+
+	remaining := distance(enemy.Position, enemy.Path[enemy.PathIndex])
+	for i := enemy.PathIndex; i < len(enemy.Path)-1; i++ {
+		remaining += distance(enemy.Path[i], enemy.Path[i+1])
+	}
+	return remaining
+}
+
+// updateProgress refreshes an enemy's normalized progress towards the goal.
+// Progress is based on distance already walked versus distance left, so it
+// stays monotonic when paths are recalculated around new towers.
+func updateProgress(enemy *Enemy) {
+	remaining := remainingPathDistance(enemy)
+	total := enemy.distanceTraveled + remaining
+	if total <= 0 {
+		enemy.Progress = 0
+		return
+	}
+	enemy.Progress = math.Min(1, enemy.distanceTraveled/total)
+}
+
+// SetTargetMode changes how a tower picks its targets
+func (gs *GameStateWithShooting) SetTargetMode(towerID EntityID, mode string) (Tower, error) {
+	result, err := gs.ApplyCommand(SetTargetMode{TowerID: towerID, Mode: mode})
+	tower, _ := result.(Tower)
+	return tower, err
+}
+
+// setTargetMode changes a tower's targeting. Callers must hold the state
+// lock.
+func (gs *GameStateWithShooting) setTargetMode(towerID EntityID, mode string) (Tower, error) {
+	if !validTargetModes[mode] {
+		return Tower{}, i18n.NewError(i18n.ErrInvalidTargetMode, map[string]interface{}{"mode": mode})
+	}
+
+	tower := gs.findTower(towerID)
+	if tower == nil {
+		return Tower{}, i18n.NewError(i18n.ErrTowerNotFound, map[string]interface{}{"tower_id": towerID})
+	}
+
+	tower.TargetMode = mode
+	gs.recordInput(InputTargetMode, map[string]interface{}{
+		"tower_id": towerID,
+		"mode":     mode,
+	})
+	return *tower, nil
+}
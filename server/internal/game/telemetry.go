@@ -0,0 +1,243 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Telemetry limits
+const (
+	telemetryInterval   = 60   // ticks between samples, once a second
+	maxTelemetrySamples = 3600 // an hour of samples
+	maxTelemetryEvents  = 5000
+	maxTelemetryBundles = 50 // finished matches kept for export
+)
+
+// Telemetry export parts, for formats that can only hold one table
+const (
+	TelemetrySamples = "samples"
+	TelemetryEvents  = "events"
+	TelemetryLedger  = "ledger"
+	TelemetryTowers  = "towers"
+)
+
+// TelemetrySample is a room's state at one moment of a match
+type TelemetrySample struct {
+	Tick        uint64  `json:"tick"`
+	GameTime    float64 `json:"game_time"`
+	Wave        int     `json:"wave"`
+	Gold        int     `json:"gold"`
+	Health      int     `json:"health"`
+	Score       int     `json:"score"`
+	Towers      int     `json:"towers"`
+	Enemies     int     `json:"enemies"`
+	Projectiles int     `json:"projectiles"`
+}
+
+// TelemetryBundle is everything recorded about a match, for offline balance
+// analysis
+type TelemetryBundle struct {
+	RoomID  string            `json:"room_id"`
+	Result  string            `json:"result,omitempty"` // empty while the match is running
+	Config  RoomConfig        `json:"config"`
+	Samples []TelemetrySample `json:"samples"`
+	Events  []GameEvent       `json:"events"` // gameplay events, without visual effects
+	Ledger  []LedgerEntry     `json:"ledger"`
+	Economy []PlayerEconomy   `json:"economy"`
+	Towers  []TowerStats      `json:"towers"` // shots, overkill and waste by tower
+}
+
+// sampleTelemetry records the room's state once per interval. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) sampleTelemetry() {
+	if gs.Tick%telemetryInterval != 0 {
+		return
+	}
+
+	gs.samples = append(gs.samples, TelemetrySample{
+		Tick:        gs.Tick,
+		GameTime:    gs.GameTime,
+		Wave:        gs.Wave,
+		Gold:        gs.Gold,
+		Health:      gs.Health,
+		Score:       gs.Score.Total,
+		Towers:      len(gs.Towers),
+		Enemies:     len(gs.Enemies),
+		Projectiles: len(gs.Projectiles),
+	})
+	if len(gs.samples) > maxTelemetrySamples {
+		gs.samples = gs.samples[len(gs.samples)-maxTelemetrySamples:]
+	}
+}
+
+// logTelemetryEvent keeps gameplay events for export. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) logTelemetryEvent(event GameEvent) {
+	if visualEvents[event.Type] {
+		return
+	}
+	gs.eventLog = append(gs.eventLog, event)
+	if len(gs.eventLog) > maxTelemetryEvents {
+		gs.eventLog = gs.eventLog[len(gs.eventLog)-maxTelemetryEvents:]
+	}
+}
+
+// telemetry bundles up what the room has recorded. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) telemetry() TelemetryBundle {
+	return TelemetryBundle{
+		RoomID:  gs.RoomID,
+		Config:  gs.Config,
+		Samples: append([]TelemetrySample{}, gs.samples...),
+		Events:  append([]GameEvent{}, gs.eventLog...),
+		Ledger:  append([]LedgerEntry{}, gs.ledger...),
+		Economy: gs.playerEconomy(),
+		Towers:  gs.towerStatsList(),
+	}
+}
+
+// Telemetry returns what the room has recorded so far
+func (gs *GameStateWithShooting) Telemetry() TelemetryBundle {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.telemetry()
+}
+
+// WriteJSONL writes the bundle one record per line. Each line has a "kind"
+// of sample, event, ledger or tower.
+func (b TelemetryBundle) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	line := func(kind string, record interface{}) error {
+		return enc.Encode(struct {
+			Kind   string      `json:"kind"`
+			RoomID string      `json:"room_id"`
+			Record interface{} `json:"record"`
+		}{kind, b.RoomID, record})
+	}
+
+	for _, s := range b.Samples {
+		if err := line("sample", s); err != nil {
+			return err
+		}
+	}
+	for _, e := range b.Events {
+		if err := line("event", e); err != nil {
+			return err
+		}
+	}
+	for _, l := range b.Ledger {
+		if err := line("ledger", l); err != nil {
+			return err
+		}
+	}
+	for _, t := range b.Towers {
+		if err := line("tower", t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes one part of the bundle as a CSV table
+func (b TelemetryBundle) WriteCSV(w io.Writer, part string) error {
+	out := csv.NewWriter(w)
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	d := strconv.Itoa
+	id := func(id EntityID) string { return strconv.FormatUint(uint64(id), 10) } // as in the JSON
+
+	switch part {
+	case TelemetrySamples:
+		out.Write([]string{"tick", "game_time", "wave", "gold", "health", "score", "towers", "enemies", "projectiles"})
+		for _, s := range b.Samples {
+			out.Write([]string{strconv.FormatUint(s.Tick, 10), f(s.GameTime), d(s.Wave), d(s.Gold), d(s.Health), d(s.Score), d(s.Towers), d(s.Enemies), d(s.Projectiles)})
+		}
+
+	case TelemetryEvents:
+		out.Write([]string{"id", "type", "game_time", "x", "y", "data"})
+		for _, e := range b.Events {
+			x, y := "", ""
+			if e.Position != nil {
+				x, y = f(e.Position.X), f(e.Position.Y)
+			}
+			data, err := json.Marshal(e.Data)
+			if err != nil {
+				return err
+			}
+			out.Write([]string{id(e.ID), e.Type, f(e.GameTime), x, y, string(data)})
+		}
+
+	case TelemetryLedger:
+		out.Write([]string{"tick", "source", "amount", "balance", "player_id", "entity_id"})
+		for _, l := range b.Ledger {
+			entity := ""
+			if l.EntityID != 0 {
+				entity = id(l.EntityID)
+			}
+			out.Write([]string{strconv.FormatUint(l.Tick, 10), l.Source, d(l.Amount), d(l.Balance), l.PlayerID, entity})
+		}
+
+	case TelemetryTowers:
+		out.Write([]string{"tower_id", "tower_type", "owner_id", "shots", "damage", "overkill", "wasted_shots", "retargeted", "kills"})
+		for _, t := range b.Towers {
+			out.Write([]string{id(t.TowerID), t.TowerType, t.OwnerID, d(t.Shots), f(t.Damage), f(t.Overkill), d(t.WastedShots), d(t.Retargeted), d(t.Kills)})
+		}
+
+	default:
+		return i18n.NewError(i18n.ErrUnknownTelemetry, map[string]interface{}{"part": part})
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// TelemetryStore keeps the telemetry of recently finished matches
+type TelemetryStore struct {
+	mu      sync.RWMutex
+	bundles map[string]TelemetryBundle
+	order   []string // room IDs, oldest first
+}
+
+// NewTelemetryStore creates an empty telemetry store
+func NewTelemetryStore() *TelemetryStore {
+	return &TelemetryStore{bundles: make(map[string]TelemetryBundle)}
+}
+
+// put keeps a finished match's telemetry, dropping the oldest past the limit
+func (s *TelemetryStore) put(bundle TelemetryBundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.bundles[bundle.RoomID]; !ok {
+		s.order = append(s.order, bundle.RoomID)
+	}
+	s.bundles[bundle.RoomID] = bundle
+	for len(s.order) > maxTelemetryBundles {
+		delete(s.bundles, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// get returns a finished match's telemetry
+func (s *TelemetryStore) get(roomID string) (TelemetryBundle, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	bundle, ok := s.bundles[roomID]
+	return bundle, ok
+}
+
+// Telemetry returns a room's telemetry: so far for running rooms, or as it
+// ended for recently finished ones
+func (m *Manager) Telemetry(roomID string) (TelemetryBundle, bool) {
+	if room, exists := m.GetShootingRoom(roomID); exists {
+		if bundle, ok := m.telemetry.get(roomID); ok {
+			return bundle, true
+		}
+		return room.Telemetry(), true
+	}
+	return m.telemetry.get(roomID)
+}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// TemplateAdmin is who the server's operators save templates as. They can
+// replace anyone's templates and aren't held to the per-player limit.
+const TemplateAdmin = "admin"
+
+// maxTemplatesPerPlayer bounds how many templates one player can save
+const maxTemplatesPerPlayer = 20
+
+// RoomTemplate is a saved room configuration new rooms can be created from
+type RoomTemplate struct {
+	Name      string     `json:"name"`
+	Config    RoomConfig `json:"config"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TemplateStore keeps room templates in memory and, once given a file,
+// writes them through to disk
+type TemplateStore struct {
+	mu        sync.RWMutex
+	templates map[string]RoomTemplate
+	path      string
+}
+
+// NewTemplateStore creates an empty in-memory template store
+func NewTemplateStore() *TemplateStore {
+	return &TemplateStore{
+		templates: make(map[string]RoomTemplate),
+	}
+}
+
+// UseFile loads templates from a JSON file, if it exists, and saves every
+// later change back to it
+func (s *TemplateStore) UseFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var templates []RoomTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return err
+	}
+	for _, t := range templates {
+		s.templates[t.Name] = t
+	}
+	return nil
+}
+
+// Save validates and stores a template, replacing any with the same name
+// its creator saved before. Players can't replace each other's templates.
+func (s *TemplateStore) Save(t RoomTemplate) (RoomTemplate, error) {
+	if t.Name == "" {
+		return RoomTemplate{}, i18n.NewError(i18n.ErrInvalidTemplate, nil)
+	}
+
+	t.Config = templateConfig(t.Config)
+	if err := t.Config.Validate(); err != nil {
+		return RoomTemplate{}, err
+	}
+	t.CreatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, replacing := s.templates[t.Name]
+	if replacing && existing.CreatedBy != t.CreatedBy && t.CreatedBy != TemplateAdmin {
+		return RoomTemplate{}, i18n.NewError(i18n.ErrTemplateTaken, map[string]interface{}{"name": t.Name})
+	}
+	if !replacing && t.CreatedBy != TemplateAdmin && s.countBy(t.CreatedBy) >= maxTemplatesPerPlayer {
+		return RoomTemplate{}, i18n.NewError(i18n.ErrTemplateLimit, map[string]interface{}{"limit": maxTemplatesPerPlayer})
+	}
+
+	s.templates[t.Name] = t
+	return t, s.persist()
+}
+
+// Get looks up a template by name
+func (s *TemplateStore) Get(name string) (RoomTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.templates[name]
+	if !ok {
+		return RoomTemplate{}, i18n.NewError(i18n.ErrTemplateNotFound, map[string]interface{}{"name": name})
+	}
+	return t, nil
+}
+
+// Delete removes a template
+func (s *TemplateStore) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[name]; !ok {
+		return false, nil
+	}
+	delete(s.templates, name)
+	return true, s.persist()
+}
+
+// List returns a realm's templates, sorted by name
+func (s *TemplateStore) List(tenant string) []RoomTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]RoomTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		if TenantOf(t.Name) == tenant {
+			templates = append(templates, t)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// count returns how many templates a realm has saved
+func (s *TemplateStore) count(tenant string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for name := range s.templates {
+		if TenantOf(name) == tenant {
+			count++
+		}
+	}
+	return count
+}
+
+// countBy returns how many templates a player has saved. Callers must hold
+// the lock.
+func (s *TemplateStore) countBy(playerID string) int {
+	count := 0
+	for _, t := range s.templates {
+		if t.CreatedBy == playerID {
+			count++
+		}
+	}
+	return count
+}
+
+// realms counts the templates saved in each realm
+func (s *TemplateStore) realms() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for name := range s.templates {
+		counts[TenantOf(name)]++
+	}
+	return counts
+}
+
+// persist writes the templates to disk if a file is configured. Callers
+// must hold the lock.
+func (s *TemplateStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	templates := make([]RoomTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, t)
+	}
+
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// templateConfig strips the settings a room picks up at creation time, such
+// as active server events and experiment variants, so they aren't baked
+// into a template. Campaign levels are left out too: they're only opened
+// through SelectLevel, which checks the player has unlocked them.
+func templateConfig(config RoomConfig) RoomConfig {
+	config.Campaign = ""
+	config.Level = ""
+	config.Ghost = false
+	config.ServerEvents = nil
+	config.GoldMultiplier = 0
+	config.BossWaves = false
+	config.Experiments = nil
+
+	mutators := make([]string, len(config.Mutators))
+	copy(mutators, config.Mutators)
+	config.Mutators = mutators
+
+	return config
+}
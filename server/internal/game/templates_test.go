@@ -0,0 +1,66 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTemplatesDontOpenCampaignLevels(t *testing.T) {
+	m := NewManager()
+	locked := DefaultRoomConfig()
+	locked.Campaign = "rust_belt"
+	locked.Level = "scrapyard"
+
+	saved, err := m.SaveTemplate(RoomTemplate{Name: "shortcut", Config: locked})
+	if err != nil {
+		t.Fatalf("failed to save template: %v", err)
+	}
+	if saved.Config.Campaign != "" || saved.Config.Level != "" {
+		t.Fatalf("template kept campaign level %s/%s", saved.Config.Campaign, saved.Config.Level)
+	}
+
+	// One written before levels were stripped, straight into the file
+	m.templates.templates["old"] = RoomTemplate{Name: "old", Config: locked}
+	room, err := m.CreateRoomFromTemplate("", "old")
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	defer m.DeleteRoom(room.RoomID)
+	if room.campaignLevel != nil {
+		t.Fatalf("template opened campaign level %s without it being unlocked", room.campaignLevel.ID)
+	}
+}
+
+func TestPlayersCantReplaceOthersTemplates(t *testing.T) {
+	s := NewTemplateStore()
+	if _, err := s.Save(RoomTemplate{Name: "mine", Config: DefaultRoomConfig(), CreatedBy: "owner"}); err != nil {
+		t.Fatalf("failed to save template: %v", err)
+	}
+
+	if _, err := s.Save(RoomTemplate{Name: "mine", Config: DefaultRoomConfig(), CreatedBy: "owner"}); err != nil {
+		t.Errorf("owner couldn't replace their template: %v", err)
+	}
+	if _, err := s.Save(RoomTemplate{Name: "mine", Config: DefaultRoomConfig(), CreatedBy: "other"}); err == nil {
+		t.Error("another player replaced the template")
+	}
+	if _, err := s.Save(RoomTemplate{Name: "mine", Config: DefaultRoomConfig(), CreatedBy: TemplateAdmin}); err != nil {
+		t.Errorf("admin couldn't replace the template: %v", err)
+	}
+}
+
+func TestTemplatesPerPlayerAreCapped(t *testing.T) {
+	s := NewTemplateStore()
+	for i := 0; i < maxTemplatesPerPlayer; i++ {
+		name := fmt.Sprintf("template-%d", i)
+		if _, err := s.Save(RoomTemplate{Name: name, Config: DefaultRoomConfig(), CreatedBy: "player"}); err != nil {
+			t.Fatalf("failed to save template %d: %v", i, err)
+		}
+	}
+
+	if _, err := s.Save(RoomTemplate{Name: "one-more", Config: DefaultRoomConfig(), CreatedBy: "player"}); err == nil {
+		t.Error("player saved past the limit")
+	}
+	if _, err := s.Save(RoomTemplate{Name: "template-0", Config: DefaultRoomConfig(), CreatedBy: "player"}); err != nil {
+		t.Errorf("player at the limit couldn't replace their own template: %v", err)
+	}
+}
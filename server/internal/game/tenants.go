@@ -0,0 +1,117 @@
+package game
+
+import (
+	"strings"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// DefaultTenant is the realm of connections no tenant rule matches. Its IDs
+// carry no prefix, so a server with a single realm works as it always has.
+const DefaultTenant = ""
+
+// tenantSeparator joins a realm to the room and player IDs inside it
+const tenantSeparator = "/"
+
+// TenantOf returns the realm a room or player ID belongs to
+func TenantOf(id string) string {
+	if i := strings.Index(id, tenantSeparator); i >= 0 {
+		return id[:i]
+	}
+	return DefaultTenant
+}
+
+// Qualify places a room or player ID in a realm. IDs already in it come
+// back unchanged, so IDs the server handed out can be sent back as they
+// are; an ID naming any other realm is nested inside this one and can't
+// reach outside it.
+func Qualify(tenant, id string) string {
+	if tenant == DefaultTenant || id == "" || strings.HasPrefix(id, tenant+tenantSeparator) {
+		return id
+	}
+	return tenant + tenantSeparator + id
+}
+
+// Claim places an ID a client sent in the client's realm, as Qualify does.
+// The default realm's IDs carry no prefix, so Qualify would let its clients
+// name any other realm's IDs; Claim refuses IDs from them that name one.
+func Claim(tenant, id string) (string, error) {
+	if tenant == DefaultTenant && strings.Contains(id, tenantSeparator) {
+		return "", i18n.NewError(i18n.ErrOutsideRealm, map[string]interface{}{"id": id})
+	}
+	return Qualify(tenant, id), nil
+}
+
+// TenantResolver maps connections to realms, by the auth token they present
+// or the host name they connected to. Tokens win over hosts.
+type TenantResolver struct {
+	mu      sync.RWMutex
+	byToken map[string]string
+	byHost  map[string]string
+}
+
+// NewTenantResolver creates a resolver that puts everyone in the default
+// realm
+func NewTenantResolver() *TenantResolver {
+	return &TenantResolver{
+		byToken: make(map[string]string),
+		byHost:  make(map[string]string),
+	}
+}
+
+// UseSpec adds rules from a comma separated list of
+// tenant=host:<name> and tenant=token:<token> entries
+func (r *TenantResolver) UseSpec(spec string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		invalid := i18n.NewError(i18n.ErrInvalidTenant, map[string]interface{}{"rule": rule})
+
+		tenant, match, ok := strings.Cut(rule, "=")
+		if !ok || tenant == "" || strings.Contains(tenant, tenantSeparator) {
+			return invalid
+		}
+		kind, value, ok := strings.Cut(match, ":")
+		if !ok || value == "" {
+			return invalid
+		}
+		switch kind {
+		case "host":
+			r.byHost[strings.ToLower(value)] = tenant
+		case "token":
+			r.byToken[value] = tenant
+		default:
+			return invalid
+		}
+	}
+	return nil
+}
+
+// Resolve returns the realm for a connection's token and host. The host
+// may include a port.
+func (r *TenantResolver) Resolve(host, token string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if tenant, ok := r.byToken[token]; ok && token != "" {
+		return tenant
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	if tenant, ok := r.byHost[strings.ToLower(host)]; ok {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// Tenants returns the resolver that assigns connections to realms
+func (m *Manager) Tenants() *TenantResolver {
+	return m.tenants
+}
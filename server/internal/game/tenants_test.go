@@ -0,0 +1,42 @@
+package game
+
+import "testing"
+
+func TestClaimKeepsClientsInTheirRealm(t *testing.T) {
+	tests := []struct {
+		tenant, id, want string
+		refused          bool
+	}{
+		{tenant: DefaultTenant, id: "room-1", want: "room-1"},
+		{tenant: DefaultTenant, id: "acme/room-1", refused: true},
+		{tenant: "acme", id: "room-1", want: "acme/room-1"},
+		{tenant: "acme", id: "acme/room-1", want: "acme/room-1"},
+		{tenant: "acme", id: "other/room-1", want: "acme/other/room-1"},
+	}
+	for _, tt := range tests {
+		got, err := Claim(tt.tenant, tt.id)
+		if tt.refused {
+			if err == nil {
+				t.Errorf("Claim(%q, %q) = %q, want it refused", tt.tenant, tt.id, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Claim(%q, %q) = %q, %v, want %q", tt.tenant, tt.id, got, err, tt.want)
+		}
+		if TenantOf(got) != tt.tenant {
+			t.Errorf("Claim(%q, %q) left realm %q", tt.tenant, tt.id, TenantOf(got))
+		}
+	}
+}
+
+func TestPartiesLiveInTheLeadersRealm(t *testing.T) {
+	store := NewPartyStore()
+	party, err := store.Create(Qualify("acme", "leader"))
+	if err != nil {
+		t.Fatalf("failed to create party: %v", err)
+	}
+	if TenantOf(party.ID) != "acme" {
+		t.Fatalf("party %s isn't in the leader's realm", party.ID)
+	}
+}
@@ -0,0 +1,92 @@
+package game
+
+import "math"
+
+// Wall is a map cell enemies can't walk through and towers can't be built
+// on. Tall walls also block line of sight, and projectiles in rooms with
+// solid terrain.
+type Wall struct {
+	X    int  `json:"x"`
+	Y    int  `json:"y"`
+	Tall bool `json:"tall,omitempty"`
+}
+
+// useWalls sets the room's terrain. Callers must hold the state lock, or
+// not have shared the state yet.
+func (gs *GameStateWithShooting) useWalls(walls []Wall) {
+	gs.Walls = walls
+	gs.wallIndex = make(map[[2]int]Wall, len(walls))
+	for _, w := range walls {
+		gs.wallIndex[[2]int{w.X, w.Y}] = w
+	}
+}
+
+// wallAt reports whether there's a wall in the cell holding pos
+func (gs *GameStateWithShooting) wallAt(pos Position) bool {
+	_, ok := gs.wallIndex[[2]int{int(math.Round(pos.X)), int(math.Round(pos.Y))}]
+	return ok
+}
+
+// tallAt reports whether the cell at x, y holds a tall wall
+func (gs *GameStateWithShooting) tallAt(x, y int) bool {
+	w, ok := gs.wallIndex[[2]int{x, y}]
+	return ok && w.Tall
+}
+
+// lineOfSight reports whether no tall wall stands between two positions
+func (gs *GameStateWithShooting) lineOfSight(from, to Position) bool {
+	if len(gs.wallIndex) == 0 {
+		return true
+	}
+	_, clear := Raycast(from, to, gs.tallAt)
+	return clear
+}
+
+// Raycast walks the grid cells a straight line from one position to another
+// passes through, in order, and stops at the first cell blocked reports.
+// It returns where the line entered that cell and false, or to and true if
+// nothing was in the way. Cells are centred on whole coordinates, as towers
+// are.
+func Raycast(from, to Position, blocked func(x, y int) bool) (Position, bool) {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	cx, cy := int(math.Floor(from.X+0.5)), int(math.Floor(from.Y+0.5))
+	endX, endY := int(math.Floor(to.X+0.5)), int(math.Floor(to.Y+0.5))
+	stepX, nextX, deltaX := raycastAxis(from.X+0.5, dx)
+	stepY, nextY, deltaY := raycastAxis(from.Y+0.5, dy)
+
+	t := 0.0
+	for {
+		if blocked(cx, cy) {
+			return Position{X: from.X + dx*t, Y: from.Y + dy*t}, false
+		}
+		if cx == endX && cy == endY {
+			return to, true
+		}
+		if nextX < nextY {
+			t = nextX
+			cx += stepX
+			nextX += deltaX
+		} else {
+			t = nextY
+			cy += stepY
+			nextY += deltaY
+		}
+		if t > 1 {
+			return to, true
+		}
+	}
+}
+
+// raycastAxis sets up one axis of a grid walk: which way it steps, how far
+// along the line (0..1) it first crosses a cell edge, and how far between
+// crossings after that
+func raycastAxis(start, delta float64) (int, float64, float64) {
+	switch {
+	case delta > 0:
+		return 1, (math.Floor(start) + 1 - start) / delta, 1 / delta
+	case delta < 0:
+		return -1, (start - math.Floor(start)) / -delta, -1 / delta
+	default:
+		return 0, math.Inf(1), math.Inf(1)
+	}
+}
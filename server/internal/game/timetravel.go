@@ -0,0 +1,147 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Time travel tuning, for debug rooms
+const (
+	travelCheckpointTicks = 600 // ten seconds between checkpoints
+	maxTravelCheckpoints  = 30  // how far back a room can be rebuilt
+)
+
+// travelCheckpoint is a debug room's full state at the end of a tick
+type travelCheckpoint struct {
+	tick    uint64
+	archive []byte // encoded roomArchive, so later ticks can't change it
+}
+
+// replayStep is a player action applied at the start of a tick, kept so
+// the tick can be simulated again
+type replayStep struct {
+	tick  uint64
+	apply func(gs *GameStateWithShooting)
+}
+
+// logStep keeps an action being applied so time travel can replay it. Only
+// debug rooms keep them. Callers must hold the state lock.
+func (gs *GameStateWithShooting) logStep(apply func(gs *GameStateWithShooting)) {
+	if gs.auditLog == nil {
+		return
+	}
+	gs.replay = append(gs.replay, replayStep{tick: gs.Tick, apply: apply})
+}
+
+// takeTravelCheckpoint encodes the whole room every checkpoint interval in
+// debug rooms, dropping the oldest checkpoint past the limit along with the
+// actions only it could replay. Callers must hold the state lock.
+func (gs *GameStateWithShooting) takeTravelCheckpoint() {
+	if gs.auditLog == nil {
+		return
+	}
+	if len(gs.travel) > 0 && gs.Tick%travelCheckpointTicks != 0 {
+		return
+	}
+
+	snapshot := gs.snapshot()
+	data, err := json.Marshal(gs.archiveWith(&snapshot))
+	if err != nil {
+		log.Printf("❌ Failed to take time travel checkpoint for room %s: %v", gs.RoomID, err)
+		return
+	}
+	gs.travel = append(gs.travel, travelCheckpoint{tick: gs.Tick, archive: data})
+	if len(gs.travel) <= maxTravelCheckpoints {
+		return
+	}
+
+	gs.travel = gs.travel[1:]
+	oldest := gs.travel[0].tick
+	kept := 0
+	for kept < len(gs.replay) && gs.replay[kept].tick < oldest {
+		kept++
+	}
+	gs.replay = gs.replay[kept:]
+}
+
+// TimeTravel rebuilds a debug room as it was at the end of a past tick, for
+// investigating bugs. It loads the newest checkpoint at or before the tick
+// and simulates forward, applying the actions players took on the ticks
+// they took them. The live room isn't touched. Spawns adaptive pacing
+// pushed back aren't replayed, so such rooms may not rebuild exactly.
+// Rooms that crashed and haven't been recovered are rebuilt from the
+// write-ahead log they left.
+func (m *Manager) TimeTravel(roomID string, tick uint64) (*Snapshot, error) {
+	room, exists := m.GetShootingRoom(roomID)
+	if !exists {
+		return m.ReplayWAL(roomID, tick)
+	}
+	return room.travelTo(tick)
+}
+
+// travelTo rebuilds the room at the end of a tick
+func (gs *GameStateWithShooting) travelTo(tick uint64) (*Snapshot, error) {
+	gs.mu.RLock()
+	if gs.auditLog == nil {
+		gs.mu.RUnlock()
+		return nil, i18n.NewError(i18n.ErrAuditDisabled, map[string]interface{}{"room_id": gs.RoomID})
+	}
+	var from *travelCheckpoint
+	for i := len(gs.travel) - 1; i >= 0; i-- {
+		if gs.travel[i].tick <= tick {
+			from = &gs.travel[i]
+			break
+		}
+	}
+	if from == nil || tick > gs.Tick {
+		params := map[string]interface{}{"tick": tick, "current": gs.Tick, "oldest": gs.Tick}
+		if len(gs.travel) > 0 {
+			params["oldest"] = gs.travel[0].tick
+		}
+		gs.mu.RUnlock()
+		return nil, i18n.NewError(i18n.ErrTickUnavailable, params)
+	}
+	checkpoint := *from
+	steps := append([]replayStep(nil), gs.replay...)
+	balance := gs.mods.balance
+	gs.mu.RUnlock()
+
+	var a roomArchive
+	if err := json.Unmarshal(checkpoint.archive, &a); err != nil {
+		return nil, err
+	}
+	past := restoreRoom(a, balance)
+	// Entities spawned while replaying get the IDs they got the first time
+	past.ids = idAllocator{epoch: a.EntityEpoch, next: a.NextEntity}
+	// The rebuilt room is thrown away, so it records nothing of its own
+	past.auditLog = nil
+
+	past.replayTo(tick, steps)
+	return past.GetSnapshot(), nil
+}
+
+// replayTo simulates a rebuilt room forward to the end of a tick. It stops
+// early if the room can't advance, once it's over or left paused.
+func (gs *GameStateWithShooting) replayTo(tick uint64, steps []replayStep) {
+	next := 0
+	for next < len(steps) && steps[next].tick < gs.Tick {
+		next++
+	}
+
+	for gs.Tick < tick {
+		gs.mu.Lock()
+		for next < len(steps) && steps[next].tick == gs.Tick {
+			steps[next].apply(gs)
+			next++
+		}
+		gs.mu.Unlock()
+
+		before := gs.Tick
+		gs.Update(1.0 / 60.0)
+		if gs.Tick == before {
+			return
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package game
+
+import "rust-rush/server/internal/i18n"
+
+// Tower lifecycle states
+const (
+	TowerStateConstructing = "constructing"
+	TowerStateActive       = "active"
+	TowerStateUpgrading    = "upgrading"
+	TowerStateSelling      = "selling"
+	TowerStateDisabled     = "disabled"
+)
+
+// Tower upgrade and sell tuning
+const (
+	maxTowerLevel          = 3
+	upgradeCostRatio       = 0.75 // of the base cost, per level
+	upgradeTimeRatio       = 0.75 // of the build time
+	upgradeDamageRatio     = 1.25
+	upgradeRangeRatio      = 1.1
+	sellTime               = 1.0 // seconds
+	sellRefundRatio        = 0.5 // of gold invested
+	towerStateTimerEpsilon = 1e-9
+)
+
+// canQueueBuilds reports whether new towers go into the build queue instead
+// of starting construction right away: while paused, or between waves when
+// no enemies are on the field
+func (gs *GameStateWithShooting) canQueueBuilds() bool {
+	return gs.Paused || len(gs.Enemies) == 0
+}
+
+// updateTowerState advances a tower's state timer and reports whether the
+// tower is active and able to shoot this frame
+func (gs *GameStateWithShooting) updateTowerState(tower *Tower, deltaTime float64) bool {
+	switch tower.State {
+	case TowerStateActive:
+		return true
+
+	case TowerStateConstructing:
+		// Queued towers wait their turn; only the head of the queue builds
+		if gs.isQueuedBehind(tower.ID) {
+			return false
+		}
+
+		tower.StateTimer -= deltaTime
+		if tower.BuildTime > 0 {
+			tower.BuildProgress = 1 - tower.StateTimer/tower.BuildTime
+		}
+		if tower.StateTimer <= towerStateTimerEpsilon {
+			gs.finishConstruction(tower)
+		}
+
+	case TowerStateUpgrading:
+		tower.StateTimer -= deltaTime
+		if tower.StateTimer <= towerStateTimerEpsilon {
+			gs.finishUpgrade(tower)
+			gs.setTowerState(tower, TowerStateActive, 0)
+		}
+
+	case TowerStateSelling:
+		tower.StateTimer -= deltaTime
+		if tower.StateTimer <= towerStateTimerEpsilon {
+			gs.adjustGold(GoldTowerSold, int(float64(tower.invested)*sellRefundRatio), tower.OwnerID, tower.ID)
+			tower.StateTimer = 0
+			tower.sold = true
+		}
+
+	case TowerStateDisabled:
+		tower.StateTimer -= deltaTime
+		if tower.StateTimer <= towerStateTimerEpsilon {
+			gs.setTowerState(tower, TowerStateActive, 0)
+		}
+	}
+
+	return false
+}
+
+// setTowerState moves a tower into a new state with the given timer
+func (gs *GameStateWithShooting) setTowerState(tower *Tower, state string, timer float64) {
+	gs.audit(AuditTowerState, tower.ID, 0, map[string]interface{}{
+		"from":  tower.State,
+		"to":    state,
+		"timer": timer,
+	})
+	tower.State = state
+	tower.StateTimer = timer
+	if state != TowerStateActive {
+		tower.CurrentTarget = 0
+	}
+}
+
+// finishConstruction marks a tower as built and pops it from the build queue
+func (gs *GameStateWithShooting) finishConstruction(tower *Tower) {
+	tower.BuildProgress = 1
+	gs.setTowerState(tower, TowerStateActive, 0)
+
+	if len(gs.buildQueue) > 0 && gs.buildQueue[0] == tower.ID {
+		gs.buildQueue = gs.buildQueue[1:]
+	}
+}
+
+// isQueuedBehind reports whether a tower is waiting in the build queue
+// behind another tower
+func (gs *GameStateWithShooting) isQueuedBehind(towerID EntityID) bool {
+	for i, id := range gs.buildQueue {
+		if id == towerID {
+			return i > 0
+		}
+	}
+	return false
+}
+
+// removeSoldTowers drops towers that finished selling and reroutes enemies
+// through the freed cells
+func (gs *GameStateWithShooting) removeSoldTowers() {
+	before := len(gs.Towers)
+	gs.Towers = updateEach(gs.Towers, func(tower *Tower) bool {
+		return !tower.sold
+	})
+
+	if len(gs.Towers) != before {
+		gs.RecalculateEnemyPaths()
+	}
+}
+
+// findTower returns the tower with the given ID
+func (gs *GameStateWithShooting) findTower(id EntityID) *Tower {
+	return findByID(gs.Towers, id)
+}
+
+// checkTowerOwner makes sure a player may change a tower. In rooms with
+// strict ownership only the player who built it and the host may. Callers
+// must hold the state lock.
+func (gs *GameStateWithShooting) checkTowerOwner(towerID EntityID, playerID string) error {
+	if !gs.Config.StrictOwnership || playerID == "" || playerID == gs.Host {
+		return nil
+	}
+
+	// Missing towers are reported by the action itself
+	tower := gs.findTower(towerID)
+	if tower == nil || tower.OwnerID == "" || tower.OwnerID == playerID {
+		return nil
+	}
+	return i18n.NewError(i18n.ErrNotTowerOwner, map[string]interface{}{
+		"tower_id": towerID,
+		"owner":    tower.OwnerName,
+	})
+}
+
+// UpgradeTower starts upgrading an active tower to the next level, if it
+// has only one way to go
+func (gs *GameStateWithShooting) UpgradeTower(towerID EntityID) (Tower, error) {
+	result, err := gs.ApplyCommand(UpgradeTower{TowerID: towerID})
+	tower, _ := result.(Tower)
+	return tower, err
+}
+
+// upgradeTower starts an upgrade along a branch of the tower's tree, or the
+// linear upgrade for types without one. Callers must hold the state lock.
+func (gs *GameStateWithShooting) upgradeTower(towerID EntityID, branchName string) (Tower, error) {
+	tower := gs.findTower(towerID)
+	if tower == nil {
+		return Tower{}, i18n.NewError(i18n.ErrTowerNotFound, map[string]interface{}{"tower_id": towerID})
+	}
+	if tower.State != TowerStateActive {
+		return Tower{}, i18n.NewError(i18n.ErrTowerBusy, map[string]interface{}{"state": tower.State})
+	}
+	branch, err := gs.chooseBranch(tower, branchName)
+	if err != nil {
+		return Tower{}, err
+	}
+	if err := gs.checkCombatLock(); err != nil {
+		return Tower{}, err
+	}
+
+	stats := gs.mods.towerStats(tower.TowerType)
+	cost := gs.upgradeCost(tower, branch)
+	if gs.Gold < cost {
+		return Tower{}, i18n.NewError(i18n.ErrInsufficientGold, map[string]interface{}{
+			"cost": cost,
+			"gold": gs.Gold,
+		})
+	}
+
+	gs.adjustGold(GoldTowerUpgrade, -cost, tower.OwnerID, tower.ID)
+	tower.invested += cost
+	if branch.Name != "" {
+		// Copied, since snapshots and checkpoints share the old slice
+		tower.Branches = append(append([]string(nil), tower.Branches...), branch.Name)
+	}
+	gs.setTowerState(tower, TowerStateUpgrading, stats.BuildTime*upgradeTimeRatio)
+	gs.recordInput(InputUpgradeTower, map[string]interface{}{"tower_id": towerID, "branch": branch.Name})
+
+	return *tower, nil
+}
+
+// SellTower starts selling a tower. The refund is paid once selling finishes.
+func (gs *GameStateWithShooting) SellTower(towerID EntityID) (Tower, error) {
+	result, err := gs.ApplyCommand(SellTower{TowerID: towerID})
+	tower, _ := result.(Tower)
+	return tower, err
+}
+
+// sellTower starts a sale. Callers must hold the state lock.
+func (gs *GameStateWithShooting) sellTower(towerID EntityID) (Tower, error) {
+	tower := gs.findTower(towerID)
+	if tower == nil {
+		return Tower{}, i18n.NewError(i18n.ErrTowerNotFound, map[string]interface{}{"tower_id": towerID})
+	}
+	if tower.State == TowerStateSelling || tower.State == TowerStateUpgrading {
+		return Tower{}, i18n.NewError(i18n.ErrTowerBusy, map[string]interface{}{"state": tower.State})
+	}
+	if err := gs.checkCombatLock(); err != nil {
+		return Tower{}, err
+	}
+	gs.recordInput(InputSellTower, map[string]interface{}{"tower_id": towerID})
+
+	// Unfinished towers are refunded in full and removed from the build queue
+	if tower.State == TowerStateConstructing {
+		for i, id := range gs.buildQueue {
+			if id == towerID {
+				gs.buildQueue = append(gs.buildQueue[:i], gs.buildQueue[i+1:]...)
+				break
+			}
+		}
+		gs.adjustGold(GoldTowerRefund, tower.invested, tower.OwnerID, tower.ID)
+		tower.invested = 0
+	}
+
+	gs.setTowerState(tower, TowerStateSelling, sellTime)
+
+	return *tower, nil
+}
+
+// inCombat reports whether a wave is being fought: enemies are on the field
+// or still to spawn. Callers must hold the state lock.
+func (gs *GameStateWithShooting) inCombat() bool {
+	return len(gs.Enemies) > 0 || len(gs.pendingSpawns) > 0
+}
+
+// checkCombatLock refuses tower changes while a wave is being fought in
+// rooms with the combat lock mutator. Callers must hold the state lock.
+func (gs *GameStateWithShooting) checkCombatLock() error {
+	if gs.mods.combatLock && gs.inCombat() {
+		enemies := len(gs.Enemies) + len(gs.pendingSpawns)
+		return i18n.NewError(i18n.ErrCombatLocked, map[string]interface{}{"enemies": enemies})
+	}
+	return nil
+}
+
+// SetPaused pauses or resumes the simulation
+func (gs *GameStateWithShooting) SetPaused(paused bool) {
+	gs.ApplyCommand(SetPaused{Paused: paused})
+}
+
+// setPaused pauses or resumes. Callers must hold the state lock.
+func (gs *GameStateWithShooting) setPaused(paused bool) {
+	gs.Paused = paused
+	gs.recordInput(InputPause, map[string]interface{}{"paused": paused})
+}
+
+// IsPaused reports whether the simulation is paused
+func (gs *GameStateWithShooting) IsPaused() bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	return gs.Paused
+}
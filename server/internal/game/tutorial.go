@@ -0,0 +1,307 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Tutorial step actions. Spawns, pauses, hints and checkpoints happen as
+// soon as they're reached; the rest hold the script until the player or
+// the field catches up.
+const (
+	StepSpawn      = "spawn"       // send Count enemies of EnemyType
+	StepPause      = "pause"       // pause the simulation
+	StepResume     = "resume"      // unpause it
+	StepHint       = "hint"        // show Text, pointing at Position if set
+	StepCheckpoint = "checkpoint"  // mark progress as Checkpoint
+	StepPlaceTower = "place_tower" // wait for the player to build a TowerType
+	StepWait       = "wait"        // wait Seconds of game time
+	StepClear      = "clear"       // wait until no enemies are left or due
+)
+
+// Tutorial event types
+const (
+	EventTutorialHint       = "tutorial_hint"
+	EventTutorialCheckpoint = "tutorial_checkpoint"
+)
+
+// TutorialStep is one instruction of a tutorial script
+type TutorialStep struct {
+	Action     string    `json:"action"`
+	EnemyType  string    `json:"enemy_type,omitempty"`
+	Count      int       `json:"count,omitempty"` // defaults to 1
+	TowerType  string    `json:"tower_type,omitempty"`
+	Seconds    float64   `json:"seconds,omitempty"`
+	Text       string    `json:"text,omitempty"`
+	Position   *Position `json:"position,omitempty"`
+	Checkpoint string    `json:"checkpoint,omitempty"`
+}
+
+// TutorialScript is a scripted sequence a tutorial room plays through. The
+// room is won when the last step completes.
+type TutorialScript struct {
+	Name  string         `json:"name"`
+	Title string         `json:"title"`
+	Steps []TutorialStep `json:"steps"`
+}
+
+// Validate checks that every step is a known action with what it needs
+func (s TutorialScript) Validate(balance *Balance) error {
+	invalid := func(field string) error {
+		return i18n.NewError(i18n.ErrInvalidTutorial, map[string]interface{}{"name": s.Name, "field": field})
+	}
+
+	if s.Name == "" {
+		return invalid("name")
+	}
+	if len(s.Steps) == 0 {
+		return invalid("steps")
+	}
+	for _, step := range s.Steps {
+		switch step.Action {
+		case StepSpawn:
+			if _, ok := balance.Enemies[step.EnemyType]; !ok {
+				return invalid("enemy_type")
+			}
+			if step.Count < 0 || step.Count > maxGroupCount {
+				return invalid("count")
+			}
+		case StepPlaceTower:
+			if _, ok := balance.Towers[step.TowerType]; !ok {
+				return invalid("tower_type")
+			}
+		case StepWait:
+			if step.Seconds <= 0 {
+				return invalid("seconds")
+			}
+		case StepHint:
+			if step.Text == "" {
+				return invalid("text")
+			}
+			if step.Position != nil && !InBounds(*step.Position) {
+				return invalid("position")
+			}
+		case StepCheckpoint:
+			if step.Checkpoint == "" {
+				return invalid("checkpoint")
+			}
+		case StepPause, StepResume, StepClear:
+		default:
+			return invalid("action")
+		}
+	}
+	return nil
+}
+
+// defaultTutorials is the built-in library
+func defaultTutorials() []TutorialScript {
+	return []TutorialScript{
+		{
+			Name:  "basics",
+			Title: "Holding the Line",
+			Steps: []TutorialStep{
+				{Action: StepHint, Text: "Enemies walk from the spawn to your base. Each one that gets through costs you health."},
+				{Action: StepSpawn, EnemyType: "basic"},
+				{Action: StepWait, Seconds: 2},
+				{Action: StepPause},
+				{Action: StepHint, Text: "Build a basic tower next to the path to stop it."},
+				{Action: StepPlaceTower, TowerType: "basic"},
+				{Action: StepResume},
+				{Action: StepClear},
+				{Action: StepCheckpoint, Checkpoint: "first_tower"},
+				{Action: StepHint, Text: "Fast enemies are harder to hit. Build more towers if you need them."},
+				{Action: StepSpawn, EnemyType: "fast", Count: 3},
+				{Action: StepClear},
+				{Action: StepCheckpoint, Checkpoint: "fast_enemies"},
+				{Action: StepHint, Text: "That's the basics. Good luck out there!"},
+			},
+		},
+	}
+}
+
+// TutorialLibrary holds the tutorial scripts. The built-in ones are always
+// available; a file can add more or replace them by name.
+type TutorialLibrary struct {
+	mu      sync.RWMutex
+	scripts map[string]TutorialScript
+}
+
+// NewTutorialLibrary creates a library with the built-in tutorials
+func NewTutorialLibrary() *TutorialLibrary {
+	l := &TutorialLibrary{scripts: make(map[string]TutorialScript)}
+	for _, s := range defaultTutorials() {
+		l.scripts[s.Name] = s
+	}
+	return l
+}
+
+// UseFile loads tutorials from a JSON file holding a list of scripts.
+// Every script is validated before any are added.
+func (l *TutorialLibrary) UseFile(path string, balance *Balance) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var scripts []TutorialScript
+	if err := json.Unmarshal(data, &scripts); err != nil {
+		return err
+	}
+	for _, s := range scripts {
+		if err := s.Validate(balance); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range scripts {
+		l.scripts[s.Name] = s
+	}
+	return nil
+}
+
+// Get looks up a tutorial by name
+func (l *TutorialLibrary) Get(name string) (TutorialScript, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	s, ok := l.scripts[name]
+	if !ok {
+		return TutorialScript{}, i18n.NewError(i18n.ErrUnknownTutorial, map[string]interface{}{"name": name})
+	}
+	return s, nil
+}
+
+// List returns every tutorial, sorted by name
+func (l *TutorialLibrary) List() []TutorialScript {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	scripts := make([]TutorialScript, 0, len(l.scripts))
+	for _, s := range l.scripts {
+		scripts = append(scripts, s)
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].Name < scripts[j].Name
+	})
+	return scripts
+}
+
+// TutorialProgress is how far a tutorial room has got, sent with its state
+type TutorialProgress struct {
+	Name       string `json:"name"`
+	Step       int    `json:"step"` // index of the step being waited on
+	Steps      int    `json:"steps"`
+	Checkpoint string `json:"checkpoint,omitempty"` // the last one reached
+	Waiting    string `json:"waiting,omitempty"`    // action the script is held on
+}
+
+// tutorialRun is a tutorial room's position in its script. It's exported
+// field by field so handoffs can carry it.
+type tutorialRun struct {
+	Script     TutorialScript `json:"script"`
+	Step       int            `json:"step"`
+	Started    bool           `json:"started"`  // the current step has begun
+	Since      float64        `json:"since"`    // game time the current step began
+	Baseline   int            `json:"baseline"` // matching towers when a place_tower step began
+	Checkpoint string         `json:"checkpoint,omitempty"`
+}
+
+// progress reports the run for snapshots. It's nil outside tutorials.
+func (t *tutorialRun) progress() *TutorialProgress {
+	if t == nil {
+		return nil
+	}
+
+	p := &TutorialProgress{
+		Name:       t.Script.Name,
+		Step:       t.Step,
+		Steps:      len(t.Script.Steps),
+		Checkpoint: t.Checkpoint,
+	}
+	if t.Started && t.Step < len(t.Script.Steps) {
+		p.Waiting = t.Script.Steps[t.Step].Action
+	}
+	return p
+}
+
+// updateTutorial runs the script until it reaches a step that isn't done
+// yet, and wins the room after the last one. It runs while paused too, so
+// the player can act on a paused field. Callers must hold the state lock.
+func (gs *GameStateWithShooting) updateTutorial() {
+	t := gs.tutorial
+	if t == nil || gs.GameOver {
+		return
+	}
+
+	for t.Step < len(t.Script.Steps) {
+		if !gs.runTutorialStep(t, t.Script.Steps[t.Step]) {
+			return
+		}
+		t.Step++
+		t.Started = false
+	}
+
+	gs.Victory = true
+	gs.GameOver = true
+	gs.lobbyChanged() // no longer in progress
+}
+
+// runTutorialStep carries out a step, reporting whether it's complete.
+// Callers must hold the state lock.
+func (gs *GameStateWithShooting) runTutorialStep(t *tutorialRun, step TutorialStep) bool {
+	if !t.Started {
+		t.Started = true
+		t.Since = gs.GameTime
+		t.Baseline = gs.countTowers(step.TowerType)
+	}
+
+	switch step.Action {
+	case StepSpawn:
+		count := step.Count
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			gs.spawnEnemy("", step.EnemyType, nil)
+		}
+	case StepPause:
+		gs.setPaused(true)
+	case StepResume:
+		gs.setPaused(false)
+	case StepHint:
+		gs.emitEvent(EventTutorialHint, step.Position, map[string]interface{}{
+			"step": t.Step,
+			"text": step.Text,
+		})
+	case StepCheckpoint:
+		t.Checkpoint = step.Checkpoint
+		gs.emitEvent(EventTutorialCheckpoint, nil, map[string]interface{}{
+			"step":       t.Step,
+			"checkpoint": step.Checkpoint,
+		})
+	case StepPlaceTower:
+		return gs.countTowers(step.TowerType) > t.Baseline
+	case StepWait:
+		return gs.GameTime-t.Since >= step.Seconds
+	case StepClear:
+		return len(gs.Enemies) == 0 && len(gs.pendingSpawns) == 0
+	}
+	return true
+}
+
+// countTowers counts the towers of a type. Callers must hold the state lock.
+func (gs *GameStateWithShooting) countTowers(towerType string) int {
+	n := 0
+	for _, t := range gs.Towers {
+		if t.TowerType == towerType {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"math"
+	"strings"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// upgradeBranch is one choice in a tower type's upgrade tree. Each upgrade
+// takes the tower a level up one branch, multiplying its stats; the
+// branches under it are the choices for the upgrade after.
+type upgradeBranch struct {
+	Name string `json:"name"`
+	Cost int    `json:"cost,omitempty"` // 0 costs the same as a linear upgrade
+
+	// Stat multipliers; 0 leaves a stat alone
+	Damage   float64 `json:"damage,omitempty"`
+	Range    float64 `json:"range,omitempty"`
+	FireRate float64 `json:"fire_rate,omitempty"`
+
+	ArmorPierce float64         `json:"armor_pierce,omitempty"` // share of enemy armor ignored from here on
+	Next        []upgradeBranch `json:"next,omitempty"`
+}
+
+// linearUpgrade is the upgrade of tower types without a tree
+var linearUpgrade = upgradeBranch{Damage: upgradeDamageRatio, Range: upgradeRangeRatio}
+
+// validateBranches rejects trees whose branches can't be told apart or
+// would break the simulation
+func validateBranches(branches []upgradeBranch) bool {
+	seen := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		if b.Name == "" || seen[b.Name] {
+			return false
+		}
+		seen[b.Name] = true
+		if b.Cost < 0 || b.Damage < 0 || b.Range < 0 || b.FireRate < 0 || b.ArmorPierce < 0 || b.ArmorPierce > 1 {
+			return false
+		}
+		if !validateBranches(b.Next) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyBranches deep copies a tree, so a copy can be changed without
+// touching a published balance
+func copyBranches(branches []upgradeBranch) []upgradeBranch {
+	if branches == nil {
+		return nil
+	}
+	copied := make([]upgradeBranch, len(branches))
+	for i, b := range branches {
+		copied[i] = b
+		copied[i].Next = copyBranches(b.Next)
+	}
+	return copied
+}
+
+// branchNames lists the names of a set of choices
+func branchNames(branches []upgradeBranch) []string {
+	names := make([]string, len(branches))
+	for i, b := range branches {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// upgradeChoices returns what a tower can be upgraded to next, following
+// the branches it has already taken. Towers whose type has no tree have the
+// linear upgrade until the level cap. It returns nil at the end of the
+// tree, or if the tree changed under the tower.
+func upgradeChoices(stats towerStats, tower *Tower) []upgradeBranch {
+	if len(stats.Upgrades) == 0 {
+		if tower.Level >= maxTowerLevel {
+			return nil
+		}
+		return []upgradeBranch{linearUpgrade}
+	}
+
+	choices := stats.Upgrades
+	for _, name := range tower.Branches {
+		next := findBranch(choices, name)
+		if next == nil {
+			return nil
+		}
+		choices = next.Next
+	}
+	return choices
+}
+
+// findBranch returns the choice with the given name
+func findBranch(branches []upgradeBranch, name string) *upgradeBranch {
+	for i := range branches {
+		if branches[i].Name == name {
+			return &branches[i]
+		}
+	}
+	return nil
+}
+
+// chooseBranch picks the upgrade a player asked for. The name can be left
+// out when there's only one choice. Callers must hold the state lock.
+func (gs *GameStateWithShooting) chooseBranch(tower *Tower, name string) (upgradeBranch, error) {
+	choices := upgradeChoices(gs.mods.towerStats(tower.TowerType), tower)
+	if len(choices) == 0 {
+		return upgradeBranch{}, i18n.NewError(i18n.ErrTowerMaxLevel, map[string]interface{}{"level": tower.Level})
+	}
+	if name == "" && len(choices) == 1 {
+		return choices[0], nil
+	}
+
+	branch := findBranch(choices, name)
+	if branch == nil {
+		return upgradeBranch{}, i18n.NewError(i18n.ErrUnknownBranch, map[string]interface{}{
+			"branch":  name,
+			"choices": strings.Join(branchNames(choices), ", "),
+		})
+	}
+	return *branch, nil
+}
+
+// upgradeCost is the gold an upgrade along a branch costs. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) upgradeCost(tower *Tower, branch upgradeBranch) int {
+	if branch.Cost > 0 {
+		return int(math.Ceil(float64(branch.Cost) * gs.mods.towerCost))
+	}
+	stats := gs.mods.towerStats(tower.TowerType)
+	return int(float64(stats.Cost*tower.Level) * upgradeCostRatio)
+}
+
+// finishUpgrade takes a tower up a level along the branch it was upgrading
+// to. If the tree changed during the upgrade it gets the linear upgrade
+// instead. Callers must hold the state lock.
+func (gs *GameStateWithShooting) finishUpgrade(tower *Tower) {
+	branch := linearUpgrade
+	if n := len(tower.Branches); n > 0 {
+		stats := gs.mods.towerStats(tower.TowerType)
+		parent := &Tower{TowerType: tower.TowerType, Branches: tower.Branches[:n-1]}
+		if b := findBranch(upgradeChoices(stats, parent), tower.Branches[n-1]); b != nil {
+			branch = *b
+		}
+	}
+
+	tower.Level++
+	if branch.Damage > 0 {
+		tower.Damage *= branch.Damage
+	}
+	if branch.Range > 0 {
+		tower.Range *= branch.Range
+	}
+	if branch.FireRate > 0 {
+		tower.FireRate *= branch.FireRate
+	}
+	if branch.ArmorPierce > tower.ArmorPierce {
+		tower.ArmorPierce = branch.ArmorPierce
+	}
+}
+
+// armorFactor is the share of a hit an enemy's armor lets through, after
+// the tower that dealt it pierces what it can. Callers must hold the state
+// lock.
+func (gs *GameStateWithShooting) armorFactor(enemy *Enemy, towerID EntityID) float64 {
+	if enemy.Armor <= 0 {
+		return 1
+	}
+	pierce := 0.0
+	if tower := gs.findTower(towerID); tower != nil {
+		pierce = tower.ArmorPierce
+	}
+	return 1 - enemy.Armor*(1-pierce)
+}
@@ -0,0 +1,229 @@
+package game
+
+// Room modes
+const (
+	ModeCoop     = "coop"
+	ModeVersus   = "versus"
+	ModeTutorial = "tutorial" // coop following a tutorial script
+	ModePractice = "practice" // one player, who can rewind
+)
+
+// Versus tuning
+const (
+	versusStartingHealth = 100
+	leakDamage           = 10
+	bossKillHeal         = 20
+	suddenDeathLeakMult  = 2
+)
+
+// VersusPlayer is one side of a versus match
+type VersusPlayer struct {
+	PlayerID     string `json:"player_id"`
+	Health       int    `json:"health"`
+	Leaks        int    `json:"leaks"`         // enemies that got through this player's defense
+	HealthStolen int    `json:"health_stolen"` // health taken from opponents by leaks
+	BossKills    int    `json:"boss_kills"`
+	Team         int    `json:"team,omitempty"` // 0 plays alone
+}
+
+// VersusState is the scoreboard of a versus match
+type VersusState struct {
+	Players     []VersusPlayer `json:"players"`
+	SuddenDeath bool           `json:"sudden_death"`
+	Winner      string         `json:"winner,omitempty"`
+
+	teams map[string]int // pre-assigned teams for matchmade parties
+}
+
+// isVersus reports whether the room is a versus match
+func (gs *GameStateWithShooting) isVersus() bool {
+	return gs.Versus != nil
+}
+
+// Ranked reports whether the room's results change player ratings. Only
+// versus matches do; every other room is a sandbox as far as ratings go.
+func (gs *GameStateWithShooting) Ranked() bool {
+	return gs.Config.Mode == ModeVersus
+}
+
+// versusPlayer returns the scoreboard entry for a player
+func (gs *GameStateWithShooting) versusPlayer(playerID string) *VersusPlayer {
+	if gs.Versus == nil {
+		return nil
+	}
+	for i := range gs.Versus.Players {
+		if gs.Versus.Players[i].PlayerID == playerID {
+			return &gs.Versus.Players[i]
+		}
+	}
+	return nil
+}
+
+// sameSide reports whether two players are the same player or teammates
+func sameSide(a, b *VersusPlayer) bool {
+	return a.PlayerID == b.PlayerID || (a.Team != 0 && a.Team == b.Team)
+}
+
+// opponentOf returns the first player on the other side of the scoreboard
+func (gs *GameStateWithShooting) opponentOf(playerID string) *VersusPlayer {
+	player := gs.versusPlayer(playerID)
+	if player == nil {
+		return nil
+	}
+	for i := range gs.Versus.Players {
+		if !sameSide(player, &gs.Versus.Players[i]) {
+			return &gs.Versus.Players[i]
+		}
+	}
+	return nil
+}
+
+// versusSides splits the scoreboard into the winner's side and everyone else
+func (gs *GameStateWithShooting) versusSides() (winners, losers []string) {
+	winner := gs.versusPlayer(gs.Versus.Winner)
+	if winner == nil {
+		return nil, nil
+	}
+	for i := range gs.Versus.Players {
+		player := &gs.Versus.Players[i]
+		if sameSide(winner, player) {
+			winners = append(winners, player.PlayerID)
+		} else {
+			losers = append(losers, player.PlayerID)
+		}
+	}
+	return winners, losers
+}
+
+// AssignTeams puts players on numbered teams before they join, so parties
+// matched together play on the same side
+func (gs *GameStateWithShooting) AssignTeams(teams [][]string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !gs.isVersus() {
+		return
+	}
+
+	gs.Versus.teams = make(map[string]int)
+	for i, members := range teams {
+		for _, playerID := range members {
+			gs.Versus.teams[playerID] = i + 1
+		}
+	}
+}
+
+// addVersusPlayer puts a newly joined player on the scoreboard. Callers must
+// hold the state lock.
+func (gs *GameStateWithShooting) addVersusPlayer(playerID string) {
+	if !gs.isVersus() || gs.versusPlayer(playerID) != nil {
+		return
+	}
+
+	gs.Versus.Players = append(gs.Versus.Players, VersusPlayer{
+		PlayerID: playerID,
+		Health:   versusStartingHealth,
+		Team:     gs.Versus.teams[playerID],
+	})
+	gs.emitScoreboard()
+}
+
+// versusLeak resolves an enemy reaching the goal in versus mode: the player
+// it was sent at loses the damage the leak mode charges and the sender
+// steals it. In sudden death leaks hit harder and nobody heals.
+func (gs *GameStateWithShooting) versusLeak(enemy *Enemy, damage int) {
+	victim := gs.versusPlayer(enemy.TargetPlayer)
+	if victim == nil {
+		return
+	}
+
+	if gs.Versus.SuddenDeath {
+		damage *= suddenDeathLeakMult
+	}
+
+	victim.Health -= damage
+	victim.Leaks++
+
+	if sender := gs.versusPlayer(enemy.SenderID); sender != nil && !gs.Versus.SuddenDeath {
+		sender.Health += damage
+		sender.HealthStolen += damage
+	}
+
+	gs.emitScoreboard()
+}
+
+// versusKill resolves an enemy death in versus mode: boss kills restore the
+// killing tower owner's health outside of sudden death
+func (gs *GameStateWithShooting) versusKill(enemy *Enemy) {
+	if enemy.EnemyType != "boss" {
+		return
+	}
+
+	tower := gs.findTower(enemy.lastHitBy)
+	if tower == nil {
+		return
+	}
+
+	owner := gs.versusPlayer(tower.OwnerID)
+	if owner == nil {
+		return
+	}
+
+	owner.BossKills++
+	if !gs.Versus.SuddenDeath {
+		owner.Health += bossKillHeal
+	}
+	gs.emitScoreboard()
+}
+
+// updateVersus enters sudden death at the configured wave and ends the match
+// once a player runs out of health
+func (gs *GameStateWithShooting) updateVersus() {
+	if !gs.isVersus() || gs.GameOver {
+		return
+	}
+
+	if !gs.Versus.SuddenDeath && gs.Config.SuddenDeathWave > 0 && gs.Wave >= gs.Config.SuddenDeathWave {
+		gs.Versus.SuddenDeath = true
+		gs.emitScoreboard()
+	}
+
+	for _, player := range gs.Versus.Players {
+		if player.Health > 0 {
+			continue
+		}
+
+		if opponent := gs.opponentOf(player.PlayerID); opponent != nil {
+			gs.Versus.Winner = opponent.PlayerID
+		}
+		gs.GameOver = true
+		gs.emitScoreboard()
+		return
+	}
+}
+
+// emitScoreboard sends the current scoreboard as an event
+func (gs *GameStateWithShooting) emitScoreboard() {
+	players := make([]VersusPlayer, len(gs.Versus.Players))
+	copy(players, gs.Versus.Players)
+
+	gs.emitEvent(EventScoreboard, nil, map[string]interface{}{
+		"players":      players,
+		"sudden_death": gs.Versus.SuddenDeath,
+		"winner":       gs.Versus.Winner,
+	})
+}
+
+// copyVersus returns a deep copy of the scoreboard for snapshots
+func copyVersus(v *VersusState) *VersusState {
+	if v == nil {
+		return nil
+	}
+	players := make([]VersusPlayer, len(v.Players))
+	copy(players, v.Players)
+	return &VersusState{
+		Players:     players,
+		SuddenDeath: v.SuddenDeath,
+		Winner:      v.Winner,
+	}
+}
@@ -0,0 +1,510 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Write-ahead log tuning
+const (
+	DefaultWALCheckpoint = 1800    // ticks between checkpoints, thirty seconds
+	walBufferSize        = 4096    // records waiting for the writer before new ones are dropped
+	walMaxRecordSize     = 1 << 26 // a checkpoint of a busy room fits
+	walFileExt           = ".wal"
+	walInstanceSeparator = "~" // between a log's room ID and room instance
+)
+
+// walRecord is one line of a room's write-ahead log: a command applied on
+// a tick, a checkpoint of the whole room, or the marker that the room
+// closed cleanly and needs no recovery
+type walRecord struct {
+	Tick       uint64          `json:"tick"`
+	Type       string          `json:"type,omitempty"`
+	Command    json.RawMessage `json:"command,omitempty"`
+	Checkpoint *roomArchive    `json:"checkpoint,omitempty"`
+	Closed     bool            `json:"closed,omitempty"`
+}
+
+// walCommands makes an empty command of each type the log records, to
+// decode into
+var walCommands = map[string]func() Command{
+	InputPlaceTower:   func() Command { return &PlaceTower{} },
+	InputSpawnEnemy:   func() Command { return &SpawnEnemy{} },
+	InputUpgradeTower: func() Command { return &UpgradeTower{} },
+	InputSellTower:    func() Command { return &SellTower{} },
+	InputTargetMode:   func() Command { return &SetTargetMode{} },
+	InputStartWave:    func() Command { return &StartWave{} },
+	InputPause:        func() Command { return &SetPaused{} },
+	InputClearTowers:  func() Command { return &ClearTowers{} },
+	InputClearEnemies: func() Command { return &ClearEnemies{} },
+	CommandClearAll:   func() Command { return &ClearAll{} },
+	InputSkipWait:     func() Command { return &SkipToNextWave{} },
+	CommandJoin:       func() Command { return &JoinRoom{} },
+	CommandLeave:      func() Command { return &LeaveRoom{} },
+	CommandReady:      func() Command { return &SetReady{} },
+	CommandKick:       func() Command { return &KickPlayer{} },
+	CommandRewind:     func() Command { return &Rewind{} },
+	CommandDropIn:     func() Command { return &SetDropIn{} },
+	CommandSurrender:  func() Command { return &Surrender{} },
+	CommandAirstrike:  func() Command { return &Airstrike{} },
+	commandAddEnemy:   func() Command { return &addEnemyCommand{} },
+}
+
+// commandAddEnemy is an enemy added outside the command queue's player
+// actions, e.g. by the legacy spawn message
+const commandAddEnemy = "add_enemy"
+
+// addEnemyCommand is AddEnemy as the log records it
+type addEnemyCommand struct {
+	EnemyType string
+	Path      []Position
+}
+
+func (addEnemyCommand) Type() string { return commandAddEnemy }
+
+func (c addEnemyCommand) apply(gs *GameStateWithShooting) (interface{}, error) {
+	return gs.addEnemy(c.EnemyType, c.Path, ""), nil
+}
+
+// walWriter appends a room's records to its log on its own goroutine, so
+// the game loop never waits on the disk. Each batch is synced before the
+// writer waits for more. A checkpoint starts the log afresh, since nothing
+// before it is needed to rebuild the room.
+type walWriter struct {
+	path       string
+	checkpoint uint64 // ticks between checkpoints
+	lines      chan walLine
+	done       chan struct{}
+}
+
+// walLine is an encoded record waiting for the writer
+type walLine struct {
+	data       []byte
+	checkpoint bool
+}
+
+// openWAL creates a room's log
+func openWAL(path string, checkpoint uint64) (*walWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walWriter{path: path, checkpoint: checkpoint, lines: make(chan walLine, walBufferSize), done: make(chan struct{})}
+	go w.run(f)
+	return w, nil
+}
+
+func (w *walWriter) run(f *os.File) {
+	defer close(w.done)
+	defer func() { f.Close() }()
+
+	out := bufio.NewWriter(f)
+	for line := range w.lines {
+		if line.checkpoint {
+			out.Flush()
+			rotated, err := w.rotate(line.data)
+			if err != nil {
+				log.Printf("❌ Failed to rotate WAL %s: %v", w.path, err)
+			} else {
+				f.Close()
+				f = rotated
+				out.Reset(f)
+				continue
+			}
+		}
+		out.Write(line.data)
+		out.WriteByte('\n')
+		if len(w.lines) > 0 {
+			continue
+		}
+		if err := out.Flush(); err != nil {
+			log.Printf("❌ Failed to write WAL %s: %v", w.path, err)
+			continue
+		}
+		f.Sync()
+	}
+	out.Flush()
+	f.Sync()
+}
+
+// rotate replaces the log with one holding just a checkpoint. The new log
+// is synced before it replaces the old, so a crash leaves one or the other.
+func (w *walWriter) rotate(checkpoint []byte) (*os.File, error) {
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(checkpoint, '\n')); err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	return f, nil
+}
+
+// append queues a record, reporting false if the writer is too far behind
+func (w *walWriter) append(record walRecord) bool {
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("❌ Failed to encode WAL record for %s: %v", w.path, err)
+		return false
+	}
+	select {
+	case w.lines <- walLine{data: data, checkpoint: record.Checkpoint != nil}:
+		return true
+	default:
+		return false
+	}
+}
+
+// close waits for every queued record to reach the disk
+func (w *walWriter) close() {
+	close(w.lines)
+	<-w.done
+}
+
+// logCommand appends an applied command to the room's log. If the writer
+// fell behind, the next tick writes a checkpoint so recovery doesn't
+// replay across the gap. Callers must hold the state lock.
+func (gs *GameStateWithShooting) logCommand(cmd Command) {
+	if gs.wal == nil {
+		return
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil || !gs.wal.append(walRecord{Tick: gs.Tick, Type: cmd.Type(), Command: data}) {
+		gs.walGap = true
+	}
+}
+
+// takeWALCheckpoint writes the whole room to its log every checkpoint
+// interval, and straight after a gap. Callers must hold the state lock.
+func (gs *GameStateWithShooting) takeWALCheckpoint() {
+	if gs.wal == nil || (!gs.walGap && gs.Tick%gs.wal.checkpoint != 0) {
+		return
+	}
+
+	snapshot := gs.snapshot()
+	a := gs.archiveWith(&snapshot)
+	gs.walGap = !gs.wal.append(walRecord{Tick: gs.Tick, Checkpoint: &a})
+}
+
+// closeWAL marks the room's log closed, so it isn't recovered, waits for
+// it to be written and deletes it. The marker keeps the room from coming
+// back if the log can't be deleted.
+func (gs *GameStateWithShooting) closeWAL() {
+	gs.mu.Lock()
+	w := gs.wal
+	gs.wal = nil
+	if w != nil && !w.append(walRecord{Tick: gs.Tick, Closed: true}) {
+		log.Printf("⚠️ WAL for room %s closed without its marker", gs.RoomID)
+	}
+	gs.mu.Unlock()
+
+	if w == nil {
+		return
+	}
+	w.close()
+	if err := os.Remove(w.path); err != nil {
+		log.Printf("⚠️ Failed to delete WAL %s: %v", w.path, err)
+	}
+}
+
+// UseWAL logs every room opened from now on to a file in dir, for crash
+// recovery and replays, with a checkpoint every so many ticks (0 for the
+// default)
+func (m *Manager) UseWAL(dir string, checkpointTicks uint64) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if checkpointTicks == 0 {
+		checkpointTicks = DefaultWALCheckpoint
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.walDir = dir
+	m.walCheckpoint = checkpointTicks
+	return nil
+}
+
+// walPath is where a new log for a room goes, or "" if rooms aren't
+// logged. Each time a room is opened it gets a log of its own, so a room
+// reopened under the same ID never mixes its records with an older one's.
+func (m *Manager) walPath(roomID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.walDir == "" {
+		return ""
+	}
+	name := url.PathEscape(roomID) + walInstanceSeparator + ids.Token(8) + walFileExt
+	return filepath.Join(m.walDir, name)
+}
+
+// walRoomID returns the room a log file belongs to
+func walRoomID(name string) (string, bool) {
+	name, ok := strings.CutSuffix(name, walFileExt)
+	if !ok {
+		return "", false
+	}
+	i := strings.LastIndex(name, walInstanceSeparator)
+	if i < 0 {
+		return "", false
+	}
+	roomID, err := url.PathUnescape(name[:i])
+	return roomID, err == nil
+}
+
+// findWAL returns the newest log left for a room, or "" if there's none
+func (m *Manager) findWAL(roomID string) string {
+	m.mu.RLock()
+	dir := m.walDir
+	m.mu.RUnlock()
+	if dir == "" {
+		return ""
+	}
+
+	pattern := filepath.Join(dir, url.PathEscape(roomID)+walInstanceSeparator+"*"+walFileExt)
+	matches, _ := filepath.Glob(pattern)
+	newest, newestAt := "", time.Time{}
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if id, ok := walRoomID(filepath.Base(path)); ok && id == roomID && info.ModTime().After(newestAt) {
+			newest, newestAt = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// attachWAL starts logging a room, beginning with a checkpoint so the log
+// can be replayed from here on its own
+func (m *Manager) attachWAL(room *GameStateWithShooting) {
+	path := m.walPath(room.RoomID)
+	if path == "" {
+		return
+	}
+	m.mu.RLock()
+	checkpoint := m.walCheckpoint
+	m.mu.RUnlock()
+	w, err := openWAL(path, checkpoint)
+	if err != nil {
+		log.Printf("❌ Failed to open WAL for room %s: %v", room.RoomID, err)
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.wal = w
+	room.walGap = true // checkpoint on the next tick
+	snapshot := room.snapshot()
+	a := room.archiveWith(&snapshot)
+	room.walGap = !w.append(walRecord{Tick: room.Tick, Checkpoint: &a})
+}
+
+// readWAL loads a room's log. A torn record at the end, from a crash
+// mid-write, ends the log.
+func readWAL(path string) ([]walRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []walRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), walMaxRecordSize)
+	for scanner.Scan() {
+		var r walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			break
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// walReplay is a log cut down to what rebuilding a room at some tick
+// needs: the newest checkpoint at or before it and the commands after
+type walReplay struct {
+	checkpoint *walRecord
+	steps      []replayStep
+	last       uint64 // tick of the newest record kept
+}
+
+// planReplay picks the records needed to rebuild a room at a tick
+func planReplay(records []walRecord, tick uint64) (walReplay, error) {
+	var plan walReplay
+	for i := range records {
+		r := &records[i]
+		if r.Tick > tick {
+			break
+		}
+		switch {
+		case r.Checkpoint != nil:
+			plan.checkpoint = r
+			plan.steps = nil
+		case r.Closed:
+		case plan.checkpoint != nil:
+			newCommand, ok := walCommands[r.Type]
+			if !ok {
+				return walReplay{}, errors.New("unknown command " + r.Type)
+			}
+			cmd := newCommand()
+			if err := json.Unmarshal(r.Command, cmd); err != nil {
+				return walReplay{}, err
+			}
+			plan.steps = append(plan.steps, replayStep{tick: r.Tick, apply: func(gs *GameStateWithShooting) { cmd.apply(gs) }})
+		}
+		plan.last = r.Tick
+	}
+	if plan.checkpoint == nil {
+		return walReplay{}, errors.New("no checkpoint")
+	}
+	return plan, nil
+}
+
+// rebuild restores the plan's checkpoint and replays its commands, leaving
+// the room at the end of the newest tick logged
+func (plan walReplay) rebuild(balance *Balance) *GameStateWithShooting {
+	a := *plan.checkpoint.Checkpoint
+	room := restoreRoom(a, balance)
+	// Entities spawned while replaying get the IDs they got the first time
+	room.ids = idAllocator{epoch: a.EntityEpoch, next: a.NextEntity}
+
+	room.replayTo(plan.last, plan.steps)
+	room.mu.Lock()
+	for _, step := range plan.steps {
+		if step.tick == room.Tick {
+			step.apply(room)
+		}
+	}
+	room.mu.Unlock()
+	return room
+}
+
+// ReplayWAL rebuilds a room as it was at the end of a past tick from its
+// log, as far back as its last checkpoint. Rooms that closed cleanly have
+// no log left.
+func (m *Manager) ReplayWAL(roomID string, tick uint64) (*Snapshot, error) {
+	path := m.findWAL(roomID)
+	if path == "" {
+		return nil, i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
+	}
+	records, err := readWAL(path)
+	if err != nil {
+		return nil, i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
+	}
+	plan, err := planReplay(records, tick)
+	if err != nil {
+		params := map[string]interface{}{"tick": tick, "current": 0, "oldest": 0}
+		if n := len(records); n > 0 {
+			params["oldest"] = records[0].Tick
+			params["current"] = records[n-1].Tick
+		}
+		return nil, i18n.NewError(i18n.ErrTickUnavailable, params)
+	}
+
+	experiments := plan.checkpoint.Checkpoint.State.Config.Experiments
+	past := plan.rebuild(m.roomBalance(m.balance.Current(), experiments))
+	past.replayTo(tick, nil)
+	return past.GetSnapshot(), nil
+}
+
+// RecoverRooms reopens every room whose log wasn't closed, as after a
+// crash, by replaying it on top of its last checkpoint. It returns the IDs
+// of the rooms recovered. Practice rewinds need checkpoints the log doesn't
+// keep, so practice rooms may not come back exactly as they were.
+func (m *Manager) RecoverRooms() []string {
+	m.mu.RLock()
+	dir := m.walDir
+	m.mu.RUnlock()
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("❌ Failed to read WAL directory %s: %v", dir, err)
+		return nil
+	}
+
+	recovered := make([]string, 0)
+	for _, entry := range entries {
+		roomID, ok := walRoomID(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		reopened, err := m.recoverRoom(roomID, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Printf("❌ Failed to recover room %s: %v", roomID, err)
+			continue
+		}
+		if reopened {
+			recovered = append(recovered, roomID)
+		}
+	}
+	return recovered
+}
+
+// recoverRoom rebuilds one room from a log and starts it running again,
+// with a log of its own. The old log is deleted once it's replaced, or if
+// the room closed cleanly or is already open. The players it had were
+// connected before the crash, so their seats wait for them to come back.
+func (m *Manager) recoverRoom(roomID, path string) (bool, error) {
+	records, err := readWAL(path)
+	if err != nil {
+		return false, err
+	}
+	if n := len(records); n > 0 && records[n-1].Closed {
+		return false, os.Remove(path)
+	}
+	if _, exists := m.GetRoom(roomID); exists {
+		return false, os.Remove(path)
+	}
+
+	plan, err := planReplay(records, ^uint64(0))
+	if err != nil {
+		return false, err
+	}
+	a := plan.checkpoint.Checkpoint
+	room := plan.rebuild(m.roomBalance(m.balance.Current(), a.State.Config.Experiments))
+	room.awaitReturn()
+	if err := m.insertRoom(roomID, room); err != nil {
+		if errors.Is(err, i18n.NewError(i18n.ErrRoomExists, nil)) {
+			return false, os.Remove(path)
+		}
+		return false, err // the log stays for the next start
+	}
+
+	m.attachWAL(room)
+	if err := os.Remove(path); err != nil {
+		log.Printf("⚠️ Failed to delete replaced WAL %s: %v", path, err)
+	}
+	m.registerRoom(roomID)
+	m.roomCreated(roomID)
+	go m.StartGameLoop(roomID)
+
+	log.Printf("🩹 Recovered room %s at tick %d from %d WAL records", roomID, room.Tick, len(records))
+	return true, nil
+}
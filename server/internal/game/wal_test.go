@@ -0,0 +1,116 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newLoggedRoom opens a room whose inputs are logged to dir, without
+// starting its game loop
+func newLoggedRoom(t *testing.T, m *Manager, dir, roomID string) *GameStateWithShooting {
+	t.Helper()
+	if err := m.UseWAL(dir, 10); err != nil {
+		t.Fatalf("failed to use WAL: %v", err)
+	}
+	room, err := m.CreateShootingRoomWithConfig(roomID, DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	m.attachWAL(room)
+	return room
+}
+
+// walFiles lists the logs in dir
+func walFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+walFileExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+// waitForRecords waits until a log holds n records
+func waitForRecords(t *testing.T, path string, n int) []walRecord {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		records, err := readWAL(path)
+		if err == nil && len(records) == n {
+			return records
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("log has %d records, want %d", len(records), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWALStartsAfreshAtCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	room := newLoggedRoom(t, NewManager(), dir, "room-1")
+	path := room.wal.path
+
+	room.Join([]string{"player"}, nil)
+	waitForRecords(t, path, 2) // the opening checkpoint and the join
+
+	room.mu.Lock()
+	room.Tick = 10
+	room.takeWALCheckpoint()
+	room.mu.Unlock()
+
+	records := waitForRecords(t, path, 1)
+	if records[0].Checkpoint == nil || records[0].Tick != 10 {
+		t.Fatalf("log starts with %+v, want the tick 10 checkpoint", records[0])
+	}
+}
+
+func TestWALDeletedOnCleanClose(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager()
+	newLoggedRoom(t, m, dir, "room-1")
+
+	m.DeleteRoom("room-1")
+	if files := walFiles(t, dir); len(files) != 0 {
+		t.Fatalf("closed room left logs %v", files)
+	}
+}
+
+func TestRecoveredRoomWaitsForItsPlayers(t *testing.T) {
+	dir := t.TempDir()
+	room := newLoggedRoom(t, NewManager(), dir, "room-1")
+	crashed := room.wal.path
+	room.Join([]string{"player"}, nil)
+	waitForRecords(t, crashed, 2)
+
+	// Another process starts on the logs the crashed one left
+	m := NewManager()
+	if err := m.UseWAL(dir, 10); err != nil {
+		t.Fatalf("failed to use WAL: %v", err)
+	}
+	recovered := m.RecoverRooms()
+	if len(recovered) != 1 || recovered[0] != "room-1" {
+		t.Fatalf("recovered %v, want room-1", recovered)
+	}
+	defer m.DeleteRoom("room-1")
+
+	if _, err := os.Stat(crashed); !os.IsNotExist(err) {
+		t.Fatal("crashed room's log wasn't replaced")
+	}
+	if files := walFiles(t, dir); len(files) != 1 {
+		t.Fatalf("recovered room has logs %v, want one", files)
+	}
+
+	reopened, _ := m.GetShootingRoom("room-1")
+	if n := reopened.PlayerCount(); n != 0 {
+		t.Fatalf("recovered room has %d players before anyone reconnected", n)
+	}
+	reopened.mu.RLock()
+	returning := reopened.returning["player"]
+	reopened.mu.RUnlock()
+	if !returning {
+		t.Fatal("recovered room isn't holding the player's seat")
+	}
+}
@@ -0,0 +1,344 @@
+package game
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// DefaultMap is the only map until more are added; wave scripts are keyed
+// by map so each one can bring its own
+const DefaultMap = "default"
+
+// Wave script limits
+const (
+	maxGroupCount = 200
+	// Waves past the end of a script repeat the last wave with this much
+	// more of every group per extra wave
+	extraWaveGrowth = 0.25
+)
+
+// SpawnGroup is a batch of one enemy type within a wave
+type SpawnGroup struct {
+	EnemyType  string    `json:"enemy_type"`
+	Count      int       `json:"count"`
+	Interval   float64   `json:"interval"`              // seconds between enemies
+	Delay      float64   `json:"delay"`                 // seconds after the wave starts
+	SpawnPoint *Position `json:"spawn_point,omitempty"` // defaults to the room's spawn point
+	Pack       string    `json:"pack,omitempty"`        // groups in a wave sharing a pack move in formation
+}
+
+// WaveDefinition is the enemies sent in one wave
+type WaveDefinition struct {
+	Groups []SpawnGroup `json:"groups"`
+}
+
+// WaveScript is a sequence of waves for a map
+type WaveScript struct {
+	Name  string           `json:"name"`
+	Map   string           `json:"map"`
+	Waves []WaveDefinition `json:"waves"`
+}
+
+// Validate checks that every group spawns a known enemy type from an open
+// cell of its map that leads to a goal, with sane counts and timings
+func (s WaveScript) Validate(balance *Balance) error {
+	invalid := func(field string) error {
+		return i18n.NewError(i18n.ErrInvalidWaves, map[string]interface{}{"name": s.Name, "field": field})
+	}
+
+	if s.Name == "" {
+		return invalid("name")
+	}
+	layout, ok := mapCatalog[s.Map]
+	if !ok {
+		return invalid("map")
+	}
+	if len(s.Waves) == 0 {
+		return invalid("waves")
+	}
+	for _, wave := range s.Waves {
+		if len(wave.Groups) == 0 {
+			return invalid("groups")
+		}
+		packSpawns := make(map[string]*Position)
+		for _, g := range wave.Groups {
+			if _, ok := balance.Enemies[g.EnemyType]; !ok {
+				return invalid("enemy_type")
+			}
+			if g.Count < 1 || g.Count > maxGroupCount {
+				return invalid("count")
+			}
+			if g.Interval < 0 {
+				return invalid("interval")
+			}
+			if g.Delay < 0 {
+				return invalid("delay")
+			}
+			if p := g.SpawnPoint; p != nil && (!InBounds(*p) || p.X != math.Round(p.X) || p.Y != math.Round(p.Y)) {
+				return invalid("spawn_point")
+			}
+			if p := g.SpawnPoint; p != nil && !layout.leadsToGoal(*p) {
+				return invalid("spawn_point")
+			}
+			// A pack walks one path, so all of it starts in the same place
+			if g.Pack != "" {
+				if from, ok := packSpawns[g.Pack]; ok && !samePoint(from, g.SpawnPoint) {
+					return invalid("pack")
+				}
+				packSpawns[g.Pack] = g.SpawnPoint
+			}
+		}
+	}
+	return nil
+}
+
+// leadsToGoal reports whether enemies spawned at a cell can walk from it
+// to one of the map's goals before any towers are built
+func (m MapDefinition) leadsToGoal(from Position) bool {
+	terrain := &GameStateWithShooting{}
+	terrain.useWalls(m.Walls)
+	if terrain.wallAt(from) {
+		return false
+	}
+	return terrain.findPath(from, append([]Position{m.Goal}, m.Goals...)...) != nil
+}
+
+// samePoint reports whether two optional spawn points are the same
+func samePoint(a, b *Position) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// wave returns the definition of a 1-based wave number. Waves past the end
+// repeat the last one with growing counts.
+func (s WaveScript) wave(number int) WaveDefinition {
+	if number <= len(s.Waves) {
+		return s.Waves[number-1]
+	}
+
+	last := s.Waves[len(s.Waves)-1]
+	growth := 1 + extraWaveGrowth*float64(number-len(s.Waves))
+
+	wave := WaveDefinition{Groups: make([]SpawnGroup, len(last.Groups))}
+	for i, g := range last.Groups {
+		g.Count = int(math.Ceil(float64(g.Count) * growth))
+		wave.Groups[i] = g
+	}
+	return wave
+}
+
+// group is shorthand for the default scripts
+func group(enemyType string, count int, interval, delay float64) SpawnGroup {
+	return SpawnGroup{EnemyType: enemyType, Count: count, Interval: interval, Delay: delay}
+}
+
+// defaultWaveScripts is the built-in library
+func defaultWaveScripts() []WaveScript {
+	return []WaveScript{
+		{
+			Name: "classic",
+			Map:  DefaultMap,
+			Waves: []WaveDefinition{
+				{Groups: []SpawnGroup{group("basic", 5, 1.5, 0)}},
+				{Groups: []SpawnGroup{group("basic", 8, 1.2, 0)}},
+				{Groups: []SpawnGroup{group("basic", 6, 1.2, 0), group("fast", 4, 0.8, 6)}},
+				{Groups: []SpawnGroup{group("basic", 8, 1, 0), group("tank", 2, 3, 4)}},
+				{Groups: []SpawnGroup{group("fast", 10, 0.6, 0), group("tank", 3, 3, 5)}},
+				{Groups: []SpawnGroup{group("basic", 10, 0.8, 0), group("flying", 6, 1, 3)}},
+				{Groups: []SpawnGroup{group("tank", 5, 2, 0), group("emp", 3, 3, 4)}},
+				{Groups: []SpawnGroup{group("fast", 15, 0.5, 0), group("flying", 8, 0.8, 4)}},
+				{Groups: []SpawnGroup{group("tank", 6, 1.5, 0), group("emp", 4, 2, 3), group("basic", 12, 0.6, 6)}},
+				{Groups: []SpawnGroup{group("basic", 10, 0.8, 0), group("boss", 1, 0, 8)}},
+			},
+		},
+		{
+			Name: "rush",
+			Map:  DefaultMap,
+			Waves: []WaveDefinition{
+				{Groups: []SpawnGroup{group("fast", 8, 0.6, 0)}},
+				{Groups: []SpawnGroup{group("fast", 12, 0.5, 0)}},
+				{Groups: []SpawnGroup{group("fast", 12, 0.4, 0), group("flying", 6, 0.6, 3)}},
+				{Groups: []SpawnGroup{group("fast", 20, 0.3, 0), group("flying", 10, 0.5, 4)}},
+				{Groups: []SpawnGroup{group("fast", 25, 0.3, 0), group("emp", 4, 1, 2)}},
+			},
+		},
+		{
+			Name: "siege",
+			Map:  DefaultMap,
+			Waves: []WaveDefinition{
+				{Groups: []SpawnGroup{group("tank", 2, 4, 0)}},
+				{Groups: []SpawnGroup{group("tank", 4, 3, 0), group("basic", 6, 1, 2)}},
+				{Groups: []SpawnGroup{group("tank", 5, 2.5, 0), group("emp", 2, 4, 5)}},
+				{Groups: []SpawnGroup{group("tank", 8, 2, 0), group("emp", 4, 3, 4)}},
+				{Groups: []SpawnGroup{group("tank", 8, 2, 0), group("boss", 2, 10, 5)}},
+			},
+		},
+	}
+}
+
+// WaveLibrary holds wave scripts per map. The built-in scripts are always
+// available; a file can add more or replace them by name.
+type WaveLibrary struct {
+	mu      sync.RWMutex
+	scripts map[string]map[string]WaveScript // map -> name -> script
+}
+
+// NewWaveLibrary creates a library with the built-in scripts
+func NewWaveLibrary() *WaveLibrary {
+	l := &WaveLibrary{scripts: make(map[string]map[string]WaveScript)}
+	for _, s := range defaultWaveScripts() {
+		l.add(s)
+	}
+	return l
+}
+
+// UseFile loads wave scripts from a JSON file holding a list of scripts.
+// Every script is validated before any are added.
+func (l *WaveLibrary) UseFile(path string, balance *Balance) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var scripts []WaveScript
+	if err := json.Unmarshal(data, &scripts); err != nil {
+		return err
+	}
+	for _, s := range scripts {
+		if err := s.Validate(balance); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range scripts {
+		l.add(s)
+	}
+	return nil
+}
+
+// add stores a script. Callers must hold the lock or own the library.
+func (l *WaveLibrary) add(s WaveScript) {
+	if l.scripts[s.Map] == nil {
+		l.scripts[s.Map] = make(map[string]WaveScript)
+	}
+	l.scripts[s.Map][s.Name] = s
+}
+
+// Get looks up a script for a map
+func (l *WaveLibrary) Get(mapName, name string) (WaveScript, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	s, ok := l.scripts[mapName][name]
+	if !ok {
+		return WaveScript{}, i18n.NewError(i18n.ErrUnknownWaves, map[string]interface{}{"name": name})
+	}
+	return s, nil
+}
+
+// List returns every script for a map, sorted by name
+func (l *WaveLibrary) List(mapName string) []WaveScript {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	scripts := make([]WaveScript, 0, len(l.scripts[mapName]))
+	for _, s := range l.scripts[mapName] {
+		scripts = append(scripts, s)
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].Name < scripts[j].Name
+	})
+	return scripts
+}
+
+// scheduledSpawn is one enemy a wave script will send
+type scheduledSpawn struct {
+	at        float64 // game time
+	enemyType string
+	from      Position
+	sender    string // versus sender of a spawn deferred by the enemy cap
+	pack      string // formation to join, if any
+	packSlot  int
+}
+
+// queueWave schedules every enemy of a scripted wave. Callers must hold the
+// state lock.
+func (gs *GameStateWithShooting) queueWave(number int) {
+	if gs.waveScript == nil || gs.SpawnPoint == nil {
+		return
+	}
+
+	// Pack slots follow script order, so earlier groups lead
+	slots := make(map[string]int)
+	for _, g := range gs.waveScript.wave(number).Groups {
+		from := *gs.SpawnPoint
+		if g.SpawnPoint != nil {
+			from = *g.SpawnPoint
+		}
+		for i := 0; i < g.Count; i++ {
+			spawn := scheduledSpawn{
+				at:        gs.GameTime + g.Delay + float64(i)*g.Interval,
+				enemyType: g.EnemyType,
+				from:      from,
+			}
+			if g.Pack != "" {
+				spawn.pack = packName(number, g.Pack)
+				spawn.packSlot = slots[spawn.pack]
+				slots[spawn.pack]++
+			}
+			gs.pendingSpawns = append(gs.pendingSpawns, spawn)
+		}
+	}
+
+	// Stable, so enemies due at the same time keep script order
+	sort.SliceStable(gs.pendingSpawns, func(i, j int) bool {
+		return gs.pendingSpawns[i].at < gs.pendingSpawns[j].at
+	})
+}
+
+// updateWaves spawns scripted enemies that are due
+func (gs *GameStateWithShooting) updateWaves(deltaTime float64) {
+	due := 0
+	for due < len(gs.pendingSpawns) && gs.pendingSpawns[due].at <= gs.GameTime {
+		spawn := gs.pendingSpawns[due]
+		count := gs.mods.enemiesPerSpawn(spawn.enemyType)
+		// Spawns wait their turn while the room is at its enemy cap
+		if !gs.enemyRoom(count) {
+			break
+		}
+		path := gs.pathFrom(spawn.from)
+		for i := 0; i < count; i++ {
+			gs.addEnemy(spawn.enemyType, path, spawn.sender)
+			if spawn.pack != "" {
+				enemy := &gs.Enemies[len(gs.Enemies)-1]
+				enemy.Pack = spawn.pack
+				enemy.PackSlot = spawn.packSlot
+			}
+		}
+		due++
+	}
+	gs.pendingSpawns = gs.pendingSpawns[due:]
+}
+
+// pathFrom routes an enemy from a spawn cell to the goal. Callers must hold
+// the state lock.
+func (gs *GameStateWithShooting) pathFrom(from Position) []Position {
+	if gs.SpawnPoint != nil && from == *gs.SpawnPoint {
+		return gs.spawnPath(nil)
+	}
+	if goals := gs.goalTargets(); len(goals) > 0 {
+		if path := gs.findPath(from, goals...); path != nil {
+			return path
+		}
+	}
+	return []Position{from}
+}
@@ -0,0 +1,37 @@
+package game
+
+import "testing"
+
+func TestWaveSpawnPointsMustLeadToAGoal(t *testing.T) {
+	// Spawn at 0,0 is walled in; 2,2 is a wall itself
+	mapCatalog["walled"] = MapDefinition{
+		ID:    "walled",
+		Spawn: Position{X: 0, Y: 7},
+		Goal:  Position{X: GridWidth - 1, Y: 7},
+		Walls: []Wall{{X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 2}},
+	}
+	defer delete(mapCatalog, "walled")
+
+	script := func(mapName string, from Position) WaveScript {
+		g := group("basic", 1, 1, 0)
+		g.SpawnPoint = &from
+		return WaveScript{Name: "test", Map: mapName, Waves: []WaveDefinition{{Groups: []SpawnGroup{g}}}}
+	}
+
+	tests := []struct {
+		name   string
+		script WaveScript
+		valid  bool
+	}{
+		{"open cell", script("walled", Position{X: 3, Y: 3}), true},
+		{"wall", script("walled", Position{X: 2, Y: 2}), false},
+		{"walled in", script("walled", Position{X: 0, Y: 0}), false},
+		{"unknown map", script("nowhere", Position{X: 3, Y: 3}), false},
+	}
+	for _, tt := range tests {
+		err := tt.script.Validate(defaultBalance())
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got error %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
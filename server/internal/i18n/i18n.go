@@ -0,0 +1,379 @@
+// Package i18n defines the message codes the server sends to clients, with a
+// catalog of translations so clients can render them in the player's locale.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Code identifies a server message independent of its wording
+type Code string
+
+// Error codes
+const (
+	ErrInternal           Code = "error.internal"
+	ErrInvalidMessage     Code = "error.invalid_message"
+	ErrUnknownType        Code = "error.unknown_message_type"
+	ErrNotInRoom          Code = "error.not_in_room"
+	ErrRoomNotFound       Code = "error.room_not_found"
+	ErrInvalidPayload     Code = "error.invalid_payload"
+	ErrUnknownMutator     Code = "error.unknown_mutator"
+	ErrDuplicateMutator   Code = "error.duplicate_mutator"
+	ErrTowerNotAllowed    Code = "error.tower_not_allowed"
+	ErrWallCell           Code = "error.wall_cell"
+	ErrInsufficientGold   Code = "error.insufficient_gold"
+	ErrRateLimited        Code = "error.rate_limited"
+	ErrUnknownQuickChat   Code = "error.unknown_quick_chat"
+	ErrTowerNotFound      Code = "error.tower_not_found"
+	ErrTowerBusy          Code = "error.tower_busy"
+	ErrTowerMaxLevel      Code = "error.tower_max_level"
+	ErrCombatLocked       Code = "error.combat_locked"
+	ErrInvalidTargetMode  Code = "error.invalid_target_mode"
+	ErrUnknownMode        Code = "error.unknown_mode"
+	ErrInvalidConfig      Code = "error.invalid_config"
+	ErrAlreadyQueued      Code = "error.already_queued"
+	ErrNotQueued          Code = "error.not_queued"
+	ErrAlreadyInParty     Code = "error.already_in_party"
+	ErrNotInParty         Code = "error.not_in_party"
+	ErrNotPartyLeader     Code = "error.not_party_leader"
+	ErrPartyFull          Code = "error.party_full"
+	ErrNoPartyInvite      Code = "error.no_party_invite"
+	ErrAlreadyFriends     Code = "error.already_friends"
+	ErrNoFriendRequest    Code = "error.no_friend_request"
+	ErrNotFriends         Code = "error.not_friends"
+	ErrPlayerOffline      Code = "error.player_offline"
+	ErrRoomPrivate        Code = "error.room_private"
+	ErrInvalidJoinCode    Code = "error.invalid_join_code"
+	ErrNotHost            Code = "error.not_host"
+	ErrInvalidServerEvent Code = "error.invalid_server_event"
+	ErrInvalidTemplate    Code = "error.invalid_template"
+	ErrTemplateNotFound   Code = "error.template_not_found"
+	ErrRoomExists         Code = "error.room_exists"
+	ErrInvalidBalance     Code = "error.invalid_balance"
+	ErrAuditDisabled      Code = "error.audit_disabled"
+	ErrInvalidResync      Code = "error.invalid_resync_reason"
+	ErrInvalidReport      Code = "error.invalid_report"
+	ErrAlreadyReported    Code = "error.already_reported"
+	ErrTooManyConnections Code = "error.too_many_connections"
+	ErrReconnectBackoff   Code = "error.reconnect_backoff"
+	ErrChallengeRequired  Code = "error.challenge_required"
+	ErrPayloadTooLarge    Code = "error.payload_too_large"
+	ErrPayloadTooDeep     Code = "error.payload_too_deep"
+	ErrInvalidWaves       Code = "error.invalid_wave_script"
+	ErrUnknownWaves       Code = "error.unknown_wave_script"
+	ErrInvalidTutorial    Code = "error.invalid_tutorial"
+	ErrUnknownTutorial    Code = "error.unknown_tutorial"
+	ErrUnknownLevel       Code = "error.unknown_level"
+	ErrLevelLocked        Code = "error.level_locked"
+	ErrServerDraining     Code = "error.server_draining"
+	ErrForbidden          Code = "error.forbidden"
+	ErrKicked             Code = "error.kicked"
+	ErrCannotKick         Code = "error.cannot_kick"
+	ErrInvalidName        Code = "error.invalid_name"
+	ErrNameRejected       Code = "error.name_rejected"
+	ErrNotTowerOwner      Code = "error.not_tower_owner"
+	ErrUnknownCosmetic    Code = "error.unknown_cosmetic"
+	ErrCosmeticLocked     Code = "error.cosmetic_locked"
+	ErrUnknownTelemetry   Code = "error.unknown_telemetry_part"
+	ErrTowerLimit         Code = "error.tower_limit"
+	ErrTooManyRooms       Code = "error.too_many_rooms"
+	ErrNotObserving       Code = "error.not_observing"
+	ErrFieldNotClear      Code = "error.field_not_clear"
+	ErrNotPractice        Code = "error.not_practice"
+	ErrNoCheckpoint       Code = "error.no_checkpoint"
+	ErrPracticeSolo       Code = "error.practice_solo"
+	ErrInvalidTenant      Code = "error.invalid_tenant"
+	ErrOutsideRealm       Code = "error.outside_realm"
+	ErrRoomQuota          Code = "error.room_quota"
+	ErrPlayerQuota        Code = "error.player_quota"
+	ErrStorageQuota       Code = "error.storage_quota"
+	ErrRoomFull           Code = "error.room_full"
+	ErrInvalidQuota       Code = "error.invalid_quota"
+	ErrInvalidBotKey      Code = "error.invalid_bot_key"
+	ErrSpectateToken      Code = "error.spectate_token"
+	ErrBotForbidden       Code = "error.bot_forbidden"
+	ErrInvalidCapture     Code = "error.invalid_capture"
+	ErrAlreadyCapturing   Code = "error.already_capturing"
+	ErrUnknownInboxKind   Code = "error.unknown_notification_kind"
+	ErrDropInClosed       Code = "error.drop_in_closed"
+	ErrInvalidExperiment  Code = "error.invalid_experiment"
+	ErrTickUnavailable    Code = "error.tick_unavailable"
+	ErrNotInProgress      Code = "error.not_in_progress"
+	ErrUnknownBranch      Code = "error.unknown_upgrade_branch"
+	ErrInvalidSettings    Code = "error.invalid_settings"
+	ErrInvalidRoomQuery   Code = "error.invalid_room_query"
+	ErrNoOpenRooms        Code = "error.no_open_rooms"
+	ErrTickTooFar         Code = "error.tick_too_far"
+	ErrPracticeParty      Code = "error.practice_party"
+	ErrTemplateTaken      Code = "error.template_taken"
+	ErrTemplateLimit      Code = "error.template_limit"
+	ErrMemberKicked       Code = "error.member_kicked"
+	ErrLockstepAirstrike  Code = "error.lockstep_airstrike"
+)
+
+// Acknowledgement codes
+const (
+	AckJoinedRoom   Code = "ack.joined_room"
+	AckTowerPlaced  Code = "ack.tower_placed"
+	AckEnemySpawned Code = "ack.enemy_spawned"
+	AckClearedAll   Code = "ack.cleared_all"
+	AckWaveStarted  Code = "ack.wave_started"
+	AckGamePaused   Code = "ack.game_paused"
+	AckGameResumed  Code = "ack.game_resumed"
+	AckTowerUpgrade Code = "ack.tower_upgrading"
+	AckTowerSelling Code = "ack.tower_selling"
+	AckTargetMode   Code = "ack.target_mode_set"
+	AckQueued       Code = "ack.queued_for_match"
+	AckLeftQueue    Code = "ack.left_queue"
+	AckPartyCreated Code = "ack.party_created"
+	AckPartyInvited Code = "ack.party_invite_sent"
+	AckPartyJoined  Code = "ack.party_joined"
+	AckPartyLeft    Code = "ack.party_left"
+	AckFriendReq    Code = "ack.friend_request_sent"
+	AckFriendAdded  Code = "ack.friend_added"
+	AckFriendRemove Code = "ack.friend_removed"
+	AckRoomInvite   Code = "ack.room_invite_sent"
+	AckJoinCode     Code = "ack.join_code_rotated"
+	AckRoomCreated  Code = "ack.room_created"
+	AckTemplateSave Code = "ack.template_saved"
+	AckReported     Code = "ack.player_reported"
+	AckLevelChosen  Code = "ack.level_selected"
+	AckWatching     Code = "ack.watching_diagnostics"
+	AckReady        Code = "ack.ready_set"
+	AckKicked       Code = "ack.player_kicked"
+	AckObserving    Code = "ack.observing_room"
+	AckUnobserved   Code = "ack.stopped_observing"
+	AckWaitSkipped  Code = "ack.wait_skipped"
+	AckRewound      Code = "ack.rewound"
+	AckDropIn       Code = "ack.drop_in_set"
+	AckVotedForfeit Code = "ack.surrender_vote"
+	AckSurrendered  Code = "ack.surrendered"
+	AckSettingsSet  Code = "ack.settings_saved"
+	AckAirstrike    Code = "ack.airstrike_called"
+)
+
+// Notice codes, for messages the server sends unprompted
+const (
+	NoticeIdle         Code = "notice.idle_disconnect"
+	NoticeFriendInvite Code = "notice.friend_invite"
+	NoticeRoundStart   Code = "notice.tournament_round"
+	NoticeDailyReset   Code = "notice.daily_reset"
+)
+
+// DefaultLocale is used when a client doesn't ask for one or asks for an
+// unsupported locale
+const DefaultLocale = "en"
+
+// catalogs maps locale -> code -> template. Templates reference parameters
+// as {name}.
+var catalogs = map[string]map[Code]string{
+	"en": {
+		ErrInternal:           "Something went wrong on the server.",
+		ErrInvalidMessage:     "The message could not be understood.",
+		ErrUnknownType:        "Unknown message type {type}.",
+		ErrNotInRoom:          "You are not in a room.",
+		ErrRoomNotFound:       "Room {room_id} does not exist.",
+		ErrInvalidPayload:     "Invalid data for {type}.",
+		ErrUnknownMutator:     "Unknown mutator {mutator}.",
+		ErrDuplicateMutator:   "Mutator {mutator} was selected more than once.",
+		ErrTowerNotAllowed:    "{tower_type} towers are disabled in this room.",
+		ErrWallCell:           "Towers can't be built on walls.",
+		ErrInsufficientGold:   "Not enough gold: {cost} needed, {gold} available.",
+		ErrRateLimited:        "You're doing that too often.",
+		ErrUnknownQuickChat:   "Unknown quick chat message {id}.",
+		ErrTowerNotFound:      "Tower {tower_id} does not exist.",
+		ErrTowerBusy:          "The tower is busy ({state}).",
+		ErrTowerMaxLevel:      "The tower is already at max level ({level}).",
+		ErrCombatLocked:       "Towers can't be sold or upgraded while enemies are on the field.",
+		ErrInvalidTargetMode:  "Unknown targeting mode {mode}.",
+		ErrUnknownMode:        "Unknown game mode {mode}.",
+		ErrInvalidConfig:      "Invalid room setting {field}.",
+		ErrAlreadyQueued:      "You are already in the match queue.",
+		ErrNotQueued:          "You are not in the match queue.",
+		ErrAlreadyInParty:     "That player is already in a party.",
+		ErrNotInParty:         "You are not in a party.",
+		ErrNotPartyLeader:     "Only the party leader can do that.",
+		ErrPartyFull:          "The party is full ({max} players).",
+		ErrNoPartyInvite:      "You have no invite to that party.",
+		ErrAlreadyFriends:     "You are already friends with {player_id}.",
+		ErrNoFriendRequest:    "{player_id} hasn't sent you a friend request.",
+		ErrNotFriends:         "You are not friends with {player_id}.",
+		ErrPlayerOffline:      "{player_id} is not online.",
+		ErrRoomPrivate:        "Room {room_id} is private. Enter its join code or password.",
+		ErrInvalidJoinCode:    "No room has that join code.",
+		ErrNotHost:            "Only the room host can do that.",
+		ErrInvalidServerEvent: "Server events need a name and an end after their start.",
+		ErrInvalidTemplate:    "Templates need a name.",
+		ErrTemplateNotFound:   "Template {name} does not exist.",
+		ErrRoomExists:         "Room {room_id} already exists.",
+		ErrInvalidBalance:     "Invalid balance stats for {type}.",
+		ErrAuditDisabled:      "Room {room_id} is not a debug room.",
+		ErrInvalidResync:      "Unknown resync reason {reason}.",
+		ErrInvalidReport:      "That report is not valid.",
+		ErrAlreadyReported:    "You already reported {player_id} in this room.",
+		ErrTooManyConnections: "Too many connections from your address (limit {limit}).",
+		ErrReconnectBackoff:   "Reconnecting too quickly, try again in {retry_after_ms} ms.",
+		ErrChallengeRequired:  "The server is busy, solve a connection challenge first.",
+		ErrPayloadTooLarge:    "The {type} message is too large (limit {limit}).",
+		ErrPayloadTooDeep:     "The message is nested too deeply (limit {limit}).",
+		ErrInvalidWaves:       "Wave script {name} has an invalid {field}.",
+		ErrUnknownWaves:       "Unknown wave script {name}.",
+		ErrInvalidTutorial:    "Tutorial {name} has an invalid {field}.",
+		ErrUnknownTutorial:    "Unknown tutorial {name}.",
+		ErrUnknownLevel:       "Unknown campaign level {campaign}/{level}.",
+		ErrLevelLocked:        "Level {level} is still locked.",
+		ErrServerDraining:     "This server is shutting down for an update. Try again in a moment.",
+		ErrForbidden:          "You are not allowed to do that.",
+		ErrKicked:             "You were removed from room {room_id}.",
+		ErrCannotKick:         "Player {player_id} can't be kicked.",
+		ErrInvalidName:        "Display names must be {min} to {max} letters, numbers, spaces, - or _.",
+		ErrNameRejected:       "That display name isn't allowed.",
+		ErrNotTowerOwner:      "Tower {tower_id} belongs to {owner}.",
+		ErrUnknownCosmetic:    "Unknown cosmetic: {cosmetic}.",
+		ErrCosmeticLocked:     "You haven't unlocked {cosmetic}.",
+		ErrUnknownTelemetry:   "Unknown telemetry part: {part}.",
+		ErrTowerLimit:         "This room already has its maximum of {limit} towers.",
+		ErrTooManyRooms:       "You can observe at most {limit} rooms.",
+		ErrNotObserving:       "You are not observing room {room_id}.",
+		ErrFieldNotClear:      "Finish off the enemies on the field first.",
+		ErrNotPractice:        "Only practice rooms can rewind.",
+		ErrNoCheckpoint:       "There is no checkpoint {seconds} seconds back.",
+		ErrPracticeSolo:       "Room {room_id} is someone else's practice room.",
+		ErrInvalidTenant:      "Invalid tenant rule {rule}.",
+		ErrOutsideRealm:       "{id} is outside your realm.",
+		ErrRoomQuota:          "This realm already has its maximum of {limit} rooms.",
+		ErrPlayerQuota:        "This realm already has its maximum of {limit} players.",
+		ErrStorageQuota:       "This realm already has its maximum of {limit} saved templates.",
+		ErrRoomFull:           "This room already has its maximum of {limit} players.",
+		ErrInvalidQuota:       "Quotas can't be negative.",
+		ErrInvalidBotKey:      "Invalid bot key.",
+		ErrSpectateToken:      "A valid spectate token is required.",
+		ErrBotForbidden:       "Bots can't do that.",
+		ErrInvalidCapture:     "A capture needs either a client_id or a room_id.",
+		ErrAlreadyCapturing:   "That client or room is already being captured.",
+		ErrUnknownInboxKind:   "Unknown notification kind: {kind}.",
+		ErrDropInClosed:       "The game in room {room_id} has already started.",
+		ErrInvalidExperiment:  "Experiment {name} has an invalid {field}.",
+		ErrTickUnavailable:    "Tick {tick} can't be rebuilt. Ticks {oldest} to {current} are available.",
+		ErrNotInProgress:      "There's no game under way to surrender.",
+		ErrUnknownBranch:      "Choose one of these upgrades: {choices}.",
+		ErrInvalidSettings:    "Invalid setting {field}.",
+		ErrInvalidRoomQuery:   "Invalid room search {field}.",
+		ErrNoOpenRooms:        "No open rooms to join right now, try creating one.",
+		ErrTickTooFar:         "Commands can only be scheduled up to {max} ticks ahead; the room is on tick {current}.",
+		ErrPracticeParty:      "Practice rooms are for one player. Leave your party to practice.",
+		ErrTemplateTaken:      "Template {name} belongs to someone else.",
+		ErrTemplateLimit:      "You can save at most {limit} templates.",
+		ErrMemberKicked:       "{player_id} in your party was removed from room {room_id}.",
+		ErrLockstepAirstrike:  "Airstrikes aren't available in lockstep rooms.",
+
+		AckJoinedRoom:   "Joined room {room_id}.",
+		AckTowerPlaced:  "Tower placed.",
+		AckEnemySpawned: "Enemy spawned.",
+		AckClearedAll:   "Cleared all towers and enemies.",
+		AckWaveStarted:  "Wave {wave} started.",
+		AckGamePaused:   "Game paused.",
+		AckGameResumed:  "Game resumed.",
+		AckTowerUpgrade: "Upgrading tower.",
+		AckTowerSelling: "Selling tower.",
+		AckTargetMode:   "Targeting mode set to {mode}.",
+		AckQueued:       "Searching for an opponent...",
+		AckLeftQueue:    "Left the match queue.",
+		AckPartyCreated: "Party created.",
+		AckPartyInvited: "Invite sent to {player_id}.",
+		AckPartyJoined:  "Joined the party.",
+		AckPartyLeft:    "Left the party.",
+		AckFriendReq:    "Friend request sent to {player_id}.",
+		AckFriendAdded:  "You are now friends with {player_id}.",
+		AckFriendRemove: "Removed {player_id} from your friends.",
+		AckRoomInvite:   "Invited {player_id} to your room.",
+		AckJoinCode:     "New join code: {code}.",
+		AckRoomCreated:  "Room {room_id} created.",
+		AckTemplateSave: "Saved template {name}.",
+		AckReported:     "Thanks, your report about {player_id} was sent.",
+		AckLevelChosen:  "Starting {level}.",
+		AckWatching:     "Watching diagnostics for room {room_id}.",
+		AckReady:        "Ready status updated.",
+		AckKicked:       "Removed {player_id} from the room.",
+		AckObserving:    "Observing room {room_id}.",
+		AckUnobserved:   "Stopped observing room {room_id}.",
+		AckWaitSkipped:  "Skipping ahead to wave {wave}.",
+		AckRewound:      "Rewound to {game_time} seconds.",
+		AckDropIn:       "Drop-in setting updated.",
+		AckVotedForfeit: "Voted to surrender. {votes} of {needed} votes needed.",
+		AckSurrendered:  "You surrendered.",
+		AckSettingsSet:  "Settings saved.",
+		AckAirstrike:    "Airstrike called in, {hits} hit.",
+
+		NoticeIdle:         "You'll be disconnected in {seconds} seconds unless you join a room or send a message.",
+		NoticeFriendInvite: "{player_id} sent you a friend request.",
+		NoticeRoundStart:   "Round {round} of {tournament} is starting.",
+		NoticeDailyReset:   "A new daily challenge is available.",
+	},
+}
+
+// Error is an error carrying a message code and its parameters
+type Error struct {
+	Code   Code                   `json:"code"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// NewError creates a coded error
+func NewError(code Code, params map[string]interface{}) *Error {
+	return &Error{Code: code, Params: params}
+}
+
+// Error renders the message in the default locale
+func (e *Error) Error() string {
+	return Render(DefaultLocale, e.Code, e.Params)
+}
+
+// Is matches any other *Error with the same code, so sentinel errors work
+// with errors.Is regardless of parameters
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Render formats a code in the given locale, falling back to the default
+// locale and finally to the code itself
+func Render(locale string, code Code, params map[string]interface{}) string {
+	template, ok := catalogs[locale][code]
+	if !ok {
+		template, ok = catalogs[DefaultLocale][code]
+	}
+	if !ok {
+		return string(code)
+	}
+
+	for name, value := range params {
+		template = strings.ReplaceAll(template, "{"+name+"}", fmt.Sprint(value))
+	}
+	return template
+}
+
+// Catalog returns every template for a locale
+func Catalog(locale string) (map[Code]string, bool) {
+	catalog, ok := catalogs[locale]
+	if !ok {
+		return nil, false
+	}
+
+	result := make(map[Code]string, len(catalog))
+	for code, template := range catalog {
+		result[code] = template
+	}
+	return result, true
+}
+
+// Locales returns the supported locales
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
@@ -0,0 +1,57 @@
+// Package ids generates identifiers and secrets without looking at the
+// clock. IDs combine a process-wide sequence, which only ever grows, with
+// random bits from crypto/rand, so two IDs from one process never collide
+// and IDs from different processes or restarts almost certainly don't,
+// however far apart their clocks are.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"sync/atomic"
+)
+
+// randomBytes is how much randomness an ID carries after its sequence
+const randomBytes = 6
+
+// sequence numbers the IDs handed out by this process
+var sequence atomic.Uint64
+
+// New returns an ID starting with prefix, such as "client-1k-9f2c01ab3e77".
+func New(prefix string) string {
+	seq := sequence.Add(1)
+	return prefix + "-" + strconv.FormatUint(seq, 36) + "-" + Token(randomBytes)
+}
+
+// Token returns n crypto-random bytes hex encoded, for secrets and other
+// values that must not be guessable
+func Token(n int) string {
+	return hex.EncodeToString(read(n))
+}
+
+// Code returns a crypto-random code of length characters from alphabet,
+// for codes people type in
+func Code(alphabet string, length int) string {
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code)
+}
+
+// read returns n bytes from crypto/rand. The system's random source failing
+// leaves nothing safe to hand out, so it panics.
+func read(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
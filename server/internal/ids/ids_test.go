@@ -0,0 +1,77 @@
+package ids
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+var idPattern = regexp.MustCompile(`^client-[0-9a-z]+-[0-9a-f]{12}$`)
+
+func checkIDs(t *testing.T, ids []string) {
+	t.Helper()
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !idPattern.MatchString(id) {
+			t.Fatalf("malformed ID %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestNewUnique(t *testing.T) {
+	const n = 100000
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = New("client")
+	}
+	checkIDs(t, ids)
+}
+
+func TestNewUniqueConcurrently(t *testing.T) {
+	const workers, perWorker = 16, 10000
+	batches := make([][]string, workers)
+
+	var wg sync.WaitGroup
+	for w := range batches {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			batch := make([]string, perWorker)
+			for i := range batch {
+				batch[i] = New("client")
+			}
+			batches[w] = batch
+		}(w)
+	}
+	wg.Wait()
+
+	var ids []string
+	for _, batch := range batches {
+		ids = append(ids, batch...)
+	}
+	checkIDs(t, ids)
+}
+
+func TestTokenAndCode(t *testing.T) {
+	if token := Token(16); !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(token) {
+		t.Fatalf("malformed token %q", token)
+	}
+
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	codePattern := regexp.MustCompile(`^[` + alphabet + `]{8}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		code := Code(alphabet, 8)
+		if !codePattern.MatchString(code) {
+			t.Fatalf("malformed code %q", code)
+		}
+		if seen[code] {
+			t.Fatalf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
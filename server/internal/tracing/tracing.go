@@ -0,0 +1,134 @@
+// Package tracing records sampled spans of server work, such as handling a
+// client message, in the OpenTelemetry span format. Spans are written as
+// JSON lines that a collector can pick up.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	mathrand "math/rand"
+	"sync"
+	"time"
+)
+
+// Tracer starts spans for a sample of operations
+type Tracer struct {
+	sampleRate float64
+	exporter   Exporter
+}
+
+// Exporter receives finished spans
+type Exporter interface {
+	Export(span *Span)
+}
+
+// New creates a tracer that traces sampleRate (0..1) of the operations
+// started on it. A nil or zero-rate tracer traces nothing.
+func New(sampleRate float64, exporter Exporter) *Tracer {
+	return &Tracer{sampleRate: sampleRate, exporter: exporter}
+}
+
+// Start begins a root span, or returns nil if the operation isn't sampled.
+// Every Span method is safe to call on nil, so callers don't need to check.
+func (t *Tracer) Start(name string) *Span {
+	if t == nil || t.sampleRate <= 0 || mathrand.Float64() >= t.sampleRate {
+		return nil
+	}
+	return &Span{
+		TraceID: newID(16),
+		SpanID:  newID(8),
+		Name:    name,
+		Start:   time.Now(),
+		tracer:  t,
+	}
+}
+
+// Span is one timed step of a traced operation
+type Span struct {
+	TraceID    string                 `json:"traceId"`
+	SpanID     string                 `json:"spanId"`
+	ParentID   string                 `json:"parentSpanId,omitempty"`
+	Name       string                 `json:"name"`
+	Start      time.Time              `json:"startTime"`
+	End        time.Time              `json:"endTime"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+
+	tracer *Tracer
+}
+
+// Child starts a span for a step within this one
+func (s *Span) Child(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{
+		TraceID:  s.TraceID,
+		SpanID:   newID(8),
+		ParentID: s.SpanID,
+		Name:     name,
+		Start:    time.Now(),
+		tracer:   s.tracer,
+	}
+}
+
+// SetAttribute attaches a key/value pair to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+}
+
+// Finish ends the span and hands it to the exporter
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	if s.tracer.exporter != nil {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// Trace returns the span's trace ID, or "" if it isn't sampled
+func (s *Span) Trace() string {
+	if s == nil {
+		return ""
+	}
+	return s.TraceID
+}
+
+// newID returns a random hex ID of n bytes, as used for W3C trace context
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// JSONExporter writes each finished span as a line of JSON
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONExporter creates an exporter writing to w
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// Export writes a span
+func (e *JSONExporter) Export(span *Span) {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(data, '\n'))
+}
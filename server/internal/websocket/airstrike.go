@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleAirstrike calls in an airstrike where the player aimed. The action
+// is stamped with the game time the player was looking at, going by the
+// round trip the server measured, so it lands on enemies where the player
+// saw them.
+func (c *Client) handleAirstrike(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	x, xOk := msg.Payload["x"].(float64)
+	y, yOk := msg.Payload["y"].(float64)
+	if !xOk || !yOk || !game.InBounds(game.Position{X: x, Y: y}) {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	seen := room.ViewTime(c.clientLatency().Measured)
+	mutation := c.traceStep("room.mutation")
+	result, err := c.applyCommand(room, msg, game.Airstrike{X: x, Y: y, Seen: seen, PlayerID: c.id})
+	mutation.Finish()
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+	hit, _ := result.([]game.EntityID)
+	log.Printf("Client %s called an airstrike in room %s, hitting %d enemies", c.id, roomID, len(hit))
+
+	c.broadcastState(roomID)
+
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status":    "ok",
+			"code":      i18n.AckAirstrike,
+			"params":    map[string]interface{}{"hits": len(hit)},
+			"enemy_ids": hit,
+		},
+	})
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleWatchAnalysis subscribes an admin to a room's analysis stream: every
+// targeting decision, hit and projectile, each tick. A client analyzes one
+// room at a time.
+func (c *Client) handleWatchAnalysis(msg *Message) {
+	token, _ := msg.Payload["token"].(string)
+	if !isAdmin(token) {
+		log.Printf("Client %s tried to watch analysis without a valid admin token", c.id)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrForbidden, nil))
+		return
+	}
+
+	_, roomID, ok := c.resolveAnyRoom(msg)
+	if !ok {
+		return
+	}
+
+	c.hub.unwatchAnalysis(c)
+	c.hub.watchAnalysis(c, roomID)
+
+	log.Printf("Client %s watching analysis for room %s", c.id, roomID)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckWatching,
+			"params": map[string]interface{}{"room_id": roomID},
+		},
+	})
+}
+
+// handleUnwatchAnalysis stops a client's analysis subscription
+func (c *Client) handleUnwatchAnalysis(msg *Message) {
+	c.hub.unwatchAnalysis(c)
+}
+
+// watchAnalysis subscribes a client to a room's analysis stream
+func (h *Hub) watchAnalysis(client *Client, roomID string) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	if h.analysts[roomID] == nil {
+		h.analysts[roomID] = make(map[*Client]bool)
+	}
+	h.analysts[roomID][client] = true
+	client.analyzing = roomID
+	h.gameManager.WatchAnalysis(roomID)
+}
+
+// unwatchAnalysis drops a client's analysis subscription, if it has one
+func (h *Hub) unwatchAnalysis(client *Client) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	roomID := client.analyzing
+	if roomID == "" {
+		return
+	}
+	delete(h.analysts[roomID], client)
+	if len(h.analysts[roomID]) == 0 {
+		delete(h.analysts, roomID)
+	}
+	client.analyzing = ""
+	h.gameManager.UnwatchAnalysis(roomID)
+}
+
+// listenToAnalysis passes the manager's analysis frames on to analysts. It
+// has its own channel, so frames never take the place of game state.
+func (h *Hub) listenToAnalysis() {
+	for msg := range h.gameManager.GetAnalysisChannel() {
+		if data := analysisMessage(msg); data != nil {
+			h.sendAnalysis(msg.RoomID, data)
+		}
+	}
+}
+
+// sendAnalysis delivers an analysis frame to a room's analysts. Analysts
+// that can't keep up miss frames rather than being dropped.
+func (h *Hub) sendAnalysis(roomID string, data []byte) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	for client := range h.analysts[roomID] {
+		client.queue(data)
+	}
+}
+
+// analysisMessage wraps an analysis frame for analysts
+func analysisMessage(msg game.BroadcastMessage) []byte {
+	data, err := json.Marshal(Message{
+		Type:    MessageTypeAnalysis,
+		RoomID:  msg.RoomID,
+		Payload: map[string]interface{}{"frame": json.RawMessage(msg.Data)},
+	})
+	if err != nil {
+		log.Printf("Failed to marshal analysis frame: %v", err)
+	}
+	return data
+}
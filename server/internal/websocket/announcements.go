@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Announcement levels
+const (
+	AnnouncementInfo        = "info"
+	AnnouncementWarning     = "warning"
+	AnnouncementMaintenance = "maintenance"
+)
+
+var validAnnouncementLevels = map[string]bool{
+	AnnouncementInfo:        true,
+	AnnouncementWarning:     true,
+	AnnouncementMaintenance: true,
+}
+
+// Announcement is a server-wide notice sent to every connected client
+type Announcement struct {
+	ID      int       `json:"id"`
+	Message string    `json:"message"`
+	Level   string    `json:"level"`
+	SendAt  time.Time `json:"send_at"`
+}
+
+// announcer schedules announcements and hands them to the hub when due
+type announcer struct {
+	mu      sync.Mutex
+	count   int
+	pending map[int]Announcement
+}
+
+// ValidAnnouncementLevel reports whether level is a known announcement level
+func ValidAnnouncementLevel(level string) bool {
+	return validAnnouncementLevels[level]
+}
+
+// Announce sends an announcement to every client at sendAt, or right away if
+// sendAt is zero or in the past
+func (h *Hub) Announce(message, level string, sendAt time.Time) Announcement {
+	now := time.Now()
+	if sendAt.IsZero() || sendAt.Before(now) {
+		sendAt = now
+	}
+
+	h.announcer.mu.Lock()
+	h.announcer.count++
+	a := Announcement{
+		ID:      h.announcer.count,
+		Message: message,
+		Level:   level,
+		SendAt:  sendAt,
+	}
+	h.announcer.pending[a.ID] = a
+	h.announcer.mu.Unlock()
+
+	time.AfterFunc(time.Until(sendAt), func() {
+		h.announcer.mu.Lock()
+		delete(h.announcer.pending, a.ID)
+		h.announcer.mu.Unlock()
+
+		log.Printf("📢 Announcement %d (%s): %s", a.ID, a.Level, a.Message)
+		h.announce <- a
+	})
+
+	return a
+}
+
+// PendingAnnouncements returns the scheduled announcements not yet sent
+func (h *Hub) PendingAnnouncements() []Announcement {
+	h.announcer.mu.Lock()
+	defer h.announcer.mu.Unlock()
+
+	pending := make([]Announcement, 0, len(h.announcer.pending))
+	for _, a := range h.announcer.pending {
+		pending = append(pending, a)
+	}
+	return pending
+}
+
+// deliverAnnouncement sends an announcement to every client that hasn't
+// opted out. It runs on the hub goroutine.
+func (h *Hub) deliverAnnouncement(a Announcement) {
+	msg := Message{
+		Type: MessageTypeAnnouncement,
+		Payload: map[string]interface{}{
+			"id":      a.ID,
+			"message": a.Message,
+			"level":   a.Level,
+		},
+	}
+
+	for client := range h.clients {
+		if client.announcementsOff.Load() {
+			continue
+		}
+		client.sendJSON(msg)
+	}
+}
+
+// handleSetAnnouncements lets a client opt out of (or back into)
+// announcements
+func (c *Client) handleSetAnnouncements(msg *Message) {
+	enabled, ok := msg.Payload["enabled"].(bool)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	c.announcementsOff.Store(!enabled)
+
+	c.reply(Message{
+		Type: MessageTypeSetAnnouncements,
+		Payload: map[string]interface{}{
+			"status":  "ok",
+			"enabled": enabled,
+		},
+	})
+}
@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// botPingMultiplier raises the map ping rate limit for bots, which script
+// pings to drive tests rather than to talk to people
+const botPingMultiplier = 4
+
+// botScopes is the scope a bot needs for each message type that takes it
+// into a room or changes one. Anything else a bot may do once it's there.
+var botScopes = map[string]string{
+	MessageTypeJoinRoom:       game.BotScopePlay,
+	MessageTypeCreateRoom:     game.BotScopePlay,
+	MessageTypeSelectLevel:    game.BotScopePlay,
+	MessageTypeObserveRoom:    game.BotScopeObserve,
+	MessageTypePlaceTower:     game.BotScopePlay,
+	MessageTypeRemoveTower:    game.BotScopePlay,
+	MessageTypeUpgradeTower:   game.BotScopePlay,
+	MessageTypeSetTargetMode:  game.BotScopePlay,
+	MessageTypeSpawnEnemy:     game.BotScopePlay,
+	MessageTypeClearAll:       game.BotScopePlay,
+	MessageTypeStartWave:      game.BotScopePlay,
+	MessageTypeSkipToNextWave: game.BotScopePlay,
+	MessageTypeRewind:         game.BotScopePlay,
+	MessageTypePauseGame:      game.BotScopePlay,
+	MessageTypeSetReady:       game.BotScopePlay,
+	MessageTypeSurrender:      game.BotScopePlay,
+	MessageTypeAirstrike:      game.BotScopePlay,
+}
+
+// botKeyFrom returns the bot key a connection presents, from the
+// X-Bot-Key header or the bot_key query parameter
+func botKeyFrom(r *http.Request) string {
+	if key := r.Header.Get("X-Bot-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("bot_key")
+}
+
+// rejectBot refuses a connection presenting an unknown bot key
+func rejectBot(w http.ResponseWriter) {
+	err := i18n.NewError(i18n.ErrInvalidBotKey, nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    err.Code,
+		"params":  err.Params,
+		"message": err.Error(),
+	})
+}
+
+// botAllowed reports whether the client may send a message type. Players
+// may send anything; bots need the scope that message type takes.
+func (c *Client) botAllowed(msgType string) bool {
+	if c.bot == nil {
+		return true
+	}
+	scope, ok := botScopes[msgType]
+	return !ok || c.bot.Allows(scope)
+}
+
+// botBarred reports whether the client is a bot acting on a ranked room.
+// Bots are kept out of ranked rooms when joining, and checked again on
+// every action in case one got in some other way.
+func (c *Client) botBarred(room *game.GameStateWithShooting) bool {
+	return c.bot != nil && room.Ranked()
+}
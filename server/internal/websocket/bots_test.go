@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"testing"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// newRankedRoom opens a versus room with one player in it
+func newRankedRoom(t *testing.T, manager *game.Manager, roomID, playerID string) *game.GameStateWithShooting {
+	t.Helper()
+	config := game.DefaultRoomConfig()
+	config.Mode = game.ModeVersus
+	room, err := manager.CreateShootingRoomWithConfig(roomID, config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	if !room.Ranked() {
+		t.Fatal("versus room isn't ranked")
+	}
+	room.Join([]string{playerID}, map[string]string{playerID: "Player"})
+	return room
+}
+
+func newTestBot(hub *Hub, scopes ...string) *Client {
+	c := newTestClient(hub, game.NewBotPlayerID("tester"))
+	c.bot = &game.BotKey{Name: "tester", Scopes: scopes}
+	return c
+}
+
+func TestBotCannotChangeRankedRoom(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newRankedRoom(t, manager, "ranked-1", "player")
+
+	// Naming the room from outside it
+	outsider := newTestBot(hub, game.BotScopePlay)
+	for _, msgType := range []string{MessageTypePlaceTower, MessageTypeSpawnEnemy, MessageTypeStartWave} {
+		msg := placeTowerMessage(room.RoomID)
+		msg.Type = msgType
+		if msgType != MessageTypePlaceTower {
+			msg.Payload = nil
+		}
+		outsider.handleMessage(msg)
+		if code := lastReply(t, outsider).Payload["code"]; code != string(i18n.ErrNotInRoom) {
+			t.Fatalf("outside bot's %s got code %v, want %s", msgType, code, i18n.ErrNotInRoom)
+		}
+	}
+
+	// A bot that got into the room without the join check
+	insider := newTestBot(hub, game.BotScopePlay)
+	room.Join([]string{insider.id}, map[string]string{insider.id: "Bot"})
+	insider.setRoom(room.RoomID)
+	insider.handleMessage(placeTowerMessage(room.RoomID))
+	if code := lastReply(t, insider).Payload["code"]; code != string(i18n.ErrBotForbidden) {
+		t.Fatalf("bot in a ranked room got code %v, want %s", code, i18n.ErrBotForbidden)
+	}
+
+	// and one whose key doesn't let it play at all
+	watcher := newTestBot(hub, game.BotScopeObserve)
+	watcher.setRoom(room.RoomID)
+	watcher.handleMessage(placeTowerMessage(room.RoomID))
+	if code := lastReply(t, watcher).Payload["code"]; code != string(i18n.ErrBotForbidden) {
+		t.Fatalf("observe-only bot got code %v, want %s", code, i18n.ErrBotForbidden)
+	}
+
+	snapshot := room.GetSnapshot()
+	if len(snapshot.Towers) != 0 || len(snapshot.Enemies) != 0 || snapshot.Wave != 1 {
+		t.Fatalf("bots changed the ranked room: %d towers, %d enemies, wave %d", len(snapshot.Towers), len(snapshot.Enemies), snapshot.Wave)
+	}
+}
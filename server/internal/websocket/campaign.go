@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// handleSelectLevel opens a room for a campaign level and moves the client
+// (and their party) into it. With ghost set, the room races the level's
+// best recorded run.
+func (c *Client) handleSelectLevel(msg *Message) {
+	campaignID, _ := msg.Payload["campaign"].(string)
+	levelID, _ := msg.Payload["level"].(string)
+	ghost, _ := msg.Payload["ghost"].(bool)
+
+	roomID, err := c.hub.gameManager.SelectLevel(c.id, campaignID, levelID, ghost)
+	if err != nil {
+		log.Printf("Client %s could not start %s/%s: %v", c.id, campaignID, levelID, err)
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.reply(Message{
+		Type:   MessageTypeSelectLevel,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status":   "selected",
+			"code":     i18n.AckLevelChosen,
+			"params":   map[string]interface{}{"level": levelID},
+			"campaign": campaignID,
+			"level":    levelID,
+		},
+	})
+
+	// Nobody else can find the level's room, so don't leave it open empty
+	if err := c.enterRoom(roomID); err != nil {
+		c.hub.gameManager.DeleteRoom(roomID)
+		c.sendError(msg.Type, err)
+	}
+}
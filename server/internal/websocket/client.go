@@ -2,11 +2,17 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+	"rust-rush/server/internal/tracing"
 
 	"github.com/gorilla/websocket"
 )
@@ -32,20 +38,64 @@ type Client struct {
 	conn   *websocket.Conn
 	send   chan []byte
 	id     string
-	roomID string
+	ip     string
+	tenant string // realm the client's room and player IDs live in
+	roomID string // guarded by roomsMu
+	// instance of the room it joined, guarded by roomsMu
+	roomInstance uint64
+	bot          *game.BotKey // set for bot connections
+
+	pingLimiter *rateLimiter
+	chatLimiter *rateLimiter
+
+	announcementsOff atomic.Bool  // opted out of global announcements
+	evicting         atomic.Bool  // too slow, waiting for the hub to drop it
+	lastActive       atomic.Int64 // unix nanos of the last application message
+	idleWarned       int64        // lastActive when last warned, hub only
+	latency          atomic.Int64 // one-way latency from the last pong
+	pings            pingTracker  // when recent pings went out
+	watching         string       // room whose diagnostics this admin watches
+	analyzing        string       // room whose analysis stream this admin watches
+	closeCode        int          // sent in the close frame, 0 for none
+	invalid          int          // malformed messages in a row
+
+	// Round trips the client probed to other regions, sent when connecting
+	regionRTTs map[string]time.Duration
+
+	// Held while a message is handled, along with the span and timing of
+	// the message being handled. Scheduled actions release it while they
+	// wait for their tick, and are counted until they finish.
+	handling  sync.Mutex
+	span      *tracing.Span       // nil if the message isn't traced
+	timing    *game.CommandTiming // when the action ran, if it was scheduled
+	scheduled sync.WaitGroup
+
+	// Set once the hub closes send. A kick, idle timeout or shutdown can
+	// close it while the client is still handling a message, so every send
+	// goes through queue.
+	closed bool
+	sendMu sync.RWMutex
+
+	// Rooms the client receives on top of roomID, without playing in them,
+	// and lockstep rooms whose frames wait for the client's keyframe
+	observing map[string]bool
+	awaiting  map[string]bool
+	roomsMu   sync.RWMutex
 }
 
 // readPump pumps messages from the WebSocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
+		c.scheduled.Wait() // nothing may send once the hub closes the channel
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
 
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error {
+	c.conn.SetPongHandler(func(appData string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.measureLatency(appData)
 		return nil
 	})
 
@@ -58,244 +108,486 @@ func (c *Client) readPump() {
 			break
 		}
 
+		root := c.hub.tracer.Start("ws.message")
+		root.SetAttribute("client.id", c.id)
+		if c.bot != nil {
+			root.SetAttribute("client.bot", c.bot.Name)
+		}
+		root.SetAttribute("message.size", len(messageBytes))
+
 		// Parse the message
-		var msg Message
-		if err := json.Unmarshal(messageBytes, &msg); err != nil {
-			log.Printf("Failed to parse message: %v", err)
+		decode := root.Child("ws.decode")
+		msg, err := decodeMessage(messageBytes)
+		decode.Finish()
+		c.hub.recorder.record(c, FrameIn, messageBytes, game.Qualify(c.tenant, msg.RoomID))
+		root.SetAttribute("message.type", msg.Type)
+		if err != nil {
+			log.Printf("Rejected message from client %s: %v", c.id, err)
+			c.handling.Lock()
+			c.span = root
+			c.sendError(msg.Type, err)
+			c.span = nil
+			c.handling.Unlock()
+			root.SetAttribute("error", err.Error())
+			root.Finish()
+
+			// The connection closes once the close frame is written,
+			// which ends this loop
+			if c.invalid++; c.invalid == maxInvalidMessages {
+				c.disconnect(CloseProtocolViolation)
+				break
+			}
 			continue
 		}
+		c.invalid = 0
+		c.touch()
 
-		// Handle the message
-		c.handleMessage(&msg)
+		c.dispatch(root, msg)
 	}
 }
 
+// dispatch handles a message. Scheduled actions are handled on their own
+// goroutine, which lets go of the handling lock while it waits for the
+// action's tick, so the client's later messages aren't held up. Either way
+// dispatch returns once the message is handled or waiting.
+func (c *Client) dispatch(root *tracing.Span, msg Message) {
+	c.handling.Lock()
+	if msg.AtTick == 0 {
+		c.handle(root, &msg)
+		return
+	}
+
+	c.scheduled.Add(1)
+	go func() {
+		defer c.scheduled.Done()
+		c.handle(root, &msg)
+	}()
+
+	// Wait for the handler to finish or start waiting
+	c.handling.Lock()
+	c.handling.Unlock()
+}
+
+// handle runs a message's handler. The caller takes the handling lock,
+// which is released once the message is handled.
+func (c *Client) handle(root *tracing.Span, msg *Message) {
+	c.span = root.Child("ws.handle")
+	c.span.SetAttribute("message.type", msg.Type)
+	c.handleMessage(msg)
+	c.span.Finish()
+	c.span = nil
+	c.timing = nil
+	c.handling.Unlock()
+	root.Finish()
+}
+
 // handleMessage processes different message types
 func (c *Client) handleMessage(msg *Message) {
 	log.Printf("Client %s received message type: %s", c.id, msg.Type)
+	if err := c.qualifyMessage(msg); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	if !c.botAllowed(msg.Type) {
+		log.Printf("Bot %s lacks the scope for %s", c.bot.Name, msg.Type)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrBotForbidden, nil))
+		return
+	}
 
 	switch msg.Type {
 	case MessageTypeJoinRoom:
-		if msg.RoomID != "" {
-			c.roomID = msg.RoomID
+		c.handleJoinRoom(msg)
 
-			// Create a shooting room if it doesn't exist
-			_, exists := c.hub.gameManager.GetShootingRoom(msg.RoomID)
-			if !exists {
-				room := c.hub.gameManager.CreateShootingRoom(msg.RoomID)
+	case MessageTypeCreateRoom:
+		c.handleCreateRoom(msg)
 
-				// Start game loop for this room
-				go c.hub.gameManager.StartGameLoop(msg.RoomID)
+	case MessageTypeObserveRoom:
+		c.handleObserveRoom(msg)
 
-				log.Printf("Created new shooting room: %s", msg.RoomID)
+	case MessageTypeUnobserveRoom:
+		c.handleUnobserveRoom(msg)
 
-				// Set spawn and goal points
-				room.SpawnPoint = &game.Position{X: 0, Y: 7}
-				room.GoalPoint = &game.Position{X: 19, Y: 7}
-			}
+	case MessageTypeWatchDemo:
+		c.handleWatchDemo(msg)
 
-			c.hub.gameManager.AddPlayer(msg.RoomID, c.id)
+	case MessageTypeSaveTemplate:
+		c.handleSaveTemplate(msg)
 
-			// Send confirmation with current game state
-			room, _ := c.hub.gameManager.GetShootingRoom(msg.RoomID)
-			snapshot := room.GetSnapshot()
+	case MessageTypeListRooms:
+		c.handleListRooms(msg)
 
-			response := Message{
-				Type:   MessageTypeJoinRoom,
-				RoomID: msg.RoomID,
-				Payload: map[string]interface{}{
-					"status":   "joined",
-					"clientId": c.id,
-					"state":    snapshot,
-				},
-			}
-			c.sendJSON(response)
+	case MessageTypeQuickMatch:
+		c.handleQuickMatch(msg)
 
-			log.Printf("Client %s joined shooting room %s", c.id, msg.RoomID)
-		}
+	case MessageTypeRotateJoinCode:
+		c.handleRotateJoinCode(msg)
 
 	case MessageTypeLeaveRoom:
-		if c.roomID != "" {
-			c.hub.gameManager.RemovePlayer(c.roomID, c.id)
-			c.roomID = ""
+		if c.currentRoom() != "" {
+			c.hub.leaveRoom(c, LeaveReasonLeft)
+			c.hub.notifyPresence(c.id)
 		}
 
 	case MessageTypePlaceTower:
-		// Use room_id from message if provided, otherwise use client's stored roomID
-		roomID := msg.RoomID
-		if roomID == "" {
-			roomID = c.roomID
-		}
-
-		if roomID == "" {
-			log.Printf("Client %s tried to place tower but is not in a room", c.id)
+		room, roomID, ok := c.resolveRoom(msg)
+		if !ok {
 			return
 		}
 
 		// Extract tower placement data
-		x, xOk := msg.Payload["x"].(float64)
-		y, yOk := msg.Payload["y"].(float64)
-		towerType, typeOk := msg.Payload["tower_type"].(string)
-
-		if !xOk || !yOk || !typeOk {
+		placement, err := decodePlacement(msg)
+		if err != nil {
 			log.Printf("Invalid tower placement data: %v", msg.Payload)
+			c.sendError(msg.Type, err)
 			return
 		}
+		x, y, towerType := *placement.X, *placement.Y, placement.TowerType
 
-		room, exists := c.hub.gameManager.GetShootingRoom(roomID)
-		if !exists {
-			log.Printf("Room %s does not exist", roomID)
+		if err := c.hub.gameManager.CheckCosmetic(c.id, placement.Cosmetic, towerType); err != nil {
+			c.sendError(msg.Type, err)
 			return
 		}
 
 		// Add tower to game state
-		tower := room.AddTower(x, y, towerType)
+		mutation := c.traceStep("room.mutation")
+		result, err := c.applyCommand(room, msg, game.PlaceTower{X: x, Y: y, TowerType: towerType, OwnerID: c.id, Cosmetic: placement.Cosmetic})
+		tower, _ := result.(game.Tower)
+		mutation.Finish()
+		if err != nil {
+			log.Printf("Rejected %s tower at (%.1f, %.1f) in room %s: %v", towerType, x, y, roomID, err)
+			c.sendError(MessageTypePlaceTower, err)
+			return
+		}
 
 		log.Printf("Placed %s tower at (%.1f, %.1f) in room %s", towerType, x, y, roomID)
 
 		// Broadcast updated state immediately
-		c.hub.BroadcastGameState(roomID)
+		c.broadcastState(roomID)
 
 		// Send acknowledgment
 		response := Message{
 			Type: MessageTypePlaceTower,
 			Payload: map[string]interface{}{
 				"status": "placed",
+				"code":   i18n.AckTowerPlaced,
 				"tower":  tower,
 			},
 		}
-		c.sendJSON(response)
+		c.reply(response)
 
 	case MessageTypeRemoveTower:
-		log.Printf("Remove tower request: %v", msg.Payload)
-		// TODO: Implement tower removal
+		c.handleSellTower(msg)
 
-	case MessageTypeSpawnEnemy:
-		// Use room_id from message if provided, otherwise use client's stored roomID
-		roomID := msg.RoomID
-		if roomID == "" {
-			roomID = c.roomID
-		}
+	case MessageTypeUpgradeTower:
+		c.handleUpgradeTower(msg)
 
-		if roomID == "" {
-			log.Printf("Client %s tried to spawn enemy but is not in a room", c.id)
-			return
-		}
+	case MessageTypeSetTargetMode:
+		c.handleSetTargetMode(msg)
 
-		room, exists := c.hub.gameManager.GetShootingRoom(roomID)
-		if !exists {
-			log.Printf("Room %s does not exist", roomID)
+	case MessageTypeSpawnEnemy:
+		room, roomID, ok := c.resolveRoom(msg)
+		if !ok {
 			return
 		}
 
 		// Extract enemy type and path
-		enemyType := "basic"
-		if et, ok := msg.Payload["enemy_type"].(string); ok {
-			enemyType = et
-		}
-
-		// Get path from payload
-		var path []game.Position
-		if pathData, ok := msg.Payload["path"].([]interface{}); ok {
-			for _, p := range pathData {
-				if posMap, ok := p.(map[string]interface{}); ok {
-					x, xOk := posMap["x"].(float64)
-					y, yOk := posMap["y"].(float64)
-					if xOk && yOk {
-						path = append(path, game.Position{X: x, Y: y})
-					}
-				}
-			}
+		var spawn enemySpawn
+		if err := decodePayload(msg, &spawn); err != nil {
+			log.Printf("Invalid spawn data: %v", msg.Payload)
+			c.sendError(msg.Type, err)
+			return
 		}
 
-		// If no path provided, use a default path
-		if len(path) == 0 {
-			if room.SpawnPoint != nil && room.GoalPoint != nil {
-				path = []game.Position{
-					*room.SpawnPoint,
-					*room.GoalPoint,
-				}
-			}
+		enemyType := "basic"
+		if spawn.EnemyType != "" {
+			enemyType = spawn.EnemyType
 		}
 
-		if len(path) > 0 {
-			enemy := room.AddEnemy(enemyType, path)
-			log.Printf("Spawned %s enemy with ID %d in room %s", enemyType, enemy.ID, roomID)
+		// The room checks the path and routes the enemy itself if it's invalid
+		mutation := c.traceStep("room.mutation")
+		result, _ := c.applyCommand(room, msg, game.SpawnEnemy{SenderID: c.id, EnemyType: enemyType, Path: spawn.Path})
+		enemies, _ := result.([]game.Enemy)
+		mutation.Finish()
+		if len(enemies) > 0 {
+			enemy := enemies[0]
+			log.Printf("Spawned %d %s enemy with ID %v in room %s", len(enemies), enemyType, enemy.ID, roomID)
 
 			// Broadcast updated state
-			c.hub.BroadcastGameState(roomID)
+			c.broadcastState(roomID)
 
 			// Send acknowledgment
 			response := Message{
 				Type: MessageTypeSpawnEnemy,
 				Payload: map[string]interface{}{
-					"status": "spawned",
-					"enemy":  enemy,
+					"status":  "spawned",
+					"code":    i18n.AckEnemySpawned,
+					"enemy":   enemy,
+					"enemies": enemies,
 				},
 			}
-			c.sendJSON(response)
+			c.reply(response)
 		}
 
 	case MessageTypeClearAll:
-		// Use room_id from message if provided, otherwise use client's stored roomID
-		roomID := msg.RoomID
-		if roomID == "" {
-			roomID = c.roomID
-		}
-
-		if roomID == "" {
-			log.Printf("Client %s tried to clear all but is not in a room", c.id)
+		room, roomID, ok := c.resolveRoom(msg)
+		if !ok {
 			return
 		}
 
-		room, exists := c.hub.gameManager.GetShootingRoom(roomID)
-		if !exists {
-			log.Printf("Room %s does not exist", roomID)
+		// Clear towers and enemies together, on the same tick
+		if _, err := c.applyCommand(room, msg, game.ClearAll{By: c.id}); err != nil {
+			c.sendError(msg.Type, err)
 			return
 		}
 
-		// Clear towers and enemies
-		room.RemoveAllTowers()
-		room.RemoveAllEnemies()
-
 		log.Printf("Cleared all towers and enemies in room %s", roomID)
 
 		// Broadcast updated state
-		c.hub.BroadcastGameState(roomID)
+		c.broadcastState(roomID)
 
 		// Send acknowledgment
 		response := Message{
 			Type: MessageTypeClearAll,
 			Payload: map[string]interface{}{
 				"status": "cleared",
+				"code":   i18n.AckClearedAll,
 			},
 		}
-		c.sendJSON(response)
+		c.reply(response)
 
 	case MessageTypeStartWave:
 		log.Printf("Start wave request from client %s", c.id)
-		// TODO: Implement wave system
+
+		room, _, ok := c.resolveRoom(msg)
+		if !ok {
+			return
+		}
+
+		mutation := c.traceStep("room.mutation")
+		result, _ := c.applyCommand(room, msg, game.StartWave{})
+		wave, _ := result.(int)
+		mutation.Finish()
 
 		// Send acknowledgment
 		response := Message{
 			Type: MessageTypeGameState,
 			Payload: map[string]interface{}{
 				"action": "wave_started",
-				"wave":   1,
+				"code":   i18n.AckWaveStarted,
+				"params": map[string]interface{}{"wave": wave},
+				"wave":   wave,
 			},
 		}
-		c.sendJSON(response)
+		c.reply(response)
+
+	case MessageTypeSkipToNextWave:
+		c.handleSkipToNextWave(msg)
+
+	case MessageTypeRewind:
+		c.handleRewind(msg)
 
 	case MessageTypePauseGame:
 		log.Printf("Pause game request from client %s", c.id)
-		// TODO: Pause game logic
+
+		room, roomID, ok := c.resolveRoom(msg)
+		if !ok {
+			return
+		}
+
+		// Toggle unless the client asks for a specific state
+		paused := !room.IsPaused()
+		if p, ok := msg.Payload["paused"].(bool); ok {
+			paused = p
+		}
+		c.applyCommand(room, msg, game.SetPaused{Paused: paused})
+
+		log.Printf("Room %s paused: %t", roomID, paused)
+
+		c.broadcastState(roomID)
+
+		code := i18n.AckGameResumed
+		if paused {
+			code = i18n.AckGamePaused
+		}
+		c.reply(Message{
+			Type: MessageTypePauseGame,
+			Payload: map[string]interface{}{
+				"status": "ok",
+				"code":   code,
+				"paused": paused,
+			},
+		})
+
+	case MessageTypeRequestState:
+		c.handleRequestState(msg)
+
+	case MessageTypeRequestFullState:
+		c.handleRequestFullState(msg)
+
+	case MessageTypeMapPing:
+		c.handleMapPing(msg)
+
+	case MessageTypeReportPlayer:
+		c.handleReportPlayer(msg)
+
+	case MessageTypeWatchDiag:
+		c.handleWatchDiagnostics(msg)
+
+	case MessageTypeUnwatchDiag:
+		c.handleUnwatchDiagnostics(msg)
+
+	case MessageTypeWatchAnalysis:
+		c.handleWatchAnalysis(msg)
+
+	case MessageTypeUnwatchAnalysis:
+		c.handleUnwatchAnalysis(msg)
+
+	case MessageTypeSetReady:
+		c.handleSetReady(msg)
+
+	case MessageTypeSetDropIn:
+		c.handleSetDropIn(msg)
+
+	case MessageTypeKickPlayer:
+		c.handleKickPlayer(msg)
+
+	case MessageTypeSurrender:
+		c.handleSurrender(msg)
+
+	case MessageTypeGetEconomyLog:
+		c.handleGetEconomyLog(msg)
+
+	case MessageTypeSelectLevel:
+		c.handleSelectLevel(msg)
+
+	case MessageTypeQuickChat:
+		c.handleQuickChat(msg)
+
+	case MessageTypeQueueForMatch:
+		c.handleQueueForMatch(msg)
+
+	case MessageTypeLeaveQueue:
+		c.handleLeaveQueue(msg)
+
+	case MessageTypePartyCreate:
+		c.handlePartyCreate(msg)
+
+	case MessageTypePartyInvite:
+		c.handlePartyInvite(msg)
+
+	case MessageTypePartyAccept:
+		c.handlePartyAccept(msg)
+
+	case MessageTypePartyLeave:
+		c.handlePartyLeave(msg)
+
+	case MessageTypeFriendRequest:
+		c.handleFriendRequest(msg)
+
+	case MessageTypeFriendAccept:
+		c.handleFriendAccept(msg)
+
+	case MessageTypeFriendRemove:
+		c.handleFriendRemove(msg)
+
+	case MessageTypeFriendList:
+		c.handleFriendList(msg)
+
+	case MessageTypeRoomInvite:
+		c.handleRoomInvite(msg)
+
+	case MessageTypeSetAnnouncements:
+		c.handleSetAnnouncements(msg)
+
+	case MessageTypeSetTelemetry:
+		c.handleSetTelemetry(msg)
+
+	case MessageTypeGetSettings:
+		c.handleGetSettings(msg)
+
+	case MessageTypeSetSettings:
+		c.handleSetSettings(msg)
+
+	case MessageTypeInbox:
+		c.handleInbox(msg)
+
+	case MessageTypeMarkRead:
+		c.handleMarkRead(msg)
+
+	case MessageTypeAirstrike:
+		c.handleAirstrike(msg)
 
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrUnknownType, map[string]interface{}{
+			"type": msg.Type,
+		}))
 	}
 }
 
-// sendJSON sends a JSON message to the client
+// sendJoined confirms a room join with the current game state. The hub
+// calls it for players moved into a room, so it doesn't reply.
+func (c *Client) sendJoined(roomID string) {
+	room, exists := c.hub.gameManager.GetShootingRoom(roomID)
+	if !exists {
+		c.sendJSON(errorMessage(MessageTypeJoinRoom, roomNotFound(roomID)))
+		return
+	}
+
+	payload := map[string]interface{}{
+		"status":   "joined",
+		"code":     i18n.AckJoinedRoom,
+		"clientId": c.id,
+	}
+	// Mid-game joiners also get history so their UI isn't empty
+	payload["state"] = c.hub.gameManager.ClientSnapshot(room)
+	payload["lobby"] = room.Lobby()
+	if room.InProgress() {
+		if bundle, ok := c.hub.gameManager.Bootstrap(roomID); ok {
+			payload["bootstrap"] = bundle
+		}
+	}
+	if events := c.hub.gameManager.Events().Active(time.Now()); len(events) > 0 {
+		payload["server_events"] = events
+	}
+	if joinCode, ok := room.JoinCode(c.id); ok {
+		payload["join_code"] = joinCode
+	}
+
+	c.sendJSON(Message{
+		Type:    MessageTypeJoinRoom,
+		RoomID:  roomID,
+		Payload: payload,
+	})
+}
+
+// reply answers the message being handled. Acks and errors carry the
+// message's trace ID so a slow action can be found, and scheduled actions
+// say which tick they landed on. Only handlers may reply, as the span and
+// timing belong to whoever holds the handling lock.
+func (c *Client) reply(msg Message) {
+	c.sendJSON(stamped(msg, c.span.Trace(), c.timing))
+}
+
+// stamped adds a trace ID and scheduling timing to an ack or error
+func stamped(msg Message, traceID string, timing *game.CommandTiming) Message {
+	if msg.Payload == nil {
+		return msg
+	}
+	if _, ok := msg.Payload["status"]; !ok {
+		return msg
+	}
+	if traceID != "" {
+		msg.Payload["trace_id"] = traceID
+	}
+	if timing != nil {
+		msg.Payload["timing"] = *timing
+	}
+	return msg
+}
+
+// sendJSON sends a JSON message to the client. It's safe from any goroutine.
 func (c *Client) sendJSON(msg Message) {
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -303,11 +595,278 @@ func (c *Client) sendJSON(msg Message) {
 		return
 	}
 
+	if !c.queue(data) {
+		log.Printf("Client %s send buffer full", c.id)
+	}
+}
+
+// queue hands a message to the write pump without blocking. It reports
+// false if the buffer is full or the connection has been closed.
+func (c *Client) queue(data []byte) bool {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
+	if c.closed {
+		return false
+	}
 	select {
 	case c.send <- data:
+		return true
 	default:
-		log.Printf("Client %s send buffer full", c.id)
+		return false
+	}
+}
+
+// closeSend closes the send channel, which ends the write pump once it has
+// written what's queued
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// sendError reports a failed action back to the client. Coded errors are sent
+// as their code and parameters so the client can localize them; anything else
+// is reported as an internal error.
+func (c *Client) sendError(msgType string, err error) {
+	c.reply(errorMessage(msgType, err))
+}
+
+// errorMessage builds the message reporting a failed action
+func errorMessage(msgType string, err error) Message {
+	var coded *i18n.Error
+	if !errors.As(err, &coded) {
+		coded = i18n.NewError(i18n.ErrInternal, nil)
+	}
+
+	if msgType == "" {
+		msgType = MessageTypeError
+	}
+
+	return Message{
+		Type: msgType,
+		Payload: map[string]interface{}{
+			"status":  "error",
+			"code":    coded.Code,
+			"params":  coded.Params,
+			"message": coded.Error(),
+		},
+	}
+}
+
+// Who may act on a room by naming it in a message
+const (
+	accessPlayers   = iota // players in the room
+	accessObservers        // players and clients observing it
+	accessAnyone           // admin messages, whose token was checked already
+)
+
+// resolveRoom finds the shooting room a message acts on, using the message's
+// room_id if provided and the client's current room otherwise. The client
+// must be playing in the room. It reports the error to the client when it
+// isn't or there is no such room.
+func (c *Client) resolveRoom(msg *Message) (*game.GameStateWithShooting, string, bool) {
+	return c.findRoom(msg, accessPlayers)
+}
+
+// resolveWatchedRoom is resolveRoom for messages observers may send too
+func (c *Client) resolveWatchedRoom(msg *Message) (*game.GameStateWithShooting, string, bool) {
+	return c.findRoom(msg, accessObservers)
+}
+
+// resolveAnyRoom is resolveRoom for admin messages, which may name any room
+func (c *Client) resolveAnyRoom(msg *Message) (*game.GameStateWithShooting, string, bool) {
+	return c.findRoom(msg, accessAnyone)
+}
+
+func (c *Client) findRoom(msg *Message, access int) (*game.GameStateWithShooting, string, bool) {
+	roomID := msg.RoomID
+	if roomID == "" {
+		roomID = c.currentRoom()
+	}
+
+	if roomID == "" {
+		log.Printf("Client %s sent %s but is not in a room", c.id, msg.Type)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInRoom, nil))
+		return nil, "", false
+	}
+
+	room, exists := c.hub.gameManager.GetShootingRoom(roomID)
+	if !exists {
+		log.Printf("Room %s does not exist", roomID)
+		c.sendError(msg.Type, roomNotFound(roomID))
+		return nil, "", false
+	}
+
+	// Naming a room doesn't get around its join code
+	allowed := true
+	switch access {
+	case accessPlayers:
+		allowed = c.inRoom(roomID) && room.HasPlayer(c.id)
+	case accessObservers:
+		allowed = c.inRoom(roomID)
+	}
+	if !allowed {
+		log.Printf("Client %s sent %s for room %s it isn't in", c.id, msg.Type, roomID)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInRoom, nil))
+		return nil, "", false
+	}
+	if access == accessPlayers && c.botBarred(room) {
+		log.Printf("Bot %s tried %s in ranked room %s", c.bot.Name, msg.Type, roomID)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrBotForbidden, nil))
+		return nil, "", false
+	}
+
+	return room, roomID, true
+}
+
+// applyCommand runs a command in a room. Messages carrying a request_id are
+// applied at most once per player, so clients can safely retry them, even
+// from a new connection made with their identity token. Messages carrying
+// an at_tick wait for that tick, and their ack says when they ran. While
+// they wait, the client's other messages are handled.
+func (c *Client) applyCommand(room *game.GameStateWithShooting, msg *Message, cmd game.Command) (interface{}, error) {
+	if msg.RequestID != "" {
+		cmd = game.Idempotent{Key: c.id + "/" + msg.RequestID + "/" + cmd.Type(), Command: cmd}
+	}
+	if msg.AtTick == 0 {
+		return room.ApplyCommand(cmd)
+	}
+
+	scheduled, err := room.ScheduleCommand(cmd, msg.AtTick)
+	if err != nil {
+		return nil, err
 	}
+
+	span := c.span
+	c.handling.Unlock()
+	result, timing, err := scheduled.Wait()
+	c.handling.Lock()
+	c.span = span
+	c.timing = &timing
+	return result, err
+}
+
+func roomNotFound(roomID string) error {
+	return i18n.NewError(i18n.ErrRoomNotFound, map[string]interface{}{"room_id": roomID})
+}
+
+func invalidPayload(msgType string) error {
+	return i18n.NewError(i18n.ErrInvalidPayload, map[string]interface{}{"type": msgType})
+}
+
+// parseRoomConfig reads optional room settings from a join_room payload
+func parseRoomConfig(payload map[string]interface{}) (game.RoomConfig, error) {
+	config := game.DefaultRoomConfig()
+
+	configData, ok := payload["config"].(map[string]interface{})
+	if !ok {
+		return config, nil
+	}
+
+	if mode, ok := configData["mode"].(string); ok {
+		config.Mode = mode
+	}
+
+	if debug, ok := configData["debug"].(bool); ok {
+		config.Debug = debug
+	}
+
+	if sync, ok := configData["sync"].(string); ok {
+		config.Sync = sync
+	}
+
+	if waves, ok := configData["waves"].(string); ok {
+		config.Waves = waves
+	}
+
+	if tutorial, ok := configData["tutorial"].(string); ok {
+		config.Tutorial = tutorial
+	}
+
+	if visibility, ok := configData["visibility"].(string); ok {
+		config.Visibility = visibility
+	}
+
+	if password, ok := configData["password"].(string); ok {
+		config.Password = password
+	}
+
+	if strict, ok := configData["strict_ownership"].(bool); ok {
+		config.StrictOwnership = strict
+	}
+
+	if pacing, ok := configData["adaptive_pacing"].(bool); ok {
+		config.AdaptivePacing = pacing
+	}
+
+	if difficulty, ok := configData["auto_difficulty"].(bool); ok {
+		config.AutoDifficulty = difficulty
+	}
+
+	if towers, ok := configData["max_towers"].(float64); ok {
+		config.MaxTowers = int(towers)
+	}
+
+	if enemies, ok := configData["max_enemies"].(float64); ok {
+		config.MaxEnemies = int(enemies)
+	}
+
+	if projectiles, ok := configData["max_projectiles"].(float64); ok {
+		config.MaxProjectiles = int(projectiles)
+	}
+
+	if smart, ok := configData["smart_shots"].(bool); ok {
+		config.SmartShots = smart
+	}
+
+	if predictive, ok := configData["predictive"].(bool); ok {
+		config.Predictive = predictive
+	}
+
+	if solid, ok := configData["solid_terrain"].(bool); ok {
+		config.SolidTerrain = solid
+	}
+
+	if leak, ok := configData["leak_mode"].(string); ok {
+		config.LeakMode = leak
+	}
+
+	if players, ok := configData["max_players"].(float64); ok {
+		config.MaxPlayers = int(players)
+	}
+
+	if dropIn, ok := configData["drop_in"].(bool); ok {
+		config.DropIn = dropIn
+	}
+
+	if wave, ok := configData["sudden_death_wave"].(float64); ok {
+		config.SuddenDeathWave = int(wave)
+	}
+
+	// Hosts can share a seed so others can take on the same game
+	if seed, ok := configData["seed"].(float64); ok {
+		if seed != float64(int64(seed)) {
+			return config, invalidPayload(MessageTypeJoinRoom)
+		}
+		config.Seed = int64(seed)
+	}
+
+	if mutatorData, ok := configData["mutators"].([]interface{}); ok {
+		for _, m := range mutatorData {
+			id, ok := m.(string)
+			if !ok {
+				return config, invalidPayload(MessageTypeJoinRoom)
+			}
+			config.Mutators = append(config.Mutators, id)
+		}
+	}
+
+	return config, config.Validate()
 }
 
 // writePump pumps messages from the hub to the WebSocket connection
@@ -318,12 +877,19 @@ func (c *Client) writePump() {
 		c.conn.Close()
 	}()
 
+	// Ping straight away, so the round trip is known before the client
+	// looks for a room
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.conn.WriteMessage(websocket.PingMessage, c.pings.payload()); err != nil {
+		return
+	}
+
 	for {
 		select {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, closeFrame(c.closeCode))
 				return
 			}
 
@@ -332,6 +898,7 @@ func (c *Client) writePump() {
 				return
 			}
 			w.Write(message)
+			c.hub.recorder.record(c, FrameOut, message, "")
 
 			if err := w.Close(); err != nil {
 				return
@@ -339,7 +906,7 @@ func (c *Client) writePump() {
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.conn.WriteMessage(websocket.PingMessage, c.pings.payload()); err != nil {
 				return
 			}
 		}
@@ -348,26 +915,70 @@ func (c *Client) writePump() {
 
 // ServeWs handles WebSocket requests from clients
 func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	ip := remoteIP(r)
+	query := r.URL.Query()
+
+	var bot *game.BotKey
+	if key := botKeyFrom(r); key != "" {
+		k, ok := hub.gameManager.BotKeys().Lookup(key)
+		if !ok {
+			log.Printf("Rejected connection from %s: unknown bot key", ip)
+			rejectBot(w)
+			return
+		}
+		bot = &k
+	}
+
+	if bot != nil && bot.Allows(game.BotScopeLoadTest) {
+		hub.throttle.admitExempt(ip)
+	} else if wait, err := hub.throttle.admit(ip, query.Get("challenge"), query.Get("nonce")); err != nil {
+		log.Printf("Rejected connection from %s: %v", ip, err)
+		rejectConnection(w, err, wait)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
+		hub.throttle.release(ip)
 		return
 	}
 
+	tenant := hub.gameManager.Tenants().Resolve(r.Host, TenantToken(r))
 	client := &Client{
-		hub:  hub,
-		conn: conn,
-		send: make(chan []byte, 256),
-		id:   generateClientID(),
+		hub:    hub,
+		conn:   conn,
+		send:   make(chan []byte, 256),
+		id:     game.Qualify(tenant, ids.New("client")),
+		ip:     ip,
+		tenant: tenant,
+
+		pingLimiter: newRateLimiter(pingRatePerSecond, pingBurst),
+		chatLimiter: newRateLimiter(quickChatRatePerSecond, quickChatBurst),
+		regionRTTs:  game.ParseRegionRTTs(query.Get("rtt")),
+	}
+	if playerID, ok := hub.gameManager.Identities().Verify(query.Get("identity")); ok &&
+		game.TenantOf(playerID) == tenant && !game.IsBot(playerID) {
+		client.id = playerID
 	}
+	if bot != nil {
+		client.bot = bot
+		client.id = game.Qualify(tenant, game.NewBotPlayerID(bot.Name))
+		client.pingLimiter = newRateLimiter(pingRatePerSecond*botPingMultiplier, pingBurst*botPingMultiplier)
+		log.Printf("Bot %s connected from %s as %s", bot.Name, ip, client.id)
+	}
+	client.touch()
 
+	// Before anything else, so the client can decode what follows
+	client.sendProtocol()
 	client.hub.register <- client
 
+	// The home page connects with ?demo=1 to watch the demo room
+	if query.Get("demo") != "" {
+		client.watchDemo()
+	}
+
 	// Start goroutines for reading and writing
 	go client.writePump()
 	go client.readPump()
 }
-
-func generateClientID() string {
-	return "client-" + time.Now().Format("20060102150405999")
-}
@@ -0,0 +1,430 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/tracing"
+)
+
+// newTestClient returns a client that isn't connected, whose replies can be
+// read back from its send channel
+func newTestClient(hub *Hub, id string) *Client {
+	return &Client{hub: hub, id: id, send: make(chan []byte, 16)}
+}
+
+// lastReply decodes the newest message sent to a test client
+func lastReply(t *testing.T, c *Client) Message {
+	t.Helper()
+	var data []byte
+	for {
+		select {
+		case next := <-c.send:
+			data = next
+			continue
+		default:
+		}
+		break
+	}
+	if data == nil {
+		t.Fatal("client was sent nothing")
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("reply isn't a message: %v", err)
+	}
+	return msg
+}
+
+// newPrivateRoom opens a private room with one player in it
+func newPrivateRoom(t *testing.T, manager *game.Manager, roomID, hostID string) *game.GameStateWithShooting {
+	t.Helper()
+	config := game.DefaultRoomConfig()
+	config.Visibility = game.VisibilityPrivate
+	room, err := manager.CreateShootingRoomWithConfig(roomID, config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Join([]string{hostID}, map[string]string{hostID: "Host"})
+	return room
+}
+
+func placeTowerMessage(roomID string) *Message {
+	return &Message{
+		Type:   MessageTypePlaceTower,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"x":          4.0,
+			"y":          6.0,
+			"tower_type": "basic",
+		},
+	}
+}
+
+func TestPlaceTowerInPrivateRoomRequiresMembership(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newPrivateRoom(t, manager, "private-1", "host")
+
+	intruder := newTestClient(hub, "intruder")
+	intruder.handleMessage(placeTowerMessage(room.RoomID))
+
+	reply := lastReply(t, intruder)
+	if code := reply.Payload["code"]; code != string(i18n.ErrNotInRoom) {
+		t.Fatalf("non-member's place_tower got code %v, want %s", code, i18n.ErrNotInRoom)
+	}
+	if towers := room.GetSnapshot().Towers; len(towers) != 0 {
+		t.Fatalf("non-member placed %d towers", len(towers))
+	}
+
+	host := newTestClient(hub, "host")
+	host.setRoom(room.RoomID)
+	host.handleMessage(placeTowerMessage(room.RoomID))
+
+	reply = lastReply(t, host)
+	if code := reply.Payload["code"]; code == string(i18n.ErrNotInRoom) {
+		t.Fatal("member's place_tower was refused as not in the room")
+	}
+}
+
+// nextReply waits for the next message sent to a test client
+func nextReply(t *testing.T, c *Client, timeout time.Duration) Message {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("reply isn't a message: %v", err)
+		}
+		return msg
+	case <-time.After(timeout):
+		t.Fatalf("no reply within %v", timeout)
+		return Message{}
+	}
+}
+
+func TestScheduledActionDoesNotHoldUpLaterMessages(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room, err := manager.OpenRoom("scheduled-1", game.DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to open room: %v", err)
+	}
+	defer manager.DeleteRoom(room.RoomID)
+	room.Join([]string{"player"}, map[string]string{"player": "Player"})
+
+	client := newTestClient(hub, "player")
+	client.setRoom(room.RoomID)
+
+	// Once the game loop is running, far enough ahead to be obviously
+	// waiting but within the schedule limit
+	deadline := time.Now().Add(time.Second)
+	for room.GetSnapshot().Tick == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	at := room.GetSnapshot().Tick + 45
+	placement := placeTowerMessage(room.RoomID)
+	placement.AtTick = at
+	client.dispatch(nil, *placement)
+	client.dispatch(nil, Message{Type: MessageTypeRequestState, Payload: map[string]interface{}{"reason": ResyncReconnect}})
+
+	reply := nextReply(t, client, 200*time.Millisecond)
+	if reply.Type != MessageTypeGameState {
+		t.Fatalf("first reply was %s %v, want the requested state", reply.Type, reply.Payload)
+	}
+
+	reply = nextReply(t, client, 2*time.Second)
+	if reply.Type != MessageTypePlaceTower || reply.Payload["status"] != "placed" {
+		t.Fatalf("second reply was %s %v, want the scheduled tower's ack", reply.Type, reply.Payload)
+	}
+	timing, ok := reply.Payload["timing"].(map[string]interface{})
+	if !ok || uint64(timing["tick"].(float64)) < at {
+		t.Fatalf("scheduled ack has timing %v, want a tick of at least %d", reply.Payload["timing"], at)
+	}
+	client.scheduled.Wait()
+}
+
+func TestSendAfterDisconnectIsDropped(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	client := newTestClient(hub, "kicked")
+	hub.clients[client] = true
+	hub.index(client)
+
+	// A handler still running when the hub kicks the client must not send
+	// on the closed channel
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			client.sendJSON(Message{Type: MessageTypeError})
+		}
+	}()
+	hub.drop(client, CloseKicked)
+	<-done
+
+	if client.queue([]byte("{}")) {
+		t.Fatal("message was queued after the connection closed")
+	}
+}
+
+func TestPushedMessagesDontCarryHandlerContext(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	client := newTestClient(hub, "player")
+	hub.index(client)
+
+	// The hub pushes an ack-shaped message while the client is handling
+	// one of its own traced messages
+	client.handling.Lock()
+	client.span = tracing.New(1, nil).Start("ws.message")
+	client.timing = &game.CommandTiming{}
+	hub.SendToPlayer(client.id, Message{
+		Type:    MessageTypeFriendAccept,
+		Payload: map[string]interface{}{"status": "accepted"},
+	})
+	client.reply(Message{
+		Type:    MessageTypeSetReady,
+		Payload: map[string]interface{}{"status": "ok"},
+	})
+	client.span = nil
+	client.timing = nil
+	client.handling.Unlock()
+
+	pushed := nextReply(t, client, time.Second)
+	if _, ok := pushed.Payload["trace_id"]; ok {
+		t.Fatal("pushed message carried the handler's trace ID")
+	}
+	if _, ok := pushed.Payload["timing"]; ok {
+		t.Fatal("pushed message carried the handler's timing")
+	}
+	reply := nextReply(t, client, time.Second)
+	if reply.Payload["trace_id"] == nil || reply.Payload["timing"] == nil {
+		t.Fatalf("reply is missing its trace ID or timing: %v", reply.Payload)
+	}
+}
+
+func TestDefaultRealmClientCantNameOtherRealms(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newPrivateRoom(t, manager, game.Qualify("acme", "room-1"), game.Qualify("acme", "host"))
+
+	outsider := newTestClient(hub, "outsider")
+	outsider.handleMessage(&Message{Type: MessageTypeRequestFullState, RoomID: room.RoomID})
+
+	reply := lastReply(t, outsider)
+	if code := reply.Payload["code"]; code != string(i18n.ErrOutsideRealm) {
+		t.Fatalf("request for another realm's room got code %v, want %s", code, i18n.ErrOutsideRealm)
+	}
+}
+
+func TestRetryFromNewConnectionIsAppliedOnce(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newPrivateRoom(t, manager, "room-1", "player")
+
+	msg := placeTowerMessage(room.RoomID)
+	msg.RequestID = "place-1"
+	first := newTestClient(hub, "player")
+	first.setRoom(room.RoomID)
+	first.handleMessage(msg)
+
+	// The ack was lost, so the player reconnects as themselves and retries
+	retry := placeTowerMessage(room.RoomID)
+	retry.RequestID = "place-1"
+	reconnected := newTestClient(hub, "player")
+	reconnected.setRoom(room.RoomID)
+	reconnected.handleMessage(retry)
+
+	if reply := lastReply(t, reconnected); reply.Payload["status"] != "placed" {
+		t.Fatalf("retry got %v, want the first attempt's result", reply.Payload)
+	}
+	if towers := room.GetSnapshot().Towers; len(towers) != 1 {
+		t.Fatalf("retry placed %d towers, want 1", len(towers))
+	}
+}
+
+func TestKickedMemberKeepsTheirPartyOut(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newPrivateRoom(t, manager, "private-1", "host")
+	room.Join([]string{"member"}, nil)
+	if _, err := room.ApplyCommand(game.KickPlayer{By: "host", PlayerID: "member"}); err != nil {
+		t.Fatalf("failed to kick: %v", err)
+	}
+
+	party, err := manager.CreateParty("leader")
+	if err != nil {
+		t.Fatalf("failed to create party: %v", err)
+	}
+	if err := manager.InviteToParty("leader", "member"); err != nil {
+		t.Fatalf("failed to invite: %v", err)
+	}
+	if _, err := manager.AcceptPartyInvite("member", party.ID); err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+
+	code, _ := room.JoinCode("host")
+	leader := newTestClient(hub, "leader")
+	leader.handleMessage(&Message{
+		Type:    MessageTypeJoinRoom,
+		RoomID:  room.RoomID,
+		Payload: map[string]interface{}{"code": code},
+	})
+
+	reply := lastReply(t, leader)
+	if got := reply.Payload["code"]; got != string(i18n.ErrMemberKicked) {
+		t.Fatalf("party with a kicked member got code %v, want %s", got, i18n.ErrMemberKicked)
+	}
+	if n := room.PlayerCount(); n != 1 {
+		t.Fatalf("room has %d players, want only the host", n)
+	}
+}
+
+func TestOnlyHostClearsStrictRooms(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	config := game.DefaultRoomConfig()
+	config.StrictOwnership = true
+	room, err := manager.CreateShootingRoomWithConfig("strict-1", config)
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Join([]string{"host", "guest"}, nil)
+
+	host := newTestClient(hub, "host")
+	host.setRoom(room.RoomID)
+	host.handleMessage(placeTowerMessage(room.RoomID))
+	lastReply(t, host)
+
+	guest := newTestClient(hub, "guest")
+	guest.setRoom(room.RoomID)
+	guest.handleMessage(&Message{Type: MessageTypeClearAll, RoomID: room.RoomID})
+	if got := lastReply(t, guest).Payload["code"]; got != string(i18n.ErrNotHost) {
+		t.Fatalf("guest's clear_all got code %v, want %s", got, i18n.ErrNotHost)
+	}
+	if towers := room.GetSnapshot().Towers; len(towers) != 1 {
+		t.Fatalf("guest's clear_all left %d towers, want the host's one", len(towers))
+	}
+
+	host.handleMessage(&Message{Type: MessageTypeClearAll, RoomID: room.RoomID})
+	if towers := room.GetSnapshot().Towers; len(towers) != 0 {
+		t.Fatalf("host's clear_all left %d towers", len(towers))
+	}
+}
+
+func TestClosingRoomSparesItsReplacement(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room := newPrivateRoom(t, manager, "room-1", "old")
+	old := newTestClient(hub, "old")
+	old.setRoom(room.RoomID)
+	instance, _ := manager.RoomInstance(room.RoomID)
+	manager.DeleteRoom(room.RoomID)
+
+	// The room is opened again before the hub hears the first one closed
+	newPrivateRoom(t, manager, "room-1", "new")
+	current := newTestClient(hub, "new")
+	current.setRoom("room-1")
+	hub.clients[old] = true
+	hub.clients[current] = true
+
+	hub.closeRoom(closedRoom{roomID: "room-1", instance: instance})
+	if old.currentRoom() != "" {
+		t.Fatal("closed room's player is still in it")
+	}
+	if lastReply(t, old).Type != MessageTypeRoomClosed {
+		t.Fatal("closed room's player wasn't told")
+	}
+	if current.currentRoom() != "room-1" {
+		t.Fatal("closing the old room took a player out of the new one")
+	}
+}
+
+func TestListRoomsIsUnpagedUnlessAsked(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	for i := 0; i < 25; i++ {
+		if _, err := manager.CreateShootingRoomWithConfig(fmt.Sprintf("room-%02d", i), game.DefaultRoomConfig()); err != nil {
+			t.Fatalf("failed to create room: %v", err)
+		}
+	}
+	c := newTestClient(hub, "browser")
+
+	c.handleMessage(&Message{Type: MessageTypeListRooms, Payload: map[string]interface{}{}})
+	if rooms := lastReply(t, c).Payload["rooms"].([]interface{}); len(rooms) != 25 {
+		t.Fatalf("list_rooms without paging returned %d rooms, want all 25", len(rooms))
+	}
+
+	c.handleMessage(&Message{Type: MessageTypeListRooms, Payload: map[string]interface{}{"limit": float64(10)}})
+	if rooms := lastReply(t, c).Payload["rooms"].([]interface{}); len(rooms) != 10 {
+		t.Fatalf("list_rooms with a limit of 10 returned %d rooms", len(rooms))
+	}
+}
+
+func TestMidGameJoinCarriesOneSnapshot(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room, err := manager.CreateShootingRoomWithConfig("midgame-1", game.DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	room.Join([]string{"host", "late"}, nil)
+	if _, err := room.ApplyCommand(game.StartWave{}); err != nil {
+		t.Fatalf("failed to start wave: %v", err)
+	}
+
+	c := newTestClient(hub, "late")
+	c.sendJoined(room.RoomID)
+	payload := lastReply(t, c).Payload
+	if _, ok := payload["state"]; !ok {
+		t.Fatal("join carries no state")
+	}
+	bootstrap, ok := payload["bootstrap"].(map[string]interface{})
+	if !ok {
+		t.Fatal("mid-game join carries no bootstrap")
+	}
+	if _, ok := bootstrap["state"]; ok {
+		t.Fatal("bootstrap repeats the state")
+	}
+}
+
+func TestScheduledClearAllLandsOnItsTick(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	room, err := manager.OpenRoom("clear-all-1", game.DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to open room: %v", err)
+	}
+	defer manager.DeleteRoom(room.RoomID)
+	room.Join([]string{"host"}, nil)
+
+	client := newTestClient(hub, "host")
+	client.setRoom(room.RoomID)
+	client.handleMessage(placeTowerMessage(room.RoomID))
+	if _, err := room.ApplyCommand(game.SpawnEnemy{EnemyType: "basic"}); err != nil {
+		t.Fatalf("failed to spawn enemy: %v", err)
+	}
+	lastReply(t, client)
+
+	deadline := time.Now().Add(time.Second)
+	for room.GetSnapshot().Tick == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	at := room.GetSnapshot().Tick + 10
+	client.dispatch(nil, Message{Type: MessageTypeClearAll, RoomID: room.RoomID, AtTick: at})
+	reply := nextReply(t, client, 2*time.Second)
+	client.scheduled.Wait()
+
+	timing, ok := reply.Payload["timing"].(map[string]interface{})
+	if !ok || uint64(timing["tick"].(float64)) != at || timing["adjusted"] != false {
+		t.Fatalf("clear_all ran with timing %v, want tick %d as scheduled", reply.Payload["timing"], at)
+	}
+	if snapshot := room.GetSnapshot(); len(snapshot.Towers) != 0 || len(snapshot.Enemies) != 0 {
+		t.Fatalf("clear_all left %d towers and %d enemies", len(snapshot.Towers), len(snapshot.Enemies))
+	}
+}
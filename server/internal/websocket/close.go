@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"log"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close codes the server ends connections with. They're in the range
+// reserved for applications, so clients can tell them apart from transport
+// level closes and show the player why they were disconnected.
+const (
+	CloseKicked            = 4000
+	CloseBanned            = 4001
+	CloseServerShutdown    = 4002
+	CloseIdleTimeout       = 4003
+	CloseProtocolViolation = 4004
+	CloseTooSlow           = 4005
+	CloseReplaced          = 4006
+)
+
+// maxInvalidMessages is how many malformed messages in a row a client may
+// send before it's disconnected for a protocol violation
+const maxInvalidMessages = 10
+
+// CloseCode describes why the server closed a connection
+type CloseCode struct {
+	Code      int    `json:"code"`
+	Reason    string `json:"reason"`
+	Reconnect bool   `json:"reconnect"` // whether clients should reconnect on their own
+}
+
+var closeCodes = map[int]CloseCode{
+	CloseKicked:            {Code: CloseKicked, Reason: "kicked", Reconnect: true},
+	CloseBanned:            {Code: CloseBanned, Reason: "banned", Reconnect: false},
+	CloseServerShutdown:    {Code: CloseServerShutdown, Reason: "server_shutdown", Reconnect: true},
+	CloseIdleTimeout:       {Code: CloseIdleTimeout, Reason: "idle_timeout", Reconnect: false},
+	CloseProtocolViolation: {Code: CloseProtocolViolation, Reason: "protocol_violation", Reconnect: false},
+	CloseTooSlow:           {Code: CloseTooSlow, Reason: "too_slow", Reconnect: true},
+	CloseReplaced:          {Code: CloseReplaced, Reason: "replaced", Reconnect: false},
+}
+
+// CloseCodes lists every close code the server sends, ordered by code
+func CloseCodes() []CloseCode {
+	codes := make([]CloseCode, 0, len(closeCodes))
+	for _, c := range closeCodes {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
+
+// closeFrame builds the close message for a code, or a bare close frame
+// for code 0
+func closeFrame(code int) []byte {
+	if code == 0 {
+		return []byte{}
+	}
+	return websocket.FormatCloseMessage(code, closeCodes[code].Reason)
+}
+
+// disconnection is a request for the hub to close a client's connection
+type disconnection struct {
+	client *Client
+	code   int
+}
+
+// disconnect asks the hub to close the client's connection with a close
+// code. Anything already queued for the client is sent first.
+func (c *Client) disconnect(code int) {
+	c.hub.disconnect <- disconnection{client: c, code: code}
+}
+
+// evict asks Run to drop a client that can't keep up with what it's sent.
+// It never blocks, so it's safe to call from Run itself.
+func (h *Hub) evict(client *Client) {
+	if client.evicting.CompareAndSwap(false, true) {
+		go func() { h.disconnect <- disconnection{client: client, code: CloseTooSlow} }()
+	}
+}
+
+// drop closes a client's connection with a close code and forgets it
+func (h *Hub) drop(client *Client, code int) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	log.Printf("Disconnecting client %s: %s", client.id, closeCodes[code].Reason)
+	client.closeCode = code
+	h.remove(client)
+}
+
+// Shutdown closes every connection with the server shutdown close code, so
+// clients know to reconnect rather than report an error
+func (h *Hub) Shutdown() {
+	h.shutdown <- struct{}{}
+}
@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Demo room admission. Visitors are joined from the home page without
+// asking, so the rate caps how fast a spike in traffic piles onto one
+// room, server-wide.
+const (
+	demoJoinRate  = 10.0 // spectators admitted per second
+	demoJoinBurst = 50
+)
+
+// handleWatchDemo adds the client to the demo room's spectators
+func (c *Client) handleWatchDemo(msg *Message) {
+	c.watchDemo()
+}
+
+// watchDemo adds the client to the demo room's spectators, as visitors
+// connecting from the home page are. The demo room lives in the default
+// realm only.
+func (c *Client) watchDemo() {
+	room, err := c.hub.gameManager.DemoRoom()
+	if err != nil || c.tenant != game.DefaultTenant {
+		c.sendError(MessageTypeWatchDemo, roomNotFound(game.DemoRoomID))
+		return
+	}
+	if !c.hub.demoJoins.Allow() {
+		c.sendError(MessageTypeWatchDemo, i18n.NewError(i18n.ErrRateLimited, nil))
+		return
+	}
+
+	roomID := room.RoomID
+	if roomID != c.currentRoom() {
+		c.hub.awaitKeyframe(c, room)
+		if !c.observe(roomID, maxObservedRooms) {
+			c.stopAwaiting(roomID)
+			c.sendError(MessageTypeWatchDemo, i18n.NewError(i18n.ErrTooManyRooms, map[string]interface{}{"limit": maxObservedRooms}))
+			return
+		}
+	}
+
+	log.Printf("Client %s watching demo room %s", c.id, roomID)
+	c.sendJSON(Message{
+		Type:   MessageTypeWatchDemo,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "observing",
+			"code":   i18n.AckObserving,
+			"params": map[string]interface{}{"room_id": roomID},
+			"state":  c.hub.gameManager.ClientSnapshot(room),
+			"lobby":  room.Lobby(),
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"os"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// isAdmin checks a token against ADMIN_TOKEN. Admin messages are disabled
+// when no token is configured.
+func isAdmin(token string) bool {
+	want := os.Getenv("ADMIN_TOKEN")
+	return want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// handleWatchDiagnostics subscribes an admin to a room's tick metrics. A
+// client watches one room at a time.
+func (c *Client) handleWatchDiagnostics(msg *Message) {
+	token, _ := msg.Payload["token"].(string)
+	if !isAdmin(token) {
+		log.Printf("Client %s tried to watch diagnostics without a valid admin token", c.id)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrForbidden, nil))
+		return
+	}
+
+	_, roomID, ok := c.resolveAnyRoom(msg)
+	if !ok {
+		return
+	}
+
+	c.hub.unwatch(c)
+	c.hub.watch(c, roomID)
+
+	log.Printf("Client %s watching diagnostics for room %s", c.id, roomID)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckWatching,
+			"params": map[string]interface{}{"room_id": roomID},
+		},
+	})
+}
+
+// handleUnwatchDiagnostics stops a client's diagnostics subscription
+func (c *Client) handleUnwatchDiagnostics(msg *Message) {
+	c.hub.unwatch(c)
+}
+
+// watch subscribes a client to a room's tick metrics
+func (h *Hub) watch(client *Client, roomID string) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	if h.watchers[roomID] == nil {
+		h.watchers[roomID] = make(map[*Client]bool)
+	}
+	h.watchers[roomID][client] = true
+	client.watching = roomID
+	h.gameManager.WatchDiagnostics(roomID)
+}
+
+// unwatch drops a client's diagnostics subscription, if it has one
+func (h *Hub) unwatch(client *Client) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	roomID := client.watching
+	if roomID == "" {
+		return
+	}
+	delete(h.watchers[roomID], client)
+	if len(h.watchers[roomID]) == 0 {
+		delete(h.watchers, roomID)
+	}
+	client.watching = ""
+	h.gameManager.UnwatchDiagnostics(roomID)
+}
+
+// sendDiagnostics delivers tick metrics to a room's watchers. Watchers that
+// can't keep up miss samples rather than being dropped.
+func (h *Hub) sendDiagnostics(roomID string, data []byte) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	for client := range h.watchers[roomID] {
+		client.queue(data)
+	}
+}
+
+// diagnosticsMessage wraps tick metrics for watchers
+func diagnosticsMessage(msg game.BroadcastMessage) []byte {
+	data, err := json.Marshal(Message{
+		Type:    MessageTypeDiagnostics,
+		RoomID:  msg.RoomID,
+		Payload: map[string]interface{}{"metrics": json.RawMessage(msg.Data)},
+	})
+	if err != nil {
+		log.Printf("Failed to marshal diagnostics: %v", err)
+	}
+	return data
+}
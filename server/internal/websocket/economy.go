@@ -0,0 +1,27 @@
+package websocket
+
+// defaultEconomyEntries is how many ledger entries get_economy_log sends
+// when the client doesn't ask for a number
+const defaultEconomyEntries = 100
+
+// handleGetEconomyLog sends the room's recent gold changes and each player's
+// gold totals
+func (c *Client) handleGetEconomyLog(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	limit := defaultEconomyEntries
+	if n, ok := msg.Payload["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"economy": room.EconomyLog(limit),
+		},
+	})
+}
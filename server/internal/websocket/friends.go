@@ -0,0 +1,230 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Friend presence statuses
+const (
+	PresenceOffline = "offline"
+	PresenceOnline  = "online"
+	PresenceInRoom  = "in_room"
+)
+
+// presence describes whether a player is connected and which room they're in
+func (h *Hub) presence(playerID string) map[string]interface{} {
+	p := map[string]interface{}{
+		"player_id": playerID,
+		"status":    PresenceOffline,
+	}
+
+	client := h.clientByID(playerID)
+	if client == nil {
+		return p
+	}
+
+	p["status"] = PresenceOnline
+	if roomID := client.currentRoom(); roomID != "" {
+		p["status"] = PresenceInRoom
+		p["room_id"] = roomID
+	}
+	return p
+}
+
+// notifyPresence tells a player's online friends about their current status
+func (h *Hub) notifyPresence(playerID string) {
+	friends := h.gameManager.Friends().Friends(playerID)
+	if len(friends) == 0 {
+		return
+	}
+
+	msg := Message{
+		Type:    MessageTypePresence,
+		Payload: h.presence(playerID),
+	}
+	for _, friendID := range friends {
+		h.SendToPlayer(friendID, msg)
+	}
+}
+
+// friendTarget reads the player_id a friend message refers to
+func (c *Client) friendTarget(msg *Message) (string, bool) {
+	playerID, ok := msg.Payload["player_id"].(string)
+	if !ok || playerID == "" || playerID == c.id {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return "", false
+	}
+	return playerID, true
+}
+
+// handleFriendRequest sends a friend request, or accepts one if the other
+// player already asked
+func (c *Client) handleFriendRequest(msg *Message) {
+	playerID, ok := c.friendTarget(msg)
+	if !ok {
+		return
+	}
+
+	accepted, err := c.hub.gameManager.Friends().Request(c.id, playerID)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	if accepted {
+		c.friendAdded(playerID)
+		return
+	}
+
+	log.Printf("Client %s sent a friend request to %s", c.id, playerID)
+
+	// Kept in their inbox in case they aren't connected
+	if _, err := c.hub.gameManager.Deliver(playerID, game.InboxFriendInvite, map[string]interface{}{"player_id": c.id}); err != nil {
+		log.Printf("⚠️ Failed to save friend request notification for %s: %v", playerID, err)
+	}
+
+	c.hub.SendToPlayer(playerID, Message{
+		Type: MessageTypeFriendRequest,
+		Payload: map[string]interface{}{
+			"status": "received",
+			"from":   c.id,
+		},
+	})
+
+	c.reply(Message{
+		Type: MessageTypeFriendRequest,
+		Payload: map[string]interface{}{
+			"status": "sent",
+			"code":   i18n.AckFriendReq,
+			"params": map[string]interface{}{"player_id": playerID},
+		},
+	})
+}
+
+// handleFriendAccept accepts a pending friend request
+func (c *Client) handleFriendAccept(msg *Message) {
+	playerID, ok := c.friendTarget(msg)
+	if !ok {
+		return
+	}
+
+	if err := c.hub.gameManager.Friends().Accept(c.id, playerID); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.friendAdded(playerID)
+}
+
+// friendAdded tells both players about their new friendship and each
+// other's presence
+func (c *Client) friendAdded(playerID string) {
+	log.Printf("Clients %s and %s are now friends", c.id, playerID)
+
+	c.reply(Message{
+		Type: MessageTypeFriendAccept,
+		Payload: map[string]interface{}{
+			"status": "accepted",
+			"code":   i18n.AckFriendAdded,
+			"params": map[string]interface{}{"player_id": playerID},
+			"friend": c.hub.presence(playerID),
+		},
+	})
+
+	c.hub.SendToPlayer(playerID, Message{
+		Type: MessageTypeFriendAccept,
+		Payload: map[string]interface{}{
+			"status": "accepted",
+			"code":   i18n.AckFriendAdded,
+			"params": map[string]interface{}{"player_id": c.id},
+			"friend": c.hub.presence(c.id),
+		},
+	})
+}
+
+// handleFriendRemove ends a friendship
+func (c *Client) handleFriendRemove(msg *Message) {
+	playerID, ok := c.friendTarget(msg)
+	if !ok {
+		return
+	}
+
+	if !c.hub.gameManager.Friends().Remove(c.id, playerID) {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotFriends, map[string]interface{}{"player_id": playerID}))
+		return
+	}
+
+	c.reply(Message{
+		Type: MessageTypeFriendRemove,
+		Payload: map[string]interface{}{
+			"status": "removed",
+			"code":   i18n.AckFriendRemove,
+			"params": map[string]interface{}{"player_id": playerID},
+		},
+	})
+}
+
+// handleFriendList sends the client their friends with presence and their
+// pending requests
+func (c *Client) handleFriendList(msg *Message) {
+	friendIDs := c.hub.gameManager.Friends().Friends(c.id)
+	friends := make([]map[string]interface{}, 0, len(friendIDs))
+	for _, friendID := range friendIDs {
+		friends = append(friends, c.hub.presence(friendID))
+	}
+
+	c.reply(Message{
+		Type: MessageTypeFriendList,
+		Payload: map[string]interface{}{
+			"friends":  friends,
+			"requests": c.hub.gameManager.Friends().Requests(c.id),
+		},
+	})
+}
+
+// handleRoomInvite invites an online friend to the client's current room
+func (c *Client) handleRoomInvite(msg *Message) {
+	playerID, ok := c.friendTarget(msg)
+	if !ok {
+		return
+	}
+
+	roomID := c.currentRoom()
+	if roomID == "" {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInRoom, nil))
+		return
+	}
+
+	params := map[string]interface{}{"player_id": playerID}
+	if !c.hub.gameManager.Friends().AreFriends(c.id, playerID) {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotFriends, params))
+		return
+	}
+
+	delivered := c.hub.SendToPlayer(playerID, Message{
+		Type:   MessageTypeRoomInvite,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "received",
+			"from":   c.id,
+		},
+	})
+	if !delivered {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrPlayerOffline, params))
+		return
+	}
+
+	log.Printf("Client %s invited %s to room %s", c.id, playerID, roomID)
+
+	c.reply(Message{
+		Type: MessageTypeRoomInvite,
+		Payload: map[string]interface{}{
+			"status": "sent",
+			"code":   i18n.AckRoomInvite,
+			"params": params,
+		},
+	})
+}
@@ -0,0 +1,32 @@
+package websocket
+
+import (
+	"log"
+)
+
+// Reconnect reasons
+const ReconnectServerDraining = "server_draining"
+
+// Drain hands every room on this instance to its peers and tells the
+// players in them to reconnect. Whichever instance they land on adopts the
+// room when they rejoin it.
+func (h *Hub) Drain() []string {
+	roomIDs := h.gameManager.Drain()
+	h.handoff <- roomIDs
+	return roomIDs
+}
+
+// sendReconnects tells the players in handed off rooms to reconnect
+func (h *Hub) sendReconnects(roomIDs []string) {
+	for _, roomID := range roomIDs {
+		h.broadcastMessage(roomID, Message{
+			Type:   MessageTypeReconnect,
+			RoomID: roomID,
+			Payload: map[string]interface{}{
+				"room_id": roomID,
+				"reason":  ReconnectServerDraining,
+			},
+		})
+	}
+	log.Printf("🚚 Told players in %d rooms to reconnect", len(roomIDs))
+}
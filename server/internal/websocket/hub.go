@@ -1,38 +1,124 @@
 package websocket
 
 import (
+	"bytes"
 	"encoding/json"
 	"log"
+	"sync"
+	"time"
 
 	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/tracing"
 )
 
 // Message types
 const (
-	MessageTypeJoinRoom    = "join_room"
-	MessageTypeLeaveRoom   = "leave_room"
-	MessageTypeGameState   = "game_state"
-	MessageTypePlaceTower  = "place_tower"
-	MessageTypeRemoveTower = "remove_tower"
-	MessageTypeStartWave   = "start_wave"
-	MessageTypePauseGame   = "pause_game"
-	MessageTypeSpawnEnemy  = "spawn_enemy"
-	MessageTypeClearAll    = "clear_all"
+	MessageTypeJoinRoom         = "join_room"
+	MessageTypeRedirect         = "redirect"
+	MessageTypeReconnect        = "reconnect"
+	MessageTypeCreateRoom       = "create_room"
+	MessageTypeSaveTemplate     = "save_template"
+	MessageTypeSelectLevel      = "select_level"
+	MessageTypeListRooms        = "list_rooms"
+	MessageTypeQuickMatch       = "quick_match"
+	MessageTypeRotateJoinCode   = "rotate_join_code"
+	MessageTypeLeaveRoom        = "leave_room"
+	MessageTypeObserveRoom      = "observe_room"
+	MessageTypeUnobserveRoom    = "unobserve_room"
+	MessageTypeWatchDemo        = "watch_demo"
+	MessageTypeGameState        = "game_state"
+	MessageTypeLobbyState       = "lobby_state"
+	MessageTypeMinimap          = "minimap"
+	MessageTypeSetReady         = "set_ready"
+	MessageTypeKickPlayer       = "kick_player"
+	MessageTypeSetDropIn        = "set_drop_in"
+	MessageTypeSurrender        = "surrender"
+	MessageTypeGameOver         = "game_over"
+	MessageTypePlayerJoined     = "player_joined"
+	MessageTypePlayerLeft       = "player_left"
+	MessageTypePlayerKicked     = "player_kicked"
+	MessageTypeHostChanged      = "host_changed"
+	MessageTypeRoomClosed       = "room_closed"
+	MessageTypeLockstepFrame    = "lockstep_frame"
+	MessageTypeRequestFullState = "request_full_state"
+	MessageTypeRequestState     = "request_state"
+	MessageTypeGetEconomyLog    = "get_economy_log"
+	MessageTypePlaceTower       = "place_tower"
+	MessageTypeRemoveTower      = "remove_tower"
+	MessageTypeUpgradeTower     = "upgrade_tower"
+	MessageTypeSetTargetMode    = "set_target_mode"
+	MessageTypeStartWave        = "start_wave"
+	MessageTypeSkipToNextWave   = "skip_to_next_wave"
+	MessageTypeRewind           = "rewind"
+	MessageTypePauseGame        = "pause_game"
+	MessageTypeSpawnEnemy       = "spawn_enemy"
+	MessageTypeClearAll         = "clear_all"
+	MessageTypeMapPing          = "map_ping"
+	MessageTypeQuickChat        = "quick_chat"
+	MessageTypeReportPlayer     = "report_player"
+	MessageTypeWatchDiag        = "watch_diagnostics"
+	MessageTypeUnwatchDiag      = "unwatch_diagnostics"
+	MessageTypeDiagnostics      = "diagnostics"
+	MessageTypeWatchAnalysis    = "watch_analysis"
+	MessageTypeUnwatchAnalysis  = "unwatch_analysis"
+	MessageTypeAnalysis         = "analysis"
+	MessageTypeQueueForMatch    = "queue_for_match"
+	MessageTypeLeaveQueue       = "leave_queue"
+	MessageTypePartyCreate      = "party_create"
+	MessageTypePartyInvite      = "party_invite"
+	MessageTypePartyAccept      = "party_accept"
+	MessageTypePartyLeave       = "party_leave"
+	MessageTypeFriendRequest    = "friend_request"
+	MessageTypeFriendAccept     = "friend_accept"
+	MessageTypeFriendRemove     = "friend_remove"
+	MessageTypeFriendList       = "friend_list"
+	MessageTypePresence         = "friend_presence"
+	MessageTypeRoomInvite       = "room_invite"
+	MessageTypeAnnouncement     = "announcement"
+	MessageTypeIdleWarning      = "idle_warning"
+	MessageTypeSetAnnouncements = "set_announcements"
+	MessageTypeSetTelemetry     = "set_telemetry"
+	MessageTypeGetSettings      = "get_settings"
+	MessageTypeSetSettings      = "set_settings"
+	MessageTypeInbox            = "inbox"
+	MessageTypeMarkRead         = "mark_read"
+	MessageTypeAirstrike        = "airstrike"
+	MessageTypeProtocol         = "protocol"
+	MessageTypeError            = "error"
 )
 
 // Message represents a WebSocket message
 type Message struct {
-	Type    string                 `json:"type"`
-	RoomID  string                 `json:"room_id,omitempty"`
-	Payload map[string]interface{} `json:"payload,omitempty"`
+	Type      string                 `json:"type"`
+	RoomID    string                 `json:"room_id,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"` // makes game actions safe to retry
+	AtTick    uint64                 `json:"at_tick,omitempty"`    // game actions only: the tick to apply it after
+	Payload   map[string]interface{} `json:"payload,omitempty"`
 }
 
 // Hub maintains active clients and broadcasts messages
 type Hub struct {
-	clients     map[*Client]bool
+	clients     map[*Client]bool   // changed by Run only
+	clientsMu   sync.RWMutex       // guards clients outside Run
+	byID        map[string]*Client // clients indexed by player ID
+	mu          sync.RWMutex       // guards byID
 	broadcast   chan []byte
 	register    chan *Client
 	unregister  chan *Client
+	announce    chan Announcement
+	handoff     chan []string               // rooms handed to other instances
+	closed      chan closedRoom             // rooms the manager closed
+	disconnect  chan disconnection          // connections to close with a code
+	shutdown    chan struct{}               // closes every connection
+	watchers    map[string]map[*Client]bool // diagnostics subscribers by room
+	analysts    map[string]map[*Client]bool // analysis stream subscribers by room
+	watchMu     sync.Mutex                  // guards watchers and analysts
+	announcer   announcer
+	throttle    *connThrottle
+	recorder    *recorder
+	pollers     *snapshotPollers
+	demoJoins   *rateLimiter    // spectators joining the demo room
+	tracer      *tracing.Tracer // nil traces nothing
 	gameManager *game.Manager
 }
 
@@ -40,9 +126,22 @@ type Hub struct {
 func NewHub(gameManager *game.Manager) *Hub {
 	return &Hub{
 		clients:     make(map[*Client]bool),
+		byID:        make(map[string]*Client),
 		broadcast:   make(chan []byte),
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
+		announce:    make(chan Announcement),
+		handoff:     make(chan []string),
+		closed:      make(chan closedRoom),
+		disconnect:  make(chan disconnection),
+		shutdown:    make(chan struct{}),
+		watchers:    make(map[string]map[*Client]bool),
+		analysts:    make(map[string]map[*Client]bool),
+		announcer:   announcer{pending: make(map[int]Announcement)},
+		throttle:    newConnThrottle(),
+		pollers:     newSnapshotPollers(),
+		demoJoins:   newRateLimiter(demoJoinRate, demoJoinBurst),
+		recorder:    newRecorder(),
 		gameManager: gameManager,
 	}
 }
@@ -51,56 +150,143 @@ func NewHub(gameManager *game.Manager) *Hub {
 func (h *Hub) Run() {
 	// Start listening to game manager broadcasts
 	go h.listenToGameBroadcasts()
+	go h.listenToAnalysis()
+	go h.listenToNotifications()
+	h.gameManager.Subscribe(game.RoomHooks{OnRoomClosed: h.onRoomClosed})
+
+	idle := time.NewTicker(idleSweepInterval)
+	defer idle.Stop()
 
 	for {
 		select {
 		case client := <-h.register:
+			// A player reconnecting with their identity takes over from
+			// any connection they left behind
+			if old := h.clientByID(client.id); old != nil {
+				h.drop(old, CloseReplaced)
+			}
+			h.clientsMu.Lock()
 			h.clients[client] = true
+			h.clientsMu.Unlock()
+			h.index(client)
 			log.Printf("Client registered: %s. Total clients: %d", client.id, len(h.clients))
+			h.notifyPresence(client.id)
+			h.sendUnread(client)
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				// Remove from room if in one
-				if client.roomID != "" {
-					h.gameManager.RemovePlayer(client.roomID, client.id)
-				}
+				h.remove(client)
+			}
+
+		case d := <-h.disconnect:
+			h.drop(d.client, d.code)
 
-				delete(h.clients, client)
-				close(client.send)
-				log.Printf("Client unregistered: %s. Total clients: %d", client.id, len(h.clients))
+		case <-idle.C:
+			h.sweepIdle()
+
+		case <-h.shutdown:
+			for client := range h.clients {
+				h.drop(client, CloseServerShutdown)
 			}
 
+		case a := <-h.announce:
+			h.deliverAnnouncement(a)
+
+		case roomIDs := <-h.handoff:
+			h.sendReconnects(roomIDs)
+
+		case room := <-h.closed:
+			h.closeRoom(room)
+
 		case message := <-h.broadcast:
 			// Broadcast to all clients
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+				if !client.queue(message) {
+					h.drop(client, CloseTooSlow)
 				}
 			}
 		}
 	}
 }
 
+// remove takes a client out of its room and every other subsystem and
+// closes its connection
+func (h *Hub) remove(client *Client) {
+	h.leaveRoom(client, LeaveReasonDisconnected)
+	h.gameManager.LeaveMatchQueue(client.id)
+	h.gameManager.LeaveParty(client.id)
+	h.gameManager.Names().Forget(client.id)
+
+	h.unwatch(client)
+	h.unwatchAnalysis(client)
+	h.clientsMu.Lock()
+	delete(h.clients, client)
+	h.clientsMu.Unlock()
+	h.unindex(client)
+	client.closeSend()
+	h.throttle.release(client.ip)
+	log.Printf("Client unregistered: %s. Total clients: %d", client.id, len(h.clients))
+	h.notifyPresence(client.id)
+}
+
+// index makes a client reachable by player ID
+func (h *Hub) index(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.byID[client.id] = client
+}
+
+// unindex removes a client from the player ID index
+func (h *Hub) unindex(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.byID[client.id] == client {
+		delete(h.byID, client.id)
+	}
+}
+
 // listenToGameBroadcasts listens for game state updates from the manager
 func (h *Hub) listenToGameBroadcasts() {
 	broadcastChan := h.gameManager.GetBroadcastChannel()
 
 	for msg := range broadcastChan {
-		// Wrap in game_state message
+		if msg.Kind == game.BroadcastDiagnostics {
+			if data := diagnosticsMessage(msg); data != nil {
+				h.sendDiagnostics(msg.RoomID, data)
+			}
+			continue
+		}
+		if msg.Kind == game.BroadcastKeyframe {
+			h.deliverKeyframe(msg)
+			continue
+		}
+
+		// Wrap in game_state message, or lockstep_frame for lockstep rooms
 		wrappedMsg := Message{
 			Type:   MessageTypeGameState,
 			RoomID: msg.RoomID,
 		}
+		key := "state"
+		switch msg.Kind {
+		case game.BroadcastLockstep:
+			wrappedMsg.Type = MessageTypeLockstepFrame
+			key = "frame"
+		case game.BroadcastLobby:
+			wrappedMsg.Type = MessageTypeLobbyState
+			key = "lobby"
+		case game.BroadcastMinimap:
+			wrappedMsg.Type = MessageTypeMinimap
+			key = "minimap"
+		case game.BroadcastGameOver:
+			wrappedMsg.Type = MessageTypeGameOver
+			key = "result"
+		}
 
-		// Parse the game state to include in payload
-		var gameState interface{}
-		if err := json.Unmarshal(msg.Data, &gameState); err == nil {
-			wrappedMsg.Payload = map[string]interface{}{
-				"state": gameState,
-			}
+		// The data is already JSON, so it's embedded as is
+		wrappedMsg.Payload = map[string]interface{}{
+			key: json.RawMessage(msg.Data),
 		}
 
 		data, err := json.Marshal(wrappedMsg)
@@ -109,61 +295,130 @@ func (h *Hub) listenToGameBroadcasts() {
 			continue
 		}
 
-		h.BroadcastToRoom(msg.RoomID, data)
+		h.sendToRoom(msg.RoomID, data, msg.Kind == game.BroadcastLockstep)
 	}
 }
 
-// BroadcastToRoom sends a message to all clients in a specific room
-func (h *Hub) BroadcastToRoom(roomID string, message []byte) {
-	for client := range h.clients {
-		if client.roomID == roomID {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients, client)
+// listenToNotifications delivers per-player notifications from the manager
+// to the matching client
+func (h *Hub) listenToNotifications() {
+	for n := range h.gameManager.GetNotificationChannel() {
+		// The manager has already added matched players to the room
+		if n.Type == game.NotifyMatchFound {
+			if roomID, ok := n.Payload["room_id"].(string); ok {
+				h.moveToRoom(n.PlayerID, roomID)
 			}
 		}
+
+		h.SendToPlayer(n.PlayerID, Message{
+			Type:    n.Type,
+			Payload: n.Payload,
+		})
 	}
 }
 
-// BroadcastGameState broadcasts the current game state to all clients in a room
-func (h *Hub) BroadcastGameState(roomID string) {
-	// Try shooting room first
-	shootingRoom, exists := h.gameManager.GetShootingRoom(roomID)
-	if exists {
-		snapshot := shootingRoom.GetSnapshot()
+// clientByID finds a connected client by player ID
+func (h *Hub) clientByID(playerID string) *Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
-		msg := Message{
-			Type:   MessageTypeGameState,
-			RoomID: roomID,
-			Payload: map[string]interface{}{
-				"state": snapshot,
-			},
+	return h.byID[playerID]
+}
+
+// SendToPlayer sends a message to the client with the given player ID. The
+// index stays locked while it's sent, since the hub unindexes clients
+// before closing their channels.
+func (h *Hub) SendToPlayer(playerID string, msg Message) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	client := h.byID[playerID]
+	if client == nil {
+		return false
+	}
+
+	client.sendJSON(msg)
+	return true
+}
+
+// moveToRoom points a player's client at a room they've already been added
+// to, leaving their previous room, and sends them the join confirmation
+func (h *Hub) moveToRoom(playerID, roomID string) {
+	client := h.clientByID(playerID)
+	if client == nil {
+		return
+	}
+
+	if client.currentRoom() != roomID {
+		h.leaveRoom(client, LeaveReasonLeft)
+	}
+	client.unobserve(roomID) // playing now, not just watching
+	if room, exists := h.gameManager.GetShootingRoom(roomID); exists {
+		h.awaitKeyframe(client, room)
+		if latency := client.latency.Load(); latency > 0 {
+			room.SetLatency(playerID, time.Duration(latency))
 		}
+	}
+	client.setRoom(roomID)
+	client.sendJoined(roomID)
+	h.announceJoin(roomID, playerID)
+	h.notifyPresence(playerID)
 
-		data, err := json.Marshal(msg)
-		if err != nil {
-			log.Printf("Failed to marshal game state: %v", err)
-			return
+	log.Printf("Client %s joined shooting room %s", client.id, roomID)
+}
+
+// BroadcastToRoom sends a message to all clients in a specific room,
+// including those observing it
+func (h *Hub) BroadcastToRoom(roomID string, message []byte) {
+	h.sendToRoom(roomID, message, false)
+}
+
+// sendToRoom sends a message to a room's clients. Lockstep frames skip
+// clients still waiting on their keyframe. It's called from many
+// goroutines, so clients too slow to keep up are left for Run to drop.
+func (h *Hub) sendToRoom(roomID string, message []byte, frame bool) {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for client := range h.clients {
+		if client.inRoom(roomID) && !(frame && client.awaitingKeyframe(roomID)) {
+			if !client.queue(message) {
+				h.evict(client)
+			}
 		}
+	}
+}
 
-		h.BroadcastToRoom(roomID, data)
+// broadcastMessage marshals a message and sends it to everyone in a room
+func (h *Hub) broadcastMessage(roomID string, msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to marshal %s message: %v", msg.Type, err)
 		return
 	}
 
-	// Fall back to legacy room
+	h.BroadcastToRoom(roomID, data)
+}
+
+// BroadcastGameState broadcasts the current game state to all clients in a
+// room. Legacy rooms send their players and engine data as the state.
+func (h *Hub) BroadcastGameState(roomID string) {
 	room, exists := h.gameManager.GetRoom(roomID)
 	if !exists {
 		return
 	}
 
+	var state bytes.Buffer
+	if err := room.WriteSnapshot(&state, h.gameManager.Precision()); err != nil {
+		log.Printf("Failed to marshal game state: %v", err)
+		return
+	}
+
 	msg := Message{
 		Type:   MessageTypeGameState,
 		RoomID: roomID,
 		Payload: map[string]interface{}{
-			"players":   room.Players,
-			"game_data": room.GameData,
+			"state": json.RawMessage(state.Bytes()),
 		},
 	}
 
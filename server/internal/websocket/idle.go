@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// Idle timeout tuning. Pings keep a dead connection from lingering; this
+// frees the slots of live connections that aren't doing anything.
+const (
+	defaultIdleMinutes = 10
+	idleWarning        = time.Minute // how long before the cutoff clients are warned
+	idleSweepInterval  = 15 * time.Second
+)
+
+// touch records that the client sent an application message
+func (c *Client) touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleTimeout returns how long clients outside rooms may send nothing
+func (t *connThrottle) idleTimeout() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Duration(t.config.IdleMinutes) * time.Minute
+}
+
+// sweepIdle warns clients nearing the idle cutoff and disconnects those
+// past it. Clients in or observing a room, queued for a match or in a party
+// are never idle, however quiet they are.
+func (h *Hub) sweepIdle() {
+	timeout := h.throttle.idleTimeout()
+	now := time.Now()
+
+	for client := range h.clients {
+		if client.currentRoom() != "" || client.observingAny() || h.gameManager.Waiting(client.id) {
+			continue
+		}
+
+		lastActive := client.lastActive.Load()
+		idle := now.Sub(time.Unix(0, lastActive))
+		switch {
+		case idle >= timeout:
+			h.drop(client, CloseIdleTimeout)
+
+		case idle >= timeout-idleWarning && client.idleWarned != lastActive:
+			// Warned once per stretch of inactivity
+			client.idleWarned = lastActive
+			seconds := int((timeout - idle).Seconds())
+			client.sendJSON(Message{
+				Type: MessageTypeIdleWarning,
+				Payload: map[string]interface{}{
+					"code":   i18n.NoticeIdle,
+					"params": map[string]interface{}{"seconds": seconds},
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"rust-rush/server/internal/game"
+)
+
+func TestQueuedAndPartiedClientsArentIdle(t *testing.T) {
+	manager := game.NewManager()
+	hub := NewHub(manager)
+	if _, err := manager.QueueForMatch("queued"); err != nil {
+		t.Fatalf("failed to queue: %v", err)
+	}
+	if _, err := manager.CreateParty("partied"); err != nil {
+		t.Fatalf("failed to create party: %v", err)
+	}
+
+	clients := make(map[string]*Client)
+	for _, id := range []string{"queued", "partied", "idle"} {
+		c := newTestClient(hub, id)
+		c.lastActive.Store(time.Now().Add(-time.Hour).UnixNano())
+		hub.clients[c] = true
+		clients[id] = c
+	}
+
+	hub.sweepIdle()
+
+	for _, id := range []string{"queued", "partied"} {
+		if !hub.clients[clients[id]] {
+			t.Errorf("%s client was disconnected as idle", id)
+		}
+	}
+	if hub.clients[clients["idle"]] {
+		t.Error("idle client was kept")
+	}
+}
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// sendUnread gives a newly connected client the notifications that arrived
+// while they were away. It runs on the hub goroutine.
+func (h *Hub) sendUnread(client *Client) {
+	unread := h.gameManager.Inbox().Unread(client.id)
+	if len(unread) == 0 {
+		return
+	}
+
+	client.sendJSON(Message{
+		Type: MessageTypeInbox,
+		Payload: map[string]interface{}{
+			"notifications": unread,
+			"unread":        len(unread),
+		},
+	})
+}
+
+// NotifyConnected delivers a notification to every connected player, such
+// as a daily challenge reset, and returns how many received it. Bots are
+// skipped.
+func (h *Hub) NotifyConnected(kind string, params map[string]interface{}) (int, error) {
+	if !game.ValidInboxKind(kind) {
+		return 0, i18n.NewError(i18n.ErrUnknownInboxKind, map[string]interface{}{"kind": kind})
+	}
+
+	h.mu.RLock()
+	players := make([]string, 0, len(h.byID))
+	for playerID, client := range h.byID {
+		if client.bot == nil {
+			players = append(players, playerID)
+		}
+	}
+	h.mu.RUnlock()
+
+	if _, err := h.gameManager.DeliverAll(players, kind, params); err != nil {
+		log.Printf("⚠️ Failed to save %s notifications: %v", kind, err)
+	}
+	log.Printf("🔔 Sent %s notification to %d players", kind, len(players))
+	return len(players), nil
+}
+
+// handleInbox sends the client their notifications, newest first
+func (c *Client) handleInbox(msg *Message) {
+	inbox := c.hub.gameManager.Inbox()
+
+	c.reply(Message{
+		Type: MessageTypeInbox,
+		Payload: map[string]interface{}{
+			"notifications": inbox.List(c.id),
+			"unread":        inbox.UnreadCount(c.id),
+		},
+	})
+}
+
+// handleMarkRead marks the listed notifications as read, or every one if
+// none are listed
+func (c *Client) handleMarkRead(msg *Message) {
+	var notificationIDs []string
+	if raw, ok := msg.Payload["ids"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			c.sendError(msg.Type, invalidPayload(msg.Type))
+			return
+		}
+		for _, v := range list {
+			id, ok := v.(string)
+			if !ok {
+				c.sendError(msg.Type, invalidPayload(msg.Type))
+				return
+			}
+			notificationIDs = append(notificationIDs, id)
+		}
+	}
+
+	inbox := c.hub.gameManager.Inbox()
+	marked, err := inbox.MarkRead(c.id, notificationIDs)
+	if err != nil {
+		log.Printf("⚠️ Failed to save notifications for %s: %v", c.id, err)
+	}
+
+	c.reply(Message{
+		Type: MessageTypeMarkRead,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"marked": marked,
+			"unread": inbox.UnreadCount(c.id),
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+
+	"rust-rush/server/internal/game"
+)
+
+// ResyncJoin is the reason on keyframes sent to newcomers unasked
+const ResyncJoin = "join"
+
+// awaitKeyframe holds a lockstep room's frames back from a client about to
+// receive them until its personal keyframe arrives, and asks the room for
+// one. Without it a newcomer would apply inputs to a state it never had.
+// Rooms broadcasting full state need no keyframe.
+func (h *Hub) awaitKeyframe(client *Client, room *game.GameStateWithShooting) {
+	if !room.IsLockstep() {
+		return
+	}
+
+	client.roomsMu.Lock()
+	if client.awaiting == nil {
+		client.awaiting = make(map[string]bool)
+	}
+	client.awaiting[room.RoomID] = true
+	client.roomsMu.Unlock()
+
+	room.RequestKeyframe(client.id)
+}
+
+// awaitingKeyframe reports whether the client's frames for a room are held
+// back until its keyframe
+func (c *Client) awaitingKeyframe(roomID string) bool {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+	return c.awaiting[roomID]
+}
+
+// stopAwaiting drops a room the client no longer waits on a keyframe for
+func (c *Client) stopAwaiting(roomID string) {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	delete(c.awaiting, roomID)
+}
+
+// deliverKeyframe sends a keyframe to the clients waiting on it. Frames
+// after it reach them from then on, since they come through the same
+// channel in order. A client whose buffer is too full for the keyframe is
+// evicted, as it could never apply the frames after it.
+func (h *Hub) deliverKeyframe(msg game.BroadcastMessage) {
+	data, err := json.Marshal(Message{
+		Type:   MessageTypeGameState,
+		RoomID: msg.RoomID,
+		Payload: map[string]interface{}{
+			"state":    json.RawMessage(msg.Data),
+			"keyframe": true,
+			"reason":   ResyncJoin,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to marshal keyframe: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, playerID := range msg.To {
+		client := h.byID[playerID]
+		if client == nil || !client.awaitingKeyframe(msg.RoomID) {
+			continue
+		}
+
+		if !client.queue(data) {
+			h.evict(client)
+			continue
+		}
+		client.stopAwaiting(msg.RoomID)
+	}
+}
@@ -0,0 +1,30 @@
+package websocket
+
+import (
+	"testing"
+
+	"rust-rush/server/internal/game"
+)
+
+func TestKeyframeToFullBufferEvicts(t *testing.T) {
+	hub := NewHub(game.NewManager())
+	client := newTestClient(hub, "spectator")
+	hub.index(client)
+	client.awaiting = map[string]bool{"room-1": true}
+	for len(client.send) < cap(client.send) {
+		client.send <- []byte("{}")
+	}
+
+	hub.deliverKeyframe(game.BroadcastMessage{
+		RoomID: "room-1",
+		To:     []string{client.id},
+		Data:   []byte("{}"),
+	})
+
+	if !client.awaitingKeyframe("room-1") {
+		t.Fatal("frames were let through to a client that missed its keyframe")
+	}
+	if !client.evicting.Load() {
+		t.Fatal("client that missed its keyframe wasn't evicted")
+	}
+}
@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/game"
+)
+
+// maxOutstandingPings is how many unanswered pings are remembered
+const maxOutstandingPings = 8
+
+// pingTracker remembers when recent pings went out. Pings carry only a
+// sequence number, so the round trip is timed by the server's clock and a
+// client can't make itself look slower or faster than it is.
+type pingTracker struct {
+	mu   sync.Mutex
+	last uint64
+	sent map[uint64]time.Time
+}
+
+// payload numbers the next ping and notes when it was sent
+func (p *pingTracker) payload() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sent == nil {
+		p.sent = make(map[uint64]time.Time)
+	}
+	p.last++
+	p.sent[p.last] = time.Now()
+	delete(p.sent, p.last-maxOutstandingPings)
+	return []byte(strconv.FormatUint(p.last, 10))
+}
+
+// answer returns the round trip of the ping a pong answers. Each ping
+// counts once; pongs for pings never sent or long forgotten are ignored.
+func (p *pingTracker) answer(appData string) (time.Duration, bool) {
+	seq, err := strconv.ParseUint(appData, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sent, ok := p.sent[seq]
+	if !ok {
+		return 0, false
+	}
+	delete(p.sent, seq)
+	return time.Since(sent), true
+}
+
+// measureLatency records the latency a pong shows and passes it on to the
+// client's room. Unsolicited pongs and ones for forgotten pings are
+// ignored.
+func (c *Client) measureLatency(appData string) {
+	rtt, ok := c.pings.answer(appData)
+	if !ok {
+		return
+	}
+	latency := rtt / 2
+	c.latency.Store(int64(latency))
+
+	roomID := c.currentRoom()
+	if roomID == "" {
+		return
+	}
+	if room, ok := c.hub.gameManager.GetShootingRoom(roomID); ok {
+		room.SetLatency(c.id, latency)
+	}
+}
+
+// clientLatency is the round trip the client can expect to this instance
+// and to the regions it probed, for recommending rooms
+func (c *Client) clientLatency() game.ClientLatency {
+	return game.ClientLatency{
+		Measured: 2 * time.Duration(c.latency.Load()),
+		Regions:  c.regionRTTs,
+	}
+}
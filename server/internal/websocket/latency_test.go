@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPongsCantForgeTheRoundTrip(t *testing.T) {
+	var pings pingTracker
+	seq := string(pings.payload())
+
+	// An old-style stamp from a minute ago, or a ping never sent
+	forged := strconv.FormatInt(time.Now().Add(-time.Minute).UnixNano(), 10)
+	if _, ok := pings.answer(forged); ok {
+		t.Fatal("pong with a made-up stamp was measured")
+	}
+
+	rtt, ok := pings.answer(seq)
+	if !ok || rtt > time.Second {
+		t.Fatalf("pong for a ping just sent measured %v (ok %v)", rtt, ok)
+	}
+	if _, ok := pings.answer(seq); ok {
+		t.Fatal("the same ping was measured twice")
+	}
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+)
+
+// closedRoom is a room the manager closed, and which instance of its ID
+type closedRoom struct {
+	roomID   string
+	instance uint64
+}
+
+// onRoomClosed is the manager's room closed hook. It runs on whichever
+// goroutine closed the room, possibly the hub's own, so the cleanup is
+// handed to the hub's loop rather than waited on. Handed off rooms are left
+// to sendReconnects.
+func (h *Hub) onRoomClosed(roomID string, instance uint64, reason string) {
+	if reason == game.RoomClosedHandedOff {
+		return
+	}
+	go func() { h.closed <- closedRoom{roomID: roomID, instance: instance} }()
+}
+
+// closeRoom tells everyone still in a closed room that it's gone and drops
+// its diagnostics and analysis subscriptions. By the time it runs a room may
+// have been opened under the same ID; its players and subscribers are left
+// alone, and only the closed room's players are told.
+func (h *Hub) closeRoom(closed closedRoom) {
+	roomID := closed.roomID
+	_, reopened := h.gameManager.RoomInstance(roomID)
+
+	gone := Message{
+		Type:    MessageTypeRoomClosed,
+		RoomID:  roomID,
+		Payload: map[string]interface{}{"room_id": roomID},
+	}
+	if !reopened {
+		h.broadcastMessage(roomID, gone)
+	}
+	for client := range h.clients {
+		left := client.leaveClosed(roomID, closed.instance) || (!reopened && client.clearRoom(roomID))
+		if !reopened {
+			client.unobserve(roomID)
+		}
+		if left || !reopened {
+			client.stopAwaiting(roomID)
+		}
+		if !left {
+			continue
+		}
+		if reopened {
+			client.sendJSON(gone)
+		}
+		h.notifyPresence(client.id)
+	}
+	if reopened {
+		log.Printf("Room %s closed; a new room has its ID", roomID)
+		return
+	}
+
+	h.watchMu.Lock()
+	var watchers, analysts []*Client
+	for client := range h.watchers[roomID] {
+		watchers = append(watchers, client)
+	}
+	for client := range h.analysts[roomID] {
+		analysts = append(analysts, client)
+	}
+	h.watchMu.Unlock()
+
+	for _, client := range watchers {
+		h.unwatch(client)
+	}
+	for _, client := range analysts {
+		h.unwatchAnalysis(client)
+	}
+
+	log.Printf("Room %s closed", roomID)
+}
@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Payload limits. maxMessageSize bounds what the connection will read at
+// all; these are the much tighter limits each message type gets.
+const (
+	defaultMessageLimit = 4 * 1024
+	maxPayloadDepth     = 6
+	// The longest legitimate array is a path visiting every cell
+	maxArrayLength = game.GridWidth * game.GridHeight
+)
+
+// messageLimits overrides defaultMessageLimit for message types that
+// legitimately carry more data
+var messageLimits = map[string]int{
+	MessageTypeSpawnEnemy:   16 * 1024,
+	MessageTypeCreateRoom:   8 * 1024,
+	MessageTypeJoinRoom:     8 * 1024,
+	MessageTypeSaveTemplate: 8 * 1024,
+}
+
+var errTooDeep = errors.New("payload nested too deeply")
+
+// decodeMessage strictly decodes a raw WebSocket frame. Oversized, deeply
+// nested or malformed messages are rejected with a coded error.
+func decodeMessage(data []byte) (Message, error) {
+	var msg Message
+
+	if err := checkDepth(data, maxPayloadDepth); err != nil {
+		return msg, i18n.NewError(i18n.ErrPayloadTooDeep, map[string]interface{}{"limit": maxPayloadDepth})
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&msg); err != nil {
+		return msg, i18n.NewError(i18n.ErrInvalidMessage, nil)
+	}
+	if dec.More() {
+		return msg, i18n.NewError(i18n.ErrInvalidMessage, nil)
+	}
+
+	limit, ok := messageLimits[msg.Type]
+	if !ok {
+		limit = defaultMessageLimit
+	}
+	if len(data) > limit {
+		return msg, i18n.NewError(i18n.ErrPayloadTooLarge, map[string]interface{}{
+			"type":  msg.Type,
+			"limit": limit,
+		})
+	}
+
+	if n := longestArray(msg.Payload); n > maxArrayLength {
+		return msg, i18n.NewError(i18n.ErrPayloadTooLarge, map[string]interface{}{
+			"type":  msg.Type,
+			"limit": maxArrayLength,
+		})
+	}
+
+	return msg, nil
+}
+
+// decodePayload decodes a message's payload into a typed struct, rejecting
+// fields the struct doesn't know about
+func decodePayload(msg *Message, v interface{}) error {
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return invalidPayload(msg.Type)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return invalidPayload(msg.Type)
+	}
+	return nil
+}
+
+// towerPlacement is a place_tower payload
+type towerPlacement struct {
+	X         *float64 `json:"x"`
+	Y         *float64 `json:"y"`
+	TowerType string   `json:"tower_type"`
+	Cosmetic  string   `json:"cosmetic"`
+}
+
+// decodePlacement decodes a place_tower payload, which needs a position and
+// a tower type
+func decodePlacement(msg *Message) (towerPlacement, error) {
+	var placement towerPlacement
+	if err := decodePayload(msg, &placement); err != nil || placement.X == nil || placement.Y == nil || placement.TowerType == "" {
+		return placement, invalidPayload(msg.Type)
+	}
+	return placement, nil
+}
+
+// enemySpawn is a spawn_enemy payload. Both fields are optional.
+type enemySpawn struct {
+	EnemyType string          `json:"enemy_type"`
+	Path      []game.Position `json:"path"`
+}
+
+// checkDepth scans raw JSON and fails if objects or arrays nest deeper than
+// limit. It runs before decoding so deeply nested input is never built up in
+// memory. The envelope itself counts as one level.
+func checkDepth(data []byte, limit int) error {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > limit+1 {
+				return errTooDeep
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// longestArray returns the length of the longest array anywhere in a
+// decoded payload
+func longestArray(v interface{}) int {
+	longest := 0
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if n := longestArray(child); n > longest {
+				longest = n
+			}
+		}
+	case []interface{}:
+		longest = len(v)
+		for _, child := range v {
+			if n := longestArray(child); n > longest {
+				longest = n
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// handleQueueForMatch puts the client, or the party they lead, in the versus
+// matchmaking queue. The match_found notification arrives once an opponent
+// is paired.
+func (c *Client) handleQueueForMatch(msg *Message) {
+	rating, err := c.hub.gameManager.QueueForMatch(c.id)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s queued for a match (rating %.1f)", c.id, rating)
+
+	c.reply(Message{
+		Type: MessageTypeQueueForMatch,
+		Payload: map[string]interface{}{
+			"status": "queued",
+			"code":   i18n.AckQueued,
+			"rating": rating,
+		},
+	})
+}
+
+// handleLeaveQueue takes the client out of the matchmaking queue
+func (c *Client) handleLeaveQueue(msg *Message) {
+	if !c.hub.gameManager.LeaveMatchQueue(c.id) {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotQueued, nil))
+		return
+	}
+
+	log.Printf("Client %s left the match queue", c.id)
+
+	c.reply(Message{
+		Type: MessageTypeLeaveQueue,
+		Payload: map[string]interface{}{
+			"status": "left",
+			"code":   i18n.AckLeftQueue,
+		},
+	})
+}
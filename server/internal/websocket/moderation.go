@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// handleReportPlayer files a report against another player in the room
+func (c *Client) handleReportPlayer(msg *Message) {
+	_, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	target, _ := msg.Payload["player_id"].(string)
+	reason, _ := msg.Payload["reason"].(string)
+	details, _ := msg.Payload["details"].(string)
+
+	report, err := c.hub.gameManager.ReportPlayer(roomID, c.id, target, reason, details)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s reported %s in room %s (%s)", c.id, target, roomID, reason)
+
+	c.reply(Message{
+		Type:   MessageTypeReportPlayer,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status":    "reported",
+			"code":      i18n.AckReported,
+			"params":    map[string]interface{}{"player_id": target},
+			"report_id": report.ID,
+		},
+	})
+}
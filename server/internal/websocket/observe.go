@@ -0,0 +1,174 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// How many rooms a client may observe on top of the one it plays in.
+// Admins watching over the server get more.
+const (
+	maxObservedRooms      = 4
+	maxAdminObservedRooms = 64
+)
+
+// inRoom reports whether the client gets a room's broadcasts, as a player
+// or an observer
+func (c *Client) inRoom(roomID string) bool {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+	return c.roomID == roomID || c.observing[roomID]
+}
+
+// currentRoom returns the room the client plays in, empty for none
+func (c *Client) currentRoom() string {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+	return c.roomID
+}
+
+// setRoom points the client at the room it plays in
+func (c *Client) setRoom(roomID string) {
+	instance, _ := c.hub.gameManager.RoomInstance(roomID)
+
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	c.roomID = roomID
+	c.roomInstance = instance
+}
+
+// clearRoom takes the client out of a room, reporting false if it wasn't
+// playing in it
+func (c *Client) clearRoom(roomID string) bool {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	if c.roomID != roomID {
+		return false
+	}
+	c.roomID = ""
+	return true
+}
+
+// leaveClosed takes the client out of a room that closed, reporting false
+// if it's playing in another room opened under the same ID since
+func (c *Client) leaveClosed(roomID string, instance uint64) bool {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	if c.roomID != roomID || c.roomInstance != instance {
+		return false
+	}
+	c.roomID = ""
+	return true
+}
+
+// observe adds a room to the client's observed set, reporting false if
+// that would go over its limit
+func (c *Client) observe(roomID string, limit int) bool {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+
+	if c.observing[roomID] {
+		return true
+	}
+	if len(c.observing) >= limit {
+		return false
+	}
+	if c.observing == nil {
+		c.observing = make(map[string]bool)
+	}
+	c.observing[roomID] = true
+	return true
+}
+
+// unobserve drops a room from the client's observed set, reporting whether
+// it was observed
+func (c *Client) unobserve(roomID string) bool {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+
+	if !c.observing[roomID] {
+		return false
+	}
+	delete(c.observing, roomID)
+	return true
+}
+
+// observingAny reports whether the client observes any room
+func (c *Client) observingAny() bool {
+	c.roomsMu.RLock()
+	defer c.roomsMu.RUnlock()
+	return len(c.observing) > 0
+}
+
+// handleObserveRoom adds a room to the ones the client receives, without
+// joining it as a player. Observed rooms need the same code or password as
+// joining; admins can observe any room.
+func (c *Client) handleObserveRoom(msg *Message) {
+	roomID := msg.RoomID
+	room, exists := c.hub.gameManager.GetShootingRoom(roomID)
+	if !exists {
+		c.sendError(msg.Type, roomNotFound(roomID))
+		return
+	}
+
+	token, _ := msg.Payload["token"].(string)
+	limit := maxObservedRooms
+	if isAdmin(token) {
+		limit = maxAdminObservedRooms
+	} else {
+		code, _ := msg.Payload["code"].(string)
+		password, _ := msg.Payload["password"].(string)
+		if err := room.CheckAccess(c.id, code, password); err != nil {
+			c.sendError(msg.Type, err)
+			return
+		}
+	}
+
+	if roomID != c.currentRoom() {
+		c.hub.awaitKeyframe(c, room)
+		if !c.observe(roomID, limit) {
+			c.stopAwaiting(roomID)
+			c.sendError(msg.Type, i18n.NewError(i18n.ErrTooManyRooms, map[string]interface{}{"limit": limit}))
+			return
+		}
+	}
+
+	log.Printf("Client %s observing room %s", c.id, roomID)
+
+	payload := map[string]interface{}{
+		"status": "observing",
+		"code":   i18n.AckObserving,
+		"params": map[string]interface{}{"room_id": roomID},
+		"state":  c.hub.gameManager.ClientSnapshot(room),
+		"lobby":  room.Lobby(),
+	}
+	if events := c.hub.gameManager.Events().Active(time.Now()); len(events) > 0 {
+		payload["server_events"] = events
+	}
+	c.reply(Message{
+		Type:    msg.Type,
+		RoomID:  roomID,
+		Payload: payload,
+	})
+}
+
+// handleUnobserveRoom stops sending the client an observed room
+func (c *Client) handleUnobserveRoom(msg *Message) {
+	if !c.unobserve(msg.RoomID) {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotObserving, map[string]interface{}{"room_id": msg.RoomID}))
+		return
+	}
+
+	log.Printf("Client %s stopped observing room %s", c.id, msg.RoomID)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: msg.RoomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckUnobserved,
+			"params": map[string]interface{}{"room_id": msg.RoomID},
+		},
+	})
+}
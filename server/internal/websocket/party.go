@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// handlePartyCreate starts a party led by the client
+func (c *Client) handlePartyCreate(msg *Message) {
+	party, err := c.hub.gameManager.CreateParty(c.id)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s created %s", c.id, party.ID)
+
+	c.reply(Message{
+		Type: MessageTypePartyCreate,
+		Payload: map[string]interface{}{
+			"status": "created",
+			"code":   i18n.AckPartyCreated,
+			"party":  party,
+		},
+	})
+}
+
+// handlePartyInvite invites another player to the client's party
+func (c *Client) handlePartyInvite(msg *Message) {
+	playerID, ok := msg.Payload["player_id"].(string)
+	if !ok || playerID == "" || playerID == c.id {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	if err := c.hub.gameManager.InviteToParty(c.id, playerID); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s invited %s to their party", c.id, playerID)
+
+	c.reply(Message{
+		Type: MessageTypePartyInvite,
+		Payload: map[string]interface{}{
+			"status": "sent",
+			"code":   i18n.AckPartyInvited,
+			"params": map[string]interface{}{"player_id": playerID},
+		},
+	})
+}
+
+// handlePartyAccept joins a party the client was invited to
+func (c *Client) handlePartyAccept(msg *Message) {
+	partyID, ok := msg.Payload["party_id"].(string)
+	if !ok || partyID == "" {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	party, err := c.hub.gameManager.AcceptPartyInvite(c.id, partyID)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s joined %s", c.id, party.ID)
+
+	c.reply(Message{
+		Type: MessageTypePartyAccept,
+		Payload: map[string]interface{}{
+			"status": "joined",
+			"code":   i18n.AckPartyJoined,
+			"party":  party,
+		},
+	})
+}
+
+// handlePartyLeave leaves the client's party
+func (c *Client) handlePartyLeave(msg *Message) {
+	if !c.hub.gameManager.LeaveParty(c.id) {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInParty, nil))
+		return
+	}
+
+	log.Printf("Client %s left their party", c.id)
+
+	c.reply(Message{
+		Type: MessageTypePartyLeave,
+		Payload: map[string]interface{}{
+			"status": "left",
+			"code":   i18n.AckPartyLeft,
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Map ping kinds
+const (
+	PingKindPointer = "pointer"
+	PingKindDanger  = "danger"
+	PingKindBuild   = "build"
+)
+
+var validPingKinds = map[string]bool{
+	PingKindPointer: true,
+	PingKindDanger:  true,
+	PingKindBuild:   true,
+}
+
+// Map pings are cheap to send, so allow a short burst but throttle spam
+const (
+	pingRatePerSecond = 2
+	pingBurst         = 4
+)
+
+// handleMapPing broadcasts a player's map marker to everyone in the room
+func (c *Client) handleMapPing(msg *Message) {
+	roomID := c.currentRoom()
+	if roomID == "" {
+		log.Printf("Client %s tried to ping but is not in a room", c.id)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInRoom, nil))
+		return
+	}
+
+	if !c.pingLimiter.Allow() {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrRateLimited, nil))
+		return
+	}
+
+	x, xOk := msg.Payload["x"].(float64)
+	y, yOk := msg.Payload["y"].(float64)
+	if !xOk || !yOk || !game.InBounds(game.Position{X: x, Y: y}) {
+		log.Printf("Invalid map ping data: %v", msg.Payload)
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	kind := PingKindPointer
+	if k, ok := msg.Payload["kind"].(string); ok {
+		kind = k
+	}
+	if !validPingKinds[kind] {
+		log.Printf("Invalid map ping kind: %s", kind)
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	sender := c.playerInfo()
+	c.hub.broadcastMessage(roomID, Message{
+		Type:   MessageTypeMapPing,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"sender":       c.id,
+			"sender_name":  sender.Name,
+			"sender_color": sender.Color,
+			"kind":         kind,
+			"position":     game.Position{X: x, Y: y},
+			"timestamp":    time.Now().UnixMilli(),
+		},
+	})
+}
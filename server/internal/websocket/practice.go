@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// defaultRewindSeconds is how far back a rewind goes when the client
+// doesn't say
+const defaultRewindSeconds = 5.0
+
+// handleRewind restores the client's practice room to an earlier checkpoint
+func (c *Client) handleRewind(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	seconds := defaultRewindSeconds
+	if s, ok := msg.Payload["seconds"].(float64); ok {
+		if s < 0 {
+			c.sendError(msg.Type, invalidPayload(msg.Type))
+			return
+		}
+		seconds = s
+	}
+
+	result, err := c.applyCommand(room, msg, game.Rewind{PlayerID: c.id, Seconds: seconds})
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+	gameTime, _ := result.(float64)
+
+	log.Printf("Client %s rewound room %s to %.1fs", c.id, roomID, gameTime)
+	c.broadcastState(roomID)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckRewound,
+			"params": map[string]interface{}{"game_time": gameTime},
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package websocket
+
+// sendProtocol tells a newly connected client how the state it'll be sent
+// is encoded, e.g. how precise positions are, and the identity token it can
+// reconnect with as the same player
+func (c *Client) sendProtocol() {
+	c.sendJSON(Message{
+		Type: MessageTypeProtocol,
+		Payload: map[string]interface{}{
+			"positions": c.hub.gameManager.Precision(),
+			"player_id": c.id,
+			"identity":  c.hub.gameManager.Identities().Issue(c.id),
+		},
+	})
+}
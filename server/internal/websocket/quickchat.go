@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// QuickChatEntry is a predefined emote or phrase players can send
+type QuickChatEntry struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"` // "emote" or "phrase"
+	Text string `json:"text"` // English fallback, clients localize by ID
+}
+
+// quickChatCatalog is the fixed set of messages allowed in quick chat.
+// Only IDs travel over the wire, so nothing here can be abused for free text.
+var quickChatCatalog = map[string]QuickChatEntry{
+	"wave":          {ID: "wave", Kind: "emote", Text: "👋"},
+	"thumbs_up":     {ID: "thumbs_up", Kind: "emote", Text: "👍"},
+	"laugh":         {ID: "laugh", Kind: "emote", Text: "😂"},
+	"crab":          {ID: "crab", Kind: "emote", Text: "🦀"},
+	"hello":         {ID: "hello", Kind: "phrase", Text: "Hello!"},
+	"good_game":     {ID: "good_game", Kind: "phrase", Text: "Good game!"},
+	"nice_shot":     {ID: "nice_shot", Kind: "phrase", Text: "Nice shot!"},
+	"need_gold":     {ID: "need_gold", Kind: "phrase", Text: "I need gold."},
+	"help_left":     {ID: "help_left", Kind: "phrase", Text: "Help on the left!"},
+	"help_right":    {ID: "help_right", Kind: "phrase", Text: "Help on the right!"},
+	"ready":         {ID: "ready", Kind: "phrase", Text: "Ready for the next wave."},
+	"wait":          {ID: "wait", Kind: "phrase", Text: "Wait!"},
+	"thanks":        {ID: "thanks", Kind: "phrase", Text: "Thanks!"},
+	"well_played":   {ID: "well_played", Kind: "phrase", Text: "Well played!"},
+	"boss_incoming": {ID: "boss_incoming", Kind: "phrase", Text: "Boss incoming!"},
+}
+
+// QuickChatCatalog returns every quick chat entry, sorted by ID
+func QuickChatCatalog() []QuickChatEntry {
+	entries := make([]QuickChatEntry, 0, len(quickChatCatalog))
+	for _, entry := range quickChatCatalog {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// Quick chat is more intrusive than pings, so it is throttled harder
+const (
+	quickChatRatePerSecond = 0.5
+	quickChatBurst         = 3
+)
+
+// handleQuickChat validates a quick chat ID and broadcasts it to the room
+func (c *Client) handleQuickChat(msg *Message) {
+	roomID := c.currentRoom()
+	if roomID == "" {
+		log.Printf("Client %s tried to quick chat but is not in a room", c.id)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotInRoom, nil))
+		return
+	}
+
+	if !c.chatLimiter.Allow() {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrRateLimited, nil))
+		return
+	}
+
+	id, _ := msg.Payload["id"].(string)
+	entry, ok := quickChatCatalog[id]
+	if !ok {
+		log.Printf("Client %s sent unknown quick chat ID: %q", c.id, id)
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrUnknownQuickChat, map[string]interface{}{"id": id}))
+		return
+	}
+
+	if err := c.hub.gameManager.Moderation().RecordChat(roomID, c.id, entry.ID); err != nil {
+		log.Printf("Failed to record chat in room %s: %v", roomID, err)
+	}
+
+	sender := c.playerInfo()
+	c.hub.broadcastMessage(roomID, Message{
+		Type:   MessageTypeQuickChat,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"sender":       c.id,
+			"sender_name":  sender.Name,
+			"sender_color": sender.Color,
+			"id":           entry.ID,
+			"kind":         entry.Kind,
+			"text":         entry.Text,
+			"timestamp":    time.Now().UnixMilli(),
+		},
+	})
+}
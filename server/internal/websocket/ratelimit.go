@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a simple token bucket used to throttle per-client actions
+type rateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens refilled per second
+	last     time.Time
+}
+
+// newRateLimiter creates a full bucket allowing burst actions at once and
+// refilling at perSecond
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		tokens:   float64(burst),
+		capacity: float64(burst),
+		rate:     perSecond,
+		last:     time.Now(),
+	}
+}
+
+// Allow consumes a token if one is available
+func (rl *rateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.capacity {
+		rl.tokens = rl.capacity
+	}
+	rl.last = now
+
+	if rl.tokens < 1 {
+		return false
+	}
+	rl.tokens--
+	return true
+}
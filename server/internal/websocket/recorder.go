@@ -0,0 +1,283 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Capture limits. Frames past a capture's size cap are dropped, and the
+// oldest finished captures make way for new ones.
+const (
+	maxCaptureBytes = 4 * 1024 * 1024
+	maxCaptures     = 16
+)
+
+// Frame directions, as seen from the server
+const (
+	FrameIn  = "in"
+	FrameOut = "out"
+)
+
+// CapturedFrame is one WebSocket frame a capture recorded
+type CapturedFrame struct {
+	AtMs      float64 `json:"at_ms"` // since the capture started
+	ClientID  string  `json:"client_id"`
+	Direction string  `json:"direction"`
+	Data      string  `json:"data"` // raw but for redacted secrets, so malformed frames replay as they came
+}
+
+// secretFields are payload fields a capture never stores, wherever they
+// appear in a frame
+var secretFields = map[string]bool{
+	"password":  true,
+	"token":     true,
+	"bot_key":   true,
+	"identity":  true,
+	"join_code": true,
+}
+
+// redactedValue replaces secrets in captured frames
+const redactedValue = "[redacted]"
+
+// redact blanks out the secrets in a frame. Clients send a private room's
+// join code as "code", which in the server's replies is the message code,
+// so that's only redacted coming in. Frames that aren't JSON or carry no
+// secrets are kept byte for byte.
+func redact(direction string, data []byte) []byte {
+	var frame interface{}
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return data
+	}
+	if !redactValue(frame, direction == FrameIn) {
+		return data
+	}
+	redacted, err := json.Marshal(frame)
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+// redactValue blanks out secrets in a decoded frame, reporting whether it
+// found any
+func redactValue(v interface{}, inbound bool) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secretFields[key] || (inbound && key == "code") {
+				v[key] = redactedValue
+				found = true
+				continue
+			}
+			found = redactValue(value, inbound) || found
+		}
+	case []interface{}:
+		for _, value := range v {
+			found = redactValue(value, inbound) || found
+		}
+	}
+	return found
+}
+
+// CaptureSummary describes a capture without its frames
+type CaptureSummary struct {
+	ID        string    `json:"id"`
+	ClientID  string    `json:"client_id,omitempty"`
+	RoomID    string    `json:"room_id,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Active    bool      `json:"active"`
+	Frames    int       `json:"frames"`
+	Bytes     int       `json:"bytes"`
+	Truncated bool      `json:"truncated"` // hit the size cap and stopped recording
+}
+
+// Capture is a recording of every frame to and from a flagged client, or
+// every client playing in a flagged room
+type Capture struct {
+	CaptureSummary
+	Frames []CapturedFrame `json:"frames"`
+}
+
+// recorder keeps the hub's captures. Frames are recorded from each
+// client's read and write pumps, so it has its own lock; active lets the
+// pumps skip it entirely while nothing is being captured.
+type recorder struct {
+	mu       sync.Mutex
+	active   atomic.Int32
+	captures map[string]*Capture
+	byClient map[string]*Capture
+	byRoom   map[string]*Capture
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		captures: make(map[string]*Capture),
+		byClient: make(map[string]*Capture),
+		byRoom:   make(map[string]*Capture),
+	}
+}
+
+// record adds a frame to the capture covering the client, if any. Inbound
+// frames also name the room they're for, so a room capture gets the frame
+// that joins the room as well as what follows.
+func (r *recorder) record(c *Client, direction string, data []byte, target string) {
+	if r.active.Load() == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capture, ok := r.byClient[c.id]
+	if roomID := c.currentRoom(); !ok && roomID != "" {
+		capture, ok = r.byRoom[roomID]
+	}
+	if !ok && target != "" {
+		capture, ok = r.byRoom[target]
+	}
+	if !ok {
+		return
+	}
+	data = redact(direction, data)
+	if capture.Bytes+len(data) > maxCaptureBytes {
+		capture.Truncated = true
+		r.stop(capture)
+		return
+	}
+
+	capture.Bytes += len(data)
+	capture.Frames = append(capture.Frames, CapturedFrame{
+		AtMs:      float64(time.Since(capture.StartedAt).Microseconds()) / 1000,
+		ClientID:  c.id,
+		Direction: direction,
+		Data:      string(data),
+	})
+}
+
+// start begins capturing a client or a room. Each may only have one
+// capture running at a time.
+func (r *recorder) start(clientID, roomID string) (CaptureSummary, error) {
+	if (clientID == "") == (roomID == "") {
+		return CaptureSummary{}, i18n.NewError(i18n.ErrInvalidCapture, nil)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byClient[clientID]; ok && clientID != "" {
+		return CaptureSummary{}, i18n.NewError(i18n.ErrAlreadyCapturing, nil)
+	}
+	if _, ok := r.byRoom[roomID]; ok && roomID != "" {
+		return CaptureSummary{}, i18n.NewError(i18n.ErrAlreadyCapturing, nil)
+	}
+	r.evict()
+
+	capture := &Capture{CaptureSummary: CaptureSummary{
+		ID:        ids.New("capture"),
+		ClientID:  clientID,
+		RoomID:    roomID,
+		StartedAt: time.Now(),
+		Active:    true,
+	}}
+	r.captures[capture.ID] = capture
+	if clientID != "" {
+		r.byClient[clientID] = capture
+	} else {
+		r.byRoom[roomID] = capture
+	}
+	r.active.Add(1)
+	return capture.summary(), nil
+}
+
+// stop ends a capture, keeping what it recorded. Callers must hold the
+// lock.
+func (r *recorder) stop(capture *Capture) {
+	if !capture.Active {
+		return
+	}
+	capture.Active = false
+	delete(r.byClient, capture.ClientID)
+	delete(r.byRoom, capture.RoomID)
+	r.active.Add(-1)
+}
+
+// evict drops the oldest finished captures until there's space for
+// another. Running captures are never dropped. Callers must hold the lock.
+func (r *recorder) evict() {
+	for len(r.captures) >= maxCaptures {
+		var oldest *Capture
+		for _, capture := range r.captures {
+			if !capture.Active && (oldest == nil || capture.StartedAt.Before(oldest.StartedAt)) {
+				oldest = capture
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(r.captures, oldest.ID)
+	}
+}
+
+// summary describes the capture. Callers must hold the recorder's lock.
+func (c *Capture) summary() CaptureSummary {
+	s := c.CaptureSummary
+	s.Frames = len(c.Frames)
+	return s
+}
+
+// StartCapture records every frame to and from a client, or to and from
+// every client playing in a room, until it's stopped or hits its size cap
+func (h *Hub) StartCapture(clientID, roomID string) (CaptureSummary, error) {
+	return h.recorder.start(clientID, roomID)
+}
+
+// StopCapture ends a capture, reporting false if there's no such capture
+func (h *Hub) StopCapture(id string) (CaptureSummary, bool) {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+
+	capture, ok := h.recorder.captures[id]
+	if !ok {
+		return CaptureSummary{}, false
+	}
+	h.recorder.stop(capture)
+	return capture.summary(), true
+}
+
+// Captures lists every capture kept, oldest first
+func (h *Hub) Captures() []CaptureSummary {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+
+	summaries := make([]CaptureSummary, 0, len(h.recorder.captures))
+	for _, capture := range h.recorder.captures {
+		summaries = append(summaries, capture.summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartedAt.Before(summaries[j].StartedAt)
+	})
+	return summaries
+}
+
+// Capture returns a copy of a capture with its frames, for export and
+// replay
+func (h *Hub) Capture(id string) (Capture, bool) {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+
+	capture, ok := h.recorder.captures[id]
+	if !ok {
+		return Capture{}, false
+	}
+	return Capture{
+		CaptureSummary: capture.summary(),
+		Frames:         append([]CapturedFrame{}, capture.Frames...),
+	}, true
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+
+	"rust-rush/server/internal/game"
+)
+
+func TestCapturesDontStoreSecrets(t *testing.T) {
+	hub := NewHub(game.NewManager())
+	c := newTestClient(hub, "flagged")
+	summary, err := hub.StartCapture(c.id, "")
+	if err != nil {
+		t.Fatalf("failed to start capture: %v", err)
+	}
+
+	hub.recorder.record(c, FrameIn, []byte(`{"type":"join_room","payload":{"room_id":"r1","password":"hunter2","code":"JOIN42"}}`), "")
+	hub.recorder.record(c, FrameIn, []byte(`{"type":"watch_diag","payload":{"token":"admin-secret"}}`), "")
+	hub.recorder.record(c, FrameOut, []byte(`{"type":"protocol","payload":{"identity":"signed-id","code":"ack.joined_room"}}`), "")
+	hub.recorder.record(c, FrameIn, []byte(`not json`), "")
+
+	capture, ok := hub.Capture(summary.ID)
+	if !ok {
+		t.Fatal("capture not found")
+	}
+	var all strings.Builder
+	for _, frame := range capture.Frames {
+		all.WriteString(frame.Data)
+	}
+	for _, secret := range []string{"hunter2", "JOIN42", "admin-secret", "signed-id"} {
+		if strings.Contains(all.String(), secret) {
+			t.Errorf("capture stored %q", secret)
+		}
+	}
+	if !strings.Contains(all.String(), "ack.joined_room") {
+		t.Error("reply's message code was redacted")
+	}
+	if last := capture.Frames[len(capture.Frames)-1].Data; last != "not json" {
+		t.Errorf("malformed frame stored as %q", last)
+	}
+}
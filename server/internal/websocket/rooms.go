@@ -0,0 +1,421 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleJoinRoom joins a room by ID or join code, creating it if it doesn't
+// exist. Private rooms need their join code or password.
+func (c *Client) handleJoinRoom(msg *Message) {
+	code, _ := msg.Payload["code"].(string)
+	password, _ := msg.Payload["password"].(string)
+	if !c.setDisplayName(msg) {
+		return
+	}
+
+	roomID := msg.RoomID
+	if roomID == "" {
+		if code == "" {
+			return
+		}
+
+		var ok bool
+		roomID, ok = c.hub.gameManager.FindRoomByCode(c.tenant, code)
+		if !ok {
+			c.sendError(MessageTypeJoinRoom, i18n.NewError(i18n.ErrInvalidJoinCode, nil))
+			return
+		}
+	}
+
+	room, exists := c.hub.gameManager.GetShootingRoom(roomID)
+	if !exists {
+		// Rooms hosted by another instance are joined there
+		if instance, local, found := c.hub.gameManager.Locate(roomID); found && !local {
+			log.Printf("Redirecting client %s to instance %s for room %s", c.id, instance.ID, roomID)
+			c.reply(Message{
+				Type:   MessageTypeRedirect,
+				RoomID: roomID,
+				Payload: map[string]interface{}{
+					"instance": instance.ID,
+					"ws_url":   instance.WSURL,
+					"region":   instance.Region,
+				},
+			})
+			return
+		}
+
+		// Rooms handed off by a draining instance resume here
+		if adopted, ok := c.hub.gameManager.Adopt(roomID); ok {
+			if err := c.checkPartyAccess(adopted, code, password); err != nil {
+				log.Printf("Client %s denied access to room %s", c.id, roomID)
+				c.sendError(MessageTypeJoinRoom, err)
+				return
+			}
+			if err := c.enterRoom(roomID); err != nil {
+				c.sendError(MessageTypeJoinRoom, err)
+			}
+			return
+		}
+
+		// Create a shooting room if it doesn't exist
+		config, err := parseRoomConfig(msg.Payload)
+		if err != nil {
+			log.Printf("Invalid room config from client %s: %v", c.id, err)
+			c.sendError(MessageTypeJoinRoom, err)
+			return
+		}
+
+		if _, err := c.hub.gameManager.OpenRoom(roomID, config); err != nil {
+			// Someone else opened it first, so join theirs instead
+			if errors.Is(err, i18n.NewError(i18n.ErrRoomExists, nil)) {
+				c.handleJoinRoom(msg)
+				return
+			}
+			log.Printf("Failed to create room %s: %v", roomID, err)
+			c.sendError(MessageTypeJoinRoom, err)
+			return
+		}
+
+		log.Printf("Created new %s shooting room: %s", config.Visibility, roomID)
+	} else if err := c.checkPartyAccess(room, code, password); err != nil {
+		log.Printf("Client %s denied access to room %s", c.id, roomID)
+		c.sendError(MessageTypeJoinRoom, err)
+		return
+	}
+
+	if err := c.enterRoom(roomID); err != nil {
+		log.Printf("Client %s turned away from room %s: %v", c.id, roomID, err)
+		c.sendError(MessageTypeJoinRoom, err)
+	}
+}
+
+// setDisplayName takes the display name from a message's payload, if it has
+// one. Invalid names are reported to the client.
+func (c *Client) setDisplayName(msg *Message) bool {
+	name, ok := msg.Payload["name"].(string)
+	if !ok {
+		return true
+	}
+
+	if _, err := c.hub.gameManager.Names().Set(c.id, name); err != nil {
+		log.Printf("Client %s chose an invalid display name: %q", c.id, name)
+		c.sendError(msg.Type, err)
+		return false
+	}
+	return true
+}
+
+// checkPartyAccess verifies that the client, and everyone following them if
+// they lead a party, may join a room. Followers come in on the leader's code
+// or password, but a member the host kicked keeps the whole party out.
+func (c *Client) checkPartyAccess(room *game.GameStateWithShooting, code, password string) error {
+	for _, member := range c.hub.gameManager.PartyGroup(c.id) {
+		err := room.CheckAccess(member, code, password)
+		if member != c.id && errors.Is(err, i18n.NewError(i18n.ErrKicked, nil)) {
+			err = i18n.NewError(i18n.ErrMemberKicked, map[string]interface{}{
+				"player_id": member,
+				"room_id":   room.RoomID,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enterRoom moves the client into a room. Party leaders bring their whole
+// party along, unless the room or realm has no space for all of them.
+func (c *Client) enterRoom(roomID string) error {
+	members := c.hub.gameManager.PartyGroup(c.id)
+	if err := c.hub.gameManager.AddPlayers(roomID, members); err != nil {
+		return err
+	}
+	for _, member := range members {
+		c.hub.moveToRoom(member, roomID)
+	}
+	return nil
+}
+
+// handleCreateRoom opens a new room from a template or an explicit config
+// and moves the client into it
+func (c *Client) handleCreateRoom(msg *Message) {
+	if !c.setDisplayName(msg) {
+		return
+	}
+
+	roomID := msg.RoomID
+	if roomID != "" {
+		if _, exists := c.hub.gameManager.GetShootingRoom(roomID); exists {
+			c.sendError(msg.Type, i18n.NewError(i18n.ErrRoomExists, map[string]interface{}{"room_id": roomID}))
+			return
+		}
+	} else {
+		roomID = game.Qualify(c.tenant, c.hub.gameManager.NextRoomID("room"))
+	}
+
+	var room *game.GameStateWithShooting
+	if templateName, ok := msg.Payload["template"].(string); ok {
+		var err error
+		room, err = c.hub.gameManager.CreateRoomFromTemplate(roomID, templateName)
+		if err != nil {
+			c.sendError(msg.Type, err)
+			return
+		}
+	} else {
+		config, err := parseRoomConfig(msg.Payload)
+		if err != nil {
+			c.sendError(msg.Type, err)
+			return
+		}
+		room, err = c.hub.gameManager.OpenRoom(roomID, config)
+		if err != nil {
+			c.sendError(msg.Type, err)
+			return
+		}
+	}
+
+	log.Printf("Client %s created room %s", c.id, room.RoomID)
+
+	c.reply(Message{
+		Type:   MessageTypeCreateRoom,
+		RoomID: room.RoomID,
+		Payload: map[string]interface{}{
+			"status": "created",
+			"code":   i18n.AckRoomCreated,
+			"params": map[string]interface{}{"room_id": room.RoomID},
+		},
+	})
+
+	if err := c.enterRoom(room.RoomID); err != nil {
+		c.sendError(msg.Type, err)
+	}
+}
+
+// handleSaveTemplate saves the current room's config as a named template.
+// Only the host may do this.
+func (c *Client) handleSaveTemplate(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	name, ok := msg.Payload["name"].(string)
+	if !ok || name == "" {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+	qualified, err := game.Claim(c.tenant, name)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	snapshot := room.GetSnapshot()
+	if snapshot.Host != c.id {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrNotHost, nil))
+		return
+	}
+
+	template, err := c.hub.gameManager.SaveTemplate(game.RoomTemplate{
+		Name:      qualified,
+		Config:    snapshot.Config,
+		CreatedBy: c.id,
+	})
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s saved room %s as template %s", c.id, roomID, name)
+
+	c.reply(Message{
+		Type: MessageTypeSaveTemplate,
+		Payload: map[string]interface{}{
+			"status":   "saved",
+			"code":     i18n.AckTemplateSave,
+			"params":   map[string]interface{}{"name": name},
+			"template": template,
+		},
+	})
+}
+
+// handleListRooms sends the client a page of the public rooms, filtered
+// and sorted as the payload asks. Unless it asks otherwise, rooms it can
+// reach fastest come first.
+func (c *Client) handleListRooms(msg *Message) {
+	query := parseRoomQuery(msg.Payload)
+	query.Latency = c.clientLatency()
+
+	page, err := c.hub.gameManager.ListRooms(c.tenant, c.id, query)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.reply(Message{
+		Type: MessageTypeListRooms,
+		Payload: map[string]interface{}{
+			"rooms":         page.Rooms,
+			"total":         page.Total,
+			"offset":        page.Offset,
+			"limit":         page.Limit,
+			"closer_region": page.CloserRegion,
+		},
+	})
+}
+
+// handleQuickMatch moves the client, and their party, into the open room
+// that suits them best. If a region the client probed is much closer than
+// this one, it's told to reconnect there instead.
+func (c *Client) handleQuickMatch(msg *Message) {
+	if !c.setDisplayName(msg) {
+		return
+	}
+
+	members := c.hub.gameManager.PartyGroup(c.id)
+	match, err := c.hub.gameManager.QuickMatch(c.tenant, members, c.clientLatency())
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	if match.RoomID != "" {
+		if err := c.enterRoom(match.RoomID); err != nil {
+			log.Printf("Client %s turned away from quick match room %s: %v", c.id, match.RoomID, err)
+			c.sendError(msg.Type, err)
+			return
+		}
+	}
+
+	c.reply(Message{
+		Type:   MessageTypeQuickMatch,
+		RoomID: match.RoomID,
+		Payload: map[string]interface{}{
+			"region": match.Region,
+			"rtt_ms": match.RTTMs,
+		},
+	})
+}
+
+// parseRoomQuery reads the room browser's filters from a list_rooms
+// payload. Missing fields don't filter.
+func parseRoomQuery(payload map[string]interface{}) game.RoomQuery {
+	var q game.RoomQuery
+	q.Mode, _ = payload["mode"].(string)
+	q.Map, _ = payload["map"].(string)
+	q.MinDifficulty, _ = payload["min_difficulty"].(float64)
+	q.MaxDifficulty, _ = payload["max_difficulty"].(float64)
+	q.HasSpace, _ = payload["has_space"].(bool)
+	q.FriendsPresent, _ = payload["friends_present"].(bool)
+	q.Region, _ = payload["region"].(string)
+	q.Sort, _ = payload["sort"].(string)
+	offset, hasOffset := payload["offset"].(float64)
+	limit, hasLimit := payload["limit"].(float64)
+	q.Offset, q.Limit = int(offset), int(limit)
+	// Clients from before paging send neither and expect every room
+	q.Unpaged = !hasOffset && !hasLimit
+	return q
+}
+
+// handleRotateJoinCode gives the room a new join code. Only the host may do
+// this.
+func (c *Client) handleRotateJoinCode(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	joinCode, err := room.RotateJoinCode(c.id)
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s rotated the join code of room %s", c.id, roomID)
+	if err := c.hub.gameManager.Moderation().RecordAction(roomID, c.id, game.ModActionRotateJoinCode, "", ""); err != nil {
+		log.Printf("Failed to record moderation action in room %s: %v", roomID, err)
+	}
+
+	c.reply(Message{
+		Type:   MessageTypeRotateJoinCode,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status":    "rotated",
+			"code":      i18n.AckJoinCode,
+			"params":    map[string]interface{}{"code": joinCode},
+			"join_code": joinCode,
+		},
+	})
+}
+
+// handleSetDropIn lets the host close the room to strangers dropping in
+// mid-game, or open it again
+func (c *Client) handleSetDropIn(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	open, ok := msg.Payload["open"].(bool)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	if _, err := c.applyCommand(room, msg, game.SetDropIn{By: c.id, Open: open}); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s set drop-ins for room %s open: %t", c.id, roomID, open)
+	if err := c.hub.gameManager.Moderation().RecordAction(roomID, c.id, game.ModActionSetDropIn, "", strconv.FormatBool(open)); err != nil {
+		log.Printf("Failed to record moderation action in room %s: %v", roomID, err)
+	}
+
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckDropIn,
+			"open":   open,
+		},
+	})
+}
+
+// handleSetReady marks the client ready or not in the lobby. Everyone in the
+// room gets the new roster with the next lobby_state.
+func (c *Client) handleSetReady(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	ready, ok := msg.Payload["ready"].(bool)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	if _, err := c.applyCommand(room, msg, game.SetReady{PlayerID: c.id, Ready: ready}); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Client %s ready in room %s: %t", c.id, roomID, ready)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckReady,
+			"ready":  ready,
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Why a player left a room
+const (
+	LeaveReasonLeft         = "left"
+	LeaveReasonDisconnected = "disconnected"
+	LeaveReasonKicked       = "kicked"
+)
+
+// announceJoin tells everyone in a room that a player arrived
+func (h *Hub) announceJoin(roomID, playerID string) {
+	room, exists := h.gameManager.GetShootingRoom(roomID)
+	if !exists {
+		return
+	}
+
+	h.broadcastMessage(roomID, Message{
+		Type:   MessageTypePlayerJoined,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"player": room.PlayerInfo(playerID),
+		},
+	})
+}
+
+// playerInfo describes the client to the rest of its room
+func (c *Client) playerInfo() game.PlayerInfo {
+	if room, exists := c.hub.gameManager.GetShootingRoom(c.currentRoom()); exists {
+		return room.PlayerInfo(c.id)
+	}
+	return game.PlayerInfo{PlayerID: c.id, Name: c.id, Role: game.RolePlayer}
+}
+
+// leaveRoom takes a client out of its room and tells the players left
+// behind, along with who hosts now
+func (h *Hub) leaveRoom(client *Client, reason string) {
+	roomID := client.currentRoom()
+	if roomID == "" {
+		return
+	}
+
+	room, exists := h.gameManager.GetShootingRoom(roomID)
+	var info game.PlayerInfo
+	if exists {
+		info = room.PlayerInfo(client.id)
+	}
+
+	h.gameManager.RemovePlayer(roomID, client.id)
+	client.clearRoom(roomID)
+	if !exists {
+		return
+	}
+
+	host := room.HostID()
+	h.broadcastMessage(roomID, Message{
+		Type:   MessageTypePlayerLeft,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"player": info,
+			"reason": reason,
+			"host":   host,
+		},
+	})
+
+	if info.Role == game.RoleHost && host != "" {
+		h.announceHostChange(room, info.PlayerID, host)
+	}
+}
+
+// announceHostChange tells a room who hosts it now. The new host also gets
+// the join code, which only hosts see.
+func (h *Hub) announceHostChange(room *game.GameStateWithShooting, previous, host string) {
+	log.Printf("Host of room %s moved from %s to %s", room.RoomID, previous, host)
+
+	payload := map[string]interface{}{
+		"previous": previous,
+		"host":     room.PlayerInfo(host),
+		"paused":   room.IsPaused(),
+	}
+	h.broadcastMessage(room.RoomID, Message{
+		Type:    MessageTypeHostChanged,
+		RoomID:  room.RoomID,
+		Payload: payload,
+	})
+
+	if joinCode, ok := room.JoinCode(host); ok {
+		h.SendToPlayer(host, Message{
+			Type:   MessageTypeRotateJoinCode,
+			RoomID: room.RoomID,
+			Payload: map[string]interface{}{
+				"join_code": joinCode,
+			},
+		})
+	}
+}
+
+// handleKickPlayer removes another player from the room and keeps them out.
+// Only the host may do this.
+func (c *Client) handleKickPlayer(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	playerID, ok := msg.Payload["player_id"].(string)
+	if !ok || playerID == "" {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	result, err := c.applyCommand(room, msg, game.KickPlayer{By: c.id, PlayerID: playerID})
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+	info, _ := result.(game.PlayerInfo)
+
+	log.Printf("Client %s kicked %s from room %s", c.id, playerID, roomID)
+	if err := c.hub.gameManager.Moderation().RecordAction(roomID, c.id, game.ModActionKick, playerID, ""); err != nil {
+		log.Printf("Failed to record moderation action in room %s: %v", roomID, err)
+	}
+
+	// The kicked player hears it too, before they're detached from the room
+	c.hub.broadcastMessage(roomID, Message{
+		Type:   MessageTypePlayerKicked,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"player": info,
+			"by":     c.id,
+			"reason": LeaveReasonKicked,
+		},
+	})
+	if kicked := c.hub.clientByID(playerID); kicked != nil && kicked.clearRoom(roomID) {
+		kicked.disconnect(CloseKicked)
+	}
+
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "kicked",
+			"code":   i18n.AckKicked,
+			"params": map[string]interface{}{"player_id": playerID},
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleGetSettings sends the client the settings saved on the player's
+// profile, empty if they've never saved any
+func (c *Client) handleGetSettings(msg *Message) {
+	settings := c.hub.gameManager.Profiles().Get(c.id).Settings
+	if settings == nil {
+		settings = &game.Settings{}
+	}
+
+	c.reply(Message{
+		Type: MessageTypeGetSettings,
+		Payload: map[string]interface{}{
+			"settings": settings,
+		},
+	})
+}
+
+// handleSetSettings replaces the player's saved settings with the ones sent
+func (c *Client) handleSetSettings(msg *Message) {
+	raw, ok := msg.Payload["settings"].(map[string]interface{})
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	// Decoded again as settings, so unknown fields and wrong types are
+	// caught
+	data, err := json.Marshal(raw)
+	if err != nil {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+	settings, err := game.ParseSettings(data)
+	if err == nil {
+		err = c.hub.gameManager.SaveSettings(c.id, settings)
+	}
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.reply(Message{
+		Type: MessageTypeSetSettings,
+		Payload: map[string]interface{}{
+			"status":   "ok",
+			"code":     i18n.AckSettingsSet,
+			"settings": settings,
+		},
+	})
+}
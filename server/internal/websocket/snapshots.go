@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Snapshot polling limits, per address. The table of addresses is cleared
+// when it grows past maxSnapshotPollers, which at worst hands a poller a
+// fresh burst.
+const (
+	snapshotPollRate   = 2.0 // requests per second
+	snapshotPollBurst  = 5
+	maxSnapshotPollers = 4096
+)
+
+// snapshotPollers rate limits snapshot polling by address
+type snapshotPollers struct {
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+func newSnapshotPollers() *snapshotPollers {
+	return &snapshotPollers{limiters: make(map[string]*rateLimiter)}
+}
+
+// allow reports whether an address may poll again
+func (p *snapshotPollers) allow(ip string) bool {
+	p.mu.Lock()
+	limiter, ok := p.limiters[ip]
+	if !ok {
+		if len(p.limiters) >= maxSnapshotPollers {
+			p.limiters = make(map[string]*rateLimiter)
+		}
+		limiter = newRateLimiter(snapshotPollRate, snapshotPollBurst)
+		p.limiters[ip] = limiter
+	}
+	p.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// ServeSnapshot answers GET /rooms/{id}/snapshot with the room's latest
+// state, for dashboards and stream overlays that poll instead of holding
+// a WebSocket. Private rooms need their join code or password, as when
+// observing them; when SPECTATE_TOKEN is set, every request must also
+// carry it as the spectate_token query parameter.
+func ServeSnapshot(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	roomID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/rooms/"), "/snapshot")
+	if !ok || roomID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !hub.pollers.allow(remoteIP(r)) {
+		wait := time.Duration(float64(time.Second) / snapshotPollRate)
+		rejectConnection(w, i18n.NewError(i18n.ErrRateLimited, nil), wait)
+		return
+	}
+
+	query := r.URL.Query()
+	if want := os.Getenv("SPECTATE_TOKEN"); want != "" &&
+		subtle.ConstantTimeCompare([]byte(query.Get("spectate_token")), []byte(want)) != 1 {
+		writeHTTPError(w, http.StatusUnauthorized, i18n.NewError(i18n.ErrSpectateToken, nil))
+		return
+	}
+
+	tenant := hub.gameManager.Tenants().Resolve(r.Host, TenantToken(r))
+	qualified, err := game.Claim(tenant, roomID)
+	if err != nil {
+		writeHTTPError(w, http.StatusNotFound, err)
+		return
+	}
+	room, exists := hub.gameManager.GetShootingRoom(qualified)
+	if !exists {
+		writeHTTPError(w, http.StatusNotFound, roomNotFound(roomID))
+		return
+	}
+	if err := room.CheckAccess("", query.Get("code"), query.Get("password")); err != nil {
+		writeHTTPError(w, http.StatusForbidden, err)
+		return
+	}
+
+	data, err := room.CachedKeyframe(hub.gameManager.Precision())
+	if err != nil {
+		http.Error(w, "snapshot unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
+
+// writeHTTPError answers a plain HTTP request with a coded error
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	body := map[string]interface{}{"message": err.Error()}
+	if coded, ok := err.(*i18n.Error); ok {
+		body["code"] = coded.Code
+		body["params"] = coded.Params
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
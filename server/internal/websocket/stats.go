@@ -0,0 +1,24 @@
+package websocket
+
+// handleSetTelemetry lets a player opt out of the anonymous balance stats,
+// or back in. It applies to matches that finish from now on.
+func (c *Client) handleSetTelemetry(msg *Message) {
+	enabled, ok := msg.Payload["enabled"].(bool)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	if err := c.hub.gameManager.Profiles().SetTelemetryOptOut(c.id, !enabled); err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.reply(Message{
+		Type: MessageTypeSetTelemetry,
+		Payload: map[string]interface{}{
+			"status":  "ok",
+			"enabled": enabled,
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleSurrender concedes the game for the client's side, or votes to.
+// Everyone in the room sees the result with the game_over that follows.
+func (c *Client) handleSurrender(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	result, err := c.applyCommand(room, msg, game.Surrender{PlayerID: c.id})
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+	vote, _ := result.(game.SurrenderVote)
+
+	code := i18n.AckVotedForfeit
+	if vote.Surrendered {
+		code = i18n.AckSurrendered
+		log.Printf("Client %s surrendered room %s", c.id, roomID)
+	} else {
+		log.Printf("Client %s voted to surrender room %s (%d/%d)", c.id, roomID, vote.Votes, vote.Needed)
+	}
+
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   code,
+			"params": map[string]interface{}{"votes": vote.Votes, "needed": vote.Needed},
+			"vote":   vote,
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// Reasons a client can give for request_state
+const (
+	ResyncReconnect     = "reconnect"
+	ResyncDesync        = "desync"
+	ResyncDroppedFrames = "dropped_frames"
+)
+
+var resyncReasons = map[string]bool{
+	ResyncReconnect:     true,
+	ResyncDesync:        true,
+	ResyncDroppedFrames: true,
+}
+
+// handleRequestState sends the client a keyframe right away instead of
+// waiting for the next broadcast
+func (c *Client) handleRequestState(msg *Message) {
+	reason, _ := msg.Payload["reason"].(string)
+	if !resyncReasons[reason] {
+		c.sendError(msg.Type, i18n.NewError(i18n.ErrInvalidResync, map[string]interface{}{"reason": reason}))
+		return
+	}
+
+	room, roomID, ok := c.resolveWatchedRoom(msg)
+	if !ok {
+		return
+	}
+
+	log.Printf("Client %s requested state for room %s (%s)", c.id, roomID, reason)
+	c.sendSnapshot(roomID, c.hub.gameManager.ClientSnapshot(room), reason)
+}
+
+// handleRequestFullState sends a fresh snapshot to a client whose local
+// state hash no longer matches the server's
+func (c *Client) handleRequestFullState(msg *Message) {
+	room, roomID, ok := c.resolveWatchedRoom(msg)
+	if !ok {
+		return
+	}
+
+	snapshot := c.hub.gameManager.ClientSnapshot(room)
+	if clientHash, ok := msg.Payload["state_hash"].(string); ok && clientHash != "" {
+		log.Printf("Client %s desynced in room %s at tick %d (client %s, server %s)",
+			c.id, roomID, snapshot.Tick, clientHash, snapshot.StateHash)
+	}
+
+	c.sendSnapshot(roomID, snapshot, ResyncDesync)
+}
+
+// sendSnapshot sends a full keyframe to this client only
+func (c *Client) sendSnapshot(roomID string, snapshot *game.Snapshot, reason string) {
+	c.reply(Message{
+		Type:   MessageTypeGameState,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"state":    snapshot,
+			"keyframe": true,
+			"reason":   reason,
+		},
+	})
+}
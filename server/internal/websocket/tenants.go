@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"net/http"
+	"strings"
+
+	"rust-rush/server/internal/game"
+)
+
+// TenantToken returns the auth token a request presents for picking its
+// realm, from a bearer Authorization header or the tenant_token query
+// parameter
+func TenantToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("tenant_token")
+}
+
+// qualifiedFields are the payload fields naming rooms, players, parties and
+// templates, which live in a realm
+var qualifiedFields = []string{"player_id", "party_id", "template"}
+
+// qualifyMessage moves the room and everything else a message names into
+// the client's realm, so a client can only ever reach what's in it
+func (c *Client) qualifyMessage(msg *Message) error {
+	roomID, err := game.Claim(c.tenant, msg.RoomID)
+	if err != nil {
+		return err
+	}
+	msg.RoomID = roomID
+
+	for _, field := range qualifiedFields {
+		id, ok := msg.Payload[field].(string)
+		if !ok {
+			continue
+		}
+		if msg.Payload[field], err = game.Claim(c.tenant, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
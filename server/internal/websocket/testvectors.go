@@ -0,0 +1,195 @@
+package websocket
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"rust-rush/server/internal/i18n"
+)
+
+// TestVector is an example client frame and how the server answers it, for
+// third-party clients to check themselves against. Payload vectors assume
+// the sender is in a running room, since room checks come first otherwise.
+type TestVector struct {
+	Name   string                 `json:"name"`
+	Frame  string                 `json:"frame"` // sent as a text frame exactly as is
+	Valid  bool                   `json:"valid"`
+	Code   i18n.Code              `json:"code,omitempty"` // the error the server replies with
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// testVector is a frame to publish. What the server makes of it comes from
+// running it through the server's own decoding.
+type testVector struct {
+	name  string
+	frame string
+}
+
+// clientMessages is every message type clients may send, each with an
+// example payload a player in a running room could send it with. The
+// message switch handles exactly these types; anything else is unknown.
+var clientMessages = map[string]string{
+	MessageTypeJoinRoom:         `{"name":"Ada"}`,
+	MessageTypeCreateRoom:       `{"config":{"mode":"coop","seed":12345}}`,
+	MessageTypeObserveRoom:      ``,
+	MessageTypeUnobserveRoom:    ``,
+	MessageTypeWatchDemo:        ``,
+	MessageTypeSaveTemplate:     `{"name":"practice"}`,
+	MessageTypeListRooms:        `{"mode":"coop","has_space":true,"offset":0,"limit":20}`,
+	MessageTypeQuickMatch:       ``,
+	MessageTypeRotateJoinCode:   ``,
+	MessageTypeLeaveRoom:        ``,
+	MessageTypePlaceTower:       `{"x":4,"y":6,"tower_type":"basic"}`,
+	MessageTypeRemoveTower:      `{"tower_id":1}`,
+	MessageTypeUpgradeTower:     `{"tower_id":1}`,
+	MessageTypeSetTargetMode:    `{"tower_id":1,"mode":"first"}`,
+	MessageTypeSpawnEnemy:       `{"enemy_type":"fast"}`,
+	MessageTypeClearAll:         ``,
+	MessageTypeStartWave:        ``,
+	MessageTypeSkipToNextWave:   ``,
+	MessageTypeRewind:           `{"seconds":5}`,
+	MessageTypePauseGame:        `{"paused":true}`,
+	MessageTypeRequestState:     `{"reason":"reconnect"}`,
+	MessageTypeRequestFullState: `{"state_hash":"0"}`,
+	MessageTypeMapPing:          `{"x":4,"y":6,"kind":"pointer"}`,
+	MessageTypeReportPlayer:     `{"player_id":"player-2","reason":"griefing","details":"sold every tower"}`,
+	MessageTypeWatchDiag:        `{"token":"admin-token"}`,
+	MessageTypeUnwatchDiag:      ``,
+	MessageTypeWatchAnalysis:    `{"token":"admin-token"}`,
+	MessageTypeUnwatchAnalysis:  ``,
+	MessageTypeSetReady:         `{"ready":true}`,
+	MessageTypeSetDropIn:        `{"open":true}`,
+	MessageTypeKickPlayer:       `{"player_id":"player-2"}`,
+	MessageTypeSurrender:        ``,
+	MessageTypeGetEconomyLog:    `{"limit":10}`,
+	MessageTypeSelectLevel:      `{"campaign":"rust_belt","level":"outskirts"}`,
+	MessageTypeQuickChat:        `{"id":"wave"}`,
+	MessageTypeQueueForMatch:    ``,
+	MessageTypeLeaveQueue:       ``,
+	MessageTypePartyCreate:      ``,
+	MessageTypePartyInvite:      `{"player_id":"player-2"}`,
+	MessageTypePartyAccept:      `{"party_id":"party-1"}`,
+	MessageTypePartyLeave:       ``,
+	MessageTypeFriendRequest:    `{"player_id":"player-2"}`,
+	MessageTypeFriendAccept:     `{"player_id":"player-2"}`,
+	MessageTypeFriendRemove:     `{"player_id":"player-2"}`,
+	MessageTypeFriendList:       ``,
+	MessageTypeRoomInvite:       `{"player_id":"player-2"}`,
+	MessageTypeSetAnnouncements: `{"enabled":false}`,
+	MessageTypeSetTelemetry:     `{"enabled":false}`,
+	MessageTypeGetSettings:      ``,
+	MessageTypeSetSettings:      `{"settings":{"keybinds":{"place_tower":"T"}}}`,
+	MessageTypeInbox:            ``,
+	MessageTypeMarkRead:         `{"ids":["notification-1"]}`,
+	MessageTypeAirstrike:        `{"x":4,"y":6}`,
+}
+
+// exampleFrame is a message of the given type with its example payload
+func exampleFrame(msgType string) string {
+	payload := clientMessages[msgType]
+	if payload == "" {
+		return `{"type":"` + msgType + `"}`
+	}
+	return `{"type":"` + msgType + `","payload":` + payload + `}`
+}
+
+// payloadSchemas checks the payloads the server decodes into typed
+// structs, the same way their handlers do
+var payloadSchemas = map[string]func(*Message) error{
+	MessageTypePlaceTower: func(msg *Message) error {
+		_, err := decodePlacement(msg)
+		return err
+	},
+	MessageTypeSpawnEnemy: func(msg *Message) error {
+		var spawn enemySpawn
+		return decodePayload(msg, &spawn)
+	},
+	MessageTypeCreateRoom: func(msg *Message) error {
+		_, err := parseRoomConfig(msg.Payload)
+		return err
+	},
+}
+
+// testVectors lists the published frames: an example of every message type,
+// then variations on them, then frames the server rejects
+func testVectors() []testVector {
+	types := make([]string, 0, len(clientMessages))
+	for msgType := range clientMessages {
+		types = append(types, msgType)
+	}
+	sort.Strings(types)
+
+	vectors := make([]testVector, 0, len(types))
+	for _, msgType := range types {
+		vectors = append(vectors, testVector{name: msgType, frame: exampleFrame(msgType)})
+	}
+	return append(vectors, []testVector{
+		{name: "place_tower naming its room", frame: `{"type":"place_tower","room_id":"room-1","payload":{"x":4,"y":6,"tower_type":"basic"}}`},
+		{name: "place_tower retried with a request ID", frame: `{"type":"place_tower","request_id":"req-1","payload":{"x":4,"y":6,"tower_type":"basic"}}`},
+		{name: "spawn_enemy with defaults", frame: `{"type":"spawn_enemy"}`},
+		{name: "spawn_enemy along a path", frame: `{"type":"spawn_enemy","payload":{"enemy_type":"fast","path":[{"x":0,"y":7},{"x":19,"y":7}]}}`},
+
+		{name: "malformed JSON", frame: `{"type":"start_wave"`},
+		{name: "trailing data", frame: `{"type":"start_wave"}{}`},
+		{name: "unknown envelope field", frame: `{"type":"start_wave","extra":true}`},
+		{name: "envelope field of the wrong type", frame: `{"type":"start_wave","at_tick":"soon"}`},
+		{name: "unknown message type", frame: `{"type":"teleport"}`},
+		{
+			name:  "payload nested too deeply",
+			frame: `{"type":"spawn_enemy","payload":{"path":` + strings.Repeat("[", maxPayloadDepth) + strings.Repeat("]", maxPayloadDepth) + `}}`,
+		},
+		{
+			name:  "message over its size limit",
+			frame: `{"type":"place_tower","payload":{"x":4,"y":6,"tower_type":"basic","cosmetic":"` + strings.Repeat("a", defaultMessageLimit) + `"}}`,
+		},
+		{
+			name:  "array over the length limit",
+			frame: `{"type":"spawn_enemy","payload":{"path":[` + strings.TrimSuffix(strings.Repeat("0,", maxArrayLength+1), ",") + `]}}`,
+		},
+		{name: "place_tower without a position", frame: `{"type":"place_tower","payload":{"x":4,"tower_type":"basic"}}`},
+		{name: "place_tower with a string coordinate", frame: `{"type":"place_tower","payload":{"x":"4","y":6,"tower_type":"basic"}}`},
+		{name: "place_tower with an unknown field", frame: `{"type":"place_tower","payload":{"x":4,"y":6,"tower_type":"basic","level":3}}`},
+		{name: "spawn_enemy with a path that isn't a list", frame: `{"type":"spawn_enemy","payload":{"path":{"x":0,"y":7}}}`},
+		{name: "create_room with a fractional seed", frame: `{"type":"create_room","payload":{"config":{"seed":1.5}}}`},
+		{name: "create_room with a negative seed", frame: `{"type":"create_room","payload":{"config":{"seed":-1}}}`},
+	}...)
+}
+
+// check runs a vector's frame through the checks it would meet before its
+// handler acts on it
+func (v testVector) check() error {
+	msg, err := decodeMessage([]byte(v.frame))
+	if err != nil {
+		return err
+	}
+	if _, known := clientMessages[msg.Type]; !known {
+		return i18n.NewError(i18n.ErrUnknownType, map[string]interface{}{"type": msg.Type})
+	}
+	if check, ok := payloadSchemas[msg.Type]; ok {
+		return check(&msg)
+	}
+	return nil
+}
+
+// TestVectors returns the published frames along with how this version of
+// the server answers each one
+func TestVectors() []TestVector {
+	frames := testVectors()
+	vectors := make([]TestVector, 0, len(frames))
+	for _, v := range frames {
+		vector := TestVector{Name: v.name, Frame: v.frame, Valid: true}
+		if err := v.check(); err != nil {
+			// Reported the way sendError would
+			var coded *i18n.Error
+			if !errors.As(err, &coded) {
+				coded = i18n.NewError(i18n.ErrInternal, nil)
+			}
+			vector.Valid = false
+			vector.Code = coded.Code
+			vector.Params = coded.Params
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors
+}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"testing"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// switchedTypes reads the message types handleMessage's switch handles
+func switchedTypes(t *testing.T) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse client.go: %v", err)
+	}
+
+	// Message type constants by name
+	values := make(map[string]string)
+	pkg, err := parser.ParseFile(fset, "hub.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse hub.go: %v", err)
+	}
+	ast.Inspect(pkg, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if i < len(spec.Values) {
+				if lit, ok := spec.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					values[name.Name], _ = strconv.Unquote(lit.Value)
+				}
+			}
+		}
+		return true
+	})
+
+	var types []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "handleMessage" {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			clause, ok := n.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, expr := range clause.List {
+				ident, ok := expr.(*ast.Ident)
+				if !ok {
+					t.Fatalf("case %T isn't a message type constant", expr)
+				}
+				value, ok := values[ident.Name]
+				if !ok {
+					t.Fatalf("case %s isn't declared in hub.go", ident.Name)
+				}
+				types = append(types, value)
+			}
+			return false
+		})
+	}
+	if len(types) == 0 {
+		t.Fatal("found no cases in handleMessage")
+	}
+	sort.Strings(types)
+	return types
+}
+
+func TestClientMessagesMatchTheSwitch(t *testing.T) {
+	registered := make([]string, 0, len(clientMessages))
+	for msgType := range clientMessages {
+		registered = append(registered, msgType)
+	}
+	sort.Strings(registered)
+
+	if got, want := fmt.Sprint(registered), fmt.Sprint(switchedTypes(t)); got != want {
+		t.Fatalf("clientMessages has %s, handleMessage handles %s", got, want)
+	}
+}
+
+// protocolCodes are the errors that reject a frame itself, as opposed to
+// what it asks for
+var protocolCodes = map[i18n.Code]bool{
+	i18n.ErrInvalidMessage:  true,
+	i18n.ErrPayloadTooDeep:  true,
+	i18n.ErrPayloadTooLarge: true,
+	i18n.ErrInvalidPayload:  true,
+	i18n.ErrUnknownType:     true,
+}
+
+// replay sends a frame to a player in a running room the way readPump
+// would and returns the error codes in the replies
+func replay(t *testing.T, hub *Hub, roomID, frame string) []i18n.Code {
+	t.Helper()
+	room, err := hub.gameManager.OpenRoom(roomID, game.DefaultRoomConfig())
+	if err != nil {
+		t.Fatalf("failed to open room: %v", err)
+	}
+	defer hub.gameManager.DeleteRoom(room.RoomID)
+	room.Join([]string{"player"}, map[string]string{"player": "Player"})
+
+	c := &Client{
+		hub:  hub,
+		id:   "player",
+		send: make(chan []byte, 256),
+
+		pingLimiter: newRateLimiter(pingRatePerSecond, pingBurst),
+		chatLimiter: newRateLimiter(quickChatRatePerSecond, quickChatBurst),
+	}
+	c.setRoom(room.RoomID)
+
+	msg, err := decodeMessage([]byte(frame))
+	if err != nil {
+		c.sendError(msg.Type, err)
+	} else {
+		c.dispatch(nil, msg)
+		c.scheduled.Wait()
+	}
+
+	var codes []i18n.Code
+	for {
+		select {
+		case data := <-c.send:
+			var reply Message
+			if err := json.Unmarshal(data, &reply); err != nil {
+				t.Fatalf("reply isn't a message: %v", err)
+			}
+			if reply.Payload["status"] == "error" {
+				code, _ := reply.Payload["code"].(string)
+				codes = append(codes, i18n.Code(code))
+			}
+			continue
+		default:
+		}
+		return codes
+	}
+}
+
+func TestVectorsMatchALiveConnection(t *testing.T) {
+	hub := NewHub(game.NewManager())
+
+	for i, vector := range TestVectors() {
+		codes := replay(t, hub, fmt.Sprintf("vectors-%d", i), vector.Frame)
+		if !vector.Valid {
+			if len(codes) != 1 || codes[0] != vector.Code {
+				t.Errorf("%s: server replied %v, published %s", vector.Name, codes, vector.Code)
+			}
+			continue
+		}
+		for _, code := range codes {
+			if protocolCodes[code] {
+				t.Errorf("%s: published as valid, server rejected it with %s", vector.Name, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package websocket
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math/bits"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"rust-rush/server/internal/i18n"
+	"rust-rush/server/internal/ids"
+)
+
+// Connection throttling defaults. Players have no persistent identity yet,
+// so connections are keyed by remote IP.
+const (
+	defaultMaxConnsPerIP = 8
+	connectRatePerSecond = 2
+	connectBurst         = 5
+	reconnectBackoffBase = time.Second
+	reconnectBackoffMax  = time.Minute
+	strikeDecay          = 30 * time.Second
+	challengeTTL         = 2 * time.Minute
+	defaultDifficulty    = 16 // leading zero bits of sha256(challenge + nonce)
+	throttleSweepSize    = 10000
+	maxChallenges        = 10000
+)
+
+// ThrottleConfig controls connection-level protection
+type ThrottleConfig struct {
+	MaxConnsPerIP  int // concurrent connections allowed per IP
+	ChallengeAbove int // total connections above which new ones must solve a challenge, 0 disables
+	Difficulty     int // proof-of-work difficulty in bits
+	IdleMinutes    int // disconnect clients outside rooms who send nothing for this long
+}
+
+// ipState tracks one remote address
+type ipState struct {
+	active       int
+	connects     *rateLimiter
+	lastConnect  time.Time
+	strikes      int // rapid reconnects in a row
+	blockedUntil time.Time
+}
+
+// connThrottle admits or rejects new WebSocket connections
+type connThrottle struct {
+	mu         sync.Mutex
+	config     ThrottleConfig
+	ips        map[string]*ipState
+	total      int
+	challenges map[string]time.Time // outstanding challenge -> expiry
+}
+
+func newConnThrottle() *connThrottle {
+	return &connThrottle{
+		config: ThrottleConfig{
+			MaxConnsPerIP: defaultMaxConnsPerIP,
+			Difficulty:    defaultDifficulty,
+			IdleMinutes:   defaultIdleMinutes,
+		},
+		ips:        make(map[string]*ipState),
+		challenges: make(map[string]time.Time),
+	}
+}
+
+// ConfigureThrottle replaces the connection throttling settings
+func (h *Hub) ConfigureThrottle(config ThrottleConfig) {
+	if config.MaxConnsPerIP <= 0 {
+		config.MaxConnsPerIP = defaultMaxConnsPerIP
+	}
+	if config.Difficulty <= 0 {
+		config.Difficulty = defaultDifficulty
+	}
+	if config.IdleMinutes <= 0 {
+		config.IdleMinutes = defaultIdleMinutes
+	}
+
+	h.throttle.mu.Lock()
+	defer h.throttle.mu.Unlock()
+	h.throttle.config = config
+}
+
+// admit records a connection attempt from ip. On rejection it returns the
+// error to report and how long the client should wait before retrying.
+func (t *connThrottle) admit(ip, challenge, nonce string) (time.Duration, *i18n.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.ips) > throttleSweepSize {
+		t.sweep(now)
+	}
+
+	s, ok := t.ips[ip]
+	if !ok {
+		s = &ipState{connects: newRateLimiter(connectRatePerSecond, connectBurst)}
+		t.ips[ip] = s
+	}
+
+	if now.Before(s.blockedUntil) {
+		return t.backoff(s.blockedUntil.Sub(now))
+	}
+
+	// Reconnecting faster than a real client would earns an exponentially
+	// growing wait
+	if now.Sub(s.lastConnect) > strikeDecay {
+		s.strikes = 0
+	}
+	if !s.connects.Allow() {
+		s.strikes++
+		wait := reconnectBackoffBase << (s.strikes - 1)
+		if wait > reconnectBackoffMax || wait <= 0 {
+			wait = reconnectBackoffMax
+		}
+		s.blockedUntil = now.Add(wait)
+		s.lastConnect = now
+		return t.backoff(wait)
+	}
+	s.lastConnect = now
+
+	if s.active >= t.config.MaxConnsPerIP {
+		return reconnectBackoffBase, i18n.NewError(i18n.ErrTooManyConnections, map[string]interface{}{
+			"limit": t.config.MaxConnsPerIP,
+		})
+	}
+
+	if t.config.ChallengeAbove > 0 && t.total >= t.config.ChallengeAbove && !t.solved(now, challenge, nonce) {
+		return 0, i18n.NewError(i18n.ErrChallengeRequired, map[string]interface{}{
+			"difficulty": t.config.Difficulty,
+		})
+	}
+
+	s.active++
+	t.total++
+	return 0, nil
+}
+
+// admitExempt records a connection from ip that skips every check, such as
+// a load testing bot's. It still takes a slot, which release frees.
+func (t *connThrottle) admitExempt(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.ips[ip]
+	if !ok {
+		s = &ipState{connects: newRateLimiter(connectRatePerSecond, connectBurst)}
+		t.ips[ip] = s
+	}
+	s.active++
+	t.total++
+}
+
+func (t *connThrottle) backoff(wait time.Duration) (time.Duration, *i18n.Error) {
+	return wait, i18n.NewError(i18n.ErrReconnectBackoff, map[string]interface{}{
+		"retry_after_ms": wait.Milliseconds(),
+	})
+}
+
+// release frees a connection slot when a client disconnects
+func (t *connThrottle) release(ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s, ok := t.ips[ip]; ok && s.active > 0 {
+		s.active--
+		t.total--
+	}
+}
+
+// newChallenge issues a single-use proof-of-work challenge
+func (t *connThrottle) newChallenge() (string, int) {
+	challenge := ids.Token(16)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for c, expiry := range t.challenges {
+		if now.After(expiry) {
+			delete(t.challenges, c)
+		}
+	}
+	// Under a flood of requests, old challenges make room for new ones
+	for c := range t.challenges {
+		if len(t.challenges) < maxChallenges {
+			break
+		}
+		delete(t.challenges, c)
+	}
+	t.challenges[challenge] = now.Add(challengeTTL)
+	return challenge, t.config.Difficulty
+}
+
+// solved checks and consumes a challenge answer. Callers must hold the lock.
+func (t *connThrottle) solved(now time.Time, challenge, nonce string) bool {
+	expiry, ok := t.challenges[challenge]
+	if !ok || now.After(expiry) {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	if leadingZeroBits(sum[:]) < t.config.Difficulty {
+		return false
+	}
+	delete(t.challenges, challenge)
+	return true
+}
+
+// sweep forgets idle addresses. Callers must hold the lock.
+func (t *connThrottle) sweep(now time.Time) {
+	for ip, s := range t.ips {
+		if s.active == 0 && now.After(s.blockedUntil) && now.Sub(s.lastConnect) > strikeDecay {
+			delete(t.ips, ip)
+		}
+	}
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
+
+// remoteIP strips the port from the request's remote address
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rejectConnection answers a refused upgrade with a coded error and a
+// Retry-After hint
+func rejectConnection(w http.ResponseWriter, err *i18n.Error, wait time.Duration) {
+	if wait > 0 {
+		// Round up so clients never retry early
+		w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":           err.Code,
+		"params":         err.Params,
+		"message":        err.Error(),
+		"retry_after_ms": wait.Milliseconds(),
+	})
+}
+
+// ServeChallenge hands out a proof-of-work challenge. Clients find a nonce
+// where sha256(challenge + nonce) starts with difficulty zero bits and pass
+// both as query parameters when connecting.
+func ServeChallenge(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	challenge, difficulty := hub.throttle.newChallenge()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"challenge":  challenge,
+		"difficulty": difficulty,
+		"expires_in": int(challengeTTL.Seconds()),
+	})
+}
@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleUpgradeTower starts upgrading a tower to its next level, along the
+// branch the client picked where its upgrade tree branches
+func (c *Client) handleUpgradeTower(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	towerID, ok := msg.Payload["tower_id"].(float64)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+	// Only needed where the tower's upgrade tree branches
+	branch, _ := msg.Payload["branch"].(string)
+
+	mutation := c.traceStep("room.mutation")
+	result, err := c.applyCommand(room, msg, game.UpgradeTower{TowerID: game.EntityID(towerID), Branch: branch, PlayerID: c.id})
+	tower, _ := result.(game.Tower)
+	mutation.Finish()
+	if err != nil {
+		log.Printf("Rejected upgrade of tower %v in room %s: %v", game.EntityID(towerID), roomID, err)
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Upgrading tower %v in room %s", tower.ID, roomID)
+	c.broadcastState(roomID)
+
+	c.reply(Message{
+		Type: MessageTypeUpgradeTower,
+		Payload: map[string]interface{}{
+			"status": "upgrading",
+			"code":   i18n.AckTowerUpgrade,
+			"tower":  tower,
+		},
+	})
+}
+
+// handleSellTower starts selling a tower; it is removed once selling finishes
+func (c *Client) handleSellTower(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	towerID, ok := msg.Payload["tower_id"].(float64)
+	if !ok {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	mutation := c.traceStep("room.mutation")
+	result, err := c.applyCommand(room, msg, game.SellTower{TowerID: game.EntityID(towerID), PlayerID: c.id})
+	tower, _ := result.(game.Tower)
+	mutation.Finish()
+	if err != nil {
+		log.Printf("Rejected sale of tower %v in room %s: %v", game.EntityID(towerID), roomID, err)
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	log.Printf("Selling tower %v in room %s", tower.ID, roomID)
+	c.broadcastState(roomID)
+
+	c.reply(Message{
+		Type: msg.Type,
+		Payload: map[string]interface{}{
+			"status": "selling",
+			"code":   i18n.AckTowerSelling,
+			"tower":  tower,
+		},
+	})
+}
+
+// handleSetTargetMode changes which enemies a tower prefers to shoot
+func (c *Client) handleSetTargetMode(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	towerID, idOk := msg.Payload["tower_id"].(float64)
+	mode, modeOk := msg.Payload["mode"].(string)
+	if !idOk || !modeOk {
+		c.sendError(msg.Type, invalidPayload(msg.Type))
+		return
+	}
+
+	mutation := c.traceStep("room.mutation")
+	result, err := c.applyCommand(room, msg, game.SetTargetMode{TowerID: game.EntityID(towerID), Mode: mode, PlayerID: c.id})
+	tower, _ := result.(game.Tower)
+	mutation.Finish()
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+
+	c.broadcastState(roomID)
+
+	c.reply(Message{
+		Type: MessageTypeSetTargetMode,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckTargetMode,
+			"params": map[string]interface{}{"mode": mode},
+			"tower":  tower,
+		},
+	})
+}
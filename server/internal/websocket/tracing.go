@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"rust-rush/server/internal/tracing"
+)
+
+// UseTracer traces a sample of client messages from read to broadcast
+func (h *Hub) UseTracer(tracer *tracing.Tracer) {
+	h.tracer = tracer
+}
+
+// traceStep starts a span for a step of the message being handled, or
+// returns nil when that message isn't traced
+func (c *Client) traceStep(name string) *tracing.Span {
+	span := c.span.Child(name)
+	span.SetAttribute("room.id", c.currentRoom())
+	return span
+}
+
+// broadcastState sends the room's state right after a client's action
+func (c *Client) broadcastState(roomID string) {
+	span := c.traceStep("ws.broadcast")
+	defer span.Finish()
+	c.hub.BroadcastGameState(roomID)
+}
@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"log"
+
+	"rust-rush/server/internal/game"
+	"rust-rush/server/internal/i18n"
+)
+
+// handleSkipToNextWave lets the host cut short the wait before the next
+// wave once the field is clear
+func (c *Client) handleSkipToNextWave(msg *Message) {
+	room, roomID, ok := c.resolveRoom(msg)
+	if !ok {
+		return
+	}
+
+	result, err := c.applyCommand(room, msg, game.SkipToNextWave{PlayerID: c.id})
+	if err != nil {
+		c.sendError(msg.Type, err)
+		return
+	}
+	wave, _ := result.(int)
+
+	log.Printf("Client %s skipped to wave %d in room %s", c.id, wave, roomID)
+	c.reply(Message{
+		Type:   msg.Type,
+		RoomID: roomID,
+		Payload: map[string]interface{}{
+			"status": "ok",
+			"code":   i18n.AckWaitSkipped,
+			"params": map[string]interface{}{"wave": wave},
+		},
+	})
+}